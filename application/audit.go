@@ -0,0 +1,138 @@
+package application
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+// Auditor пишет действия организаторов в журнал аудита. Ошибку записи в журнал сервисы возвращают
+// вызывающему: действие без записи в журнале не должно выглядеть успешным. Nil Auditor ничего не пишет
+type Auditor struct {
+	Rep    mathbattle.AuditRepository
+	Logger *mlog.Logger
+}
+
+func (a *Auditor) logger() *mlog.Logger {
+	if a == nil {
+		return mlog.Default()
+	}
+	return loggerOrDefault(a.Logger)
+}
+
+func (a *Auditor) value(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		a.logger().Errorf("Failed to marshal audit value: %v", err)
+		return ""
+	}
+	return string(data)
+}
+
+func (a *Auditor) Record(actorID int64, action mathbattle.AuditAction, target string, before, after interface{}) error {
+	if a == nil || a.Rep == nil {
+		return nil
+	}
+
+	_, err := a.Rep.Store(mathbattle.AuditRecord{
+		Time:    time.Now().UTC(),
+		ActorID: actorID,
+		Action:  action,
+		Target:  target,
+		Before:  a.value(before),
+		After:   a.value(after),
+	})
+	if err != nil {
+		a.logger().Errorf("Failed to store audit record %s for %s by %d: %v", action, target, actorID, err)
+	}
+	return err
+}
+
+// isOwn - пользователь с Telegram ID actorID - это participantID или член команды teamID. Своё участники
+// удаляют при пересдаче, в журнал попадает только то, что удалили организаторы
+func isOwn(participants mathbattle.ParticipantRepository, teams mathbattle.TeamRepository, actorID int64,
+	participantID string, teamID string) (bool, error) {
+
+	if actorID == 0 {
+		return false, nil
+	}
+
+	participant, err := participants.GetByTelegramID(actorID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if participant.ID == participantID {
+		return true, nil
+	}
+	if teamID == "" {
+		return false, nil
+	}
+
+	team, err := teams.Get(teamID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return team.IsMember(participant.ID), nil
+}
+
+// csvCell экранирует ячейки, которые табличные редакторы приняли бы за формулу
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type AuditService struct {
+	Rep mathbattle.AuditRepository
+}
+
+func (s *AuditService) Find(filter mathbattle.AuditFilter) ([]mathbattle.AuditRecord, error) {
+	return s.Rep.Find(filter)
+}
+
+func (s *AuditService) ExportCSV(filter mathbattle.AuditFilter) ([]byte, error) {
+	records, err := s.Rep.Find(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"id", "time", "actor_id", "action", "target", "before", "after"}); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		err := w.Write([]string{
+			record.ID,
+			record.Time.UTC().Format(time.RFC3339),
+			strconv.FormatInt(record.ActorID, 10),
+			csvCell(string(record.Action)),
+			csvCell(record.Target),
+			csvCell(record.Before),
+			csvCell(record.After),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRoleChange(t *testing.T) {
+	req := require.New(t)
+
+	audit := &memoryAudit{}
+	users := &memoryUsers{users: []mathbattle.User{
+		{ID: "1", TelegramUsername: "owner", Role: mathbattle.RoleOwner},
+		{ID: "2", TelegramUsername: "ivanov"},
+	}}
+	rs := &RoleService{Users: users, Audit: &Auditor{Rep: audit}}
+
+	_, err := rs.Grant(mathbattle.RoleOrder{ActorID: 100, TelegramName: "ivanov", Role: mathbattle.RoleJury})
+	req.Nil(err)
+	// Неудачная попытка в журнал не попадает
+	_, err = rs.Grant(mathbattle.RoleOrder{ActorID: 100, TelegramName: "petrov", Role: mathbattle.RoleJury})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+
+	req.Len(audit.records, 1)
+	record := audit.records[0]
+	req.Equal(int64(100), record.ActorID)
+	req.Equal(mathbattle.AuditRoleChange, record.Action)
+	req.Equal("2", record.Target)
+	req.Equal(`""`, record.Before)
+	req.Equal(`"jury"`, record.After)
+
+	as := &AuditService{Rep: audit}
+	content, err := as.ExportCSV(mathbattle.AuditFilter{Action: mathbattle.AuditRoleChange})
+	req.Nil(err)
+	rows, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	req.Nil(err)
+	req.Len(rows, 2)
+	req.Equal([]string{"1", record.Time.Format("2006-01-02T15:04:05Z07:00"), "100", "role_change", "2", `""`, `"jury"`}, rows[1])
+
+	// Без журнала сервисы работают как раньше
+	var auditor *Auditor
+	req.Nil(auditor.Record(1, mathbattle.AuditBroadcast, "", nil, nil))
+}
+
+func TestAuditStoreError(t *testing.T) {
+	req := require.New(t)
+
+	storeErr := errors.New("disk full")
+	users := &memoryUsers{users: []mathbattle.User{{ID: "2", TelegramUsername: "ivanov"}}}
+	rs := &RoleService{Users: users, Audit: &Auditor{Rep: &memoryAudit{err: storeErr}}}
+
+	_, err := rs.Grant(mathbattle.RoleOrder{ActorID: 100, TelegramName: "ivanov", Role: mathbattle.RoleJury})
+	req.Equal(storeErr, err)
+}
+
+func TestAuditCSVEscapesFormulas(t *testing.T) {
+	req := require.New(t)
+
+	audit := &memoryAudit{}
+	auditor := &Auditor{Rep: audit}
+	req.Nil(auditor.Record(-1, mathbattle.AuditBroadcast, "=HYPERLINK(\"x\")", nil, "@SUM(A1)"))
+	req.Nil(auditor.Record(1, mathbattle.AuditBroadcast, "+1", -2, nil))
+
+	content, err := (&AuditService{Rep: audit}).ExportCSV(mathbattle.AuditFilter{})
+	req.Nil(err)
+	rows, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	req.Nil(err)
+	req.Len(rows, 3)
+	req.Equal("-1", rows[1][2])
+	req.Equal(`'=HYPERLINK("x")`, rows[1][4])
+	req.Equal(`"@SUM(A1)"`, rows[1][6])
+	req.Equal("'+1", rows[2][4])
+	req.Equal("'-2", rows[2][5])
+}
+
+func TestAuditSolutionDeleteByStaffOnly(t *testing.T) {
+	req := require.New(t)
+
+	audit := &memoryAudit{}
+	solutions := &memorySolutions{solutions: []mathbattle.Solution{
+		{ID: "s1", ParticipantID: "1"},
+		{ID: "s2", ParticipantID: "1"},
+	}}
+	ss := &SolutionService{
+		Rep:          solutions,
+		Participants: &memoryParticipants{participants: []mathbattle.Participant{{ID: "1", User: mathbattle.User{TelegramID: 101}}}},
+		Audit:        &Auditor{Rep: audit},
+	}
+
+	// Пересдача своего решения - не действие организатора
+	req.Nil(ss.Delete("s1", 101))
+	req.Len(audit.records, 0)
+
+	req.Nil(ss.Delete("s2", 200))
+	req.Len(audit.records, 1)
+	req.Equal(mathbattle.AuditSolutionDelete, audit.records[0].Action)
+	req.Len(solutions.solutions, 0)
+
+	// Без записи в журнале решение остаётся
+	solutions.solutions = []mathbattle.Solution{{ID: "s3", ParticipantID: "1"}}
+	ss.Audit = &Auditor{Rep: &memoryAudit{err: errors.New("disk full")}}
+	req.NotNil(ss.Delete("s3", 200))
+	req.Len(solutions.solutions, 1)
+}
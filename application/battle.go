@@ -0,0 +1,288 @@
+package application
+
+import (
+	"time"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+type BattleService struct {
+	Rep          mathbattle.BattleRepository
+	Rounds       mathbattle.RoundRepository
+	Teams        mathbattle.TeamRepository
+	Participants mathbattle.ParticipantRepository
+	Postman      mathbattle.PostmanService
+	Repliers     Repliers
+	Audit        *Auditor
+	Logger       *mlog.Logger
+}
+
+func (s *BattleService) logger() *mlog.Logger {
+	return loggerOrDefault(s.Logger)
+}
+
+// matboiRound - матбой идёт в текущем раунде RoundMatboi лиги после окончания подготовки
+func (s *BattleService) matboiRound(league string) (mathbattle.Round, error) {
+	round, err := s.Rounds.GetReviewPending(league)
+	if err != nil {
+		return round, err
+	}
+
+	if !round.IsMatboi() {
+		return round, mathbattle.ErrNotFound
+	}
+	return round, nil
+}
+
+// Start - жюри начинает бой двух команд. Команды должны получить в раунде одинаковые задачи
+// и не должны уже играть в этом раунде
+func (s *BattleService) Start(order mathbattle.BattleOrder) (mathbattle.Battle, error) {
+	if len(order.TeamIDs) != 2 || order.TeamIDs[0] == order.TeamIDs[1] {
+		return mathbattle.Battle{}, mathbattle.ErrWrongUserInput
+	}
+
+	round, err := s.matboiRound(order.League)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return mathbattle.Battle{}, mathbattle.ErrWrongUserInput
+		}
+		return mathbattle.Battle{}, err
+	}
+
+	battles, err := s.Rep.GetByRound(round.ID)
+	if err != nil {
+		return mathbattle.Battle{}, err
+	}
+	for _, battle := range battles {
+		if battle.IsParticipant(order.TeamIDs[0]) || battle.IsParticipant(order.TeamIDs[1]) {
+			return mathbattle.Battle{}, mathbattle.ErrWrongUserInput
+		}
+	}
+
+	teams := []mathbattle.Team{}
+	for _, teamID := range order.TeamIDs {
+		team, err := s.Teams.Get(teamID)
+		if err != nil {
+			if err == mathbattle.ErrNotFound {
+				return mathbattle.Battle{}, mathbattle.ErrWrongUserInput
+			}
+			return mathbattle.Battle{}, err
+		}
+		teams = append(teams, team)
+	}
+
+	problems := round.ProblemDistribution[teams[0].ID]
+	rivalProblems := round.ProblemDistribution[teams[1].ID]
+	if len(problems) == 0 || len(problems) != len(rivalProblems) {
+		return mathbattle.Battle{}, mathbattle.ErrWrongUserInput
+	}
+	for i := range problems {
+		if problems[i] != rivalProblems[i] {
+			return mathbattle.Battle{}, mathbattle.ErrWrongUserInput
+		}
+	}
+
+	battle, err := s.Rep.Store(mathbattle.NewBattle(round.ID, teams, problems))
+	if err != nil {
+		return battle, err
+	}
+	if err := s.Audit.Record(order.ActorID, mathbattle.AuditBattleStart, battle.ID, nil, battle); err != nil {
+		return battle, err
+	}
+
+	s.notify(battle, func(r Replier, teamID string) string {
+		return r.BattleStarted(battle, teamID)
+	})
+	return battle, nil
+}
+
+func (s *BattleService) Get(ID string) (mathbattle.Battle, error) {
+	return s.Rep.Get(ID)
+}
+
+func (s *BattleService) GetRunning(participantID string) (mathbattle.Battle, error) {
+	participant, err := s.Participants.GetByID(participantID)
+	if err != nil {
+		return mathbattle.Battle{}, err
+	}
+
+	round, err := s.matboiRound(participant.League)
+	if err != nil {
+		return mathbattle.Battle{}, err
+	}
+
+	team, err := s.Teams.GetByMember(participantID)
+	if err != nil {
+		return mathbattle.Battle{}, err
+	}
+
+	battles, err := s.Rep.GetByRound(round.ID)
+	if err != nil {
+		return mathbattle.Battle{}, err
+	}
+
+	for _, battle := range battles {
+		if battle.IsParticipant(team.ID) && battle.State != mathbattle.BattleFinished {
+			return battle, nil
+		}
+	}
+
+	return mathbattle.Battle{}, mathbattle.ErrNotFound
+}
+
+func (s *BattleService) GetByRound(roundID string) ([]mathbattle.Battle, error) {
+	return s.Rep.GetByRound(roundID)
+}
+
+// Move - ход капитана команды
+func (s *BattleService) Move(move mathbattle.BattleMove) (mathbattle.Battle, error) {
+	battle, err := s.Rep.Get(move.BattleID)
+	if err != nil {
+		return battle, err
+	}
+
+	// Капитана определяем по тому, кто прислал ход, а не по ParticipantID из тела запроса
+	participant, err := s.Participants.GetByTelegramID(move.ActorID)
+	if err == mathbattle.ErrNotFound {
+		return battle, mathbattle.ErrWrongUserInput
+	}
+	if err != nil {
+		return battle, err
+	}
+	if move.ParticipantID != "" && move.ParticipantID != participant.ID {
+		return battle, mathbattle.ErrWrongUserInput
+	}
+
+	team, err := s.Teams.GetByMember(participant.ID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return battle, mathbattle.ErrWrongUserInput
+		}
+		return battle, err
+	}
+
+	if !battle.IsParticipant(team.ID) || team.CaptainID != participant.ID {
+		return battle, mathbattle.ErrWrongUserInput
+	}
+
+	if err := battle.Move(team.ID, move.Action, move.ProblemID); err != nil {
+		return battle, err
+	}
+
+	if err := s.update(battle); err != nil {
+		return battle, err
+	}
+
+	s.notify(battle, func(r Replier, teamID string) string {
+		return r.BattleStatus(battle, teamID)
+	})
+	return battle, nil
+}
+
+// Judge - баллы жюри за текущий доклад или досрочное окончание боя
+func (s *BattleService) Judge(verdict mathbattle.BattleVerdict) (mathbattle.Battle, error) {
+	battle, err := s.Rep.Get(verdict.BattleID)
+	if err != nil {
+		return battle, err
+	}
+
+	if battle.State == mathbattle.BattleFinished {
+		return battle, mathbattle.ErrWrongUserInput
+	}
+	// Judge меняет последний разбор на месте, поэтому разборы копируются
+	before := battle
+	before.Fights = append([]mathbattle.BattleFight{}, battle.Fights...)
+
+	if verdict.Finish {
+		battle.Finish()
+	} else if err := battle.Judge(verdict.ReporterPoints, verdict.OpponentPoints); err != nil {
+		return battle, err
+	}
+
+	if err := s.update(battle); err != nil {
+		return battle, err
+	}
+	if err := s.Audit.Record(verdict.ActorID, mathbattle.AuditBattleMark, battle.ID, before, battle); err != nil {
+		return battle, err
+	}
+
+	s.notify(battle, func(r Replier, teamID string) string {
+		if verdict.Finish {
+			return r.BattleStatus(battle, teamID)
+		}
+		return r.BattleJudged(battle, battle.Fights[len(battle.Fights)-1]) + "\n\n" + r.BattleStatus(battle, teamID)
+	})
+	return battle, nil
+}
+
+// update сохраняет бой. Когда сыграны все бои раунда и новую пару команд уже не составить, раунд заканчивается
+func (s *BattleService) update(battle mathbattle.Battle) error {
+	if err := s.Rep.Update(battle); err != nil {
+		return err
+	}
+
+	if battle.State != mathbattle.BattleFinished {
+		return nil
+	}
+
+	round, err := s.Rounds.Get(battle.RoundID)
+	if err != nil {
+		return err
+	}
+
+	battles, err := s.Rep.GetByRound(round.ID)
+	if err != nil {
+		return err
+	}
+
+	played := make(map[string]bool)
+	for _, cur := range battles {
+		if cur.State != mathbattle.BattleFinished {
+			return nil
+		}
+		for _, teamID := range cur.TeamIDs {
+			played[teamID] = true
+		}
+	}
+
+	waiting := 0
+	for teamID := range round.ProblemDistribution {
+		if !played[teamID] {
+			waiting++
+		}
+	}
+	if waiting >= 2 {
+		return nil
+	}
+
+	// Этапа ревью в матбое нет, раунд заканчивается вместе с последним боем
+	round.SetReviewStartDate(time.Now())
+	round.SetReviewEndDate(time.Now())
+	return s.Rounds.Update(round)
+}
+
+// notify пишет о бое всем участникам обеих команд
+func (s *BattleService) notify(battle mathbattle.Battle, message func(r Replier, teamID string) string) {
+	for _, teamID := range battle.TeamIDs {
+		team, err := s.Teams.Get(teamID)
+		if err != nil {
+			s.logger().Errorf("BattleService - failed to get team %s, error: %v", teamID, err)
+			continue
+		}
+
+		for _, memberID := range team.MemberIDs {
+			participant, err := s.Participants.GetByID(memberID)
+			if err != nil {
+				s.logger().Errorf("BattleService - failed to get participant %s, error: %v", memberID, err)
+				continue
+			}
+
+			err = s.Postman.SendSimpleMessage(participant.TelegramID,
+				message(s.Repliers.ForLanguage(participant.Language), teamID))
+			if err != nil {
+				s.logger().Errorf("BattleService - failed to send message to participant, error: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"testing"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBattleProtocol(t *testing.T) {
+	req := require.New(t)
+
+	battle := mathbattle.NewBattle("1", []mathbattle.Team{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}},
+		[]mathbattle.ProblemDescriptor{{Caption: "A", ProblemID: "p1"}, {Caption: "B", ProblemID: "p2"},
+			{Caption: "C", ProblemID: "p3"}})
+	req.Equal(mathbattle.BattleChallenge, battle.State)
+	req.Equal("a", battle.Turn)
+
+	// Не в свой ход и на несуществующую задачу вызвать нельзя
+	req.Equal(mathbattle.ErrWrongUserInput, battle.Move("b", mathbattle.BattleMoveChallenge, "p1"))
+	req.Equal(mathbattle.ErrWrongUserInput, battle.Move("a", mathbattle.BattleMoveChallenge, "p4"))
+
+	// Принятый вызов: докладывает вызванная команда, ход переходит к ней
+	req.Nil(battle.Move("a", mathbattle.BattleMoveChallenge, "p1"))
+	req.Equal(mathbattle.BattleResponse, battle.State)
+	req.Nil(battle.Move("b", mathbattle.BattleMoveAccept, ""))
+	req.Equal(mathbattle.BattleReport, battle.State)
+	req.Equal(mathbattle.ErrWrongUserInput, battle.Judge(10, 3))
+	req.Nil(battle.Judge(8, 3))
+	req.Equal(8, battle.Score("b"))
+	req.Equal(3, battle.Score("a"))
+	req.Equal(1, battle.JuryScore())
+	req.Equal("b", battle.Turn)
+
+	// Некорректный вызов: вызвавшая команда не рассказала задачу и вызывает ещё раз
+	req.Equal(mathbattle.ErrWrongUserInput, battle.Move("b", mathbattle.BattleMoveChallenge, "p1"))
+	req.Nil(battle.Move("b", mathbattle.BattleMoveChallenge, "p2"))
+	req.Nil(battle.Move("a", mathbattle.BattleMoveRefuse, ""))
+	req.Equal("b", battle.Current().ReporterTeamID)
+	req.Equal("a", battle.Current().OpponentTeamID)
+	req.Nil(battle.Judge(2, 6))
+	req.Equal("b", battle.Turn)
+	req.Equal(10, battle.Score("b"))
+	req.Equal(9, battle.Score("a"))
+
+	// Обе команды подряд отказались вызывать - бой окончен
+	req.Nil(battle.Move("b", mathbattle.BattleMovePass, ""))
+	req.Equal("a", battle.Turn)
+	req.Nil(battle.Move("a", mathbattle.BattleMovePass, ""))
+	req.Equal(mathbattle.BattleFinished, battle.State)
+	req.Equal(mathbattle.ErrWrongUserInput, battle.Move("a", mathbattle.BattleMoveChallenge, "p3"))
+}
+
+func TestBattleEndsWhenAllProblemsDiscussed(t *testing.T) {
+	req := require.New(t)
+
+	battle := mathbattle.NewBattle("1", []mathbattle.Team{{ID: "a"}, {ID: "b"}},
+		[]mathbattle.ProblemDescriptor{{Caption: "A", ProblemID: "p1"}})
+
+	req.Nil(battle.Move("a", mathbattle.BattleMoveChallenge, "p1"))
+	req.Nil(battle.Move("b", mathbattle.BattleMoveAccept, ""))
+	req.Nil(battle.Judge(12, 0))
+	req.Equal(mathbattle.BattleFinished, battle.State)
+	req.Empty(battle.Turn)
+}
+
+func TestBattleMoveOnlyByCaptain(t *testing.T) {
+	req := require.New(t)
+
+	teams := []mathbattle.Team{
+		{ID: "a", CaptainID: "1", MemberIDs: []string{"1", "2"}},
+		{ID: "b", CaptainID: "3", MemberIDs: []string{"3"}},
+	}
+	battle := mathbattle.NewBattle("r1", teams, []mathbattle.ProblemDescriptor{{Caption: "A", ProblemID: "p1"}})
+	battle.ID = "1"
+	participants := &memoryParticipants{}
+	for _, ID := range []string{"1", "2", "3"} {
+		participants.participants = append(participants.participants, mathbattle.Participant{
+			ID:   ID,
+			User: mathbattle.User{TelegramID: telegramID(ID)},
+		})
+	}
+	battles := &memoryBattles{battles: []mathbattle.Battle{battle}}
+	bs := &BattleService{
+		Rep:          battles,
+		Teams:        &memoryTeams{teams: teams},
+		Participants: participants,
+		Postman:      &recordingPostman{},
+		Repliers:     textRepliers{},
+	}
+
+	// В теле запроса указан капитан, но ходит не он
+	_, err := bs.Move(mathbattle.BattleMove{BattleID: "1", ParticipantID: "1", ActorID: telegramID("2"),
+		Action: mathbattle.BattleMoveChallenge, ProblemID: "p1"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+	// Незарегистрированный пользователь
+	_, err = bs.Move(mathbattle.BattleMove{BattleID: "1", ActorID: 1, Action: mathbattle.BattleMoveChallenge, ProblemID: "p1"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+	req.Equal(mathbattle.BattleChallenge, battles.battles[0].State)
+
+	moved, err := bs.Move(mathbattle.BattleMove{BattleID: "1", ParticipantID: "1", ActorID: telegramID("1"),
+		Action: mathbattle.BattleMoveChallenge, ProblemID: "p1"})
+	req.Nil(err)
+	req.Equal(mathbattle.BattleResponse, moved.State)
+	req.Equal(mathbattle.BattleResponse, battles.battles[0].State)
+}
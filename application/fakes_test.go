@@ -0,0 +1,531 @@
+package application
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	solutiondistributor "mathbattle/application/solution_distributor"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+// textReplier - ответы, по которым в тестах видно, какое сообщение отправлено. Остальные методы не нужны
+type textReplier struct {
+	Replier
+}
+
+func (r textReplier) ProblemsPostBefore(stageDuration time.Duration, stageEnd time.Time) string {
+	return "problems before"
+}
+
+func (r textReplier) ProblemsPostAfter() string {
+	return "problems after"
+}
+
+func (r textReplier) ReviewPostBefore(stageDuration time.Duration, stageEnd time.Time) string {
+	return "review before"
+}
+
+func (r textReplier) ReviewPostCaption(problemCaption string, solutionNumber int) string {
+	return fmt.Sprintf("%s%d", problemCaption, solutionNumber)
+}
+
+func (r textReplier) ReviewPostAfter() string {
+	return "review after"
+}
+
+func (r textReplier) ReviewReassignRemoved(problemCaption string, solutionNumber int, actual []mathbattle.SolutionDescriptor) string {
+	return fmt.Sprintf("removed %s%d", problemCaption, solutionNumber)
+}
+
+func (r textReplier) ReviewReassignAdded() string {
+	return "added"
+}
+
+func (r textReplier) ReminderSolveStage(timeLeft time.Duration, notSolvedCaptions []string) string {
+	return "remind solve " + strings.Join(notSolvedCaptions, ",")
+}
+
+func (r textReplier) ReminderReviewStage(timeLeft time.Duration, notReviewedCaptions []string) string {
+	return "remind review " + strings.Join(notReviewedCaptions, ",")
+}
+
+func (r textReplier) BattleStatus(battle mathbattle.Battle, teamID string) string {
+	return "battle " + battle.ID + " for " + teamID
+}
+
+type textRepliers struct{}
+
+func (r textRepliers) Default() Replier {
+	return textReplier{}
+}
+
+func (r textRepliers) ForLanguage(language string) Replier {
+	return textReplier{}
+}
+
+func (r textRepliers) Languages() []string {
+	return []string{"en"}
+}
+
+type recordingPostman struct {
+	sent []sentItem
+}
+
+func (pm *recordingPostman) SendSimpleToUsers(msg mathbattle.SimpleMessage) error {
+	return nil
+}
+
+func (pm *recordingPostman) SendSimpleMessage(chatID int64, message string) error {
+	pm.sent = append(pm.sent, sentItem{chatID: chatID, kind: "text", caption: message})
+	return nil
+}
+
+func (pm *recordingPostman) SendImage(chatID int64, caption string, image []byte) error {
+	pm.sent = append(pm.sent, sentItem{chatID: chatID, kind: "image", caption: caption, count: 1})
+	return nil
+}
+
+func (pm *recordingPostman) SendAlbum(chatID int64, caption string, images [][]byte) error {
+	pm.sent = append(pm.sent, sentItem{chatID: chatID, kind: "album", caption: caption, count: len(images)})
+	return nil
+}
+
+func (pm *recordingPostman) SendDocument(chatID int64, fileName string, caption string, content []byte) error {
+	pm.sent = append(pm.sent, sentItem{chatID: chatID, kind: "document", caption: caption, fileName: fileName, count: 1})
+	return nil
+}
+
+type sentItem struct {
+	chatID   int64
+	kind     string
+	caption  string
+	fileName string
+	count    int
+}
+
+type twoPagesPreviewer struct{}
+
+func (p twoPagesPreviewer) Preview(document mathbattle.Image) ([][]byte, error) {
+	return [][]byte{{1}, {2}}, nil
+}
+
+type memoryUsers struct {
+	mathbattle.UserRepository
+	users []mathbattle.User
+}
+
+func (r *memoryUsers) GetAll() ([]mathbattle.User, error) {
+	return append([]mathbattle.User{}, r.users...), nil
+}
+
+func (r *memoryUsers) GetByTelegramName(name string) (mathbattle.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.TelegramUsername, strings.TrimPrefix(name, "@")) {
+			return user, nil
+		}
+	}
+	return mathbattle.User{}, mathbattle.ErrNotFound
+}
+
+func (r *memoryUsers) Update(user mathbattle.User) error {
+	for i := range r.users {
+		if r.users[i].ID == user.ID {
+			r.users[i] = user
+		}
+	}
+	return nil
+}
+
+type memoryAudit struct {
+	records []mathbattle.AuditRecord
+	err     error
+}
+
+func (r *memoryAudit) Store(record mathbattle.AuditRecord) (mathbattle.AuditRecord, error) {
+	if r.err != nil {
+		return record, r.err
+	}
+	record.ID = strconv.Itoa(len(r.records) + 1)
+	r.records = append(r.records, record)
+	return record, nil
+}
+
+func (r *memoryAudit) Find(filter mathbattle.AuditFilter) ([]mathbattle.AuditRecord, error) {
+	result := []mathbattle.AuditRecord{}
+	for _, record := range r.records {
+		if filter.Action != "" && record.Action != filter.Action {
+			continue
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+type memoryParticipants struct {
+	mathbattle.ParticipantRepository
+	participants []mathbattle.Participant
+}
+
+func (r *memoryParticipants) GetByID(ID string) (mathbattle.Participant, error) {
+	for _, participant := range r.participants {
+		if participant.ID == ID {
+			return participant, nil
+		}
+	}
+	return mathbattle.Participant{}, mathbattle.ErrNotFound
+}
+
+func (r *memoryParticipants) GetByTelegramID(TelegramID int64) (mathbattle.Participant, error) {
+	for _, participant := range r.participants {
+		if participant.TelegramID == TelegramID {
+			return participant, nil
+		}
+	}
+	return mathbattle.Participant{}, mathbattle.ErrNotFound
+}
+
+func (r *memoryParticipants) GetAll() ([]mathbattle.Participant, error) {
+	return append([]mathbattle.Participant{}, r.participants...), nil
+}
+
+func (r *memoryParticipants) Update(participant mathbattle.Participant) error {
+	for i := range r.participants {
+		if r.participants[i].ID == participant.ID {
+			r.participants[i] = participant
+		}
+	}
+	return nil
+}
+
+type memoryTeams struct {
+	mathbattle.TeamRepository
+	teams []mathbattle.Team
+}
+
+func (r *memoryTeams) Store(team mathbattle.Team) (mathbattle.Team, error) {
+	team.ID = strconv.Itoa(len(r.teams) + 1)
+	r.teams = append(r.teams, team)
+	return team, nil
+}
+
+func (r *memoryTeams) find(isSuitable func(team mathbattle.Team) bool) (mathbattle.Team, error) {
+	for _, team := range r.teams {
+		if isSuitable(team) {
+			return team, nil
+		}
+	}
+	return mathbattle.Team{}, mathbattle.ErrNotFound
+}
+
+func (r *memoryTeams) GetByMember(participantID string) (mathbattle.Team, error) {
+	return r.find(func(team mathbattle.Team) bool { return team.IsMember(participantID) })
+}
+
+func (r *memoryTeams) GetByInviteCode(inviteCode string) (mathbattle.Team, error) {
+	return r.find(func(team mathbattle.Team) bool { return team.InviteCode == inviteCode })
+}
+
+func (r *memoryTeams) Update(team mathbattle.Team) error {
+	for i := range r.teams {
+		if r.teams[i].ID == team.ID {
+			r.teams[i] = team
+		}
+	}
+	return nil
+}
+
+func (r *memoryTeams) Delete(ID string) error {
+	teams := []mathbattle.Team{}
+	for _, team := range r.teams {
+		if team.ID != ID {
+			teams = append(teams, team)
+		}
+	}
+	r.teams = teams
+	return nil
+}
+
+func (r *memoryTeams) Get(ID string) (mathbattle.Team, error) {
+	return r.find(func(team mathbattle.Team) bool { return team.ID == ID })
+}
+
+func (r *memoryTeams) GetAll() ([]mathbattle.Team, error) {
+	return r.teams, nil
+}
+
+type memoryRounds struct {
+	mathbattle.RoundRepository
+	running *mathbattle.Round
+	updates int
+}
+
+func (r *memoryRounds) Get(ID string) (mathbattle.Round, error) {
+	if r.running == nil || r.running.ID != ID {
+		return mathbattle.Round{}, mathbattle.ErrNotFound
+	}
+	return *r.running, nil
+}
+
+func (r *memoryRounds) inStage(league string, stage mathbattle.RoundStage) (mathbattle.Round, error) {
+	if r.running == nil || r.running.League != league || mathbattle.GetRoundStage(*r.running) != stage {
+		return mathbattle.Round{}, mathbattle.ErrNotFound
+	}
+	return *r.running, nil
+}
+
+func (r *memoryRounds) GetReviewPending(league string) (mathbattle.Round, error) {
+	return r.inStage(league, mathbattle.StageReviewPending)
+}
+
+func (r *memoryRounds) GetReviewRunning(league string) (mathbattle.Round, error) {
+	return r.inStage(league, mathbattle.StageReview)
+}
+
+func (r *memoryRounds) Update(round mathbattle.Round) error {
+	if r.running == nil || r.running.ID != round.ID {
+		return mathbattle.ErrNotFound
+	}
+	r.updates++
+	// Как в sqldb: раскрытия дописывает только AppendIdentityReveal
+	reveals := r.running.IdentityReveals
+	*r.running = round
+	r.running.IdentityReveals = reveals
+	return nil
+}
+
+func (r *memoryRounds) AppendIdentityReveal(roundID string, reveal mathbattle.IdentityReveal) error {
+	if r.running == nil || r.running.ID != roundID {
+		return mathbattle.ErrNotFound
+	}
+	r.running.IdentityReveals = append(r.running.IdentityReveals, reveal)
+	return nil
+}
+
+func (r *memoryRounds) GetAllRunning() ([]mathbattle.Round, error) {
+	if r.running == nil {
+		return []mathbattle.Round{}, nil
+	}
+	return []mathbattle.Round{*r.running}, nil
+}
+
+func (r *memoryRounds) GetRunning(league string) (mathbattle.Round, error) {
+	if r.running == nil || r.running.League != league || !r.running.IsActive() {
+		return mathbattle.Round{}, mathbattle.ErrNotFound
+	}
+	return *r.running, nil
+}
+
+func (r *memoryRounds) GetLast(league string) (mathbattle.Round, error) {
+	if r.running == nil || r.running.League != league {
+		return mathbattle.Round{}, mathbattle.ErrNotFound
+	}
+	return *r.running, nil
+}
+
+type memoryBattles struct {
+	mathbattle.BattleRepository
+	battles []mathbattle.Battle
+}
+
+func (r *memoryBattles) Get(ID string) (mathbattle.Battle, error) {
+	for _, battle := range r.battles {
+		if battle.ID == ID {
+			return battle, nil
+		}
+	}
+	return mathbattle.Battle{}, mathbattle.ErrNotFound
+}
+
+func (r *memoryBattles) Update(battle mathbattle.Battle) error {
+	for i := range r.battles {
+		if r.battles[i].ID == battle.ID {
+			r.battles[i] = battle
+		}
+	}
+	return nil
+}
+
+type memorySolutions struct {
+	mathbattle.SolutionRepository
+	solutions []mathbattle.Solution
+}
+
+func (r *memorySolutions) Get(ID string) (mathbattle.Solution, error) {
+	for _, solution := range r.solutions {
+		if solution.ID == ID {
+			return solution, nil
+		}
+	}
+	return mathbattle.Solution{}, mathbattle.ErrNotFound
+}
+
+func (r *memorySolutions) find(roundID string, problemID string, isSuitable func(solution mathbattle.Solution) bool) []mathbattle.Solution {
+	result := []mathbattle.Solution{}
+	for _, solution := range r.solutions {
+		if (roundID == "" || solution.RoundID == roundID) && (problemID == "" || solution.ProblemID == problemID) &&
+			isSuitable(solution) {
+			result = append(result, solution)
+		}
+	}
+	return result
+}
+
+func (r *memorySolutions) FindMany(roundID string, participantID string, problemID string) ([]mathbattle.Solution, error) {
+	return r.find(roundID, problemID, func(solution mathbattle.Solution) bool {
+		return participantID == "" || solution.ParticipantID == participantID
+	}), nil
+}
+
+func (r *memorySolutions) FindManyByTeam(roundID string, teamID string, problemID string) ([]mathbattle.Solution, error) {
+	return r.find(roundID, problemID, func(solution mathbattle.Solution) bool {
+		return teamID == "" || solution.TeamID == teamID
+	}), nil
+}
+
+func (r *memorySolutions) AppendPart(ID string, part mathbattle.Image) error {
+	for i := range r.solutions {
+		if r.solutions[i].ID == ID {
+			r.solutions[i].Parts = append(r.solutions[i].Parts, part)
+			return nil
+		}
+	}
+	return mathbattle.ErrNotFound
+}
+
+func (r *memorySolutions) Delete(ID string) error {
+	solutions := []mathbattle.Solution{}
+	for _, solution := range r.solutions {
+		if solution.ID != ID {
+			solutions = append(solutions, solution)
+		}
+	}
+	r.solutions = solutions
+	return nil
+}
+
+type memoryReviews struct {
+	mathbattle.ReviewRepository
+	reviews []mathbattle.Review
+}
+
+func (r *memoryReviews) FindMany(reviewerID, solutionID string) ([]mathbattle.Review, error) {
+	result := []mathbattle.Review{}
+	for _, review := range r.reviews {
+		if (reviewerID == "" || review.ReviewerID == reviewerID) && (solutionID == "" || review.SolutionID == solutionID) {
+			result = append(result, review)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryReviews) FindManyByTeam(reviewerTeamID, solutionID string) ([]mathbattle.Review, error) {
+	result := []mathbattle.Review{}
+	for _, review := range r.reviews {
+		if (reviewerTeamID == "" || review.ReviewerTeamID == reviewerTeamID) && (solutionID == "" || review.SolutionID == solutionID) {
+			result = append(result, review)
+		}
+	}
+	return result, nil
+}
+
+// roundFixture - раунд с участниками 1, 2, 3, каждому из которых досталась задача A (ID "p1")
+// и каждый прислал решение s1, s2, s3. Этап решения закончился, этап ревью ещё не начат
+type roundFixture struct {
+	rs           *RoundService
+	rounds       *memoryRounds
+	participants *memoryParticipants
+	solutions    *memorySolutions
+	reviews      *memoryReviews
+	postman      *recordingPostman
+}
+
+func newRoundFixture() *roundFixture {
+	return newRoundFixtureOf(3)
+}
+
+// newRoundFixtureOf - то же, что newRoundFixture, но с участниками от 1 до count
+func newRoundFixtureOf(count int) *roundFixture {
+	round := mathbattle.NewRoundFromEnd(time.Now().Add(-time.Hour))
+	round.ID = "r1"
+	round.SetSolveStartDate(time.Now().Add(-48 * time.Hour))
+
+	participants := &memoryParticipants{}
+	solutions := &memorySolutions{}
+	for i := 1; i <= count; i++ {
+		ID := fmt.Sprint(i)
+		participants.participants = append(participants.participants, mathbattle.Participant{
+			ID:       ID,
+			Name:     "Participant " + ID,
+			IsActive: true,
+			User:     mathbattle.User{TelegramID: int64(100 + i)},
+		})
+		round.ProblemDistribution[ID] = []mathbattle.ProblemDescriptor{{Caption: "A", ProblemID: "p1"}}
+		solutions.solutions = append(solutions.solutions, mathbattle.Solution{
+			ID:            "s" + ID,
+			ParticipantID: ID,
+			ProblemID:     "p1",
+			RoundID:       round.ID,
+			Parts:         []mathbattle.Image{{Extension: ".jpg", MimeType: mathbattle.MimeTypeJPEG, Content: []byte{1}}},
+		})
+	}
+
+	f := &roundFixture{
+		rounds:       &memoryRounds{running: &round},
+		participants: participants,
+		solutions:    solutions,
+		reviews:      &memoryReviews{},
+		postman:      &recordingPostman{},
+	}
+	f.rs = &RoundService{
+		Rep:                    f.rounds,
+		Repliers:               textRepliers{},
+		Postman:                f.postman,
+		Participants:           participants,
+		Solutions:              f.solutions,
+		Reviews:                f.reviews,
+		Teams:                  &memoryTeams{},
+		ReviewStageDistributor: &solutiondistributor.SolutionDistributor{},
+		ReviewersCount:         1,
+		TimeZone:               time.UTC,
+	}
+	return f
+}
+
+// startReview начинает этап ревью в раунде фикстуры и возвращает ревьюера решения s1 и третьего участника
+func (f *roundFixture) startReview(req *require.Assertions) (string, string) {
+	_, err := f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: reviewStageEnd()})
+	req.NoError(err)
+	f.postman.sent = nil
+
+	reviewers := f.rounds.running.ReviewDistribution.Reviewers("s1")
+	req.Len(reviewers, f.rs.ReviewersCount)
+	for _, ID := range []string{"2", "3"} {
+		if ID != reviewers[0] {
+			return reviewers[0], ID
+		}
+	}
+	return reviewers[0], ""
+}
+
+func (f *roundFixture) sentTo(chatID int64) []string {
+	result := []string{}
+	for _, item := range f.postman.sent {
+		if item.chatID == chatID {
+			result = append(result, item.caption)
+		}
+	}
+	return result
+}
+
+func reviewStageEnd() string {
+	return time.Now().AddDate(0, 0, 7).Format("02.01.2006")
+}
+
+func telegramID(participantID string) int64 {
+	ID, _ := strconv.ParseInt(participantID, 10, 64)
+	return 100 + ID
+}
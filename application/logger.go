@@ -0,0 +1,13 @@
+package application
+
+import (
+	"mathbattle/libs/mlog"
+)
+
+// loggerOrDefault - логгер, который контейнер передал сервису, или mlog.Default, если сервис собран без него
+func loggerOrDefault(l *mlog.Logger) *mlog.Logger {
+	if l == nil {
+		return mlog.Default()
+	}
+	return l
+}
@@ -1,12 +1,30 @@
 package application
 
-import "mathbattle/models/mathbattle"
+import (
+	"strconv"
+	"time"
+
+	"mathbattle/models/mathbattle"
+)
 
 type ParticipantService struct {
-	Rep mathbattle.ParticipantRepository
+	Rep  mathbattle.ParticipantRepository
+	Form mathbattle.RegistrationForm
+	// Участники распределяются по лигам по классу, см. Leagues.Assign
+	Leagues mathbattle.Leagues
+	Audit   *Auditor
 }
 
 func (ps *ParticipantService) Store(participant mathbattle.Participant) (mathbattle.Participant, error) {
+	if err := ps.Form.Validate(participant); err != nil {
+		return participant, err
+	}
+
+	if participant.GradeYear == 0 {
+		participant.GradeYear = mathbattle.SchoolYear(time.Now())
+	}
+	ps.Leagues.Assign(&participant)
+
 	return ps.Rep.Store(participant)
 }
 
@@ -23,11 +41,28 @@ func (ps *ParticipantService) GetAll() ([]mathbattle.Participant, error) {
 }
 
 func (ps *ParticipantService) Update(participant mathbattle.Participant) error {
+	if err := mathbattle.ValidateParticipantFields(participant); err != nil {
+		return err
+	}
+
+	if !ps.Leagues.IsExist(participant.League) {
+		return mathbattle.ErrWrongUserInput
+	}
+	ps.Leagues.Assign(&participant)
+
 	return ps.Rep.Update(participant)
 }
 
-func (ps *ParticipantService) Delete(ID string) error {
-	return ps.Rep.Delete(ID)
+func (ps *ParticipantService) Delete(ID string, actorID int64) error {
+	participant, err := ps.Rep.GetByID(ID)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.Rep.Delete(ID); err != nil {
+		return err
+	}
+	return ps.Audit.Record(actorID, mathbattle.AuditParticipantDelete, ID, participant, nil)
 }
 
 func (ps *ParticipantService) Unsubscribe(ID string) error {
@@ -39,3 +74,38 @@ func (ps *ParticipantService) Unsubscribe(ID string) error {
 	participant.IsActive = false
 	return ps.Rep.Update(participant)
 }
+
+// PromoteGrades переводит всех участников в классы учебного года schoolYear. Повторный перевод
+// в тот же год ничего не меняет, перевод в ещё не начавшийся год запрещён
+func (ps *ParticipantService) PromoteGrades(schoolYear int, actorID int64) (mathbattle.GradePromotion, error) {
+	result := mathbattle.GradePromotion{SchoolYear: schoolYear}
+	if schoolYear > mathbattle.SchoolYear(time.Now()) {
+		return result, mathbattle.ErrWrongUserInput
+	}
+
+	participants, err := ps.Rep.GetAll()
+	if err != nil {
+		return result, err
+	}
+
+	for _, participant := range participants {
+		wasActive := participant.IsActive
+		if !participant.PromoteGrade(schoolYear) {
+			continue
+		}
+		ps.Leagues.Assign(&participant)
+
+		if err := ps.Rep.Update(participant); err != nil {
+			return result, err
+		}
+
+		if wasActive && !participant.IsActive {
+			result.Graduated++
+		} else {
+			result.Promoted++
+		}
+	}
+	err = ps.Audit.Record(actorID, mathbattle.AuditGradesPromote, strconv.Itoa(schoolYear), nil, result)
+
+	return result, err
+}
@@ -0,0 +1,78 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromoteGrades(t *testing.T) {
+	req := require.New(t)
+
+	year := mathbattle.SchoolYear(time.Now())
+	rep := &memoryParticipants{participants: []mathbattle.Participant{
+		{ID: "1", Grade: 7, GradeYear: year - 1, IsActive: true},
+		{ID: "2", Grade: 11, GradeYear: year - 1, IsActive: true},
+		{ID: "3", Grade: 9, GradeYear: 0, IsActive: true},
+		{ID: "4", Grade: 5, GradeYear: year, IsActive: true},
+		{ID: "5", Grade: 8, GradeYear: year - 2, IsActive: false},
+	}}
+	ps := &ParticipantService{Rep: rep}
+
+	promotion, err := ps.PromoteGrades(year, 0)
+	req.Nil(err)
+	req.Equal(mathbattle.GradePromotion{SchoolYear: year, Promoted: 2, Graduated: 1}, promotion)
+
+	req.Equal(8, rep.participants[0].Grade)
+	req.Equal(11, rep.participants[1].Grade)
+	req.False(rep.participants[1].IsActive)
+	// Учебный год неизвестен - класс не угадываем
+	req.Equal(9, rep.participants[2].Grade)
+	req.Zero(rep.participants[2].GradeYear)
+	req.Equal(5, rep.participants[3].Grade)
+	req.Equal(10, rep.participants[4].Grade)
+	for _, i := range []int{0, 1, 3, 4} {
+		req.Equal(year, rep.participants[i].GradeYear)
+	}
+
+	// Повторный перевод ничего не меняет
+	promotion, err = ps.PromoteGrades(year, 0)
+	req.Nil(err)
+	req.Equal(mathbattle.GradePromotion{SchoolYear: year}, promotion)
+
+	_, err = ps.PromoteGrades(year+1, 0)
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+}
+
+func TestSchoolYear(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal(2025, mathbattle.SchoolYear(time.Date(2026, time.August, 31, 23, 0, 0, 0, time.UTC)))
+	req.Equal(2026, mathbattle.SchoolYear(time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestPromoteGradesMovesLeague(t *testing.T) {
+	req := require.New(t)
+
+	year := mathbattle.SchoolYear(time.Now())
+	rep := &memoryParticipants{participants: []mathbattle.Participant{
+		{ID: "1", Grade: 6, GradeYear: year - 1, IsActive: true, League: "junior"},
+		{ID: "2", Grade: 5, GradeYear: year - 1, IsActive: true},
+		{ID: "3", Grade: 6, GradeYear: year - 1, IsActive: true, League: "invited"},
+	}}
+	ps := &ParticipantService{Rep: rep, Leagues: mathbattle.Leagues{
+		{Name: "junior", Grades: []int{5, 6}},
+		{Name: "invited"},
+	}}
+
+	_, err := ps.PromoteGrades(year, 0)
+	req.Nil(err)
+
+	// Из лиги по классам участник переходит в лигу нового класса, лигу без классов сохраняет
+	req.Equal("", rep.participants[0].League)
+	req.Equal("junior", rep.participants[1].League)
+	req.Equal("invited", rep.participants[2].League)
+}
@@ -1,48 +1,57 @@
 package application
 
 import (
-	"log"
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
 type PostmanService struct {
 	Users   mathbattle.UserRepository
 	Postman mathbattle.PostmanService
+	Audit   *Auditor
+	Logger  *mlog.Logger
+}
+
+func (s *PostmanService) logger() *mlog.Logger {
+	return loggerOrDefault(s.Logger)
 }
 
 func (s *PostmanService) SendSimpleToUsers(msg mathbattle.SimpleMessage) error {
-	log.Printf("[PostmanService] SendSimpleToUsers, msg = %s", msg)
+	s.logger().Debugf("[PostmanService] SendSimpleToUsers, msg = %v", msg)
+	if err := s.Audit.Record(msg.ActorID, mathbattle.AuditBroadcast, "", nil, msg); err != nil {
+		return err
+	}
 
 	if len(msg.UsersIDS) == 0 {
-		log.Printf("[PostmanService] SendSimpleToUsers, send to everyone")
+		s.logger().Debugf("[PostmanService] SendSimpleToUsers, send to everyone")
 
 		users, err := s.Users.GetAll()
 		if err != nil {
-			log.Printf("[PostmanService][SendSimpleToUsers] Failed to get all users, error: %v", err)
+			s.logger().Errorf("[PostmanService][SendSimpleToUsers] Failed to get all users, error: %v", err)
 			return err
 		}
 
 		for _, user := range users {
 			err = s.SendSimpleMessage(user.TelegramID, msg.Text)
 			if err != nil {
-				log.Printf("[PostmanService][SendSimpleToUsers] Failed to send to user with telegram id %d, error: %v", user.TelegramID, err)
+				s.logger().Errorf("[PostmanService][SendSimpleToUsers] Failed to send to user with telegram id %d, error: %v", user.TelegramID, err)
 			} else {
-				log.Printf("[PostmanService][SendSimpleToUsers] Success sent to user with telegram id %d", user.TelegramID)
+				s.logger().Debugf("[PostmanService][SendSimpleToUsers] Success sent to user with telegram id %d", user.TelegramID)
 			}
 		}
 	} else {
 		for _, userID := range msg.UsersIDS {
 			user, err := s.Users.GetByID(userID)
 			if err != nil {
-				log.Printf("[PostmanService][SendSimpleToUsers] Failed to get user, error: %v", err)
+				s.logger().Errorf("[PostmanService][SendSimpleToUsers] Failed to get user, error: %v", err)
 				return err
 			}
 
 			err = s.SendSimpleMessage(user.TelegramID, msg.Text)
 			if err != nil {
-				log.Printf("[PostmanService][SendSimpleToUsers] Failed to send to user, error: %v", err)
+				s.logger().Errorf("[PostmanService][SendSimpleToUsers] Failed to send to user, error: %v", err)
 			} else {
-				log.Printf("[PostmanService][SendSimpleToUsers] Success sent to user with telegram id %d", user.TelegramID)
+				s.logger().Debugf("[PostmanService][SendSimpleToUsers] Success sent to user with telegram id %d", user.TelegramID)
 			}
 		}
 	}
@@ -61,3 +70,7 @@ func (s *PostmanService) SendImage(chatID int64, imageCaption string, image []by
 func (s *PostmanService) SendAlbum(chatID int64, albumCaption string, images [][]byte) error {
 	return s.Postman.SendAlbum(chatID, albumCaption, images)
 }
+
+func (s *PostmanService) SendDocument(chatID int64, fileName string, caption string, content []byte) error {
+	return s.Postman.SendDocument(chatID, fileName, caption, content)
+}
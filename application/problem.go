@@ -9,3 +9,7 @@ type ProblemService struct {
 func (s *ProblemService) GetByID(ID string) (mathbattle.Problem, error) {
 	return s.Rep.GetByID(ID)
 }
+
+func (s *ProblemService) GetAll() ([]mathbattle.Problem, error) {
+	return s.Rep.GetAll()
+}
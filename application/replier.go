@@ -11,7 +11,17 @@ type TelegramCommandHelp struct {
 	Desc string
 }
 
+// Repliers выбирает Replier по языку пользователя
+type Repliers interface {
+	Default() Replier
+	ForLanguage(language string) Replier
+	Languages() []string
+}
+
 type Replier interface {
+	Language() string
+	LanguageName() string
+
 	GetStartMessage() string
 	GetAvailableCommands(availableCommands []TelegramCommandHelp) string
 	GetHelpMessages() []string
@@ -44,15 +54,70 @@ type Replier interface {
 	CmdServiceMsgDesc() string
 	CmdGetMyResultsName() string
 	CmdGetMyResultsDesc() string
+	CmdReassignReviewName() string
+	CmdReassignReviewDesc() string
+	CmdRemindersName() string
+	CmdRemindersDesc() string
+	CmdLanguageName() string
+	CmdLanguageDesc() string
+	CmdTimeZoneName() string
+	CmdTimeZoneDesc() string
+	CmdLeagueName() string
+	CmdLeagueDesc() string
+	CmdRoleName() string
+	CmdRoleDesc() string
+	CmdProfileName() string
+	CmdProfileDesc() string
+	CmdTeamName() string
+	CmdTeamDesc() string
+	CmdBattleName() string
+	CmdBattleDesc() string
 
 	InternalError() string
 	NotParticipant() string
+	CallbackOutdated() string
 	NoRoundRunning() string
 
 	SolveStageEnd() string
 	SolveStageEndNoSolutions() string
+	BattlePreparationEnd() string
 	ReviewStageEnd() string
 
+	// Replies used to remind about stage end
+	ReminderSolveStage(timeLeft time.Duration, notSolvedCaptions []string) string
+	ReminderReviewStage(timeLeft time.Duration, notReviewedCaptions []string) string
+
+	// Replies used in CmdReminders
+	RemindersStatus(isOff bool) string
+	RemindersTurnOn() string
+	RemindersTurnOff() string
+	RemindersChanged(isOff bool) string
+
+	// Replies used in CmdLanguage
+	LanguageAsk() string
+	LanguageWrong() string
+	LanguageChanged() string
+
+	// Replies used in CmdTimeZone
+	TimeZoneAsk(now time.Time) string
+	TimeZoneEventDefault() string
+	TimeZoneWrong() string
+	TimeZoneChanged(now time.Time) string
+
+	// Replies used in CmdLeague
+	LeagueAsk(current string) string
+	LeagueMain() string
+	LeagueWrong() string
+	LeagueChanged(league string) string
+
+	// Replies used in CmdRole
+	RoleAskUser(staff []mathbattle.User) string
+	RoleName(role mathbattle.Role) string
+	RoleAskRole() string
+	RoleWrongRole() string
+	RoleFailed() string
+	RoleChanged(user mathbattle.User) string
+
 	// Replies used in CmdSubscribe
 	AlreadyRegistered() string
 	RegisterNameExpect() string
@@ -60,7 +125,59 @@ type Replier interface {
 	RegisterGradeExpect() string
 	RegisterGradeWrong() string
 	RegisterSuccess() string
-	RegisterSuccessRoundRunning(solveStageDuration time.Duration, solveStageEndMsk time.Time) string
+	RegisterSuccessRoundRunning(solveStageDuration time.Duration, solveStageEnd time.Time) string
+	RegisterSkip() string
+	RegisterSchoolExpect() string
+	RegisterSchoolChoose() string
+	RegisterSchoolWrong() string
+	RegisterRegionExpect() string
+	RegisterRegionWrong() string
+	RegisterTeacherContactExpect() string
+	RegisterTeacherContactWrong() string
+	RegisterConsentExpect() string
+	RegisterConsentRequired() string
+
+	// Replies used in CmdProfile
+	Profile(participant mathbattle.Participant, form mathbattle.RegistrationForm) string
+	ProfileFieldName() string
+	ProfileFieldGrade() string
+	ProfileGradeExpect() string
+	ProfileFieldSchool() string
+	ProfileFieldRegion() string
+	ProfileFieldTeacherContact() string
+	ProfileFieldParentalConsent() string
+	ProfileChooseField() string
+	ProfileWrongField() string
+	ProfileUpdated() string
+	ProfileNotChanged() string
+
+	// Replies used in CmdTeam
+	Team(team mathbattle.Team, memberNames []string, isCaptain bool) string
+	TeamNone() string
+	TeamCreate() string
+	TeamJoin() string
+	TeamLeave() string
+	TeamExpectName() string
+	TeamWrongName() string
+	TeamExpectInviteCode() string
+	TeamWrongInviteCode() string
+	TeamCreated(team mathbattle.Team) string
+	TeamJoined(team mathbattle.Team) string
+	TeamLeft() string
+	TeamLocked() string
+	TeamNotChanged() string
+	TeamRoundNoTeam() string
+
+	// Replies used in CmdBattle and BattleService
+	BattleStarted(battle mathbattle.Battle, teamID string) string
+	BattleStatus(battle mathbattle.Battle, teamID string) string
+	BattleJudged(battle mathbattle.Battle, fight mathbattle.BattleFight) string
+	BattleNone() string
+	BattlePass() string
+	BattleAccept() string
+	BattleRefuse() string
+	BattleMoveDone() string
+	BattleMoveWrong() string
 
 	// Replies used in CmdUnsubscribe
 	NotSubscribed() string
@@ -82,25 +199,67 @@ type Replier interface {
 	SolutionFinishUploading() string
 	SolutionWrongFormat() string
 	SolutionEmpty() string
+	SolutionPartTooLarge(maxSize int64) string
+	SolutionPartUnsupported() string
 
 	// Replies used in CmdStartReviewStage
-	StartReviewGetDuration() string
+	StartReviewGetDuration(timeZone *time.Location) string
 	StartReviewWrongDuration() string
 	StartReviewConfirmDuration(untilDate time.Time) string
 	StartReviewSuccess(FailedParticipants []mathbattle.ParticipantError) string
+	StartReviewDistributionChanged() string
+
+	// Replies used in CmdReassignReview
+	ReassignAskAction(isPreview bool) string
+	ReassignActionMove() string
+	ReassignActionAddReviewer() string
+	ReassignActionToOrganizers() string
+	ReassignAbort() string
+	ReassignUseButtons() string
+	ReassignNothing() string
+	ReassignChooseProblem() string
+	ReassignProblemButton(problemID string) string
+	ReassignChooseSolution() string
+	ReassignChooseFrom() string
+	ReassignChooseTo() string
+	ReassignNoChoice() string
+	ReassignConfirm(action string, solution string, from string, to string) string
+	ReassignWrong() string
+	ReassignReviewSubmitted() string
+	ReassignSuccess(result mathbattle.ReassignResult) string
 
 	// Replies used in CmdStartRound
-	StartRoundGetDuration() string
+	StartRoundGetDuration(timeZone *time.Location) string
 	StartRoundWrongDuration() string
 	StartRoundConfirmDuration(untillDate time.Time) string
 	StartRoundSuccess(startResult mathbattle.SSStartResult) string
-	StartRoundAskProblemsIDs() string
+	StartRoundProblemBankEmpty() string
+	StartRoundProblemCaption(problem mathbattle.Problem) string
+	StartRoundProblemButton(problem mathbattle.Problem, isPicked bool) string
+	StartRoundPickProblems() string
+	StartRoundPickedProblems(picked []mathbattle.Problem) string
+	StartRoundPickWrong() string
+	StartRoundPickNothing() string
+	StartRoundPickDone() string
+	StartRoundReorder() string
+	StartRoundReorderExpect(picked []mathbattle.Problem) string
+	StartRoundReorderWrong() string
+	StartRoundAbort() string
+	StartRoundPreview() string
+	StartRoundConfirmStart(picked []mathbattle.Problem) string
+	StartRoundModeIndividual() string
+	StartRoundModeTeam() string
+	StartRoundModeMatboi() string
 
 	// Replies used to post solutions to other participants to review
 	ReviewPostBefore(stageDuration time.Duration, stageEnd time.Time) string
 	ReviewPostCaption(problemCaption string, solutionNumber int) string
 	ReviewPostAfter() string
 
+	// Replies used to notify participants about manual changes of review distribution
+	ReviewReassignRemoved(problemCaption string, solutionNumber int, actual []mathbattle.SolutionDescriptor) string
+	ReviewReassignAdded() string
+
 	// Replies used in CmdSubmitReview
 	ReviewGetSolutionCaptions(descriptors []mathbattle.SolutionDescriptor) []string
 	ReviewGetDescriptor(userInput string) (mathbattle.SolutionDescriptor, bool)
@@ -111,6 +270,9 @@ type Replier interface {
 	ReviewUploadSuccess() string
 	ReviewMsgForReviewee(review mathbattle.Review) string
 
+	// Replies used in CmdGetReviews
+	GetReviewsComment(number int, problemCaption string, content string) string
+
 	// Replies used in CmdStat
 	FormatStat(stat mathbattle.Stat) string
 
@@ -3,9 +3,19 @@ package application
 import "mathbattle/models/mathbattle"
 
 type ReviewService struct {
-	Rep       mathbattle.ReviewRepository
-	Rounds    mathbattle.RoundRepository
-	Solutions mathbattle.SolutionRepository
+	Rep          mathbattle.ReviewRepository
+	Rounds       mathbattle.RoundRepository
+	Solutions    mathbattle.SolutionRepository
+	Teams        mathbattle.TeamRepository
+	Participants mathbattle.ParticipantRepository
+	Audit        *Auditor
+}
+
+func (s *ReviewService) findMany(descriptor mathbattle.ReviewFindDescriptor, solutionID string) ([]mathbattle.Review, error) {
+	if descriptor.ReviewerTeamID != "" {
+		return s.Rep.FindManyByTeam(descriptor.ReviewerTeamID, solutionID)
+	}
+	return s.Rep.FindMany(descriptor.ReviewerID, solutionID)
 }
 
 func (s *ReviewService) Store(review mathbattle.Review) (mathbattle.Review, error) {
@@ -14,7 +24,7 @@ func (s *ReviewService) Store(review mathbattle.Review) (mathbattle.Review, erro
 
 func (s *ReviewService) FindMany(descriptor mathbattle.ReviewFindDescriptor) ([]mathbattle.Review, error) {
 	if descriptor.ProblemID == "" {
-		return s.Rep.FindMany(descriptor.ReviewerID, descriptor.SolutionID)
+		return s.findMany(descriptor, descriptor.SolutionID)
 	} else {
 		result := []mathbattle.Review{}
 
@@ -28,7 +38,7 @@ func (s *ReviewService) FindMany(descriptor mathbattle.ReviewFindDescriptor) ([]
 		}
 
 		for _, solution := range allSolutions {
-			reviews, err := s.Rep.FindMany(descriptor.ReviewerID, solution.ID)
+			reviews, err := s.findMany(descriptor, solution.ID)
 			if err != nil {
 				return []mathbattle.Review{}, err
 			}
@@ -39,15 +49,43 @@ func (s *ReviewService) FindMany(descriptor mathbattle.ReviewFindDescriptor) ([]
 	}
 }
 
-func (s *ReviewService) Delete(ID string) error {
+func (s *ReviewService) Delete(ID string, actorID int64) error {
+	review, err := s.Rep.Get(ID)
+	if err != nil {
+		return err
+	}
+
+	own, err := isOwn(s.Participants, s.Teams, actorID, review.ReviewerID, review.ReviewerTeamID)
+	if err != nil {
+		return err
+	}
+	if !own {
+		if err := s.Audit.Record(actorID, mathbattle.AuditReviewDelete, ID, review, nil); err != nil {
+			return err
+		}
+	}
+
 	return s.Rep.Delete(ID)
 }
 
 func (s *ReviewService) RevewStageDescriptors(participantID string) ([]mathbattle.SolutionDescriptor, error) {
-	round, err := s.Rounds.GetLast()
+	participant, err := s.Participants.GetByID(participantID)
+	if err != nil {
+		return []mathbattle.SolutionDescriptor{}, err
+	}
+
+	round, err := s.Rounds.GetLast(participant.League)
 	if err != nil {
 		return []mathbattle.SolutionDescriptor{}, err
 	}
 
-	return mathbattle.SolutionDescriptorsFromSolutionIDs(s.Solutions, participantID, round)
+	competitorID, err := mathbattle.CompetitorID(round, participantID, s.Teams)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return []mathbattle.SolutionDescriptor{}, nil
+		}
+		return []mathbattle.SolutionDescriptor{}, err
+	}
+
+	return mathbattle.SolutionDescriptorsFromSolutionIDs(s.Solutions, competitorID, round)
 }
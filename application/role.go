@@ -0,0 +1,88 @@
+package application
+
+import (
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+type RoleService struct {
+	Users  mathbattle.UserRepository
+	Audit  *Auditor
+	Logger *mlog.Logger
+}
+
+func (s *RoleService) logger() *mlog.Logger {
+	return loggerOrDefault(s.Logger)
+}
+
+func (s *RoleService) findUser(order mathbattle.RoleOrder) (mathbattle.User, error) {
+	var user mathbattle.User
+	var err error
+	if order.TelegramID != 0 {
+		user, err = s.Users.GetByTelegramID(order.TelegramID)
+	} else {
+		user, err = s.Users.GetByTelegramName(order.TelegramName)
+	}
+
+	if err == mathbattle.ErrNotFound {
+		return user, mathbattle.ErrWrongUserInput
+	}
+	return user, err
+}
+
+// Grant выдаёт пользователю роль или забирает её. Последнего владельца оставить без роли нельзя:
+// иначе выдавать роли будет некому
+func (s *RoleService) Grant(order mathbattle.RoleOrder) (mathbattle.User, error) {
+	role, err := mathbattle.ParseRole(string(order.Role))
+	if err != nil {
+		return mathbattle.User{}, err
+	}
+
+	user, err := s.findUser(order)
+	if err != nil {
+		return user, err
+	}
+
+	if user.Role == mathbattle.RoleOwner && role != mathbattle.RoleOwner {
+		staff, err := s.GetStaff()
+		if err != nil {
+			return user, err
+		}
+
+		owners := 0
+		for _, member := range staff {
+			if member.Role == mathbattle.RoleOwner {
+				owners++
+			}
+		}
+		if owners <= 1 {
+			return user, mathbattle.ErrWrongUserInput
+		}
+	}
+
+	previous := user.Role
+	user.Role = role
+	if err := s.Users.Update(user); err != nil {
+		return user, err
+	}
+	s.logger().Infof("Role of user %s (@%s) changed from '%s' to '%s' by %d", user.ID, user.TelegramUsername, previous, role, order.ActorID)
+	err = s.Audit.Record(order.ActorID, mathbattle.AuditRoleChange, user.ID, previous, role)
+
+	return user, err
+}
+
+// GetStaff - пользователи с ролями
+func (s *RoleService) GetStaff() ([]mathbattle.User, error) {
+	users, err := s.Users.GetAll()
+	if err != nil {
+		return []mathbattle.User{}, err
+	}
+
+	result := []mathbattle.User{}
+	for _, user := range users {
+		if user.IsStaff() {
+			result = append(result, user)
+		}
+	}
+	return result, nil
+}
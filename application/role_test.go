@@ -0,0 +1,59 @@
+package application
+
+import (
+	"bytes"
+	"testing"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrantRole(t *testing.T) {
+	req := require.New(t)
+
+	users := &memoryUsers{users: []mathbattle.User{
+		{ID: "1", TelegramUsername: "owner", Role: mathbattle.RoleOwner},
+		{ID: "2", TelegramUsername: "Ivanov"},
+	}}
+	rs := &RoleService{Users: users}
+
+	user, err := rs.Grant(mathbattle.RoleOrder{TelegramName: "@ivanov", Role: mathbattle.RoleJury})
+	req.Nil(err)
+	req.Equal(mathbattle.RoleJury, user.Role)
+	req.True(users.users[1].Can(mathbattle.PermissionJudge))
+	req.False(users.users[1].Can(mathbattle.PermissionManageRounds))
+
+	_, err = rs.Grant(mathbattle.RoleOrder{TelegramName: "ivanov", Role: "president"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+
+	_, err = rs.Grant(mathbattle.RoleOrder{TelegramName: "petrov", Role: mathbattle.RoleJury})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+
+	// Последнего владельца оставить без роли нельзя
+	_, err = rs.Grant(mathbattle.RoleOrder{TelegramName: "owner"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+
+	_, err = rs.Grant(mathbattle.RoleOrder{TelegramName: "ivanov", Role: mathbattle.RoleOwner})
+	req.Nil(err)
+	_, err = rs.Grant(mathbattle.RoleOrder{TelegramName: "owner"})
+	req.Nil(err)
+
+	staff, err := rs.GetStaff()
+	req.Nil(err)
+	req.Equal(1, len(staff))
+	req.Equal("2", staff[0].ID)
+}
+
+func TestRoleServiceLogger(t *testing.T) {
+	req := require.New(t)
+
+	var buf bytes.Buffer
+	users := &memoryUsers{users: []mathbattle.User{{ID: "2", TelegramUsername: "ivanov"}}}
+	rs := &RoleService{Users: users, Logger: mlog.New(&buf, mlog.LevelInfo, mlog.FormatText).With("request_id", "abc")}
+
+	_, err := rs.Grant(mathbattle.RoleOrder{ActorID: 100, TelegramName: "ivanov", Role: mathbattle.RoleJury})
+	req.Nil(err)
+	req.Contains(buf.String(), "INFO Role of user 2 (@ivanov) changed from '' to 'jury' by 100 request_id=abc")
+}
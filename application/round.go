@@ -3,16 +3,29 @@ package application
 import (
 	"errors"
 	"fmt"
-	"log"
+	"sort"
+	"sync"
 	"time"
 
 	"mathbattle/application/ssd"
+	"mathbattle/libs/mlog"
 	"mathbattle/libs/mstd"
 	"mathbattle/models/mathbattle"
 )
 
-func ReviewDistrubitonToString(participants mathbattle.ParticipantRepository, solutions mathbattle.SolutionRepository,
-	d mathbattle.ReviewDistribution) (mathbattle.ReviewDistributionDesc, error) {
+// ReviewDistrubitonToString - распределение решений для организаторов. hideAuthors - вместо авторов решений
+// писать псевдонимы решений (слепая проверка). teams не nil в командном раунде, тогда вместо участников пишутся команды
+func ReviewDistrubitonToString(participants mathbattle.ParticipantRepository, teams mathbattle.TeamRepository,
+	solutions mathbattle.SolutionRepository, d mathbattle.ReviewDistribution, hideAuthors bool) (mathbattle.ReviewDistributionDesc, error) {
+
+	competitorName := func(competitorID string) (string, error) {
+		if teams != nil {
+			team, err := teams.Get(competitorID)
+			return team.Name, err
+		}
+		p, err := participants.GetByID(competitorID)
+		return p.Name, err
+	}
 
 	result := ""
 	result += "To orgs: \n"
@@ -23,6 +36,20 @@ func ReviewDistrubitonToString(participants mathbattle.ParticipantRepository, so
 			return mathbattle.ReviewDistributionDesc{Desc: ""}, err
 		}
 
+		if hideAuthors {
+			result += fmt.Sprintf("Solution %s on %s\n", solution.Alias(), solution.ProblemID)
+			continue
+		}
+
+		if teams != nil {
+			name, err := competitorName(solution.CompetitorID())
+			if err != nil {
+				return mathbattle.ReviewDistributionDesc{Desc: ""}, err
+			}
+			result += fmt.Sprintf("Team '%s', solution on %s\n", name, solution.ProblemID)
+			continue
+		}
+
 		p, err := participants.GetByID(solution.ParticipantID)
 		if err != nil {
 			return mathbattle.ReviewDistributionDesc{Desc: ""}, err
@@ -32,10 +59,14 @@ func ReviewDistrubitonToString(participants mathbattle.ParticipantRepository, so
 	}
 	result += "\n"
 
-	result += "Between participants: \n"
+	if teams != nil {
+		result += "Between teams: \n"
+	} else {
+		result += "Between participants: \n"
+	}
 	result += "---------\n"
 	for participantID, solutionIDs := range d.BetweenParticipants {
-		p, err := participants.GetByID(participantID)
+		reviewerName, err := competitorName(participantID)
 		if err != nil {
 			return mathbattle.ReviewDistributionDesc{Desc: ""}, err
 		}
@@ -45,11 +76,15 @@ func ReviewDistrubitonToString(participants mathbattle.ParticipantRepository, so
 			if err != nil {
 				return mathbattle.ReviewDistributionDesc{Desc: ""}, err
 			}
-			fromParticipant, err := participants.GetByID(solution.ParticipantID)
+			if hideAuthors {
+				result += fmt.Sprintf("'%s' <- Solution %s (Problem %s)\n", reviewerName, solution.Alias(), solution.ProblemID)
+				continue
+			}
+			authorName, err := competitorName(solution.CompetitorID())
 			if err != nil {
 				return mathbattle.ReviewDistributionDesc{Desc: ""}, err
 			}
-			result += fmt.Sprintf("Participant '%s' <- Participant '%s' (Problem %s)\n", p.Name, fromParticipant.Name, solution.ProblemID)
+			result += fmt.Sprintf("'%s' <- '%s' (Problem %s)\n", reviewerName, authorName, solution.ProblemID)
 		}
 	}
 
@@ -65,19 +100,125 @@ type SSD interface {
 type SolutionDistributor interface {
 	// Распределить все решения, сданные в текущем раунде, на ревью между участниками.
 	// Каждое решение будет отправлено нескольким другим участникам (reviewerCount)
-	Get(allRoundSolutions []mathbattle.Solution, reviewerCount uint) mathbattle.ReviewDistribution
+	// Один и тот же seed даёт одно и то же распределение
+	Get(allRoundSolutions []mathbattle.Solution, reviewerCount uint, seed int64) mathbattle.ReviewDistribution
 }
 
 type RoundService struct {
 	Rep                    mathbattle.RoundRepository
-	Replier                Replier
+	Repliers               Repliers
 	Postman                mathbattle.PostmanService
 	Participants           mathbattle.ParticipantRepository
 	Solutions              mathbattle.SolutionRepository
 	Problems               mathbattle.ProblemRepository
 	Reviews                mathbattle.ReviewRepository
+	Teams                  mathbattle.TeamRepository
 	ReviewStageDistributor SolutionDistributor
 	ReviewersCount         int
+	// За сколько до конца этапа ревью забирать решения у неактивных участников. 0 - не забирать
+	ReviewWatchdogBefore time.Duration
+	// За сколько до конца этапа напоминать тем, кто ещё не всё сдал
+	RemindersBefore []time.Duration
+	// Превью pdf решений для ревьюеров, nil - отправлять только сам документ
+	Previewer mathbattle.DocumentPreviewer
+	// Слепая проверка: организаторы не видят авторов решений, пока результаты раунда не опубликованы
+	BlindGrading bool
+	// Часовой пояс мероприятия: в нём показываются сроки участникам, не выбравшим свой пояс
+	TimeZone *time.Location
+	// Лиги, в которых можно начинать раунды, кроме основной
+	Leagues mathbattle.Leagues
+	Audit   *Auditor
+	Logger  *mlog.Logger
+
+	remindedMutex sync.Mutex
+	// Уже отправленные напоминания, см. markReminded
+	reminded map[string]bool
+}
+
+func (rs *RoundService) logger() *mlog.Logger {
+	return loggerOrDefault(rs.Logger)
+}
+
+// roundAudit - то, что пишется о раунде в журнал аудита. Распределения туда не попадают: они большие
+func roundAudit(round mathbattle.Round) map[string]interface{} {
+	return map[string]interface{}{
+		"league":            round.League,
+		"mode":              round.Mode,
+		"stage":             mathbattle.GetRoundStage(round),
+		"results_published": round.ResultsPublished,
+	}
+}
+
+// replier - ответы на языке участника
+func (rs *RoundService) replier(participant mathbattle.Participant) Replier {
+	return rs.Repliers.ForLanguage(participant.Language)
+}
+
+// timeZone - часовой пояс, в котором участнику показываются сроки
+func (rs *RoundService) timeZone(participant mathbattle.Participant) *time.Location {
+	return mathbattle.TimeZoneOr(participant.TimeZone, rs.TimeZone)
+}
+
+// orderTimeZone - часовой пояс, в котором организатор указал срок этапа. Пустой - пояс мероприятия
+func (rs *RoundService) orderTimeZone(startOrder mathbattle.StartOrder) (*time.Location, error) {
+	if startOrder.TimeZone == "" {
+		return rs.TimeZone, nil
+	}
+	return mathbattle.LoadTimeZone(startOrder.TimeZone)
+}
+
+// competitorMembers - кому писать за участника раунда с ID competitorID: в командном раунде всем участникам команды
+func (rs *RoundService) competitorMembers(round mathbattle.Round, competitorID string) ([]mathbattle.Participant, error) {
+	if !round.IsTeam() {
+		participant, err := rs.Participants.GetByID(competitorID)
+		if err != nil {
+			return []mathbattle.Participant{}, err
+		}
+		return []mathbattle.Participant{participant}, nil
+	}
+
+	team, err := rs.Teams.Get(competitorID)
+	if err != nil {
+		return []mathbattle.Participant{}, err
+	}
+
+	result := []mathbattle.Participant{}
+	for _, memberID := range team.MemberIDs {
+		participant, err := rs.Participants.GetByID(memberID)
+		if err != nil {
+			if err == mathbattle.ErrNotFound {
+				continue
+			}
+			return result, err
+		}
+		result = append(result, participant)
+	}
+
+	return result, nil
+}
+
+// leagueParticipants - участники лиги, в которой идёт раунд
+func (rs *RoundService) leagueParticipants(league string) ([]mathbattle.Participant, error) {
+	participants, err := rs.Participants.GetAll()
+	if err != nil {
+		return participants, err
+	}
+
+	result := []mathbattle.Participant{}
+	for _, participant := range participants {
+		if participant.League == league {
+			result = append(result, participant)
+		}
+	}
+	return result, nil
+}
+
+// teamsOf - команды для ReviewDistrubitonToString, nil в индивидуальном раунде
+func (rs *RoundService) teamsOf(round mathbattle.Round) mathbattle.TeamRepository {
+	if round.IsTeam() {
+		return rs.Teams
+	}
+	return nil
 }
 
 func (rs *RoundService) getSSDNewRound(startOrder mathbattle.StartOrder) (SSD, error) {
@@ -85,13 +226,9 @@ func (rs *RoundService) getSSDNewRound(startOrder mathbattle.StartOrder) (SSD, e
 	return ssd.NewEqualDistributor(rs.Problems, startOrder.ProblemsIDs)
 }
 
-func (rs *RoundService) getSSDCurrentRound() (SSD, error) {
+func (rs *RoundService) getSSDCurrentRound(round mathbattle.Round) (SSD, error) {
 	// В данный момент поддерживается только EqualDistributor
 	// Неявно предполагаем, что всем участникам разосланы одни и те же задачи
-	round, err := rs.Rep.GetRunning()
-	if err != nil {
-		return nil, errors.New("Round not running")
-	}
 
 	// Получаем первого попавшегося участника
 	participantID := ""
@@ -108,41 +245,56 @@ func (rs *RoundService) getSSDCurrentRound() (SSD, error) {
 	return ssd.NewEqualDistributor(rs.Problems, problemsIDs)
 }
 
-func (rs *RoundService) StartRoundForParticipant(ssd SSD, round mathbattle.Round, participant mathbattle.Participant) error {
+// distributeForParticipant раздаёт задачи участнику раунда competitorID. В командном раунде participant - капитан команды
+func (rs *RoundService) distributeForParticipant(ssd SSD, round mathbattle.Round, competitorID string,
+	participant mathbattle.Participant) ([]mathbattle.Problem, error) {
+
 	participantProblems, err := ssd.GetForParticipant(participant)
 	if err != nil {
-		return err
+		return participantProblems, err
 	}
 
 	for i, problem := range participantProblems {
-		round.ProblemDistribution[participant.ID] = append(round.ProblemDistribution[participant.ID],
+		round.ProblemDistribution[competitorID] = append(round.ProblemDistribution[competitorID],
 			mathbattle.ProblemDescriptor{
 				Caption:   mstd.IndexToLetter(i),
 				ProblemID: problem.ID,
 			})
 	}
 
-	duration := round.GetSolveStageDuration()
-	stageEndMsk, err := round.GetSolveEndDateMsk()
+	return participantProblems, nil
+}
+
+func (rs *RoundService) StartRoundForParticipant(ssd SSD, round mathbattle.Round, participant mathbattle.Participant) error {
+	participantProblems, err := rs.distributeForParticipant(ssd, round, participant.ID, participant)
 	if err != nil {
 		return err
 	}
 
-	message := rs.Replier.ProblemsPostBefore(duration, stageEndMsk)
-	err = rs.Postman.SendSimpleMessage(participant.TelegramID, message)
+	return rs.sendProblems(round, participant, participant.ID, participantProblems)
+}
+
+func (rs *RoundService) sendProblems(round mathbattle.Round, participant mathbattle.Participant, competitorID string,
+	participantProblems []mathbattle.Problem) error {
+
+	duration := round.GetSolveStageDuration()
+	stageEnd := round.GetSolveEndDate().In(rs.timeZone(participant))
+
+	message := rs.replier(participant).ProblemsPostBefore(duration, stageEnd)
+	err := rs.Postman.SendSimpleMessage(participant.TelegramID, message)
 	if err != nil {
 		return err
 	}
 
 	for i := 0; i < len(participantProblems); i++ {
-		err = rs.Postman.SendImage(participant.TelegramID, round.ProblemDistribution[participant.ID][i].Caption,
+		err = rs.Postman.SendImage(participant.TelegramID, round.ProblemDistribution[competitorID][i].Caption,
 			participantProblems[i].Content)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.Replier.ProblemsPostAfter())
+	err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.replier(participant).ProblemsPostAfter())
 	if err != nil {
 		return err
 	}
@@ -153,7 +305,11 @@ func (rs *RoundService) StartRoundForParticipant(ssd SSD, round mathbattle.Round
 func (rs *RoundService) StartNew(startOrder mathbattle.StartOrder) (mathbattle.SSStartResult, error) {
 	result := mathbattle.SSStartResult{}
 
-	_, err := rs.Rep.GetRunning()
+	if !rs.Leagues.IsExist(startOrder.League) {
+		return result, mathbattle.ErrWrongUserInput
+	}
+
+	_, err := rs.Rep.GetRunning(startOrder.League)
 	if err != mathbattle.ErrNotFound {
 		if err == nil {
 			return result, errors.New("Round already started")
@@ -161,36 +317,53 @@ func (rs *RoundService) StartNew(startOrder mathbattle.StartOrder) (mathbattle.S
 		return result, err
 	}
 
-	solveEndTime, err := mathbattle.ParseStageEndDate(startOrder.StageEnd)
+	location, err := rs.orderTimeZone(startOrder)
+	if err != nil {
+		rs.logger().Warnf("Unknown time zone: '%s'", startOrder.TimeZone)
+		return result, err
+	}
+	solveEndTime, err := mathbattle.ParseStageEndDate(startOrder.StageEnd, location)
 	if err != nil {
-		log.Printf("Failed to parse stage end date: '%s', Error: '%v'", startOrder.StageEnd, err)
+		rs.logger().Warnf("Failed to parse stage end date: '%s', Error: '%v'", startOrder.StageEnd, err)
 		return result, err
 	}
 
 	distributor, err := rs.getSSDNewRound(startOrder)
 	if err != nil {
-		log.Printf("Failed to get solve stage distributor, error: %v", err)
+		rs.logger().Errorf("Failed to get solve stage distributor, error: %v", err)
 		return result, err
 	}
 
 	round := mathbattle.NewRoundFromEnd(solveEndTime)
+	round.League = startOrder.League
+	switch startOrder.Mode {
+	case "", mathbattle.RoundIndividual:
+		round.Mode = mathbattle.RoundIndividual
+	case mathbattle.RoundTeam, mathbattle.RoundMatboi:
+		round.Mode = startOrder.Mode
+	default:
+		return result, mathbattle.ErrWrongUserInput
+	}
 
-	participants, err := rs.Participants.GetAll()
+	participants, err := rs.leagueParticipants(round.League)
 	if err != nil {
 		return result, err
 	}
 	result.TotalParticipants = len(participants)
 
-	for _, participant := range participants {
-		err := rs.StartRoundForParticipant(distributor, round, participant)
+	if round.IsTeam() {
+		err = rs.startNewForTeams(distributor, round, participants, startOrder.DryRun, &result)
 		if err != nil {
-			result.FailedParticipants = append(result.FailedParticipants, mathbattle.ParticipantError{
-				Participant: participant,
-				Error:       err.Error(),
-			})
-		} else {
-			result.TotalSuccessParticipants++
+			return result, err
 		}
+	} else {
+		rs.startNewForParticipants(distributor, round, participants, startOrder.DryRun, &result)
+	}
+
+	if startOrder.DryRun {
+		result.Round = round
+		result.DryRun = true
+		return result, nil
 	}
 
 	round, err = rs.Rep.Store(round)
@@ -198,28 +371,103 @@ func (rs *RoundService) StartNew(startOrder mathbattle.StartOrder) (mathbattle.S
 		return result, err
 	}
 	result.Round = round
-
-	err = rs.StartSchedulingActions()
-	if err != nil {
+	if err := rs.Audit.Record(startOrder.ActorID, mathbattle.AuditRoundStart, round.ID, nil, roundAudit(round)); err != nil {
 		return result, err
 	}
 
+	rs.scheduleRound(round)
 	return result, nil
 }
 
-func (rs *RoundService) startReviewStageForParticipant(round mathbattle.Round, participant mathbattle.Participant) error {
-	endMsk, err := round.GetReviewEndDateMsk()
+func (rs *RoundService) startNewForParticipants(distributor SSD, round mathbattle.Round, participants []mathbattle.Participant,
+	dryRun bool, result *mathbattle.SSStartResult) {
+
+	for _, participant := range participants {
+		var err error
+		if dryRun {
+			_, err = rs.distributeForParticipant(distributor, round, participant.ID, participant)
+		} else {
+			err = rs.StartRoundForParticipant(distributor, round, participant)
+		}
+		if err != nil {
+			result.FailedParticipants = append(result.FailedParticipants, mathbattle.ParticipantError{
+				Participant: participant,
+				Error:       err.Error(),
+			})
+		} else {
+			result.TotalSuccessParticipants++
+		}
+	}
+}
+
+// startNewForTeams раздаёт задачи командам: одни и те же задачи получает каждый участник команды.
+// Участники без команды в командном раунде не участвуют. Команда играет в лиге своего капитана
+func (rs *RoundService) startNewForTeams(distributor SSD, round mathbattle.Round, participants []mathbattle.Participant,
+	dryRun bool, result *mathbattle.SSStartResult) error {
+
+	teams, err := rs.Teams.GetAll()
 	if err != nil {
 		return err
 	}
 
-	err = rs.Postman.SendSimpleMessage(participant.TelegramID,
-		rs.Replier.ReviewPostBefore(round.GetReviewStageDuration(), endMsk))
+	inLeague := make(map[string]bool)
+	for _, participant := range participants {
+		inLeague[participant.ID] = true
+	}
+
+	inTeam := make(map[string]bool)
+	for _, team := range teams {
+		if !inLeague[team.CaptainID] {
+			continue
+		}
+
+		members, err := rs.competitorMembers(round, team.ID)
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		problems, err := rs.distributeForParticipant(distributor, round, team.ID, members[0])
+		for _, member := range members {
+			inTeam[member.ID] = true
+			if err == nil && !dryRun {
+				err = rs.sendProblems(round, member, team.ID, problems)
+			}
+			if err != nil {
+				result.FailedParticipants = append(result.FailedParticipants, mathbattle.ParticipantError{
+					Participant: member,
+					Error:       err.Error(),
+				})
+			} else {
+				result.TotalSuccessParticipants++
+			}
+		}
+	}
+
+	for _, participant := range participants {
+		if !inTeam[participant.ID] {
+			result.FailedParticipants = append(result.FailedParticipants, mathbattle.ParticipantError{
+				Participant: participant,
+				Error:       "participant is not in a team",
+			})
+		}
+	}
+
+	return nil
+}
+
+func (rs *RoundService) startReviewStageForParticipant(round mathbattle.Round, competitorID string, participant mathbattle.Participant) error {
+	stageEnd := round.GetReviewEndDate().In(rs.timeZone(participant))
+
+	err := rs.Postman.SendSimpleMessage(participant.TelegramID,
+		rs.replier(participant).ReviewPostBefore(round.GetReviewStageDuration(), stageEnd))
 	if err != nil {
 		return err
 	}
 
-	descriptors, err := mathbattle.SolutionDescriptorsFromSolutionIDs(rs.Solutions, participant.ID, round)
+	descriptors, err := mathbattle.SolutionDescriptorsFromSolutionIDs(rs.Solutions, competitorID, round)
 	if err != nil {
 		return err
 	}
@@ -231,20 +479,20 @@ func (rs *RoundService) startReviewStageForParticipant(round mathbattle.Round, p
 			return err
 		}
 
-		images := [][]byte{}
-		for _, part := range solution.Parts {
-			images = append(images, part.Content)
+		parts, err := mathbattle.WithContent(rs.Solutions, solution.Parts)
+		if err != nil {
+			return err
 		}
 
-		caption := rs.Replier.ReviewPostCaption(descriptors[i].ProblemCaption, descriptors[i].SolutionNumber)
-		err = rs.Postman.SendAlbum(participant.TelegramID, caption, images)
+		caption := rs.replier(participant).ReviewPostCaption(descriptors[i].ProblemCaption, descriptors[i].SolutionNumber)
+		err = sendSolution(rs.Postman, rs.Previewer, rs.logger(), participant.TelegramID, caption, descriptors[i].SolutionNumber, parts)
 		if err != nil {
 			return err
 		}
 
 	}
 
-	err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.Replier.ReviewPostAfter())
+	err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.replier(participant).ReviewPostAfter())
 	if err != nil {
 		return err
 	}
@@ -252,215 +500,1059 @@ func (rs *RoundService) startReviewStageForParticipant(round mathbattle.Round, p
 	return nil
 }
 
+func (rs *RoundService) reviewStageDistribution(league string, seed int64) (mathbattle.Round, mathbattle.ReviewDistribution, int64, error) {
+	round, err := rs.Rep.GetReviewPending(league)
+	if err != nil {
+		return round, mathbattle.ReviewDistribution{}, seed, err
+	}
+
+	// В матбое решения не рецензируют, после подготовки команды встречаются в боях
+	if round.IsMatboi() {
+		return round, mathbattle.ReviewDistribution{}, seed, mathbattle.ErrWrongUserInput
+	}
+
+	allRoundSolutions, err := rs.Solutions.FindMany(round.ID, "", "")
+	if err != nil {
+		return round, mathbattle.ReviewDistribution{}, seed, err
+	}
+
+	if seed == 0 {
+		seed = round.ReviewDistribution.PreviewSeed
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	distribution := rs.ReviewStageDistributor.Get(allRoundSolutions, uint(rs.ReviewersCount), seed)
+	if seed == round.ReviewDistribution.PreviewSeed {
+		distribution = rs.applyPreviewOverrides(round, distribution)
+	}
+	return round, distribution, seed, nil
+}
+
+// applyPreviewOverrides применяет к посчитанному распределению правки организаторов. Правку, которая
+// больше не подходит (например, решение удалили), пропускаем
+func (rs *RoundService) applyPreviewOverrides(round mathbattle.Round, distribution mathbattle.ReviewDistribution) mathbattle.ReviewDistribution {
+	preview := round
+	preview.ReviewDistribution = distribution
+	for _, order := range round.ReviewDistribution.PreviewOverrides {
+		solution, err := rs.Solutions.Get(order.SolutionID)
+		if err == nil {
+			_, _, err = rs.applyReassign(&preview, solution, order)
+		}
+		if err != nil {
+			rs.logger().Warnf("applyPreviewOverrides - skip %s of solution %s, error: %v", order.Action, order.SolutionID, err)
+		}
+	}
+	return preview.ReviewDistribution
+}
+
 func (rs *RoundService) StartReviewStage(startOrder mathbattle.StartOrder) (mathbattle.CSStartResult, error) {
 	result := mathbattle.CSStartResult{}
 
-	untilDate, err := mathbattle.ParseStageEndDate(startOrder.StageEnd)
+	location, err := rs.orderTimeZone(startOrder)
 	if err != nil {
 		return result, err
 	}
-
-	round, err := rs.Rep.GetReviewPending()
+	untilDate, err := mathbattle.ParseStageEndDate(startOrder.StageEnd, location)
 	if err != nil {
 		return result, err
 	}
 
-	allRoundSolutions, err := rs.Solutions.FindMany(round.ID, "", "")
+	round, distribution, seed, err := rs.reviewStageDistribution(startOrder.League, startOrder.Seed)
 	if err != nil {
 		return result, err
 	}
+	result.Seed = seed
+	result.DistributionHash = distribution.Hash()
+	before := roundAudit(round)
 
-	distribution := rs.ReviewStageDistributor.Get(allRoundSolutions, uint(rs.ReviewersCount))
+	if startOrder.DistributionHash != "" && startOrder.DistributionHash != result.DistributionHash {
+		return result, mathbattle.ErrDistributionChanged
+	}
 
 	round.SetReviewStartDate(time.Now())
 	round.SetReviewEndDate(untilDate)
 	round.ReviewDistribution = distribution
+
+	if startOrder.DryRun {
+		desc, err := ReviewDistrubitonToString(rs.Participants, rs.teamsOf(round), rs.Solutions, distribution,
+			round.IdentitiesHidden(rs.BlindGrading))
+		if err != nil {
+			return result, err
+		}
+		result.Desc = desc.Desc
+		result.Round = round
+		result.DryRun = true
+		return result, nil
+	}
+
 	if err = rs.Rep.Update(round); err != nil {
 		return result, err
 	}
 	result.Round = round
+	if err := rs.Audit.Record(startOrder.ActorID, mathbattle.AuditReviewStart, round.ID, before, roundAudit(round)); err != nil {
+		return result, err
+	}
 
 	for participantID, _ := range distribution.BetweenParticipants {
-		participant, err := rs.Participants.GetByID(participantID)
+		members, err := rs.competitorMembers(round, participantID)
 		if err != nil {
 			return result, err
 		}
 
-		err = rs.startReviewStageForParticipant(round, participant)
-		if err != nil {
-			result.FailedParticipants = append(result.FailedParticipants, mathbattle.ParticipantError{
-				Participant: participant,
-				Error:       err.Error(),
-			})
+		for _, participant := range members {
+			err = rs.startReviewStageForParticipant(round, participantID, participant)
+			if err != nil {
+				result.FailedParticipants = append(result.FailedParticipants, mathbattle.ParticipantError{
+					Participant: participant,
+					Error:       err.Error(),
+				})
+			}
 		}
 	}
 
-	err = rs.StartSchedulingActions()
-	if err != nil {
-		return result, err
-	}
-
+	rs.scheduleRound(round)
 	return result, nil
 }
 
-func (rs *RoundService) ReviewStageDistributionDesc() (mathbattle.ReviewDistributionDesc, error) {
-	round, err := rs.Rep.GetReviewPending()
-	if err != nil {
-		return mathbattle.ReviewDistributionDesc{Desc: ""}, err
-	}
-
-	allRoundSolutions, err := rs.Solutions.FindMany(round.ID, "", "")
+func (rs *RoundService) ReviewStageDistributionDesc(league string, seed int64) (mathbattle.ReviewDistributionDesc, error) {
+	round, distribution, seed, err := rs.reviewStageDistribution(league, seed)
 	if err != nil {
 		return mathbattle.ReviewDistributionDesc{Desc: ""}, err
 	}
 
-	distribution := rs.ReviewStageDistributor.Get(allRoundSolutions, 2)
-
-	return ReviewDistrubitonToString(rs.Participants, rs.Solutions, distribution)
-}
-
-func (rs *RoundService) GetAll() ([]mathbattle.Round, error) {
-	return rs.Rep.GetAll()
+	result, err := ReviewDistrubitonToString(rs.Participants, rs.teamsOf(round), rs.Solutions, distribution,
+		round.IdentitiesHidden(rs.BlindGrading))
+	result.Seed = seed
+	result.Hash = distribution.Hash()
+	return result, err
 }
 
-func (rs *RoundService) GetByID(ID string) (mathbattle.Round, error) {
-	return rs.Rep.Get(ID)
-}
+func checkNewReviewer(round mathbattle.Round, solution mathbattle.Solution, participantID string) error {
+	if participantID == solution.CompetitorID() {
+		return fmt.Errorf("participant %s can't review own solution", participantID)
+	}
 
-func (rs *RoundService) GetRunning() (mathbattle.Round, error) {
-	return rs.Rep.GetRunning()
-}
+	if round.ReviewDistribution.IsReviewer(participantID, solution.ID) {
+		return fmt.Errorf("participant %s already reviews solution %s", participantID, solution.ID)
+	}
 
-func (rs *RoundService) GetReviewPending() (mathbattle.Round, error) {
-	return rs.Rep.GetReviewPending()
-}
+	// Участник должен был получить эту задачу, иначе он не сможет её назвать при отправке ревью
+	if _, err := round.ProblemDistribution.FindDescriptor(participantID, solution.ProblemID); err != nil {
+		return fmt.Errorf("participant %s didn't get problem %s", participantID, solution.ProblemID)
+	}
 
-func (rs *RoundService) GetReviewRunning() (mathbattle.Round, error) {
-	return rs.Rep.GetReviewRunning()
+	return nil
 }
 
-func (rs *RoundService) GetLast() (mathbattle.Round, error) {
-	return rs.Rep.GetLast()
-}
+func (rs *RoundService) notifyReviewRemoved(round mathbattle.Round, oldDistribution mathbattle.ReviewDistribution,
+	participantID string, solutionID string) error {
 
-func (rs *RoundService) GetProblemDescriptors(participantID string) ([]mathbattle.ProblemDescriptor, error) {
-	curRound, err := rs.Rep.GetRunning()
+	members, err := rs.competitorMembers(round, participantID)
 	if err != nil {
-		return []mathbattle.ProblemDescriptor{}, err
+		return err
 	}
 
-	participant, err := rs.Participants.GetByID(participantID)
+	oldRound := round
+	oldRound.ReviewDistribution = oldDistribution
+	oldDescriptors, err := mathbattle.SolutionDescriptorsFromSolutionIDs(rs.Solutions, participantID, oldRound)
 	if err != nil {
-		return []mathbattle.ProblemDescriptor{}, err
+		return err
 	}
 
-	problemDescriptors, areExist := curRound.ProblemDistribution[participantID]
-	if !areExist { // Новый участник
-		distributor, err := rs.getSSDCurrentRound()
-		if err != nil {
-			return []mathbattle.ProblemDescriptor{}, err
-		}
-
-		problems, err := distributor.GetForParticipant(participant)
-		if err != nil {
-			return []mathbattle.ProblemDescriptor{}, err
-		}
+	actualDescriptors, err := mathbattle.SolutionDescriptorsFromSolutionIDs(rs.Solutions, participantID, round)
+	if err != nil {
+		return err
+	}
 
-		for i, problem := range problems {
-			curRound.ProblemDistribution[participant.ID] = append(curRound.ProblemDistribution[participant.ID],
-				mathbattle.ProblemDescriptor{
-					Caption:   mstd.IndexToLetter(i),
-					ProblemID: problem.ID,
-				})
+	for _, descriptor := range oldDescriptors {
+		if descriptor.SolutionID != solutionID {
+			continue
 		}
 
-		err = rs.Rep.Update(curRound)
-		if err != nil {
-			return []mathbattle.ProblemDescriptor{}, err
+		for _, participant := range members {
+			err := rs.Postman.SendSimpleMessage(participant.TelegramID,
+				rs.replier(participant).ReviewReassignRemoved(descriptor.ProblemCaption, descriptor.SolutionNumber, actualDescriptors))
+			if err != nil {
+				return err
+			}
 		}
-
-		problemDescriptors = curRound.ProblemDistribution[participant.ID]
+		return nil
 	}
 
-	return problemDescriptors, nil
+	return mathbattle.ErrNotFound
 }
 
-func (rs *RoundService) onSolveStageEnd() {
-	participants, err := rs.Participants.GetAll()
+func (rs *RoundService) notifyReviewAdded(round mathbattle.Round, participantID string, solution mathbattle.Solution) error {
+	members, err := rs.competitorMembers(round, participantID)
 	if err != nil {
-		log.Printf("onSolveStageEnd - failed to get participants, error: %v", err)
-		return
+		return err
 	}
 
-	round, err := rs.Rep.GetRunning()
+	descriptors, err := mathbattle.SolutionDescriptorsFromSolutionIDs(rs.Solutions, participantID, round)
 	if err != nil {
-		log.Printf("onSolveStageEnd - failed to get current round, error: %v", err)
-		return
+		return err
 	}
 
-	for _, participant := range participants {
-		allParticipantSolutions, err := rs.Solutions.FindMany(round.ID, participant.ID, "")
-		if err != nil {
-			log.Printf("onSolveStageEnd - failed to get all participant solutions, error: %v", err)
+	for _, descriptor := range descriptors {
+		if descriptor.SolutionID != solution.ID {
+			continue
 		}
 
-		var msg string
-		if len(allParticipantSolutions) == 0 {
-			msg = rs.Replier.SolveStageEndNoSolutions()
-		} else {
-			msg = rs.Replier.SolveStageEnd()
+		parts, err := mathbattle.WithContent(rs.Solutions, solution.Parts)
+		if err != nil {
+			return err
 		}
 
-		err = rs.Postman.SendSimpleMessage(participant.TelegramID, msg)
-		if err != nil {
-			log.Printf("onSolveStageEnd - failed to send message to participant: %v", err)
+		for _, participant := range members {
+			err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.replier(participant).ReviewReassignAdded())
+			if err != nil {
+				return err
+			}
+
+			err = sendSolution(rs.Postman, rs.Previewer, rs.logger(), participant.TelegramID,
+				rs.replier(participant).ReviewPostCaption(descriptor.ProblemCaption, descriptor.SolutionNumber), descriptor.SolutionNumber, parts)
+			if err != nil {
+				return err
+			}
 		}
+		return nil
 	}
+
+	return mathbattle.ErrNotFound
 }
 
-func (rs *RoundService) onReviewStageEnd() {
-	participants, err := rs.Participants.GetAll()
-	if err != nil {
-		log.Printf("onReviewStageEnd - failed to get all participants, error: %v", err)
-		return
+// competitorName - имя участника раунда, в командном раунде - название команды
+func (rs *RoundService) competitorName(round mathbattle.Round, competitorID string) (string, error) {
+	if round.IsTeam() {
+		team, err := rs.Teams.Get(competitorID)
+		return team.Name, err
 	}
+	participant, err := rs.Participants.GetByID(competitorID)
+	return participant.Name, err
+}
 
-	for _, participant := range participants {
-		err := rs.Postman.SendSimpleMessage(participant.TelegramID, rs.Replier.ReviewStageEnd())
+// reassignRound - раунд, распределение которого меняют организаторы: с идущим этапом ревью или, до его начала,
+// предпросмотр распределения с данным seed (0 - уже поправленный или новый). Возвращает seed предпросмотра,
+// 0 - этап ревью уже идёт
+func (rs *RoundService) reassignRound(league string, seed int64) (mathbattle.Round, int64, error) {
+	round, err := rs.Rep.GetReviewRunning(league)
+	if err != mathbattle.ErrNotFound {
+		return round, 0, err
+	}
+
+	round, err = rs.Rep.GetReviewPending(league)
+	if err != nil {
+		return round, 0, err
+	}
+
+	preview, distribution, seed, err := rs.reviewStageDistribution(league, seed)
+	if err != nil {
+		return round, 0, err
+	}
+	preview.ReviewDistribution = distribution
+	return preview, seed, nil
+}
+
+// applyReassign меняет распределение раунда по order. Решение, на которое ревьюер уже прислал ревью,
+// у него не забирается. Возвращает, у кого решение забрали и кому его отдали
+func (rs *RoundService) applyReassign(round *mathbattle.Round, solution mathbattle.Solution,
+	order mathbattle.ReassignOrder) ([]string, []string, error) {
+
+	removedFrom := []string{}
+	addedTo := []string{}
+	isSubmitted := func(participantID string) (bool, error) {
+		reviews, err := mathbattle.FindCompetitorReviews(rs.Reviews, *round, participantID, solution.ID)
+		return len(reviews) != 0, err
+	}
+
+	switch order.Action {
+	case mathbattle.ReassignMove:
+		if !round.ReviewDistribution.IsReviewer(order.FromParticipantID, solution.ID) {
+			rs.logger().Warnf("applyReassign - participant %s doesn't review solution %s", order.FromParticipantID, solution.ID)
+			return removedFrom, addedTo, mathbattle.ErrWrongUserInput
+		}
+		submitted, err := isSubmitted(order.FromParticipantID)
 		if err != nil {
-			log.Printf("onReviewStageEnd - failed to send message to participant, error: %v", err)
+			return removedFrom, addedTo, err
+		}
+		if submitted {
+			return removedFrom, addedTo, mathbattle.ErrReviewSubmitted
+		}
+		if err := checkNewReviewer(*round, solution, order.ToParticipantID); err != nil {
+			rs.logger().Warnf("applyReassign - %v", err)
+			return removedFrom, addedTo, mathbattle.ErrWrongUserInput
+		}
+		round.ReviewDistribution.RemoveReview(order.FromParticipantID, solution.ID)
+		round.ReviewDistribution.AddReview(order.ToParticipantID, solution.ID)
+		round.ReviewDistribution.LogReassignment(solution.ID, order.FromParticipantID, order.ToParticipantID,
+			mathbattle.ReassignReasonManual)
+		removedFrom = append(removedFrom, order.FromParticipantID)
+		addedTo = append(addedTo, order.ToParticipantID)
+	case mathbattle.ReassignAddReviewer:
+		if err := checkNewReviewer(*round, solution, order.ToParticipantID); err != nil {
+			rs.logger().Warnf("applyReassign - %v", err)
+			return removedFrom, addedTo, mathbattle.ErrWrongUserInput
+		}
+		round.ReviewDistribution.AddReview(order.ToParticipantID, solution.ID)
+		round.ReviewDistribution.LogReassignment(solution.ID, "", order.ToParticipantID, mathbattle.ReassignReasonManual)
+		addedTo = append(addedTo, order.ToParticipantID)
+	case mathbattle.ReassignToOrganizers:
+		for _, participantID := range round.ReviewDistribution.Reviewers(solution.ID) {
+			submitted, err := isSubmitted(participantID)
+			if err != nil {
+				return removedFrom, addedTo, err
+			}
+			// Присланное ревью остаётся в силе, организаторы проверяют решение вдобавок к нему
+			if submitted {
+				continue
+			}
+			round.ReviewDistribution.RemoveReview(participantID, solution.ID)
+			round.ReviewDistribution.LogReassignment(solution.ID, participantID, "", mathbattle.ReassignReasonManual)
+			removedFrom = append(removedFrom, participantID)
 		}
+		round.ReviewDistribution.AddToOrganizers(solution.ID)
+	default:
+		rs.logger().Warnf("applyReassign - unknown action '%s'", order.Action)
+		return removedFrom, addedTo, mathbattle.ErrWrongUserInput
 	}
+
+	return removedFrom, addedTo, nil
 }
 
-func (rs *RoundService) StartSchedulingActions() error {
-	log.Printf("StartSchedulingActions()")
+// ReassignReview вручную меняет распределение решений на ревью. В идущем этапе ревью участники, которых это
+// затронуло, получают уведомление. До начала этапа правка запоминается для предпросмотра с order.Seed
+// и применяется, когда этап начнут с этим seed
+func (rs *RoundService) ReassignReview(order mathbattle.ReassignOrder) (mathbattle.ReassignResult, error) {
+	result := mathbattle.ReassignResult{}
 
-	round, err := rs.Rep.GetRunning()
+	round, seed, err := rs.reassignRound(order.League, order.Seed)
+	if err != nil {
+		return result, err
+	}
+
+	solution, err := rs.Solutions.Get(order.SolutionID)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return nil
+			return result, mathbattle.ErrWrongUserInput
+		}
+		return result, err
+	}
+
+	if solution.RoundID != round.ID {
+		rs.logger().Warnf("ReassignReview - solution %s is not from the current round", solution.ID)
+		return result, mathbattle.ErrWrongUserInput
+	}
+
+	oldDistribution := round.ReviewDistribution.Copy()
+	reviewersBefore := oldDistribution.Reviewers(solution.ID)
+	removedFrom, addedTo, err := rs.applyReassign(&round, solution, order)
+	if err != nil {
+		return result, err
+	}
+
+	if seed != 0 {
+		// Раунд перечитываем, чтобы не затереть то, что поменялось после reassignRound
+		stored, err := rs.Rep.Get(round.ID)
+		if err != nil {
+			return result, err
+		}
+		// Правки другого предпросмотра к этому распределению не относятся
+		if stored.ReviewDistribution.PreviewSeed != seed {
+			stored.ReviewDistribution.PreviewSeed = seed
+			stored.ReviewDistribution.PreviewOverrides = nil
+		}
+		order.Seed = seed
+		stored.ReviewDistribution.PreviewOverrides = append(stored.ReviewDistribution.PreviewOverrides, order)
+		if err = rs.Rep.Update(stored); err != nil {
+			return result, err
+		}
+
+		result.Round = round
+		result.IsPreview = true
+		err = rs.Audit.Record(order.ActorID, mathbattle.AuditReviewReassign, solution.ID,
+			reviewersBefore, round.ReviewDistribution.Reviewers(solution.ID))
+		return result, err
+	}
+
+	if err = rs.Rep.Update(round); err != nil {
+		return result, err
+	}
+	result.Round = round
+	err = rs.Audit.Record(order.ActorID, mathbattle.AuditReviewReassign, solution.ID,
+		reviewersBefore, round.ReviewDistribution.Reviewers(solution.ID))
+	if err != nil {
+		return result, err
+	}
+
+	notifyFailed := func(participantID string, err error) {
+		rs.logger().Errorf("ReassignReview - failed to notify participant %s, error: %v", participantID, err)
+		// В командном раунде participantID - ID команды
+		participant := mathbattle.Participant{ID: participantID}
+		if !round.IsTeam() {
+			participant, _ = rs.Participants.GetByID(participantID)
+		}
+		result.FailedParticipants = append(result.FailedParticipants, mathbattle.ParticipantError{
+			Participant: participant,
+			Error:       err.Error(),
+		})
+	}
+
+	for _, participantID := range removedFrom {
+		if err := rs.notifyReviewRemoved(round, oldDistribution, participantID, solution.ID); err != nil {
+			notifyFailed(participantID, err)
+		}
+	}
+
+	for _, participantID := range addedTo {
+		if err := rs.notifyReviewAdded(round, participantID, solution); err != nil {
+			notifyFailed(participantID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// ReassignOptions - решения раунда, их ревьюеры и те, кому решения можно отдать. Кандидаты идут от самых
+// свободных. При слепой проверке вместо авторов решений - псевдонимы решений
+func (rs *RoundService) ReassignOptions(league string, seed int64) (mathbattle.ReassignOptions, error) {
+	result := mathbattle.ReassignOptions{Solutions: []mathbattle.ReassignSolution{}}
+
+	round, seed, err := rs.reassignRound(league, seed)
+	if err != nil {
+		return result, err
+	}
+	result.Seed = seed
+	result.IsPreview = seed != 0
+
+	solutions, err := rs.Solutions.FindMany(round.ID, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	names := make(map[string]string)
+	competitorIDs := []string{}
+	for competitorID := range round.ProblemDistribution {
+		name, err := rs.competitorName(round, competitorID)
+		if err != nil && err != mathbattle.ErrNotFound {
+			return result, err
+		}
+		names[competitorID] = name
+		competitorIDs = append(competitorIDs, competitorID)
+	}
+	sort.Slice(competitorIDs, func(i, j int) bool {
+		left := len(round.ReviewDistribution.BetweenParticipants[competitorIDs[i]])
+		right := len(round.ReviewDistribution.BetweenParticipants[competitorIDs[j]])
+		if left != right {
+			return left < right
+		}
+		return names[competitorIDs[i]] < names[competitorIDs[j]]
+	})
+
+	hideAuthors := round.IdentitiesHidden(rs.BlindGrading)
+	for _, solution := range solutions {
+		item := mathbattle.ReassignSolution{
+			ReassignChoice: mathbattle.ReassignChoice{ID: solution.ID, Label: solution.Alias()},
+			ProblemID:      solution.ProblemID,
+			Reviewers:      []mathbattle.ReassignChoice{},
+			Candidates:     []mathbattle.ReassignChoice{},
+		}
+		if !hideAuthors {
+			item.Label = names[solution.CompetitorID()]
+		}
+
+		for _, reviewerID := range round.ReviewDistribution.Reviewers(solution.ID) {
+			reviews, err := mathbattle.FindCompetitorReviews(rs.Reviews, round, reviewerID, solution.ID)
+			if err != nil {
+				return result, err
+			}
+			item.Reviewers = append(item.Reviewers, mathbattle.ReassignChoice{
+				ID:        reviewerID,
+				Label:     names[reviewerID],
+				Submitted: len(reviews) != 0,
+			})
+		}
+
+		for _, competitorID := range competitorIDs {
+			if checkNewReviewer(round, solution, competitorID) == nil {
+				item.Candidates = append(item.Candidates, mathbattle.ReassignChoice{ID: competitorID, Label: names[competitorID]})
+			}
+		}
+
+		result.Solutions = append(result.Solutions, item)
+	}
+
+	sort.Slice(result.Solutions, func(i, j int) bool {
+		if result.Solutions[i].ProblemID != result.Solutions[j].ProblemID {
+			return result.Solutions[i].ProblemID < result.Solutions[j].ProblemID
+		}
+		return result.Solutions[i].Label < result.Solutions[j].Label
+	})
+	return result, nil
+}
+
+type pendingReview struct {
+	reviewerID string
+	solutionID string
+}
+
+// reassignInactiveReviews забирает непроверенные решения у неактивных участников: отписавшихся и тех, кто
+// не прислал ни одного ревью, и отдаёт их активным участникам, которые уже проверили всё, что им досталось.
+// У того, кто проверяет, но ещё не закончил, решения не забираются
+func (rs *RoundService) reassignInactiveReviews(roundID string) {
+	round, err := rs.Rep.Get(roundID)
+	if err != nil {
+		rs.logger().Errorf("reassignInactiveReviews - failed to get round %s, error: %v", roundID, err)
+		return
+	}
+
+	if mathbattle.GetRoundStage(round) != mathbattle.StageReview {
+		rs.logger().Warnf("reassignInactiveReviews - review stage of round %s is not running", roundID)
+		return
+	}
+
+	participants, err := rs.Participants.GetAll()
+	if err != nil {
+		rs.logger().Errorf("reassignInactiveReviews - failed to get participants, error: %v", err)
+		return
+	}
+	isActive := make(map[string]bool)
+	for _, participant := range participants {
+		isActive[participant.ID] = participant.IsActive
+	}
+
+	// Команда активна, пока в ней есть активные участники
+	if round.IsTeam() {
+		teams, err := rs.Teams.GetAll()
+		if err != nil {
+			rs.logger().Errorf("reassignInactiveReviews - failed to get teams, error: %v", err)
+			return
+		}
+		isMemberActive := isActive
+		isActive = make(map[string]bool)
+		for _, team := range teams {
+			for _, memberID := range team.MemberIDs {
+				isActive[team.ID] = isActive[team.ID] || isMemberActive[memberID]
+			}
+		}
+	}
+
+	reviewerIDs := []string{}
+	for reviewerID := range round.ReviewDistribution.BetweenParticipants {
+		reviewerIDs = append(reviewerIDs, reviewerID)
+	}
+	sort.Strings(reviewerIDs)
+
+	pending := []pendingReview{}
+	candidates := []string{}
+	for _, reviewerID := range reviewerIDs {
+		notReviewed := []pendingReview{}
+		for _, solutionID := range round.ReviewDistribution.BetweenParticipants[reviewerID] {
+			reviews, err := mathbattle.FindCompetitorReviews(rs.Reviews, round, reviewerID, solutionID)
+			if err != nil {
+				rs.logger().Errorf("reassignInactiveReviews - failed to get reviews, error: %v", err)
+				return
+			}
+
+			if len(reviews) == 0 {
+				notReviewed = append(notReviewed, pendingReview{reviewerID: reviewerID, solutionID: solutionID})
+			}
+		}
+
+		isFinished := len(notReviewed) == 0
+		isIdle := len(notReviewed) == len(round.ReviewDistribution.BetweenParticipants[reviewerID])
+		if isFinished && isActive[reviewerID] {
+			candidates = append(candidates, reviewerID)
+		}
+		if !isActive[reviewerID] || isIdle {
+			pending = append(pending, notReviewed...)
+		}
+	}
+
+	if len(pending) == 0 {
+		rs.logger().Infof("reassignInactiveReviews - nothing to reassign")
+		return
+	}
+
+	oldDistribution := round.ReviewDistribution.Copy()
+	moved := []pendingReview{}
+	movedTo := []string{}
+	for _, item := range pending {
+		solution, err := rs.Solutions.Get(item.solutionID)
+		if err != nil {
+			rs.logger().Errorf("reassignInactiveReviews - failed to get solution %s, error: %v", item.solutionID, err)
+			continue
+		}
+
+		// Отдаём самому свободному из подходящих участников
+		target := ""
+		for _, candidateID := range candidates {
+			if checkNewReviewer(round, solution, candidateID) != nil {
+				continue
+			}
+			if target == "" || len(round.ReviewDistribution.BetweenParticipants[candidateID]) <
+				len(round.ReviewDistribution.BetweenParticipants[target]) {
+				target = candidateID
+			}
+		}
+
+		if target == "" {
+			rs.logger().Warnf("reassignInactiveReviews - no one to give solution %s of participant %s", item.solutionID, item.reviewerID)
+			continue
+		}
+
+		round.ReviewDistribution.RemoveReview(item.reviewerID, item.solutionID)
+		round.ReviewDistribution.AddReview(target, item.solutionID)
+		round.ReviewDistribution.LogReassignment(item.solutionID, item.reviewerID, target, mathbattle.ReassignReasonInactive)
+		moved = append(moved, item)
+		movedTo = append(movedTo, target)
+		rs.logger().Infof("reassignInactiveReviews - solution %s: %s -> %s", item.solutionID, item.reviewerID, target)
+	}
+
+	if len(moved) == 0 {
+		return
+	}
+
+	if err = rs.Rep.Update(round); err != nil {
+		rs.logger().Errorf("reassignInactiveReviews - failed to update round, error: %v", err)
+		return
+	}
+
+	for i, item := range moved {
+		if isActive[item.reviewerID] {
+			if err := rs.notifyReviewRemoved(round, oldDistribution, item.reviewerID, item.solutionID); err != nil {
+				rs.logger().Errorf("reassignInactiveReviews - failed to notify participant %s, error: %v", item.reviewerID, err)
+			}
+		}
+
+		solution, err := rs.Solutions.Get(item.solutionID)
+		if err != nil {
+			rs.logger().Errorf("reassignInactiveReviews - failed to get solution %s, error: %v", item.solutionID, err)
+			continue
+		}
+		if err := rs.notifyReviewAdded(round, movedTo[i], solution); err != nil {
+			rs.logger().Errorf("reassignInactiveReviews - failed to notify participant %s, error: %v", movedTo[i], err)
+		}
+	}
+}
+
+func (rs *RoundService) GetAll() ([]mathbattle.Round, error) {
+	return rs.Rep.GetAll()
+}
+
+func (rs *RoundService) GetByID(ID string) (mathbattle.Round, error) {
+	return rs.Rep.Get(ID)
+}
+
+func (rs *RoundService) GetRunning(league string) (mathbattle.Round, error) {
+	return rs.Rep.GetRunning(league)
+}
+
+func (rs *RoundService) GetReviewPending(league string) (mathbattle.Round, error) {
+	return rs.Rep.GetReviewPending(league)
+}
+
+func (rs *RoundService) GetReviewRunning(league string) (mathbattle.Round, error) {
+	return rs.Rep.GetReviewRunning(league)
+}
+
+func (rs *RoundService) GetLast(league string) (mathbattle.Round, error) {
+	return rs.Rep.GetLast(league)
+}
+
+func (rs *RoundService) GetProblemDescriptors(participantID string) ([]mathbattle.ProblemDescriptor, error) {
+	participant, err := rs.Participants.GetByID(participantID)
+	if err != nil {
+		return []mathbattle.ProblemDescriptor{}, err
+	}
+
+	curRound, err := rs.Rep.GetRunning(participant.League)
+	if err != nil {
+		return []mathbattle.ProblemDescriptor{}, err
+	}
+
+	// Команды во время командного раунда не меняются, все они получили задачи при его начале
+	if curRound.IsTeam() {
+		teamID, err := mathbattle.CompetitorID(curRound, participantID, rs.Teams)
+		if err != nil {
+			return []mathbattle.ProblemDescriptor{}, err
+		}
+		return curRound.ProblemDistribution[teamID], nil
+	}
+
+	problemDescriptors, areExist := curRound.ProblemDistribution[participantID]
+	if !areExist { // Новый участник
+		distributor, err := rs.getSSDCurrentRound(curRound)
+		if err != nil {
+			return []mathbattle.ProblemDescriptor{}, err
+		}
+
+		problems, err := distributor.GetForParticipant(participant)
+		if err != nil {
+			return []mathbattle.ProblemDescriptor{}, err
+		}
+
+		for i, problem := range problems {
+			curRound.ProblemDistribution[participant.ID] = append(curRound.ProblemDistribution[participant.ID],
+				mathbattle.ProblemDescriptor{
+					Caption:   mstd.IndexToLetter(i),
+					ProblemID: problem.ID,
+				})
+		}
+
+		err = rs.Rep.Update(curRound)
+		if err != nil {
+			return []mathbattle.ProblemDescriptor{}, err
+		}
+
+		problemDescriptors = curRound.ProblemDistribution[participant.ID]
+	}
+
+	return problemDescriptors, nil
+}
+
+// PublishResults открывает жюри авторов решений закончившегося раунда
+func (rs *RoundService) PublishResults(roundID string, actorID int64) (mathbattle.Round, error) {
+	round, err := rs.Rep.Get(roundID)
+	if err != nil {
+		return round, err
+	}
+
+	if mathbattle.GetRoundStage(round) != mathbattle.StageFinished {
+		return round, mathbattle.ErrRoundNotFinished
+	}
+
+	before := roundAudit(round)
+	round.ResultsPublished = true
+	if err := rs.Rep.Update(round); err != nil {
+		return round, err
+	}
+	err = rs.Audit.Record(actorID, mathbattle.AuditResultsPublish, round.ID, before, roundAudit(round))
+
+	return round, err
+}
+
+// RevealIdentities - авторы всех решений раунда до публикации результатов. Каждое раскрытие записывается в раунд
+func (rs *RoundService) RevealIdentities(order mathbattle.RevealOrder) ([]mathbattle.SolutionIdentity, error) {
+	result := []mathbattle.SolutionIdentity{}
+	if order.By == "" || order.Reason == "" {
+		return result, mathbattle.ErrWrongUserInput
+	}
+
+	round, err := rs.Rep.Get(order.RoundID)
+	if err != nil {
+		return result, err
+	}
+
+	solutions, err := rs.Solutions.FindMany(round.ID, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	for _, solution := range solutions {
+		identity := mathbattle.SolutionIdentity{
+			SolutionID:    solution.ID,
+			Pseudonym:     solution.Alias(),
+			ProblemID:     solution.ProblemID,
+			ParticipantID: solution.ParticipantID,
+		}
+
+		participant, err := rs.Participants.GetByID(solution.ParticipantID)
+		if err == nil {
+			identity.ParticipantName = participant.Name
+		} else if err != mathbattle.ErrNotFound {
+			return result, err
+		}
+
+		if solution.TeamID != "" {
+			identity.TeamID = solution.TeamID
+			team, err := rs.Teams.Get(solution.TeamID)
+			if err == nil {
+				identity.TeamName = team.Name
+			} else if err != mathbattle.ErrNotFound {
+				return result, err
+			}
+		}
+
+		result = append(result, identity)
+	}
+
+	if err := rs.Rep.AppendIdentityReveal(round.ID, mathbattle.NewIdentityReveal(order.By, order.Reason)); err != nil {
+		return []mathbattle.SolutionIdentity{}, err
+	}
+	rs.logger().Infof("Identities of round %s revealed by '%s', reason: '%s'", round.ID, order.By, order.Reason)
+	err = rs.Audit.Record(order.ActorID, mathbattle.AuditIdentitiesReveal, round.ID, nil, map[string]string{
+		"by":     order.By,
+		"reason": order.Reason,
+	})
+
+	return result, err
+}
+
+func (rs *RoundService) onSolveStageEnd(roundID string) {
+	round, err := rs.Rep.Get(roundID)
+	if err != nil {
+		rs.logger().Errorf("onSolveStageEnd - failed to get round %s, error: %v", roundID, err)
+		return
+	}
+
+	participants, err := rs.leagueParticipants(round.League)
+	if err != nil {
+		rs.logger().Errorf("onSolveStageEnd - failed to get participants, error: %v", err)
+		return
+	}
+
+	for _, participant := range participants {
+		competitorID, err := mathbattle.CompetitorID(round, participant.ID, rs.Teams)
+		if err != nil {
+			// Участник без команды не участвовал в командном раунде
+			if err != mathbattle.ErrNotFound {
+				rs.logger().Errorf("onSolveStageEnd - failed to get participant team, error: %v", err)
+			}
+			continue
+		}
+
+		// В матбое письменных решений нет, дальше команды встречаются в боях
+		if round.IsMatboi() {
+			err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.replier(participant).BattlePreparationEnd())
+			if err != nil {
+				rs.logger().Errorf("onSolveStageEnd - failed to send message to participant: %v", err)
+			}
+			continue
+		}
+
+		allParticipantSolutions, err := mathbattle.FindCompetitorSolutions(rs.Solutions, round, competitorID, "")
+		if err != nil {
+			rs.logger().Errorf("onSolveStageEnd - failed to get all participant solutions, error: %v", err)
+		}
+
+		var msg string
+		if len(allParticipantSolutions) == 0 {
+			msg = rs.replier(participant).SolveStageEndNoSolutions()
+		} else {
+			msg = rs.replier(participant).SolveStageEnd()
+		}
+
+		err = rs.Postman.SendSimpleMessage(participant.TelegramID, msg)
+		if err != nil {
+			rs.logger().Errorf("onSolveStageEnd - failed to send message to participant: %v", err)
+		}
+	}
+}
+
+func (rs *RoundService) onReviewStageEnd(roundID string) {
+	round, err := rs.Rep.Get(roundID)
+	if err != nil {
+		rs.logger().Errorf("onReviewStageEnd - failed to get round %s, error: %v", roundID, err)
+		return
+	}
+
+	participants, err := rs.leagueParticipants(round.League)
+	if err != nil {
+		rs.logger().Errorf("onReviewStageEnd - failed to get all participants, error: %v", err)
+		return
+	}
+
+	for _, participant := range participants {
+		err := rs.Postman.SendSimpleMessage(participant.TelegramID, rs.replier(participant).ReviewStageEnd())
+		if err != nil {
+			rs.logger().Errorf("onReviewStageEnd - failed to send message to participant, error: %v", err)
 		}
-		log.Printf("StartSchedulingActions(), failed to get current round, error: %v", err)
+	}
+}
+
+// markReminded отмечает напоминание о конце этапа раунда за timeLeft до него. Возвращает false, если оно уже
+// было отправлено: раунд может быть запланирован повторно, а напоминать дважды не нужно
+func (rs *RoundService) markReminded(round mathbattle.Round, timeLeft time.Duration) bool {
+	rs.remindedMutex.Lock()
+	defer rs.remindedMutex.Unlock()
+
+	if rs.reminded == nil {
+		rs.reminded = make(map[string]bool)
+	}
+	key := fmt.Sprintf("%s/%v/%v", round.ID, mathbattle.GetRoundStage(round), timeLeft)
+	if rs.reminded[key] {
+		return false
+	}
+	rs.reminded[key] = true
+	return true
+}
+
+func (rs *RoundService) remindSolveStage(roundID string, timeLeft time.Duration) {
+	round, err := rs.Rep.Get(roundID)
+	if err != nil {
+		rs.logger().Errorf("remindSolveStage - failed to get round %s, error: %v", roundID, err)
+		return
+	}
+
+	if mathbattle.GetRoundStage(round) != mathbattle.StageSolve || round.IsMatboi() || !rs.markReminded(round, timeLeft) {
+		return
+	}
+
+	participants, err := rs.leagueParticipants(round.League)
+	if err != nil {
+		rs.logger().Errorf("remindSolveStage - failed to get participants, error: %v", err)
+		return
+	}
+
+	for _, participant := range participants {
+		if !participant.IsActive || participant.RemindersOff {
+			continue
+		}
+
+		competitorID, err := mathbattle.CompetitorID(round, participant.ID, rs.Teams)
+		if err != nil {
+			if err != mathbattle.ErrNotFound {
+				rs.logger().Errorf("remindSolveStage - failed to get participant team, error: %v", err)
+			}
+			continue
+		}
+
+		solutions, err := mathbattle.FindCompetitorSolutions(rs.Solutions, round, competitorID, "")
+		if err != nil {
+			rs.logger().Errorf("remindSolveStage - failed to get participant solutions, error: %v", err)
+			continue
+		}
+
+		solved := make(map[string]bool)
+		for _, solution := range solutions {
+			solved[solution.ProblemID] = true
+		}
+
+		notSolved := []string{}
+		for _, descriptor := range round.ProblemDistribution[competitorID] {
+			if !solved[descriptor.ProblemID] {
+				notSolved = append(notSolved, descriptor.Caption)
+			}
+		}
+
+		if len(notSolved) == 0 {
+			continue
+		}
+
+		err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.replier(participant).ReminderSolveStage(timeLeft, notSolved))
+		if err != nil {
+			rs.logger().Errorf("remindSolveStage - failed to send message to participant, error: %v", err)
+		}
+	}
+}
+
+func (rs *RoundService) remindReviewStage(roundID string, timeLeft time.Duration) {
+	round, err := rs.Rep.Get(roundID)
+	if err != nil {
+		rs.logger().Errorf("remindReviewStage - failed to get round %s, error: %v", roundID, err)
+		return
+	}
+
+	if mathbattle.GetRoundStage(round) != mathbattle.StageReview || !rs.markReminded(round, timeLeft) {
+		return
+	}
+
+	for participantID := range round.ReviewDistribution.BetweenParticipants {
+		members, err := rs.competitorMembers(round, participantID)
+		if err != nil {
+			rs.logger().Errorf("remindReviewStage - failed to get participant, error: %v", err)
+			continue
+		}
+
+		descriptors, err := mathbattle.SolutionDescriptorsFromSolutionIDs(rs.Solutions, participantID, round)
+		if err != nil {
+			rs.logger().Errorf("remindReviewStage - failed to get solution descriptors, error: %v", err)
+			continue
+		}
+
+		notReviewed := []mathbattle.SolutionDescriptor{}
+		for _, descriptor := range descriptors {
+			reviews, err := mathbattle.FindCompetitorReviews(rs.Reviews, round, participantID, descriptor.SolutionID)
+			if err != nil {
+				rs.logger().Errorf("remindReviewStage - failed to get reviews, error: %v", err)
+				continue
+			}
+
+			if len(reviews) == 0 {
+				notReviewed = append(notReviewed, descriptor)
+			}
+		}
+
+		if len(notReviewed) == 0 {
+			continue
+		}
+
+		for _, participant := range members {
+			if !participant.IsActive || participant.RemindersOff {
+				continue
+			}
+
+			captions := []string{}
+			for _, descriptor := range notReviewed {
+				captions = append(captions, rs.replier(participant).ReviewPostCaption(descriptor.ProblemCaption, descriptor.SolutionNumber))
+			}
+
+			err = rs.Postman.SendSimpleMessage(participant.TelegramID, rs.replier(participant).ReminderReviewStage(timeLeft, captions))
+			if err != nil {
+				rs.logger().Errorf("remindReviewStage - failed to send message to participant, error: %v", err)
+			}
+		}
+	}
+}
+
+func (rs *RoundService) scheduleReminders(stageEnd time.Time, remind func(timeLeft time.Duration)) {
+	for _, before := range rs.RemindersBefore {
+		runFuncAfter := time.Until(stageEnd.Add(-before))
+		if runFuncAfter <= 0 {
+			continue
+		}
+
+		timeLeft := before
+		time.AfterFunc(runFuncAfter, func() { remind(timeLeft) })
+		rs.logger().Infof("StartSchedulingActions(), reminder is scheduled after %v", runFuncAfter)
+	}
+}
+
+// StartSchedulingActions планирует окончания этапов и напоминания всех идущих раундов - по одному в каждой лиге
+func (rs *RoundService) StartSchedulingActions() error {
+	rs.logger().Infof("StartSchedulingActions()")
+
+	rounds, err := rs.Rep.GetAllRunning()
+	if err != nil {
+		rs.logger().Errorf("StartSchedulingActions(), failed to get running rounds, error: %v", err)
 		return err
 	}
 
+	for _, round := range rounds {
+		rs.scheduleRound(round)
+	}
+
+	return nil
+}
+
+func (rs *RoundService) scheduleRound(round mathbattle.Round) {
+	roundID := round.ID
 	roundStage := mathbattle.GetRoundStage(round)
-	log.Printf("StartSchedulingActions(), round stage is %v", roundStage)
+	rs.logger().Infof("StartSchedulingActions(), round %s (league '%s') stage is %v", roundID, round.League, roundStage)
 	switch roundStage {
 	case mathbattle.StageSolve:
 		runFuncAfter := time.Until(round.GetSolveEndDate())
-		time.AfterFunc(runFuncAfter, rs.onSolveStageEnd)
-		log.Printf("StartSchedulingActions(), onSolveStagEnd is scheduled after %v, solve stage end date is %v",
+		time.AfterFunc(runFuncAfter, func() { rs.onSolveStageEnd(roundID) })
+		rs.logger().Infof("StartSchedulingActions(), onSolveStagEnd is scheduled after %v, solve stage end date is %v",
 			runFuncAfter, round.GetSolveEndDate())
+		rs.scheduleReminders(round.GetSolveEndDate(), func(timeLeft time.Duration) { rs.remindSolveStage(roundID, timeLeft) })
 	case mathbattle.StageReview:
 		runFuncAfter := time.Until(round.GetReviewEndDate())
-		time.AfterFunc(runFuncAfter, rs.onReviewStageEnd)
-		log.Printf("StartSchedulingActions(), onReviewStagEnd is scheduled after %v, solve stage end date is %v",
+		time.AfterFunc(runFuncAfter, func() { rs.onReviewStageEnd(roundID) })
+		rs.logger().Infof("StartSchedulingActions(), onReviewStagEnd is scheduled after %v, solve stage end date is %v",
 			runFuncAfter, round.GetReviewEndDate())
+		rs.scheduleReminders(round.GetReviewEndDate(), func(timeLeft time.Duration) { rs.remindReviewStage(roundID, timeLeft) })
+
+		if rs.ReviewWatchdogBefore > 0 {
+			watchdogAfter := time.Until(round.GetReviewEndDate().Add(-rs.ReviewWatchdogBefore))
+			if watchdogAfter > 0 {
+				time.AfterFunc(watchdogAfter, func() { rs.reassignInactiveReviews(roundID) })
+				rs.logger().Infof("StartSchedulingActions(), reassignInactiveReviews is scheduled after %v", watchdogAfter)
+			}
+		}
 	default:
-		log.Printf("StartSchedulingActions(), not scheduling anything")
+		rs.logger().Infof("StartSchedulingActions(), not scheduling anything")
 	}
-
-	return nil
 }
@@ -0,0 +1,439 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartReviewStageDryRun(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+
+	dryRun, err := f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: reviewStageEnd(), DryRun: true})
+	req.NoError(err)
+	req.True(dryRun.DryRun)
+	req.NotZero(dryRun.Seed)
+	req.NotEmpty(dryRun.DistributionHash)
+	req.Contains(dryRun.Desc, "Participant 1")
+	req.Equal(dryRun.DistributionHash, dryRun.Round.ReviewDistribution.Hash())
+
+	// Пробный запуск ничего не сохраняет и никому не пишет
+	req.Equal(0, f.rounds.updates)
+	req.Empty(f.postman.sent)
+	req.Equal(mathbattle.StageReviewPending, mathbattle.GetRoundStage(*f.rounds.running))
+
+	// Тот же seed - то же распределение
+	again, err := f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: reviewStageEnd(), DryRun: true, Seed: dryRun.Seed})
+	req.NoError(err)
+	req.Equal(dryRun.DistributionHash, again.DistributionHash)
+
+	desc, err := f.rs.ReviewStageDistributionDesc("", dryRun.Seed)
+	req.NoError(err)
+	req.Equal(dryRun.DistributionHash, desc.Hash)
+
+	result, err := f.rs.StartReviewStage(mathbattle.StartOrder{
+		StageEnd:         reviewStageEnd(),
+		Seed:             dryRun.Seed,
+		DistributionHash: dryRun.DistributionHash,
+	})
+	req.NoError(err)
+	req.False(result.DryRun)
+	req.Empty(result.Desc)
+	req.Equal(1, f.rounds.updates)
+	req.Equal(mathbattle.StageReview, mathbattle.GetRoundStage(*f.rounds.running))
+	req.Equal(dryRun.Round.ReviewDistribution.BetweenParticipants, f.rounds.running.ReviewDistribution.BetweenParticipants)
+	req.NotEmpty(f.postman.sent)
+}
+
+func TestStartReviewStageRejectsChangedDistribution(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+
+	dryRun, err := f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: reviewStageEnd(), DryRun: true})
+	req.NoError(err)
+
+	// После пробного запуска решение удалили, распределение с тем же seed стало другим
+	req.NoError(f.solutions.Delete("s3"))
+
+	_, err = f.rs.StartReviewStage(mathbattle.StartOrder{
+		StageEnd:         reviewStageEnd(),
+		Seed:             dryRun.Seed,
+		DistributionHash: dryRun.DistributionHash,
+	})
+	req.Equal(mathbattle.ErrDistributionChanged, err)
+	req.Equal(0, f.rounds.updates)
+	req.Empty(f.postman.sent)
+	req.Equal(mathbattle.StageReviewPending, mathbattle.GetRoundStage(*f.rounds.running))
+}
+
+func TestReassignReviewMove(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	reviewer, other := f.startReview(req)
+
+	result, err := f.rs.ReassignReview(mathbattle.ReassignOrder{
+		Action:            mathbattle.ReassignMove,
+		SolutionID:        "s1",
+		FromParticipantID: reviewer,
+		ToParticipantID:   other,
+	})
+	req.NoError(err)
+	req.False(result.IsPreview)
+	req.Equal([]string{other}, f.rounds.running.ReviewDistribution.Reviewers("s1"))
+
+	reassignments := f.rounds.running.ReviewDistribution.Reassignments
+	req.Len(reassignments, 1)
+	req.Equal(reviewer, reassignments[0].FromParticipantID)
+	req.Equal(other, reassignments[0].ToParticipantID)
+
+	req.Equal([]string{"removed A1"}, f.sentTo(telegramID(reviewer)))
+	req.Contains(f.sentTo(telegramID(other)), "added")
+}
+
+func TestReassignReviewMoveSubmitted(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	reviewer, other := f.startReview(req)
+	f.reviews.reviews = append(f.reviews.reviews, mathbattle.Review{ReviewerID: reviewer, SolutionID: "s1"})
+
+	_, err := f.rs.ReassignReview(mathbattle.ReassignOrder{
+		Action:            mathbattle.ReassignMove,
+		SolutionID:        "s1",
+		FromParticipantID: reviewer,
+		ToParticipantID:   other,
+	})
+	req.Equal(mathbattle.ErrReviewSubmitted, err)
+	req.Equal([]string{reviewer}, f.rounds.running.ReviewDistribution.Reviewers("s1"))
+	req.Empty(f.postman.sent)
+}
+
+func TestReassignReviewAddReviewer(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	reviewer, other := f.startReview(req)
+
+	_, err := f.rs.ReassignReview(mathbattle.ReassignOrder{
+		Action:          mathbattle.ReassignAddReviewer,
+		SolutionID:      "s1",
+		ToParticipantID: other,
+	})
+	req.NoError(err)
+	req.ElementsMatch([]string{reviewer, other}, f.rounds.running.ReviewDistribution.Reviewers("s1"))
+	req.Empty(f.sentTo(telegramID(reviewer)))
+	req.Contains(f.sentTo(telegramID(other)), "added")
+}
+
+func TestReassignReviewWrongInput(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	reviewer, other := f.startReview(req)
+
+	for _, order := range []mathbattle.ReassignOrder{
+		// Своё решение проверять нельзя
+		{Action: mathbattle.ReassignAddReviewer, SolutionID: "s1", ToParticipantID: "1"},
+		// Уже проверяет
+		{Action: mathbattle.ReassignAddReviewer, SolutionID: "s1", ToParticipantID: reviewer},
+		// Забрать можно только у того, кто проверяет
+		{Action: mathbattle.ReassignMove, SolutionID: "s1", FromParticipantID: other, ToParticipantID: reviewer},
+		{Action: mathbattle.ReassignMove, SolutionID: "unknown", FromParticipantID: reviewer, ToParticipantID: other},
+		{Action: "swap", SolutionID: "s1"},
+	} {
+		_, err := f.rs.ReassignReview(order)
+		req.Equal(mathbattle.ErrWrongUserInput, err, order)
+	}
+
+	req.Equal(0, len(f.rounds.running.ReviewDistribution.Reassignments))
+	req.Empty(f.postman.sent)
+}
+
+func TestReassignReviewToOrganizersKeepsSubmitted(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	f.rs.ReviewersCount = 2
+	f.startReview(req)
+	req.ElementsMatch([]string{"2", "3"}, f.rounds.running.ReviewDistribution.Reviewers("s1"))
+	f.reviews.reviews = append(f.reviews.reviews, mathbattle.Review{ReviewerID: "2", SolutionID: "s1"})
+
+	_, err := f.rs.ReassignReview(mathbattle.ReassignOrder{Action: mathbattle.ReassignToOrganizers, SolutionID: "s1"})
+	req.NoError(err)
+	req.Equal([]string{"2"}, f.rounds.running.ReviewDistribution.Reviewers("s1"))
+	req.Equal([]string{"s1"}, f.rounds.running.ReviewDistribution.ToOrganizers)
+	req.Empty(f.sentTo(telegramID("2")))
+	req.NotEmpty(f.sentTo(telegramID("3")))
+}
+
+func TestReassignReviewPreview(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+
+	options, err := f.rs.ReassignOptions("", 0)
+	req.NoError(err)
+	req.True(options.IsPreview)
+	req.NotZero(options.Seed)
+	req.Len(options.Solutions, 3)
+
+	s1, isExist := findOption(options, "s1")
+	req.True(isExist)
+	req.Len(s1.Reviewers, 1)
+	req.Len(s1.Candidates, 1)
+	reviewer, other := s1.Reviewers[0].ID, s1.Candidates[0].ID
+
+	result, err := f.rs.ReassignReview(mathbattle.ReassignOrder{
+		Action:            mathbattle.ReassignMove,
+		SolutionID:        "s1",
+		FromParticipantID: reviewer,
+		ToParticipantID:   other,
+		Seed:              options.Seed,
+	})
+	req.NoError(err)
+	req.True(result.IsPreview)
+	req.Equal([]string{other}, result.Round.ReviewDistribution.Reviewers("s1"))
+
+	// Этап не начат, участникам ничего не пишем, запоминаем только правку
+	req.Empty(f.postman.sent)
+	req.Equal(mathbattle.StageReviewPending, mathbattle.GetRoundStage(*f.rounds.running))
+	req.Equal(options.Seed, f.rounds.running.ReviewDistribution.PreviewSeed)
+	req.Len(f.rounds.running.ReviewDistribution.PreviewOverrides, 1)
+
+	// Пробный запуск без seed показывает поправленный предпросмотр
+	dryRun, err := f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: reviewStageEnd(), DryRun: true})
+	req.NoError(err)
+	req.Equal(options.Seed, dryRun.Seed)
+	req.Equal([]string{other}, dryRun.Round.ReviewDistribution.Reviewers("s1"))
+
+	_, err = f.rs.StartReviewStage(mathbattle.StartOrder{
+		StageEnd:         reviewStageEnd(),
+		Seed:             dryRun.Seed,
+		DistributionHash: dryRun.DistributionHash,
+	})
+	req.NoError(err)
+	req.Equal(mathbattle.StageReview, mathbattle.GetRoundStage(*f.rounds.running))
+	req.Equal([]string{other}, f.rounds.running.ReviewDistribution.Reviewers("s1"))
+	req.Zero(f.rounds.running.ReviewDistribution.PreviewSeed)
+	req.Empty(f.rounds.running.ReviewDistribution.PreviewOverrides)
+}
+
+func TestReassignReviewPreviewOtherSeed(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+
+	options, err := f.rs.ReassignOptions("", 0)
+	req.NoError(err)
+	_, err = f.rs.ReassignReview(mathbattle.ReassignOrder{
+		Action:     mathbattle.ReassignToOrganizers,
+		SolutionID: "s1",
+		Seed:       options.Seed,
+	})
+	req.NoError(err)
+
+	// Правка другого предпросмотра начинает новый набор правок
+	_, err = f.rs.ReassignReview(mathbattle.ReassignOrder{
+		Action:     mathbattle.ReassignToOrganizers,
+		SolutionID: "s2",
+		Seed:       options.Seed + 1,
+	})
+	req.NoError(err)
+	req.Equal(options.Seed+1, f.rounds.running.ReviewDistribution.PreviewSeed)
+	req.Len(f.rounds.running.ReviewDistribution.PreviewOverrides, 1)
+	req.Equal("s2", f.rounds.running.ReviewDistribution.PreviewOverrides[0].SolutionID)
+}
+
+func TestReassignOptions(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	reviewer, other := f.startReview(req)
+	f.reviews.reviews = append(f.reviews.reviews, mathbattle.Review{ReviewerID: reviewer, SolutionID: "s1"})
+
+	options, err := f.rs.ReassignOptions("", 0)
+	req.NoError(err)
+	req.False(options.IsPreview)
+	req.Zero(options.Seed)
+
+	s1, isExist := findOption(options, "s1")
+	req.True(isExist)
+	req.Equal("Participant 1", s1.Label)
+	req.Equal([]mathbattle.ReassignChoice{{ID: reviewer, Label: "Participant " + reviewer, Submitted: true}}, s1.Reviewers)
+	req.Equal([]mathbattle.ReassignChoice{{ID: other, Label: "Participant " + other}}, s1.Candidates)
+
+	// При слепой проверке вместо авторов - псевдонимы решений
+	f.rs.BlindGrading = true
+	options, err = f.rs.ReassignOptions("", 0)
+	req.NoError(err)
+	s1, _ = findOption(options, "s1")
+	req.Equal(mathbattle.SolutionCode("r1", "s1"), s1.Label)
+}
+
+func findOption(options mathbattle.ReassignOptions, solutionID string) (mathbattle.ReassignSolution, bool) {
+	for _, solution := range options.Solutions {
+		if solution.ID == solutionID {
+			return solution, true
+		}
+	}
+	return mathbattle.ReassignSolution{}, false
+}
+
+// newWatchdogFixture - этап ревью среди участников 1..4, где 2 проверяет s1 и s3, 3 - s4, 4 - s2.
+// 3 и 4 всё проверили, 2 проверил только s1
+func newWatchdogFixture(req *require.Assertions) *roundFixture {
+	f := newRoundFixtureOf(4)
+	f.startReview(req)
+	f.rounds.running.ReviewDistribution = mathbattle.ReviewDistribution{BetweenParticipants: map[string][]string{
+		"2": {"s1", "s3"},
+		"3": {"s4"},
+		"4": {"s2"},
+	}}
+	f.reviews.reviews = []mathbattle.Review{
+		{ReviewerID: "2", SolutionID: "s1"},
+		{ReviewerID: "3", SolutionID: "s4"},
+		{ReviewerID: "4", SolutionID: "s2"},
+	}
+	return f
+}
+
+func TestReassignInactiveReviewsKeepsActiveReviewer(t *testing.T) {
+	req := require.New(t)
+	f := newWatchdogFixture(req)
+
+	// 2 проверяет, просто ещё не закончил
+	f.rs.reassignInactiveReviews("r1")
+	req.Equal([]string{"2"}, f.rounds.running.ReviewDistribution.Reviewers("s3"))
+	req.Empty(f.rounds.running.ReviewDistribution.Reassignments)
+	req.Empty(f.postman.sent)
+}
+
+func TestReassignInactiveReviewsFromUnsubscribed(t *testing.T) {
+	req := require.New(t)
+	f := newWatchdogFixture(req)
+	f.participants.participants[1].IsActive = false
+
+	f.rs.reassignInactiveReviews("r1")
+	// 3 - автор s3, поэтому решение получает 4
+	req.Equal([]string{"4"}, f.rounds.running.ReviewDistribution.Reviewers("s3"))
+	// Проверенное решение остаётся у того, кто его проверил
+	req.Equal([]string{"2"}, f.rounds.running.ReviewDistribution.Reviewers("s1"))
+
+	reassignments := f.rounds.running.ReviewDistribution.Reassignments
+	req.Len(reassignments, 1)
+	req.Equal(mathbattle.ReviewReassignment{
+		Date:              reassignments[0].Date,
+		SolutionID:        "s3",
+		FromParticipantID: "2",
+		ToParticipantID:   "4",
+		Reason:            mathbattle.ReassignReasonInactive,
+	}, reassignments[0])
+
+	// Отписавшемуся не пишем
+	req.Empty(f.sentTo(telegramID("2")))
+	req.Contains(f.sentTo(telegramID("4")), "added")
+}
+
+func TestReassignInactiveReviewsFromIdle(t *testing.T) {
+	req := require.New(t)
+	f := newWatchdogFixture(req)
+	// 2 не прислал ни одного ревью
+	f.reviews.reviews = f.reviews.reviews[1:]
+
+	f.rs.reassignInactiveReviews("r1")
+	req.Empty(f.rounds.running.ReviewDistribution.BetweenParticipants["2"])
+	req.Equal([]string{"4"}, f.rounds.running.ReviewDistribution.Reviewers("s3"))
+	req.Len(f.rounds.running.ReviewDistribution.Reviewers("s1"), 1)
+	req.Len(f.rounds.running.ReviewDistribution.Reassignments, 2)
+	req.Contains(f.sentTo(telegramID("2")), "removed A1")
+}
+
+func TestRemindSolveStage(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	f.rounds.running.SetSolveEndDate(time.Now().Add(time.Hour))
+	// 1 всё решил, 2 - нет, 3 не решил, но отключил напоминания
+	req.NoError(f.solutions.Delete("s2"))
+	req.NoError(f.solutions.Delete("s3"))
+	f.participants.participants[2].RemindersOff = true
+
+	f.rs.remindSolveStage("r1", time.Hour)
+	req.Empty(f.sentTo(telegramID("1")))
+	req.Equal([]string{"remind solve A"}, f.sentTo(telegramID("2")))
+	req.Empty(f.sentTo(telegramID("3")))
+
+	// Повторно запланированное напоминание не отправляется, следующее - отправляется
+	f.rs.remindSolveStage("r1", time.Hour)
+	req.Len(f.postman.sent, 1)
+	f.rs.remindSolveStage("r1", time.Minute)
+	req.Len(f.postman.sent, 2)
+
+	// После конца этапа решения о нём не напоминаем
+	f.rounds.running.SetSolveEndDate(time.Now().Add(-time.Minute))
+	f.rs.remindSolveStage("r1", time.Second)
+	req.Len(f.postman.sent, 2)
+}
+
+func TestRemindReviewStage(t *testing.T) {
+	req := require.New(t)
+	f := newRoundFixture()
+	f.startReview(req)
+	f.rounds.running.ReviewDistribution = mathbattle.ReviewDistribution{BetweenParticipants: map[string][]string{
+		"1": {"s2"},
+		"2": {"s3"},
+		"3": {"s1"},
+	}}
+	// 1 всё проверил, 2 - нет, 3 не проверил, но отключил напоминания
+	f.reviews.reviews = []mathbattle.Review{{ReviewerID: "1", SolutionID: "s2"}}
+	f.participants.participants[2].RemindersOff = true
+
+	f.rs.remindReviewStage("r1", time.Hour)
+	req.Empty(f.sentTo(telegramID("1")))
+	req.Equal([]string{"remind review A1"}, f.sentTo(telegramID("2")))
+	req.Empty(f.sentTo(telegramID("3")))
+
+	f.rs.remindReviewStage("r1", time.Hour)
+	req.Len(f.postman.sent, 1)
+}
+
+func TestRevealIdentities(t *testing.T) {
+	req := require.New(t)
+
+	f := newRoundFixture()
+	f.rs.BlindGrading = true
+
+	_, err := f.rs.RevealIdentities(mathbattle.RevealOrder{RoundID: "r1", By: "admin"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+
+	// Копия раунда до раскрытия, например у параллельного запроса
+	stale := *f.rounds.running
+
+	identities, err := f.rs.RevealIdentities(mathbattle.RevealOrder{RoundID: "r1", By: "admin", Reason: "appeal"})
+	req.NoError(err)
+	req.Len(identities, 3)
+	req.Equal(mathbattle.SolutionIdentity{
+		SolutionID:      "s1",
+		Pseudonym:       mathbattle.SolutionCode("r1", "s1"),
+		ProblemID:       "p1",
+		ParticipantID:   "1",
+		ParticipantName: "Participant 1",
+	}, identities[0])
+	req.Len(f.rounds.running.IdentityReveals, 1)
+	req.Equal("admin", f.rounds.running.IdentityReveals[0].By)
+	req.Equal("appeal", f.rounds.running.IdentityReveals[0].Reason)
+
+	// Раскрытие остаётся в журнале, даже если потом сохранят устаревшую копию
+	req.NoError(f.rounds.Update(stale))
+	req.Len(f.rounds.running.IdentityReveals, 1)
+}
+
+func TestStartReviewStageTimeZone(t *testing.T) {
+	req := require.New(t)
+
+	f := newRoundFixture()
+	_, err := f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: "20.10.2099 18:00", TimeZone: "Mars/Olympus"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+	req.Equal(mathbattle.StageReviewPending, mathbattle.GetRoundStage(*f.rounds.running))
+	req.Empty(f.postman.sent)
+
+	_, err = f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: "20.10.2099 18:00", TimeZone: "UTC+5"})
+	req.NoError(err)
+	req.True(f.rounds.running.GetReviewEndDate().Equal(time.Date(2099, 10, 20, 13, 0, 0, 0, time.UTC)))
+}
@@ -1,27 +1,160 @@
 package application
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
 type SolutionService struct {
-	Rep    mathbattle.SolutionRepository
-	Rounds mathbattle.RoundRepository
+	Rep          mathbattle.SolutionRepository
+	Rounds       mathbattle.RoundRepository
+	Teams        mathbattle.TeamRepository
+	Participants mathbattle.ParticipantRepository
+	Limits       mathbattle.SolutionPartLimits
+	// nil - сохранять фотографии как есть
+	Normalizer mathbattle.ImageNormalizer
+	// Страницы pdf решений в общем pdf для жюри, nil - вместо них страница со ссылкой на файл
+	Previewer mathbattle.DocumentPreviewer
+	// Слепая проверка: жюри не видят авторов решений, пока результаты раунда не опубликованы
+	BlindGrading bool
+	Audit        *Auditor
+	Logger       *mlog.Logger
+}
+
+func (s *SolutionService) logger() *mlog.Logger {
+	return loggerOrDefault(s.Logger)
+}
+
+const pseudonymAttempts = 10
+
+// newPseudonym - случайный код, которого ещё нет у решений раунда
+func (s *SolutionService) newPseudonym(roundID string) (string, error) {
+	roundSolutions, err := s.Rep.FindMany(roundID, "", "")
+	if err != nil {
+		return "", err
+	}
+	used := make(map[string]bool)
+	for _, solution := range roundSolutions {
+		used[solution.Alias()] = true
+	}
+
+	for i := 0; i < pseudonymAttempts; i++ {
+		buf := make([]byte, 4)
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		pseudonym := strings.ToUpper(hex.EncodeToString(buf))
+		if !used[pseudonym] {
+			return pseudonym, nil
+		}
+	}
+
+	return "", errors.New("failed to generate unique pseudonym")
 }
 
 func (s *SolutionService) Create(solution mathbattle.Solution) (mathbattle.Solution, error) {
+	if solution.Pseudonym == "" {
+		pseudonym, err := s.newPseudonym(solution.RoundID)
+		if err != nil {
+			return solution, err
+		}
+		solution.Pseudonym = pseudonym
+	}
+
 	return s.Rep.Store(solution)
 }
 
+// Get и Find при слепой проверке отдают решения без участников: через API их видит и жюри
 func (s *SolutionService) Get(ID string) (mathbattle.Solution, error) {
-	return s.Rep.Get(ID)
+	solution, err := s.Rep.Get(ID)
+	if err != nil {
+		return solution, err
+	}
+
+	solutions := []mathbattle.Solution{solution}
+	if err := s.hideAuthors(solutions); err != nil {
+		return mathbattle.Solution{}, err
+	}
+	return solutions[0], nil
 }
 
 func (s *SolutionService) Find(descriptor mathbattle.FindDescriptor) ([]mathbattle.Solution, error) {
-	return s.Rep.FindMany(descriptor.RoundID, descriptor.ParticipantID, descriptor.ProblemID)
+	var solutions []mathbattle.Solution
+	var err error
+	if descriptor.TeamID != "" {
+		solutions, err = s.Rep.FindManyByTeam(descriptor.RoundID, descriptor.TeamID, descriptor.ProblemID)
+	} else {
+		solutions, err = s.Rep.FindMany(descriptor.RoundID, descriptor.ParticipantID, descriptor.ProblemID)
+	}
+	if err != nil {
+		return []mathbattle.Solution{}, err
+	}
+
+	if err := s.hideAuthors(solutions); err != nil {
+		return []mathbattle.Solution{}, err
+	}
+	return solutions, nil
+}
+
+// hideAuthors обезличивает решения раундов, участники которых скрыты от жюри, см. Round.IdentitiesHidden
+func (s *SolutionService) hideAuthors(solutions []mathbattle.Solution) error {
+	if !s.BlindGrading {
+		return nil
+	}
+
+	isHidden := map[string]bool{}
+	for i := range solutions {
+		hidden, isKnown := isHidden[solutions[i].RoundID]
+		if !isKnown {
+			round, err := s.Rounds.Get(solutions[i].RoundID)
+			if err != nil {
+				return err
+			}
+			hidden = round.IdentitiesHidden(s.BlindGrading)
+			isHidden[round.ID] = hidden
+		}
+
+		if hidden {
+			solutions[i] = solutions[i].Anonymized()
+		}
+	}
+
+	return nil
 }
 
 func (s *SolutionService) AppendPart(ID string, part mathbattle.Image) error {
+	// Клиент не прислал тип - определяем по содержимому, нераспознанное не принимаем
+	if part.MimeType == "" {
+		part.MimeType = strings.Split(http.DetectContentType(part.Content), ";")[0]
+		if part.MimeType == "application/octet-stream" {
+			return mathbattle.ErrSolutionPartUnsupported
+		}
+	}
+
+	if err := s.Limits.Check(part); err != nil {
+		return err
+	}
+
+	if s.Normalizer != nil && part.IsPicture() {
+		normalized, err := s.Normalizer.Normalize(part)
+		if err == mathbattle.ErrSolutionPartTooLarge {
+			// Такую картинку не сможем и показать
+			return err
+		}
+		if err != nil {
+			// Например, формат, который не умеем декодировать. Такую часть всё равно можно посмотреть
+			s.logger().Errorf("Failed to normalize solution part, storing as is, error: %v", err)
+		} else {
+			part = normalized
+		}
+	}
+
 	return s.Rep.AppendPart(ID, part)
 }
 
@@ -29,19 +162,59 @@ func (s *SolutionService) Update(solution mathbattle.Solution) error {
 	return s.Rep.Update(solution)
 }
 
-func (s *SolutionService) Delete(ID string) error {
+func (s *SolutionService) Delete(ID string, actorID int64) error {
+	solution, err := s.Rep.Get(ID)
+	if err != nil {
+		return err
+	}
+
+	own, err := isOwn(s.Participants, s.Teams, actorID, solution.ParticipantID, solution.TeamID)
+	if err != nil {
+		return err
+	}
+	if !own {
+		// Фотографии решения в журнал не попадают
+		audited := solution
+		audited.Parts = nil
+		if err := s.Audit.Record(actorID, mathbattle.AuditSolutionDelete, ID, audited, nil); err != nil {
+			return err
+		}
+	}
+
 	return s.Rep.Delete(ID)
 }
 
+func (s *SolutionService) JurySolutions(roundID string, problemID string) ([]mathbattle.Solution, error) {
+	round, err := s.Rounds.Get(roundID)
+	if err != nil {
+		return []mathbattle.Solution{}, err
+	}
+
+	return s.Find(mathbattle.FindDescriptor{RoundID: round.ID, ProblemID: problemID})
+}
+
 func (s *SolutionService) GetProblemDescriptors(participantID string) ([]mathbattle.ProblemDescriptor, error) {
 	result := []mathbattle.ProblemDescriptor{}
 
-	round, err := s.Rounds.GetRunning()
+	participant, err := s.Participants.GetByID(participantID)
+	if err != nil {
+		return result, err
+	}
+
+	round, err := s.Rounds.GetRunning(participant.League)
+	if err != nil {
+		return result, err
+	}
+
+	competitorID, err := mathbattle.CompetitorID(round, participantID, s.Teams)
 	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return result, nil
+		}
 		return result, err
 	}
 
-	for _, desc := range round.ProblemDistribution[participantID] {
+	for _, desc := range round.ProblemDistribution[competitorID] {
 		result = append(result, desc)
 	}
 
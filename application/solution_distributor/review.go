@@ -3,38 +3,47 @@ package solutiondistributor
 import (
 	"math/rand"
 	"mathbattle/models/mathbattle"
-	"time"
+	"sort"
 )
 
 type SolutionDistributor struct{}
 
-func distributeSolutionsToParticipants(solutions []mathbattle.Solution, reviewerCount uint) map[string][]string {
+func distributeSolutionsToParticipants(rnd *rand.Rand, solutions []mathbattle.Solution, reviewerCount uint) map[string][]string {
 	// Shuffle
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(solutions), func(i, j int) {
+	rnd.Shuffle(len(solutions), func(i, j int) {
 		solutions[i], solutions[j] = solutions[j], solutions[i]
 	})
 
-	// Map each solution ID to participant IDs it needs to be sent
+	// Map each solution ID to competitor IDs it needs to be sent: teams in a team round, participants otherwise
 	result := make(map[string][]string)
 	targets := append(solutions[1:], solutions[:reviewerCount]...)
 
 	for i := 0; i < len(solutions); i++ {
 		for j := uint(0); j < reviewerCount; j++ {
-			result[solutions[i].ID] = append(result[solutions[i].ID], targets[uint(i)+j].ParticipantID)
+			result[solutions[i].ID] = append(result[solutions[i].ID], targets[uint(i)+j].CompetitorID())
 		}
 	}
 
 	return result
 }
 
-func (d *SolutionDistributor) Get(allRoundSolutions []mathbattle.Solution, reviewerCount uint) mathbattle.ReviewDistribution {
+// Get при одинаковых seed и наборе решений всегда возвращает одно и то же распределение
+func (d *SolutionDistributor) Get(allRoundSolutions []mathbattle.Solution, reviewerCount uint, seed int64) mathbattle.ReviewDistribution {
 	result := mathbattle.ReviewDistribution{
 		BetweenParticipants: make(map[string][]string),
 		ToOrganizers:        make([]string, 0),
 	}
 
-	for _, problemSolutions := range mathbattle.SplitInGroupsByProblem(allRoundSolutions) {
+	groups := mathbattle.SplitInGroupsByProblem(allRoundSolutions)
+	problemIDs := []string{}
+	for problemID := range groups {
+		problemIDs = append(problemIDs, problemID)
+	}
+	sort.Strings(problemIDs)
+
+	rnd := rand.New(rand.NewSource(seed))
+	for _, problemID := range problemIDs {
+		problemSolutions := groups[problemID]
 		if len(problemSolutions) == 0 {
 			continue
 		}
@@ -44,14 +53,19 @@ func (d *SolutionDistributor) Get(allRoundSolutions []mathbattle.Solution, revie
 			continue
 		}
 
+		sort.Slice(problemSolutions, func(i, j int) bool {
+			return problemSolutions[i].ID < problemSolutions[j].ID
+		})
+
 		finalReviewerCount := reviewerCount
 		if uint(len(problemSolutions)) < reviewerCount+1 {
 			finalReviewerCount = uint(len(problemSolutions)) - 1
 		}
 
-		for solutionID, participantIDs := range distributeSolutionsToParticipants(problemSolutions, finalReviewerCount) {
-			for _, pID := range participantIDs {
-				result.BetweenParticipants[pID] = append(result.BetweenParticipants[pID], solutionID)
+		distributed := distributeSolutionsToParticipants(rnd, problemSolutions, finalReviewerCount)
+		for _, solution := range problemSolutions {
+			for _, pID := range distributed[solution.ID] {
+				result.BetweenParticipants[pID] = append(result.BetweenParticipants[pID], solution.ID)
 			}
 		}
 	}
@@ -0,0 +1,63 @@
+package solutiondistributor
+
+import (
+	"fmt"
+	"testing"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSameSeedSameDistribution(t *testing.T) {
+	req := require.New(t)
+
+	solutions := []mathbattle.Solution{}
+	for i := 0; i < 6; i++ {
+		solutions = append(solutions, mathbattle.Solution{
+			ID:            fmt.Sprintf("s%d", i),
+			ParticipantID: fmt.Sprintf("p%d", i%3),
+			ProblemID:     fmt.Sprintf("problem%d", i%2),
+		})
+	}
+	reversed := []mathbattle.Solution{}
+	for i := len(solutions) - 1; i >= 0; i-- {
+		reversed = append(reversed, solutions[i])
+	}
+
+	d := SolutionDistributor{}
+	first := d.Get(solutions, 2, 42)
+	req.Equal(first, d.Get(reversed, 2, 42))
+	req.Equal(first, d.Get(solutions, 2, 42))
+}
+
+func TestTeamSolutionsGoToTeams(t *testing.T) {
+	req := require.New(t)
+
+	// Решения сдают разные участники команды, а проверяют их команды
+	solutions := []mathbattle.Solution{}
+	for i := 0; i < 6; i++ {
+		solutions = append(solutions, mathbattle.Solution{
+			ID:            fmt.Sprintf("s%d", i),
+			ParticipantID: fmt.Sprintf("p%d", i),
+			TeamID:        fmt.Sprintf("t%d", i%3),
+			ProblemID:     fmt.Sprintf("problem%d", i/3),
+		})
+	}
+
+	d := SolutionDistributor{}
+	distribution := d.Get(solutions, 2, 42)
+	req.Len(distribution.BetweenParticipants, 3)
+	for reviewerID, solutionIDs := range distribution.BetweenParticipants {
+		req.Contains([]string{"t0", "t1", "t2"}, reviewerID)
+		req.Len(solutionIDs, 4)
+		for _, solutionID := range solutionIDs {
+			for _, solution := range solutions {
+				if solution.ID == solutionID {
+					req.NotEqual(solution.TeamID, reviewerID)
+				}
+			}
+		}
+	}
+	req.Empty(distribution.ToOrganizers)
+}
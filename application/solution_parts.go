@@ -0,0 +1,65 @@
+package application
+
+import (
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+// Телеграм не принимает в альбом больше 10 фотографий
+const maxAlbumSize = 10
+
+// sendSolution отправляет решение: фотографии и превью pdf альбомом, документы - отдельными сообщениями.
+// Подпись получает только первое сообщение
+func sendSolution(postman mathbattle.PostmanService, previewer mathbattle.DocumentPreviewer, logger *mlog.Logger,
+	chatID int64, caption string, solutionNumber int, parts []mathbattle.Image) error {
+
+	pictures := [][]byte{}
+	documents := []int{}
+	for i, part := range parts {
+		if part.IsPicture() {
+			pictures = append(pictures, part.Content)
+			continue
+		}
+
+		documents = append(documents, i)
+		if part.IsPDF() && previewer != nil {
+			previews, err := previewer.Preview(part)
+			if err != nil {
+				// Без превью решение всё равно можно посмотреть, открыв документ
+				logger.Errorf("Failed to render preview of solution part, error: %v", err)
+				continue
+			}
+			pictures = append(pictures, previews...)
+		}
+	}
+
+	for len(pictures) > 0 {
+		count := len(pictures)
+		if count > maxAlbumSize {
+			count = maxAlbumSize
+		}
+
+		var err error
+		if count == 1 {
+			err = postman.SendImage(chatID, caption, pictures[0])
+		} else {
+			err = postman.SendAlbum(chatID, caption, pictures[:count])
+		}
+		if err != nil {
+			return err
+		}
+
+		pictures = pictures[count:]
+		caption = ""
+	}
+
+	for _, i := range documents {
+		fileName := mathbattle.PartFileName(solutionNumber, i+1, parts[i])
+		if err := postman.SendDocument(chatID, fileName, caption, parts[i].Content); err != nil {
+			return err
+		}
+		caption = ""
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package application
+
+import (
+	"testing"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSolutionWithDocuments(t *testing.T) {
+	req := require.New(t)
+
+	parts := []mathbattle.Image{
+		{Extension: ".jpg", MimeType: mathbattle.MimeTypeJPEG, Content: []byte{1}},
+		{Extension: ".pdf", MimeType: mathbattle.MimeTypePDF, Content: []byte{2}},
+		{Extension: ".png", MimeType: "image/png", Content: []byte{3}},
+	}
+
+	postman := &recordingPostman{}
+	req.NoError(sendSolution(postman, twoPagesPreviewer{}, mlog.Default(), 1, "caption", 2, parts))
+	req.Equal([]sentItem{
+		{chatID: 1, kind: "album", caption: "caption", count: 4},
+		{chatID: 1, kind: "document", fileName: "solution_2_2.pdf", count: 1},
+	}, postman.sent)
+
+	postman = &recordingPostman{}
+	req.NoError(sendSolution(postman, nil, mlog.Default(), 1, "caption", 2, parts[1:2]))
+	req.Equal([]sentItem{
+		{chatID: 1, kind: "document", caption: "caption", fileName: "solution_2_1.pdf", count: 1},
+	}, postman.sent)
+}
+
+func TestSendSolutionSplitsLargeAlbums(t *testing.T) {
+	req := require.New(t)
+
+	parts := []mathbattle.Image{}
+	for i := 0; i < 11; i++ {
+		parts = append(parts, mathbattle.Image{Extension: ".jpg", MimeType: mathbattle.MimeTypeJPEG, Content: []byte{byte(i)}})
+	}
+
+	postman := &recordingPostman{}
+	req.NoError(sendSolution(postman, nil, mlog.Default(), 1, "caption", 1, parts))
+	req.Equal([]sentItem{
+		{chatID: 1, kind: "album", caption: "caption", count: 10},
+		{chatID: 1, kind: "image", count: 1},
+	}, postman.sent)
+}
+
+func TestSolutionPartLimits(t *testing.T) {
+	req := require.New(t)
+
+	limits := mathbattle.SolutionPartLimits{
+		MaxImageSize:    2,
+		MaxDocumentSize: 3,
+		DocumentTypes:   []string{mathbattle.MimeTypePDF},
+	}
+
+	req.NoError(limits.Check(mathbattle.Image{MimeType: mathbattle.MimeTypeJPEG, Content: []byte{1, 2}}))
+	req.Equal(mathbattle.ErrSolutionPartTooLarge, limits.Check(mathbattle.Image{MimeType: mathbattle.MimeTypeJPEG, Content: []byte{1, 2, 3}}))
+	req.NoError(limits.Check(mathbattle.Image{MimeType: mathbattle.MimeTypePDF, Content: []byte{1, 2, 3}}))
+	req.Equal(mathbattle.ErrSolutionPartTooLarge, limits.Check(mathbattle.Image{MimeType: mathbattle.MimeTypePDF, Content: []byte{1, 2, 3, 4}}))
+	req.Equal(mathbattle.ErrSolutionPartUnsupported, limits.Check(mathbattle.Image{MimeType: "application/zip", Content: []byte{1}}))
+}
+
+func TestAppendPartDetectsMimeType(t *testing.T) {
+	req := require.New(t)
+
+	solutions := &memorySolutions{solutions: []mathbattle.Solution{{ID: "s1"}}}
+	service := SolutionService{Rep: solutions}
+
+	req.NoError(service.AppendPart("s1", mathbattle.Image{Extension: ".pdf", Content: []byte("%PDF-1.4")}))
+	req.Equal(mathbattle.MimeTypePDF, solutions.solutions[0].Parts[0].MimeType)
+	req.False(solutions.solutions[0].Parts[0].IsPicture())
+
+	req.NoError(service.AppendPart("s1", mathbattle.Image{Extension: ".png", Content: []byte("\x89PNG\x0D\x0A\x1A\x0A")}))
+	req.Equal("image/png", solutions.solutions[0].Parts[1].MimeType)
+
+	// Без типа и с нераспознанным содержимым часть не принимается
+	req.Equal(mathbattle.ErrSolutionPartUnsupported,
+		service.AppendPart("s1", mathbattle.Image{Extension: ".bin", Content: []byte{0, 1, 2}}))
+	req.Len(solutions.solutions[0].Parts, 2)
+}
@@ -0,0 +1,128 @@
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // регистрирует декодер png для image.Decode
+	"sort"
+
+	"mathbattle/libs/pdfwriter"
+	"mathbattle/models/mathbattle"
+)
+
+// MergedPDF собирает решения в один pdf для печати: перед каждым решением титульная страница
+// с обезличенным кодом и обозначением задачи, затем все части по порядку
+func (s *SolutionService) MergedPDF(roundID string, problemID string) ([]byte, error) {
+	round, err := s.Rounds.Get(roundID)
+	if err != nil {
+		return nil, err
+	}
+
+	solutions, err := s.Rep.FindMany(roundID, "", problemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(solutions) == 0 {
+		return nil, mathbattle.ErrNotFound
+	}
+
+	sort.Slice(solutions, func(i, j int) bool {
+		if solutions[i].ProblemID != solutions[j].ProblemID {
+			return solutions[i].ProblemID < solutions[j].ProblemID
+		}
+		return solutions[i].ID < solutions[j].ID
+	})
+
+	doc := pdfwriter.New()
+	for _, solution := range solutions {
+		caption := ""
+		for _, descriptor := range round.ProblemDistribution[solution.ParticipantID] {
+			if descriptor.ProblemID == solution.ProblemID {
+				caption = descriptor.Caption
+			}
+		}
+
+		problemShortID := solution.ProblemID
+		if len(problemShortID) > 8 {
+			problemShortID = problemShortID[:8]
+		}
+
+		doc.AddTextPage([]string{
+			fmt.Sprintf("Solution %s", solution.Alias()),
+			fmt.Sprintf("Problem %s (%s)", caption, problemShortID),
+			fmt.Sprintf("Round %s", round.ID),
+			fmt.Sprintf("Parts: %d", len(solution.Parts)),
+			"",
+			"Mark: ________",
+		})
+
+		// Части читаем по одной, чтобы не держать в памяти все решения раунда
+		for i, part := range solution.Parts {
+			loaded, err := mathbattle.WithContent(s.Rep, []mathbattle.Image{part})
+			if err != nil {
+				return nil, err
+			}
+			if err := s.addPartPages(doc, i+1, loaded[0]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return doc.Bytes(), nil
+}
+
+func (s *SolutionService) addPartPages(doc *pdfwriter.Document, partNumber int, part mathbattle.Image) error {
+	if part.IsPicture() {
+		content, err := asJPEG(part)
+		if err != nil {
+			// Не получилось прочитать картинку - жюри найдёт её по имени файла
+			s.logger().Errorf("Failed to add solution part to pdf, error: %v", err)
+			doc.AddTextPage([]string{fmt.Sprintf("Part %d", partNumber), "Unreadable image, see the original file"})
+			return nil
+		}
+		return doc.AddJPEGPage(content)
+	}
+
+	if part.IsPDF() && s.Previewer != nil {
+		pages, err := s.Previewer.Preview(part)
+		if err == nil {
+			for _, page := range pages {
+				content, err := asJPEG(mathbattle.Image{Content: page})
+				if err != nil {
+					return err
+				}
+				if err := doc.AddJPEGPage(content); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		s.logger().Errorf("Failed to render solution part for pdf, error: %v", err)
+	}
+
+	doc.AddTextPage([]string{
+		fmt.Sprintf("Part %d", partNumber),
+		fmt.Sprintf("Document %s (%s), see the original file", part.Extension, part.MimeType),
+	})
+	return nil
+}
+
+// asJPEG - jpeg встраивается в pdf как есть, остальное перекодируется
+func asJPEG(part mathbattle.Image) ([]byte, error) {
+	if _, err := jpeg.DecodeConfig(bytes.NewReader(part.Content)); err == nil {
+		return part.Content, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(part.Content))
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
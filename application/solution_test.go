@@ -0,0 +1,72 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBlindSolutionService(f *roundFixture) *SolutionService {
+	return &SolutionService{
+		Rep:          f.solutions,
+		Rounds:       f.rounds,
+		Participants: f.participants,
+		Teams:        &memoryTeams{},
+		BlindGrading: true,
+	}
+}
+
+func TestJurySolutions(t *testing.T) {
+	req := require.New(t)
+
+	f := newRoundFixture()
+	service := newBlindSolutionService(f)
+
+	solutions, err := service.JurySolutions("r1", "p1")
+	req.NoError(err)
+	req.Len(solutions, 3)
+	for _, solution := range solutions {
+		req.Empty(solution.ParticipantID)
+		req.Empty(solution.TeamID)
+		req.NotEmpty(solution.Alias())
+	}
+
+	// Раунд закончился и результаты опубликованы - жюри видят участников
+	f.rounds.running.SetReviewStartDate(time.Now().Add(-2 * time.Hour))
+	f.rounds.running.SetReviewEndDate(time.Now().Add(-time.Hour))
+	f.rounds.running.ResultsPublished = true
+	solutions, err = service.JurySolutions("r1", "p1")
+	req.NoError(err)
+	req.Equal("1", solutions[0].ParticipantID)
+
+	_, err = service.JurySolutions("unknown", "")
+	req.Equal(mathbattle.ErrNotFound, err)
+}
+
+func TestSolutionReadsHideAuthors(t *testing.T) {
+	req := require.New(t)
+
+	f := newRoundFixture()
+	service := newBlindSolutionService(f)
+
+	solution, err := service.Get("s1")
+	req.NoError(err)
+	req.Equal("s1", solution.ID)
+	req.Empty(solution.ParticipantID)
+
+	solutions, err := service.Find(mathbattle.FindDescriptor{RoundID: "r1"})
+	req.NoError(err)
+	req.Len(solutions, 3)
+	for _, solution := range solutions {
+		req.Empty(solution.ParticipantID)
+	}
+
+	// Без слепой проверки решения отдаются как есть
+	service.BlindGrading = false
+	solution, err = service.Get("s1")
+	req.NoError(err)
+	req.Equal("1", solution.ParticipantID)
+}
@@ -25,18 +25,31 @@ func FilterRegisteredAfter(participants []mathbattle.Participant, datetime time.
 	return result
 }
 
-func (ss *StatService) Stat() (mathbattle.Stat, error) {
-	result := mathbattle.Stat{}
+func FilterLeague(participants []mathbattle.Participant, league string) []mathbattle.Participant {
+	result := []mathbattle.Participant{}
+
+	for _, participant := range participants {
+		if participant.League == league {
+			result = append(result, participant)
+		}
+	}
+
+	return result
+}
+
+func (ss *StatService) Stat(league string) (mathbattle.Stat, error) {
+	result := mathbattle.Stat{League: league}
 
 	pAll, err := ss.Participants.GetAll()
 	if err != nil {
 		return result, err
 	}
+	pAll = FilterLeague(pAll, league)
 	pToday := FilterRegisteredAfter(pAll, time.Now().Truncate(24*time.Hour))
 	result.ParticipantsTotal = len(pAll)
 	result.ParticipantsToday = len(pToday)
 
-	round, err := ss.Rounds.GetRunning()
+	round, err := ss.Rounds.GetRunning(league)
 	result.RoundStage = mathbattle.GetRoundStage(round)
 	if err != nil {
 		if err != mathbattle.ErrNotFound {
@@ -0,0 +1,133 @@
+package application
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"mathbattle/models/mathbattle"
+)
+
+type TeamService struct {
+	Rep          mathbattle.TeamRepository
+	Participants mathbattle.ParticipantRepository
+	Rounds       mathbattle.RoundRepository
+}
+
+func newInviteCode() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(buf)), nil
+}
+
+// checkUnlocked - пока идёт командный раунд хотя бы в одной лиге, состав команд не меняется:
+// по нему распределены задачи и решения
+func (s *TeamService) checkUnlocked() error {
+	rounds, err := s.Rounds.GetAllRunning()
+	if err != nil {
+		return err
+	}
+
+	for _, round := range rounds {
+		if round.IsTeam() {
+			return mathbattle.ErrTeamLocked
+		}
+	}
+	return nil
+}
+
+// checkFree - участник существует и ещё не состоит в команде
+func (s *TeamService) checkFree(participantID string) error {
+	if _, err := s.Participants.GetByID(participantID); err != nil {
+		if err == mathbattle.ErrNotFound {
+			return mathbattle.ErrWrongUserInput
+		}
+		return err
+	}
+
+	_, err := s.Rep.GetByMember(participantID)
+	if err == nil {
+		return mathbattle.ErrWrongUserInput
+	}
+	if err != mathbattle.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+func (s *TeamService) Create(team mathbattle.Team) (mathbattle.Team, error) {
+	name, ok := mathbattle.ValidateTeamName(team.Name)
+	if !ok {
+		return team, mathbattle.ErrWrongUserInput
+	}
+
+	if err := s.checkUnlocked(); err != nil {
+		return team, err
+	}
+
+	if err := s.checkFree(team.CaptainID); err != nil {
+		return team, err
+	}
+
+	inviteCode, err := newInviteCode()
+	if err != nil {
+		return team, err
+	}
+
+	return s.Rep.Store(mathbattle.Team{
+		Name:       name,
+		CaptainID:  team.CaptainID,
+		MemberIDs:  []string{team.CaptainID},
+		InviteCode: inviteCode,
+	})
+}
+
+func (s *TeamService) Get(ID string) (mathbattle.Team, error) {
+	return s.Rep.Get(ID)
+}
+
+func (s *TeamService) GetByMember(participantID string) (mathbattle.Team, error) {
+	return s.Rep.GetByMember(participantID)
+}
+
+func (s *TeamService) GetAll() ([]mathbattle.Team, error) {
+	return s.Rep.GetAll()
+}
+
+func (s *TeamService) Join(order mathbattle.JoinOrder) (mathbattle.Team, error) {
+	team, err := s.Rep.GetByInviteCode(strings.ToUpper(strings.TrimSpace(order.InviteCode)))
+	if err != nil {
+		return team, err
+	}
+
+	if err := s.checkUnlocked(); err != nil {
+		return team, err
+	}
+
+	if err := s.checkFree(order.ParticipantID); err != nil {
+		return team, err
+	}
+
+	team.MemberIDs = append(team.MemberIDs, order.ParticipantID)
+	return team, s.Rep.Update(team)
+}
+
+// Leave - последний ушедший участник удаляет команду
+func (s *TeamService) Leave(participantID string) error {
+	team, err := s.Rep.GetByMember(participantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkUnlocked(); err != nil {
+		return err
+	}
+
+	team.RemoveMember(participantID)
+	if len(team.MemberIDs) == 0 {
+		return s.Rep.Delete(team.ID)
+	}
+	return s.Rep.Update(team)
+}
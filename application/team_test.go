@@ -0,0 +1,165 @@
+package application
+
+import (
+	"strconv"
+	"testing"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamMembership(t *testing.T) {
+	req := require.New(t)
+
+	teams := &memoryTeams{}
+	rounds := &memoryRounds{}
+	ts := &TeamService{
+		Rep: teams,
+		Participants: &memoryParticipants{participants: []mathbattle.Participant{
+			{ID: "1"}, {ID: "2"}, {ID: "3"},
+		}},
+		Rounds: rounds,
+	}
+
+	_, err := ts.Create(mathbattle.Team{Name: "  ", CaptainID: "1"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+	_, err = ts.Create(mathbattle.Team{Name: "Pi", CaptainID: "4"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+
+	team, err := ts.Create(mathbattle.Team{Name: " Pi ", CaptainID: "1"})
+	req.Nil(err)
+	req.Equal("Pi", team.Name)
+	req.Equal([]string{"1"}, team.MemberIDs)
+	req.NotEmpty(team.InviteCode)
+
+	// Капитан не может создать вторую команду
+	_, err = ts.Create(mathbattle.Team{Name: "E", CaptainID: "1"})
+	req.Equal(mathbattle.ErrWrongUserInput, err)
+
+	_, err = ts.Join(mathbattle.JoinOrder{ParticipantID: "2", InviteCode: "WRONG"})
+	req.Equal(mathbattle.ErrNotFound, err)
+
+	joined, err := ts.Join(mathbattle.JoinOrder{ParticipantID: "2", InviteCode: " " + team.InviteCode + " "})
+	req.Nil(err)
+	req.Equal([]string{"1", "2"}, joined.MemberIDs)
+
+	// Во время командного раунда состав не меняется
+	rounds.running = &mathbattle.Round{Mode: mathbattle.RoundTeam}
+	_, err = ts.Join(mathbattle.JoinOrder{ParticipantID: "3", InviteCode: team.InviteCode})
+	req.Equal(mathbattle.ErrTeamLocked, err)
+	req.Equal(mathbattle.ErrTeamLocked, ts.Leave("1"))
+
+	rounds.running = &mathbattle.Round{Mode: mathbattle.RoundIndividual}
+	req.Nil(ts.Leave("1"))
+	team, err = ts.GetByMember("2")
+	req.Nil(err)
+	req.Equal("2", team.CaptainID)
+	req.Equal([]string{"2"}, team.MemberIDs)
+
+	req.Nil(ts.Leave("2"))
+	req.Empty(teams.teams)
+	req.Equal(mathbattle.ErrNotFound, ts.Leave("2"))
+}
+
+// newTeamRoundFixture - командный раунд с командами 1 (участники 1, 2), 2 (3, 4) и 3 (5, 6).
+// Решение задачи A сдал один участник каждой команды: s1 - участник 2, s2 - 3, s3 - 6
+func newTeamRoundFixture() *roundFixture {
+	f := newRoundFixtureOf(6)
+	round := f.rounds.running
+	round.Mode = mathbattle.RoundTeam
+	round.ProblemDistribution = map[string][]mathbattle.ProblemDescriptor{}
+
+	teams := &memoryTeams{}
+	solutions := []mathbattle.Solution{}
+	for i, authorID := range []string{"2", "3", "6"} {
+		teamID := strconv.Itoa(i + 1)
+		teams.teams = append(teams.teams, mathbattle.Team{
+			ID:        teamID,
+			Name:      "Team " + teamID,
+			CaptainID: strconv.Itoa(2*i + 1),
+			MemberIDs: []string{strconv.Itoa(2*i + 1), strconv.Itoa(2*i + 2)},
+		})
+		round.ProblemDistribution[teamID] = []mathbattle.ProblemDescriptor{{Caption: "A", ProblemID: "p1"}}
+
+		solution, err := f.solutions.Get("s" + authorID)
+		if err != nil {
+			panic(err)
+		}
+		solution.ID = "s" + teamID
+		solution.TeamID = teamID
+		solutions = append(solutions, solution)
+	}
+	f.solutions.solutions = solutions
+	f.rs.Teams = teams
+	return f
+}
+
+func TestTeamReviewStage(t *testing.T) {
+	req := require.New(t)
+
+	f := newTeamRoundFixture()
+	_, err := f.rs.StartReviewStage(mathbattle.StartOrder{StageEnd: reviewStageEnd()})
+	req.NoError(err)
+
+	distribution := f.rounds.running.ReviewDistribution
+	req.Len(distribution.BetweenParticipants, 3)
+	for teamID, solutionIDs := range distribution.BetweenParticipants {
+		req.Len(solutionIDs, 1)
+		req.NotContains(solutionIDs, "s"+teamID)
+
+		// Решение на проверку получают все участники команды
+		team, err := f.rs.Teams.Get(teamID)
+		req.NoError(err)
+		for _, memberID := range team.MemberIDs {
+			member, err := f.participants.GetByID(memberID)
+			req.NoError(err)
+			req.NotEmpty(f.sentTo(member.TelegramID), memberID)
+		}
+	}
+}
+
+func TestTeamSubmissionAndScoring(t *testing.T) {
+	req := require.New(t)
+
+	f := newTeamRoundFixture()
+	solutions := &SolutionService{Rep: f.solutions, Rounds: f.rounds, Teams: f.rs.Teams, Participants: f.participants}
+	reviews := &ReviewService{Rep: f.reviews, Rounds: f.rounds, Solutions: f.solutions, Teams: f.rs.Teams,
+		Participants: f.participants}
+
+	// Задачи и решение у команды общие: капитан видит решение, которое сдал другой участник
+	problems, err := solutions.GetProblemDescriptors("1")
+	req.NoError(err)
+	req.Equal(f.rounds.running.ProblemDistribution["1"], problems)
+	teamSolutions, err := solutions.Find(mathbattle.FindDescriptor{RoundID: "r1", TeamID: "1"})
+	req.NoError(err)
+	req.Len(teamSolutions, 1)
+	req.Equal("2", teamSolutions[0].ParticipantID)
+
+	f.startReview(req)
+	solutionID := f.rounds.running.ReviewDistribution.BetweenParticipants["1"][0]
+
+	first, err := reviews.RevewStageDescriptors("1")
+	req.NoError(err)
+	second, err := reviews.RevewStageDescriptors("2")
+	req.NoError(err)
+	req.Len(first, 1)
+	req.Equal(first, second)
+	req.Equal(solutionID, first[0].SolutionID)
+
+	// Ревью одного участника засчитывается всей команде
+	f.reviews.reviews = append(f.reviews.reviews, mathbattle.Review{
+		ReviewerID: "2", ReviewerTeamID: "1", SolutionID: solutionID, Mark: 5,
+	})
+	teamReviews, err := reviews.FindMany(mathbattle.ReviewFindDescriptor{ReviewerTeamID: "1", SolutionID: solutionID})
+	req.NoError(err)
+	req.Len(teamReviews, 1)
+	req.Equal(mathbattle.Mark(5), teamReviews[0].Mark)
+
+	teamReviews, err = mathbattle.FindCompetitorReviews(f.reviews, *f.rounds.running, "1", solutionID)
+	req.NoError(err)
+	req.Len(teamReviews, 1)
+	teamReviews, err = mathbattle.FindCompetitorReviews(f.reviews, *f.rounds.running, "2", solutionID)
+	req.NoError(err)
+	req.Empty(teamReviews)
+}
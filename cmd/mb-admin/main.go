@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,6 +19,8 @@ import (
 
 	"mathbattle/config"
 	"mathbattle/infrastructure"
+	"mathbattle/infrastructure/blobstore/s3test"
+	"mathbattle/interfaces/bot/webhooktest"
 	"mathbattle/libs/fstraverser"
 	"mathbattle/models/mathbattle"
 
@@ -42,6 +45,105 @@ func main() {
 		runBot(configPath)
 	case "send-kb":
 		sendKb()
+	case "merge-pdf":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: merge-pdf <out.pdf> <round_id> [problem_id]")
+			return
+		}
+		problemID := ""
+		if len(os.Args) > 4 {
+			problemID = os.Args[4]
+		}
+		container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
+		mergeSolutionsPdf(container.SolutionService(), os.Args[2], os.Args[3], problemID)
+	case "publish-results":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: publish-results <round_id>")
+			return
+		}
+		container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
+		publishResults(container.RoundService(), os.Args[2])
+	case "reveal-identities":
+		if len(os.Args) < 5 {
+			fmt.Println("Usage: reveal-identities <round_id> <by> <reason>")
+			return
+		}
+		container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
+		revealIdentities(container.RoundService(), mathbattle.RevealOrder{
+			RoundID: os.Args[2],
+			By:      os.Args[3],
+			Reason:  os.Args[4],
+		})
+	case "promote-grades":
+		schoolYear := mathbattle.SchoolYear(time.Now())
+		if len(os.Args) > 2 {
+			var err error
+			if schoolYear, err = strconv.Atoi(os.Args[2]); err != nil {
+				fmt.Println("Usage: promote-grades [school_year]")
+				return
+			}
+		}
+		container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
+		promoteGrades(container.ParticipantService(), schoolYear)
+	case "grant-role", "revoke-role":
+		order := mathbattle.RoleOrder{}
+		if os.Args[1] == "grant-role" {
+			if len(os.Args) < 4 {
+				fmt.Println("Usage: grant-role <@username|telegram_id> <owner|organizer|jury|moderator>")
+				return
+			}
+			order.Role = mathbattle.Role(os.Args[3])
+		} else if len(os.Args) < 3 {
+			fmt.Println("Usage: revoke-role <@username|telegram_id>")
+			return
+		}
+		if telegramID, err := strconv.ParseInt(os.Args[2], 10, 64); err == nil {
+			order.TelegramID = telegramID
+		} else {
+			order.TelegramName = os.Args[2]
+		}
+		container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
+		grantRole(container.RoleService(), order)
+	case "list-staff":
+		container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
+		listStaff(container.RoleService())
+	case "export-audit":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: export-audit <out.csv> [since YYYY-MM-DD]")
+			return
+		}
+		filter := mathbattle.AuditFilter{}
+		if len(os.Args) > 3 {
+			var err error
+			if filter.Since, err = time.Parse("2006-01-02", os.Args[3]); err != nil {
+				fmt.Println("Usage: export-audit <out.csv> [since YYYY-MM-DD]")
+				return
+			}
+		}
+		container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
+		exportAudit(container.AuditService(), os.Args[2], filter)
+	case "fake-s3":
+		listen := ":9000"
+		if len(os.Args) > 2 {
+			listen = os.Args[2]
+		}
+		accessKey := ""
+		if len(os.Args) > 3 {
+			accessKey = os.Args[3]
+		}
+		runFakeS3(listen, accessKey)
+	case "fake-telegram":
+		listen := ":8081"
+		if len(os.Args) > 2 {
+			listen = os.Args[2]
+		}
+		runFakeTelegram(listen)
+	case "replay-updates":
+		if len(os.Args) < 5 {
+			fmt.Println("Usage: replay-updates <updates.jsonl> <webhook_url> <secret>")
+			return
+		}
+		replayUpdates(os.Args[2], os.Args[3], os.Args[4])
 	default:
 		fmt.Println("Unknow command")
 	}
@@ -59,6 +161,101 @@ func runBot(configPath string) {
 	}
 }
 
+func mergeSolutionsPdf(solutionService mathbattle.SolutionService, outPath string, roundID string, problemID string) {
+	content, err := solutionService.MergedPDF(roundID, problemID)
+	if err != nil {
+		log.Fatalf("Failed to build pdf, error: %v", err)
+	}
+
+	if err := ioutil.WriteFile(outPath, content, 0666); err != nil {
+		log.Fatalf("Failed to write pdf, error: %v", err)
+	}
+	log.Printf("Solutions saved to %s", outPath)
+}
+
+func publishResults(roundService mathbattle.RoundService, roundID string) {
+	if _, err := roundService.PublishResults(roundID, 0); err != nil {
+		log.Fatalf("Failed to publish results, error: %v", err)
+	}
+	log.Printf("Results of round %s are published", roundID)
+}
+
+func revealIdentities(roundService mathbattle.RoundService, order mathbattle.RevealOrder) {
+	identities, err := roundService.RevealIdentities(order)
+	if err != nil {
+		log.Fatalf("Failed to reveal identities, error: %v", err)
+	}
+
+	for _, identity := range identities {
+		fmt.Printf("%s\t%s\t%s\t%s\n", identity.Pseudonym, identity.ProblemID, identity.ParticipantID, identity.ParticipantName)
+	}
+}
+
+func promoteGrades(participantService mathbattle.ParticipantService, schoolYear int) {
+	promotion, err := participantService.PromoteGrades(schoolYear, 0)
+	if err != nil {
+		log.Fatalf("Failed to promote grades, error: %v", err)
+	}
+	log.Printf("School year %d/%d: %d participants promoted, %d graduated", promotion.SchoolYear, promotion.SchoolYear+1,
+		promotion.Promoted, promotion.Graduated)
+}
+
+func grantRole(roleService mathbattle.RoleService, order mathbattle.RoleOrder) {
+	user, err := roleService.Grant(order)
+	if err != nil {
+		if err == mathbattle.ErrWrongUserInput {
+			log.Fatalf("Failed to change role: unknown role, user not found or this is the last owner")
+		}
+		log.Fatalf("Failed to change role, error: %v", err)
+	}
+	log.Printf("Role of user %s (@%s) is '%s' now", user.ID, user.TelegramUsername, user.Role)
+}
+
+func listStaff(roleService mathbattle.RoleService) {
+	staff, err := roleService.GetStaff()
+	if err != nil {
+		log.Fatalf("Failed to get staff, error: %v", err)
+	}
+
+	for _, user := range staff {
+		fmt.Printf("%d\t@%s\t%s\n", user.TelegramID, user.TelegramUsername, user.Role)
+	}
+}
+
+func exportAudit(auditService mathbattle.AuditService, outPath string, filter mathbattle.AuditFilter) {
+	content, err := auditService.ExportCSV(filter)
+	if err != nil {
+		log.Fatalf("Failed to export audit log, error: %v", err)
+	}
+
+	if err := ioutil.WriteFile(outPath, content, 0666); err != nil {
+		log.Fatalf("Failed to write audit log, error: %v", err)
+	}
+	log.Printf("Audit log saved to %s", outPath)
+}
+
+func runFakeS3(listen string, accessKey string) {
+	log.Printf("Fake S3 is listening on %s", listen)
+	log.Fatal(http.ListenAndServe(listen, s3test.NewFakeS3(accessKey)))
+}
+
+func runFakeTelegram(listen string) {
+	log.Printf("Fake Telegram Bot API is listening on %s", listen)
+	log.Fatal(http.ListenAndServe(listen, webhooktest.NewFakeTelegramAPI()))
+}
+
+func replayUpdates(updatesPath string, webhookURL string, secret string) {
+	updates, err := webhooktest.LoadUpdates(updatesPath)
+	if err != nil {
+		log.Fatalf("Failed to load updates, error: %v", err)
+	}
+
+	if err := webhooktest.ReplayUpdates(webhookURL, secret, updates, 100*time.Millisecond); err != nil {
+		log.Fatalf("Failed to replay updates, error: %v", err)
+	}
+	log.Printf("Replayed %d updates", len(updates))
+}
+
 func sendKb() {
 	container := infrastructure.NewServerContainer(config.LoadConfig("config.yaml"))
 
@@ -74,6 +271,12 @@ func sendKb() {
 	if err != nil {
 		log.Panic(err)
 	}
+	for i := range solutions {
+		solutions[i].Parts, err = mathbattle.WithContent(srep, solutions[i].Parts)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
 
 	curSolutionI := 0
 	controlBlock := []*tb.Message{}
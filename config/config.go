@@ -1,40 +1,142 @@
 package config
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 
+	"mathbattle/libs/mlog"
+
 	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
-	TelegramToken            string `yaml:"telegram_token"`
-	APIUrl                   string `yaml:"api_url"`
-	DatabaseType             string `yaml:"db_type"`
-	DatabaseConnectionString string `yaml:"db_connection_string"`
-	ProblemsPath             string `yaml:"problems_path"`
-	SolutionsPath            string `yaml:"solutions_path"`
+	TelegramToken            string          `yaml:"telegram_token"`
+	APIUrl                   string          `yaml:"api_url"`
+	APIToken                 string          `yaml:"api_token"`
+	DatabaseType             string          `yaml:"db_type"`
+	DatabaseConnectionString string          `yaml:"db_connection_string"`
+	ProblemsPath             string          `yaml:"problems_path"`
+	SolutionsPath            string          `yaml:"solutions_path"`
+	ReviewWatchdogBefore     string          `yaml:"review_watchdog_before"`
+	RemindersBefore          []string        `yaml:"reminders_before"`
+	ConversationTTL          string          `yaml:"conversation_ttl"`
+	BotWorkers               int             `yaml:"bot_workers"`
+	TelegramAPIUrl           string          `yaml:"telegram_api_url"`
+	BotMode                  string          `yaml:"bot_mode"`
+	Webhook                  Webhook         `yaml:"webhook"`
+	SolutionLimits           SolutionLimits  `yaml:"solution_limits"`
+	PdfPreview               PdfPreview      `yaml:"pdf_preview"`
+	ImageProcessing          ImageProcessing `yaml:"image_processing"`
+	BlindGrading             bool            `yaml:"blind_grading"`
+	BlobStore                BlobStore       `yaml:"blob_store"`
+	Languages                Languages       `yaml:"languages"`
+	TimeZone                 string          `yaml:"time_zone"`
+	Registration             Registration    `yaml:"registration"`
+	Leagues                  []League        `yaml:"leagues"`
+	Logging                  Logging         `yaml:"logging"`
+}
+
+// Logging - логи в logs/<программа>.log. Файл начинается заново, когда он больше MaxSizeMB или старше MaxAge
+type Logging struct {
+	// debug, info, warn, error
+	Level string `yaml:"level"`
+	// text или json
+	Format     string `yaml:"format"`
+	MaxSizeMB  int64  `yaml:"max_size_mb"`
+	MaxAge     string `yaml:"max_age"`
+	MaxBackups int    `yaml:"max_backups"`
+}
+
+// League - лига со своими раундами. Участники классов Grades попадают в неё автоматически
+type League struct {
+	Name   string `yaml:"name"`
+	Grades []int  `yaml:"grades"`
+}
+
+// Registration - анкета участника. Режимы полей: off, optional, required
+type Registration struct {
+	School          string `yaml:"school"`
+	Region          string `yaml:"region"`
+	TeacherContact  string `yaml:"teacher_contact"`
+	ConsentMaxGrade int    `yaml:"consent_max_grade"`
+	// Текстовый файл со списком школ для подсказок, по школе на строку
+	SchoolsDirectory string `yaml:"schools_directory"`
+}
+
+type Languages struct {
+	Default string `yaml:"default"`
+	// Код языка -> путь к yaml каталогу с текстами бота
+	Catalogs map[string]string `yaml:"catalogs"`
+}
+
+type BlobStore struct {
+	Type string `yaml:"type"`
+	S3   S3     `yaml:"s3"`
+}
+
+type S3 struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+type ImageProcessing struct {
+	Enabled       bool    `yaml:"enabled"`
+	MaxSize       int     `yaml:"max_size"`
+	Quality       int     `yaml:"quality"`
+	Grayscale     bool    `yaml:"grayscale"`
+	Contrast      float64 `yaml:"contrast"`
+	ThumbnailSize int     `yaml:"thumbnail_size"`
+}
+
+type SolutionLimits struct {
+	ImageMaxSize    int64    `yaml:"image_max_size"`
+	DocumentMaxSize int64    `yaml:"document_max_size"`
+	DocumentTypes   []string `yaml:"document_types"`
+}
+
+type PdfPreview struct {
+	Command string `yaml:"command"`
+	Pages   int    `yaml:"pages"`
+	DPI     int    `yaml:"dpi"`
+}
+
+type Webhook struct {
+	Listen    string `yaml:"listen"`
+	PublicURL string `yaml:"public_url"`
+	Secret    string `yaml:"secret"`
+	TLSCert   string `yaml:"tls_cert"`
+	TLSKey    string `yaml:"tls_key"`
+	Register  bool   `yaml:"register"`
+}
+
+// fatalf пишет ошибку и завершает программу: без конфига работать нечему
+func fatalf(format string, args ...interface{}) {
+	mlog.Default().Errorf(format, args...)
+	os.Exit(1)
 }
 
+// LoadConfig пишет в mlog.Default: логгер программы настраивается уже по загруженному конфигу
 func LoadConfig(configPath string) Config {
 	result := Config{}
 
 	configPath, err := filepath.Abs(configPath)
 	if err != nil {
-		log.Fatalf("Failed to get config path, error: %v", err)
+		fatalf("Failed to get config path, error: %v", err)
 	}
 
-	log.Printf("Loading config: %s", configPath)
+	mlog.Default().Infof("Loading config: %s", configPath)
 	f, err := os.Open(configPath)
 	if err != nil {
-		log.Fatalf("Failed to open config path, error: %v", err)
+		fatalf("Failed to open config path, error: %v", err)
 	}
 	defer f.Close()
 
 	err = yaml.NewDecoder(f).Decode(&result)
 	if err != nil {
-		log.Fatalf("Failed to decode config, error: %v", err)
+		fatalf("Failed to decode config, error: %v", err)
 	}
 
 	return result
@@ -3,14 +3,11 @@ module mathbattle
 go 1.15
 
 require (
-	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
 	github.com/lib/pq v1.9.0
 	github.com/mattn/go-sqlite3 v1.14.5
-	github.com/pkg/errors v0.9.1
+	github.com/pkg/errors v0.9.1 // indirect
 	github.com/stretchr/testify v1.6.1
-	github.com/technoweenie/multipartstreamer v1.0.1 // indirect
-	gopkg.in/telegram-bot-api.v4 v4.6.4
 	gopkg.in/tucnak/telebot.v2 v2.3.5
 	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,29 @@
+package infrastructure
+
+import (
+	"fmt"
+
+	"mathbattle/config"
+	"mathbattle/infrastructure/blobstore"
+	"mathbattle/models/mathbattle"
+)
+
+// NewBlobStore - хранилище файлов одного вида: задач или решений. Локально они лежат в localPath,
+// в S3 - в одном bucket под разными prefix
+func NewBlobStore(cfg config.BlobStore, localPath string, prefix string) (mathbattle.BlobStore, error) {
+	switch cfg.Type {
+	case "", "local":
+		return blobstore.NewLocalStore(localPath)
+	case "s3":
+		return blobstore.NewS3Store(blobstore.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			Prefix:    prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store type '%s'", cfg.Type)
+	}
+}
@@ -0,0 +1,113 @@
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"mathbattle/models/mathbattle"
+)
+
+var keyRegexp = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// ErrInvalidKey - ключ не похож на sha256, такой файл не мог быть сохранён через Put
+var ErrInvalidKey = errors.New("invalid blob key")
+
+func checkKey(key string) error {
+	if !keyRegexp.MatchString(key) {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// LocalStore хранит файлы в папке, раскладывая по подпапкам по первым двум символам ключа: <root>/ab/abcd...
+type LocalStore struct {
+	root string
+}
+
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0777); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{root: root}, nil
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.root, key[:2], key)
+}
+
+func (s *LocalStore) Put(content io.Reader) (string, error) {
+	// Ключ известен только после чтения всего содержимого, поэтому сначала пишем во временный файл
+	tmp, err := ioutil.TempFile(s.root, "upload_*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	_, err = io.Copy(tmp, io.TeeReader(content, h))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	if err := os.MkdirAll(filepath.Dir(s.path(key)), 0777); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (s *LocalStore) Open(key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, mathbattle.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (s *LocalStore) Exists(key string) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+
+	_, err := os.Stat(s.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (s *LocalStore) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,90 @@
+// Package s3test - S3-совместимый сервер в памяти для проверки S3Store без MinIO
+package s3test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FakeS3 понимает path-style GET/HEAD/PUT/DELETE объектов. Подпись не проверяет, только что запрос
+// подписан ключом AccessKey и что x-amz-content-sha256 совпадает с телом, как это делает настоящий S3
+type FakeS3 struct {
+	AccessKey string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    int
+}
+
+func NewFakeS3(accessKey string) *FakeS3 {
+	return &FakeS3{
+		AccessKey: accessKey,
+		objects:   make(map[string][]byte),
+	}
+}
+
+// Objects - копия пар путь (/<bucket>/<key>) -> содержимое
+func (f *FakeS3) Objects() map[string][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make(map[string][]byte)
+	for path, content := range f.objects {
+		result[path] = append([]byte{}, content...)
+	}
+	return result
+}
+
+// Puts - сколько раз объекты загружались
+func (f *FakeS3) Puts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.puts
+}
+
+func (f *FakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+f.AccessKey+"/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		content, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(content)
+		}
+	case http.MethodPut:
+		content, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		hash := sha256.Sum256(content)
+		if r.Header.Get("x-amz-content-sha256") != hex.EncodeToString(hash[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = content
+		f.puts++
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
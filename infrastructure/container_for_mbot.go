@@ -1,11 +1,7 @@
 package infrastructure
 
 import (
-	"fmt"
-	"io"
 	"log"
-	"os"
-	"path/filepath"
 	"time"
 
 	"mathbattle/application"
@@ -14,11 +10,16 @@ import (
 	"mathbattle/infrastructure/repository/sqldb"
 	"mathbattle/interfaces/client"
 	"mathbattle/interfaces/replier"
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
 type MBotContainer struct {
-	cfg config.Config
+	cfg    config.Config
+	logger *mlog.Logger
+	// ID обновления телеграма, которое обрабатывается с этим контейнером, и его автор, см. ForRequest
+	requestID string
+	actorID   int64
 
 	roundService       *client.APIRound
 	statService        *client.APIStat
@@ -26,44 +27,71 @@ type MBotContainer struct {
 	solutionService    *client.APISolution
 	reviewService      *client.APIReview
 	problemService     *client.APIProblem
-
-	replier                application.Replier
+	teamService        *client.APITeam
+	battleService      *client.APIBattle
+	roleService        *application.RoleService
+
+	repliers               application.Repliers
+	timeZone               *time.Location
+	registrationForm       *mathbattle.RegistrationForm
+	schoolDirectory        *SchoolDirectory
 	userRepository         *sqldb.UserRepository
 	participantRepsitory   *sqldb.ParticipantRepository
 	roundRepository        *sqldb.RoundRepository
 	problemRepository      *sqldb.ProblemRepository
 	solutionRepository     *sqldb.SolutionRepository
 	reviewRepository       *sqldb.ReviewRepository
+	auditRepository        *sqldb.AuditRepository
 	postman                mathbattle.PostmanService
 	reviewStageDistributor application.SolutionDistributor
+
+	telegramContextRepository TelegramContextRepository
 }
 
 func NewBotContainer(config config.Config) MBotContainer {
-	log.SetOutput(logFileMbot())
-
 	return MBotContainer{
-		cfg: config,
+		cfg:    config,
+		logger: newLogger(config.Logging, "mb-bot"),
 	}
 }
 
-func logFileMbot() io.Writer {
-	logDirectory := "logs"
-	if err := os.MkdirAll(logDirectory, 0777); err != nil {
-		log.Fatalf("Failed to create log directory, error: %v", err)
-	}
+func (c *MBotContainer) Config() config.Config {
+	return c.cfg
+}
 
-	logFileName := fmt.Sprintf("mb-bot_%s.txt", time.Now().Format("02-01-2006-15-04-05"))
-	logFilePath := filepath.Join(logDirectory, logFileName)
-	file, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err != nil {
-		log.Fatalf("Failed to create log file, error: %v", err)
-	}
+func (c *MBotContainer) Logger() *mlog.Logger {
+	return c.logger
+}
 
-	return io.MultiWriter(os.Stdout, file)
+// ForRequest - контейнер для обработки одного обновления: клиенты сервера передают серверу requestID
+// и Telegram ID автора обновления, а логгер пишет их в каждую запись.
+// Репозитории и остальное общие с c, поэтому их нужно создать в c заранее
+func (c *MBotContainer) ForRequest(requestID string, actorID int64) MBotContainer {
+	result := *c
+	result.requestID = requestID
+	result.actorID = actorID
+	result.logger = c.logger.With("request_id", requestID, "chat_id", actorID)
+	result.roundService = nil
+	result.statService = nil
+	result.participantService = nil
+	result.solutionService = nil
+	result.reviewService = nil
+	result.problemService = nil
+	result.teamService = nil
+	result.battleService = nil
+	result.roleService = nil
+	result.postman = nil
+	return result
 }
 
-func (c *MBotContainer) Config() config.Config {
-	return c.cfg
+func (c *MBotContainer) api() client.API {
+	return client.API{
+		BaseUrl:   c.APIBaseUrl(),
+		RequestID: c.requestID,
+		ActorID:   c.actorID,
+		Token:     c.Config().APIToken,
+		Logger:    c.Logger(),
+	}
 }
 
 func (c *MBotContainer) APIBaseUrl() string {
@@ -72,7 +100,7 @@ func (c *MBotContainer) APIBaseUrl() string {
 
 func (c *MBotContainer) RoundService() mathbattle.RoundService {
 	if c.roundService == nil {
-		c.roundService = &client.APIRound{BaseUrl: c.APIBaseUrl()}
+		c.roundService = &client.APIRound{API: c.api()}
 	}
 
 	return c.roundService
@@ -80,7 +108,7 @@ func (c *MBotContainer) RoundService() mathbattle.RoundService {
 
 func (c *MBotContainer) StatService() mathbattle.StatService {
 	if c.statService == nil {
-		c.statService = &client.APIStat{BaseUrl: c.APIBaseUrl()}
+		c.statService = &client.APIStat{API: c.api()}
 	}
 
 	return c.statService
@@ -88,7 +116,7 @@ func (c *MBotContainer) StatService() mathbattle.StatService {
 
 func (c *MBotContainer) ParticipantService() mathbattle.ParticipantService {
 	if c.participantService == nil {
-		c.participantService = &client.APIParticipant{BaseUrl: c.APIBaseUrl()}
+		c.participantService = &client.APIParticipant{API: c.api()}
 	}
 
 	return c.participantService
@@ -96,7 +124,7 @@ func (c *MBotContainer) ParticipantService() mathbattle.ParticipantService {
 
 func (c *MBotContainer) SolutionService() mathbattle.SolutionService {
 	if c.solutionService == nil {
-		c.solutionService = &client.APISolution{BaseUrl: c.APIBaseUrl()}
+		c.solutionService = &client.APISolution{API: c.api()}
 	}
 
 	return c.solutionService
@@ -104,7 +132,7 @@ func (c *MBotContainer) SolutionService() mathbattle.SolutionService {
 
 func (c *MBotContainer) ReviewService() mathbattle.ReviewService {
 	if c.reviewService == nil {
-		c.reviewService = &client.APIReview{BaseUrl: c.APIBaseUrl()}
+		c.reviewService = &client.APIReview{API: c.api()}
 	}
 
 	return c.reviewService
@@ -112,29 +140,132 @@ func (c *MBotContainer) ReviewService() mathbattle.ReviewService {
 
 func (c *MBotContainer) ProblemService() mathbattle.ProblemService {
 	if c.problemService == nil {
-		c.problemService = &client.APIProblem{BaseUrl: c.APIBaseUrl()}
+		c.problemService = &client.APIProblem{API: c.api()}
 	}
 
 	return c.problemService
 }
 
+func (c *MBotContainer) TeamService() mathbattle.TeamService {
+	if c.teamService == nil {
+		c.teamService = &client.APITeam{API: c.api()}
+	}
+
+	return c.teamService
+}
+
+func (c *MBotContainer) BattleService() mathbattle.BattleService {
+	if c.battleService == nil {
+		c.battleService = &client.APIBattle{API: c.api()}
+	}
+
+	return c.battleService
+}
+
+// RoleService работает с пользователями напрямую, как и команды /language и /timezone
+func (c *MBotContainer) RoleService() mathbattle.RoleService {
+	if c.roleService == nil {
+		c.roleService = &application.RoleService{
+			Users:  c.UserRepository(),
+			Audit:  &application.Auditor{Rep: c.AuditRepository(), Logger: c.Logger()},
+			Logger: c.Logger(),
+		}
+	}
+
+	return c.roleService
+}
+
+func (c *MBotContainer) AuditRepository() mathbattle.AuditRepository {
+	if c.auditRepository == nil {
+		var err error
+		c.auditRepository, err = sqldb.NewAuditRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
+		if err != nil {
+			log.Fatalf("Failed to get audit repository, error: %v", err)
+		}
+	}
+
+	return c.auditRepository
+}
+
+func (c *MBotContainer) Repliers() application.Repliers {
+	if c.repliers == nil {
+		var err error
+		c.repliers, err = replier.NewRepliers(c.Config().Languages.Default, c.Config().Languages.Catalogs, c.Logger())
+		if err != nil {
+			log.Fatalf("Failed to get repliers, error: %v", err)
+		}
+	}
+
+	return c.repliers
+}
+
 func (c *MBotContainer) Replier() application.Replier {
-	if c.replier == nil {
-		c.replier = &replier.RussianReplier{}
+	return c.Repliers().Default()
+}
+
+// RegistrationForm - анкета участника из конфига
+func (c *MBotContainer) RegistrationForm() mathbattle.RegistrationForm {
+	if c.registrationForm == nil {
+		form, err := newRegistrationForm(c.Config().Registration)
+		if err != nil {
+			log.Fatalf("Failed to load registration form, error: %v", err)
+		}
+		c.registrationForm = &form
+	}
+
+	return *c.registrationForm
+}
+
+// SchoolDirectory - справочник школ для подсказок, пустой если файл не указан в конфиге
+func (c *MBotContainer) SchoolDirectory() mathbattle.SchoolDirectory {
+	if c.schoolDirectory == nil {
+		path := c.Config().Registration.SchoolsDirectory
+		if path == "" {
+			c.schoolDirectory = NewSchoolDirectory(nil)
+		} else {
+			var err error
+			c.schoolDirectory, err = LoadSchoolDirectory(path)
+			if err != nil {
+				log.Fatalf("Failed to load schools directory %s, error: %v", path, err)
+			}
+		}
+	}
+
+	return c.schoolDirectory
+}
+
+// Leagues - лиги из конфигурации, кроме основной
+func (c *MBotContainer) Leagues() mathbattle.Leagues {
+	return newLeagues(c.Config().Leagues)
+}
+
+// TimeZone - часовой пояс мероприятия
+func (c *MBotContainer) TimeZone() *time.Location {
+	if c.timeZone == nil {
+		name := c.Config().TimeZone
+		if name == "" {
+			name = mathbattle.DefaultTimeZone
+		}
+
+		var err error
+		c.timeZone, err = mathbattle.LoadTimeZone(name)
+		if err != nil {
+			log.Fatalf("Failed to load time zone %s, error: %v", name, err)
+		}
 	}
 
-	return c.replier
+	return c.timeZone
 }
 
 func (c *MBotContainer) UserRepository() mathbattle.UserRepository {
 	if c.userRepository == nil {
 		var err error
-		c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get user repository, error: %v", err)
 		}
 
-		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository)
+		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get participant repository, error: %v", err)
 		}
@@ -145,10 +276,35 @@ func (c *MBotContainer) UserRepository() mathbattle.UserRepository {
 	return c.userRepository
 }
 
+func (c *MBotContainer) TelegramContextRepository() TelegramContextRepository {
+	if c.telegramContextRepository == nil {
+		var ttl time.Duration
+		if c.Config().ConversationTTL != "" {
+			var err error
+			ttl, err = time.ParseDuration(c.Config().ConversationTTL)
+			if err != nil {
+				log.Fatalf("Failed to parse conversation_ttl, error: %v", err)
+			}
+		}
+
+		storage, err := sqldb.NewTelegramContextRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
+		if err != nil {
+			log.Fatalf("Failed to get telegram context storage, error: %v", err)
+		}
+
+		c.telegramContextRepository, err = NewPersistentTelegramContextRepository(storage, c.UserRepository(), ttl)
+		if err != nil {
+			log.Fatalf("Failed to get telegram context repository, error: %v", err)
+		}
+	}
+
+	return c.telegramContextRepository
+}
+
 func (c *MBotContainer) RoundRepository() mathbattle.RoundRepository {
 	if c.roundRepository == nil {
 		var err error
-		c.roundRepository, err = sqldb.NewRoundRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.roundRepository, err = sqldb.NewRoundRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get round repository, error: %v", err)
 		}
@@ -161,14 +317,14 @@ func (c *MBotContainer) ParticipantRepository() mathbattle.ParticipantRepository
 	if c.participantRepsitory == nil {
 		if c.userRepository == nil {
 			var err error
-			c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+			c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 			if err != nil {
 				log.Fatalf("Failed to initialize user repository, error: %v", err)
 			}
 		}
 
 		var err error
-		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository)
+		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get participant repository, error: %v", err)
 		}
@@ -180,7 +336,11 @@ func (c *MBotContainer) ParticipantRepository() mathbattle.ParticipantRepository
 func (c *MBotContainer) ProblemRepository() mathbattle.ProblemRepository {
 	if c.problemRepository == nil {
 		var err error
-		c.problemRepository, err = sqldb.NewProblemRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Config().ProblemsPath)
+		blobs, err := NewBlobStore(c.Config().BlobStore, c.Config().ProblemsPath, "problems/")
+		if err != nil {
+			log.Fatalf("Failed to get problems blob store, error: %v", err)
+		}
+		c.problemRepository, err = sqldb.NewProblemRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, blobs, c.Config().ProblemsPath, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get problems repository, error: %v", err)
 		}
@@ -192,7 +352,11 @@ func (c *MBotContainer) ProblemRepository() mathbattle.ProblemRepository {
 func (c *MBotContainer) SolutionRepository() mathbattle.SolutionRepository {
 	if c.solutionRepository == nil {
 		var err error
-		c.solutionRepository, err = sqldb.NewSolutionRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Config().SolutionsPath)
+		blobs, err := NewBlobStore(c.Config().BlobStore, c.Config().SolutionsPath, "solutions/")
+		if err != nil {
+			log.Fatalf("Failed to get solutions blob store, error: %v", err)
+		}
+		c.solutionRepository, err = sqldb.NewSolutionRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, blobs, c.Config().SolutionsPath, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get solutions repository, error: %v", err)
 		}
@@ -204,7 +368,7 @@ func (c *MBotContainer) SolutionRepository() mathbattle.SolutionRepository {
 func (c *MBotContainer) ReviewRepository() mathbattle.ReviewRepository {
 	if c.reviewRepository == nil {
 		var err error
-		c.reviewRepository, err = sqldb.NewReviewRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.reviewRepository, err = sqldb.NewReviewRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get review repository, error: %v", err)
 		}
@@ -215,7 +379,7 @@ func (c *MBotContainer) ReviewRepository() mathbattle.ReviewRepository {
 
 func (c *MBotContainer) Postman() mathbattle.PostmanService {
 	if c.postman == nil {
-		c.postman = &client.APIPostman{BaseUrl: c.APIBaseUrl()}
+		c.postman = &client.APIPostman{API: c.api()}
 	}
 
 	return c.postman
@@ -1,11 +1,7 @@
 package infrastructure
 
 import (
-	"fmt"
-	"io"
 	"log"
-	"os"
-	"path/filepath"
 	"time"
 
 	"mathbattle/application"
@@ -13,11 +9,13 @@ import (
 	"mathbattle/config"
 	"mathbattle/infrastructure/repository/sqldb"
 	"mathbattle/interfaces/replier"
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
 type Container struct {
-	cfg config.Config
+	cfg    config.Config
+	logger *mlog.Logger
 
 	// Server side services
 	roundService       *application.RoundService
@@ -26,58 +24,83 @@ type Container struct {
 	solutionService    *application.SolutionService
 	reviewService      *application.ReviewService
 	problemService     *application.ProblemService
+	teamService        *application.TeamService
+	battleService      *application.BattleService
+	roleService        *application.RoleService
+	auditService       *application.AuditService
 
 	// Others
-	replier                application.Replier
+	repliers               application.Repliers
+	timeZone               *time.Location
+	registrationForm       *mathbattle.RegistrationForm
 	userRepository         *sqldb.UserRepository
 	participantRepsitory   *sqldb.ParticipantRepository
 	roundRepository        *sqldb.RoundRepository
 	problemRepository      *sqldb.ProblemRepository
 	solutionRepository     *sqldb.SolutionRepository
 	reviewRepository       *sqldb.ReviewRepository
+	teamRepository         *sqldb.TeamRepository
+	battleRepository       *sqldb.BattleRepository
+	auditRepository        *sqldb.AuditRepository
+	auditor                *application.Auditor
 	postman                mathbattle.PostmanService
 	reviewStageDistributor application.SolutionDistributor
 }
 
 func NewServerContainer(config config.Config) Container {
-	log.SetOutput(logFileMbserver())
-
 	return Container{
-		cfg: config,
-	}
-}
-
-func logFileMbserver() io.Writer {
-	logDirectory := "logs"
-	if err := os.MkdirAll(logDirectory, 0777); err != nil {
-		log.Fatalf("Failed to create log directory, error: %v", err)
+		cfg:    config,
+		logger: newLogger(config.Logging, "mb-server"),
 	}
-
-	logFileName := fmt.Sprintf("mb-server_%s.txt", time.Now().Format("02-01-2006-15-04-05"))
-	logFilePath := filepath.Join(logDirectory, logFileName)
-	file, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err != nil {
-		log.Fatalf("Failed to create log file, error: %v", err)
-	}
-
-	return io.MultiWriter(os.Stdout, file)
 }
 
 func (c *Container) Config() config.Config {
 	return c.cfg
 }
 
+func (c *Container) Logger() *mlog.Logger {
+	return c.logger
+}
+
 func (c *Container) RoundService() mathbattle.RoundService {
 	if c.roundService == nil {
+		var watchdogBefore time.Duration
+		if c.Config().ReviewWatchdogBefore != "" {
+			var err error
+			watchdogBefore, err = time.ParseDuration(c.Config().ReviewWatchdogBefore)
+			if err != nil {
+				log.Fatalf("Failed to parse review_watchdog_before, error: %v", err)
+			}
+		}
+
+		remindersBefore := []time.Duration{}
+		for _, item := range c.Config().RemindersBefore {
+			before, err := time.ParseDuration(item)
+			if err != nil {
+				log.Fatalf("Failed to parse reminders_before, error: %v", err)
+			}
+			remindersBefore = append(remindersBefore, before)
+		}
+
 		result := &application.RoundService{
 			Rep:                    c.RoundRepository(),
-			Replier:                c.Replier(),
+			Repliers:               c.Repliers(),
 			Postman:                c.Postman(),
 			Participants:           c.ParticipantRepository(),
 			Problems:               c.ProblemRepository(),
 			Solutions:              c.SolutionRepository(),
+			Reviews:                c.ReviewRepository(),
+			Teams:                  c.TeamRepository(),
 			ReviewStageDistributor: c.ReviewStageDistributor(),
 			ReviewersCount:         2,
+			ReviewWatchdogBefore:   watchdogBefore,
+			RemindersBefore:        remindersBefore,
+			Previewer:              c.DocumentPreviewer(),
+			BlindGrading:           c.Config().BlindGrading,
+			TimeZone:               c.TimeZone(),
+			Leagues:                c.Leagues(),
+			Audit:                  c.Auditor(),
+			Logger:                 c.Logger(),
 		}
 		if err := result.StartSchedulingActions(); err != nil {
 			log.Fatal(err)
@@ -104,7 +127,10 @@ func (c *Container) StatService() mathbattle.StatService {
 func (c *Container) ParticipantService() mathbattle.ParticipantService {
 	if c.participantService == nil {
 		c.participantService = &application.ParticipantService{
-			Rep: c.ParticipantRepository(),
+			Rep:     c.ParticipantRepository(),
+			Form:    c.RegistrationForm(),
+			Leagues: c.Leagues(),
+			Audit:   c.Auditor(),
 		}
 	}
 
@@ -114,26 +140,114 @@ func (c *Container) ParticipantService() mathbattle.ParticipantService {
 func (c *Container) SolutionService() mathbattle.SolutionService {
 	if c.solutionService == nil {
 		c.solutionService = &application.SolutionService{
-			Rep:    c.SolutionRepository(),
-			Rounds: c.RoundRepository(),
+			Rep:          c.SolutionRepository(),
+			Rounds:       c.RoundRepository(),
+			Teams:        c.TeamRepository(),
+			Participants: c.ParticipantRepository(),
+			Limits:       c.SolutionPartLimits(),
+			Previewer:    c.DocumentPreviewer(),
+			BlindGrading: c.Config().BlindGrading,
+			Audit:        c.Auditor(),
+			Logger:       c.Logger(),
+		}
+		if c.Config().ImageProcessing.Enabled {
+			c.solutionService.Normalizer = NewImageNormalizer(c.Config().ImageProcessing)
 		}
 	}
 
 	return c.solutionService
 }
 
+func (c *Container) SolutionPartLimits() mathbattle.SolutionPartLimits {
+	return NewSolutionPartLimits(c.Config().SolutionLimits)
+}
+
+func (c *Container) DocumentPreviewer() mathbattle.DocumentPreviewer {
+	previewCfg := c.Config().PdfPreview
+	if previewCfg.Command == "" {
+		return nil
+	}
+
+	return &PdftoppmPreviewer{
+		Command: previewCfg.Command,
+		Pages:   previewCfg.Pages,
+		DPI:     previewCfg.DPI,
+	}
+}
+
 func (c *Container) ReviewService() mathbattle.ReviewService {
 	if c.reviewService == nil {
 		c.reviewService = &application.ReviewService{
-			Rep:       c.ReviewRepository(),
-			Rounds:    c.RoundRepository(),
-			Solutions: c.SolutionRepository(),
+			Rep:          c.ReviewRepository(),
+			Rounds:       c.RoundRepository(),
+			Solutions:    c.SolutionRepository(),
+			Teams:        c.TeamRepository(),
+			Participants: c.ParticipantRepository(),
+			Audit:        c.Auditor(),
 		}
 	}
 
 	return c.reviewService
 }
 
+func (c *Container) TeamService() mathbattle.TeamService {
+	if c.teamService == nil {
+		c.teamService = &application.TeamService{
+			Rep:          c.TeamRepository(),
+			Participants: c.ParticipantRepository(),
+			Rounds:       c.RoundRepository(),
+		}
+	}
+
+	return c.teamService
+}
+
+func (c *Container) BattleService() mathbattle.BattleService {
+	if c.battleService == nil {
+		c.battleService = &application.BattleService{
+			Rep:          c.BattleRepository(),
+			Rounds:       c.RoundRepository(),
+			Teams:        c.TeamRepository(),
+			Participants: c.ParticipantRepository(),
+			Postman:      c.Postman(),
+			Repliers:     c.Repliers(),
+			Audit:        c.Auditor(),
+			Logger:       c.Logger(),
+		}
+	}
+
+	return c.battleService
+}
+
+func (c *Container) RoleService() mathbattle.RoleService {
+	if c.roleService == nil {
+		c.roleService = &application.RoleService{
+			Users:  c.UserRepository(),
+			Audit:  c.Auditor(),
+			Logger: c.Logger(),
+		}
+	}
+
+	return c.roleService
+}
+
+func (c *Container) AuditService() mathbattle.AuditService {
+	if c.auditService == nil {
+		c.auditService = &application.AuditService{Rep: c.AuditRepository()}
+	}
+
+	return c.auditService
+}
+
+// Auditor пишет в журнал аудита действия, сделанные через сервисы сервера
+func (c *Container) Auditor() *application.Auditor {
+	if c.auditor == nil {
+		c.auditor = &application.Auditor{Rep: c.AuditRepository(), Logger: c.Logger()}
+	}
+
+	return c.auditor
+}
+
 func (c *Container) ProblemService() mathbattle.ProblemService {
 	if c.problemService == nil {
 		c.problemService = &application.ProblemService{
@@ -144,23 +258,67 @@ func (c *Container) ProblemService() mathbattle.ProblemService {
 	return c.problemService
 }
 
+func (c *Container) Repliers() application.Repliers {
+	if c.repliers == nil {
+		var err error
+		c.repliers, err = replier.NewRepliers(c.Config().Languages.Default, c.Config().Languages.Catalogs, c.Logger())
+		if err != nil {
+			log.Fatalf("Failed to get repliers, error: %v", err)
+		}
+	}
+
+	return c.repliers
+}
+
 func (c *Container) Replier() application.Replier {
-	if c.replier == nil {
-		c.replier = &replier.RussianReplier{}
+	return c.Repliers().Default()
+}
+
+// RegistrationForm - анкета участника из конфига
+func (c *Container) RegistrationForm() mathbattle.RegistrationForm {
+	if c.registrationForm == nil {
+		form, err := newRegistrationForm(c.Config().Registration)
+		if err != nil {
+			log.Fatalf("Failed to load registration form, error: %v", err)
+		}
+		c.registrationForm = &form
+	}
+
+	return *c.registrationForm
+}
+
+// Leagues - лиги из конфигурации, кроме основной
+func (c *Container) Leagues() mathbattle.Leagues {
+	return newLeagues(c.Config().Leagues)
+}
+
+// TimeZone - часовой пояс мероприятия
+func (c *Container) TimeZone() *time.Location {
+	if c.timeZone == nil {
+		name := c.Config().TimeZone
+		if name == "" {
+			name = mathbattle.DefaultTimeZone
+		}
+
+		var err error
+		c.timeZone, err = mathbattle.LoadTimeZone(name)
+		if err != nil {
+			log.Fatalf("Failed to load time zone %s, error: %v", name, err)
+		}
 	}
 
-	return c.replier
+	return c.timeZone
 }
 
 func (c *Container) UserRepository() mathbattle.UserRepository {
 	if c.userRepository == nil {
 		var err error
-		c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get user repository, error: %v", err)
 		}
 
-		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository)
+		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get participant repository, error: %v", err)
 		}
@@ -174,7 +332,7 @@ func (c *Container) UserRepository() mathbattle.UserRepository {
 func (c *Container) RoundRepository() mathbattle.RoundRepository {
 	if c.roundRepository == nil {
 		var err error
-		c.roundRepository, err = sqldb.NewRoundRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.roundRepository, err = sqldb.NewRoundRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get round repository, error: %v", err)
 		}
@@ -187,14 +345,14 @@ func (c *Container) ParticipantRepository() mathbattle.ParticipantRepository {
 	if c.participantRepsitory == nil {
 		if c.userRepository == nil {
 			var err error
-			c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+			c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 			if err != nil {
 				log.Fatalf("Failed to initialize user repository, error: %v", err)
 			}
 		}
 
 		var err error
-		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository)
+		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get participant repository, error: %v", err)
 		}
@@ -206,7 +364,11 @@ func (c *Container) ParticipantRepository() mathbattle.ParticipantRepository {
 func (c *Container) ProblemRepository() mathbattle.ProblemRepository {
 	if c.problemRepository == nil {
 		var err error
-		c.problemRepository, err = sqldb.NewProblemRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Config().ProblemsPath)
+		blobs, err := NewBlobStore(c.Config().BlobStore, c.Config().ProblemsPath, "problems/")
+		if err != nil {
+			log.Fatalf("Failed to get problems blob store, error: %v", err)
+		}
+		c.problemRepository, err = sqldb.NewProblemRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, blobs, c.Config().ProblemsPath, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get problems repository, error: %v", err)
 		}
@@ -218,7 +380,11 @@ func (c *Container) ProblemRepository() mathbattle.ProblemRepository {
 func (c *Container) SolutionRepository() mathbattle.SolutionRepository {
 	if c.solutionRepository == nil {
 		var err error
-		c.solutionRepository, err = sqldb.NewSolutionRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Config().SolutionsPath)
+		blobs, err := NewBlobStore(c.Config().BlobStore, c.Config().SolutionsPath, "solutions/")
+		if err != nil {
+			log.Fatalf("Failed to get solutions blob store, error: %v", err)
+		}
+		c.solutionRepository, err = sqldb.NewSolutionRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, blobs, c.Config().SolutionsPath, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get solutions repository, error: %v", err)
 		}
@@ -230,7 +396,7 @@ func (c *Container) SolutionRepository() mathbattle.SolutionRepository {
 func (c *Container) ReviewRepository() mathbattle.ReviewRepository {
 	if c.reviewRepository == nil {
 		var err error
-		c.reviewRepository, err = sqldb.NewReviewRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.reviewRepository, err = sqldb.NewReviewRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
 		if err != nil {
 			log.Fatalf("Failed to get review repository, error: %v", err)
 		}
@@ -239,6 +405,42 @@ func (c *Container) ReviewRepository() mathbattle.ReviewRepository {
 	return c.reviewRepository
 }
 
+func (c *Container) TeamRepository() mathbattle.TeamRepository {
+	if c.teamRepository == nil {
+		var err error
+		c.teamRepository, err = sqldb.NewTeamRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
+		if err != nil {
+			log.Fatalf("Failed to get team repository, error: %v", err)
+		}
+	}
+
+	return c.teamRepository
+}
+
+func (c *Container) BattleRepository() mathbattle.BattleRepository {
+	if c.battleRepository == nil {
+		var err error
+		c.battleRepository, err = sqldb.NewBattleRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
+		if err != nil {
+			log.Fatalf("Failed to get battle repository, error: %v", err)
+		}
+	}
+
+	return c.battleRepository
+}
+
+func (c *Container) AuditRepository() mathbattle.AuditRepository {
+	if c.auditRepository == nil {
+		var err error
+		c.auditRepository, err = sqldb.NewAuditRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Logger())
+		if err != nil {
+			log.Fatalf("Failed to get audit repository, error: %v", err)
+		}
+	}
+
+	return c.auditRepository
+}
+
 func (c *Container) Postman() mathbattle.PostmanService {
 	if c.postman == nil {
 		tgPostman, err := NewTelegramPostman(c.Config().TelegramToken)
@@ -249,6 +451,8 @@ func (c *Container) Postman() mathbattle.PostmanService {
 		c.postman = &application.PostmanService{
 			Users:   c.UserRepository(),
 			Postman: tgPostman,
+			Audit:   c.Auditor(),
+			Logger:  c.Logger(),
 		}
 	}
 
@@ -11,8 +11,10 @@ import (
 	solutiondistributor "mathbattle/application/solution_distributor"
 	"mathbattle/application/ssd"
 	"mathbattle/config"
+	"mathbattle/infrastructure/blobstore"
 	"mathbattle/infrastructure/repository/sqldb"
 	"mathbattle/interfaces/replier"
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
@@ -27,15 +29,20 @@ type TestContainer struct {
 	statService        mathbattle.StatService
 	participantService mathbattle.ParticipantService
 	solutionService    mathbattle.SolutionService
+	teamService        mathbattle.TeamService
 
-	replier                application.Replier
+	repliers               application.Repliers
+	timeZone               *time.Location
+	registrationForm       *mathbattle.RegistrationForm
 	userRepository         *sqldb.UserRepository
 	participantRepsitory   *sqldb.ParticipantRepository
 	roundRepository        *sqldb.RoundRepository
 	problemRepository      *sqldb.ProblemRepository
 	solutionRepository     *sqldb.SolutionRepository
 	reviewRepository       *sqldb.ReviewRepository
+	teamRepository         *sqldb.TeamRepository
 	postman                mathbattle.PostmanService
+	messenger              *FakeMessenger
 	solveStageDistributor  application.SSD
 	reviewStageDistributor application.SolutionDistributor
 }
@@ -57,7 +64,7 @@ func NewTestContainer() TestContainer {
 		}
 	}
 
-	err = sqldb.DeinitAndRemove(cfg.DatabaseType, cfg.DatabaseConnectionString)
+	err = sqldb.DeinitAndRemove(cfg.DatabaseType, cfg.DatabaseConnectionString, mlog.Default())
 	if err != nil {
 		log.Fatalf("Failed to deinit database, err: %v", err)
 	}
@@ -75,13 +82,16 @@ func (c *TestContainer) RoundService() mathbattle.RoundService {
 	if c.roundService == nil {
 		c.roundService = &application.RoundService{
 			Rep:                    c.RoundRepository(),
-			Replier:                c.Replier(),
+			Repliers:               c.Repliers(),
 			Postman:                c.Postman(),
 			Participants:           c.ParticipantRepository(),
 			Solutions:              c.SolutionRepository(),
 			Problems:               c.ProblemRepository(),
+			Reviews:                c.ReviewRepository(),
+			Teams:                  c.TeamRepository(),
 			ReviewStageDistributor: c.ReviewStageDistributor(),
 			ReviewersCount:         2,
+			TimeZone:               c.TimeZone(),
 		}
 	}
 
@@ -104,7 +114,8 @@ func (c *TestContainer) StatService() mathbattle.StatService {
 func (c *TestContainer) ParticipantService() mathbattle.ParticipantService {
 	if c.participantService == nil {
 		c.participantService = &application.ParticipantService{
-			Rep: c.ParticipantRepository(),
+			Rep:  c.ParticipantRepository(),
+			Form: c.RegistrationForm(),
 		}
 	}
 
@@ -114,31 +125,77 @@ func (c *TestContainer) ParticipantService() mathbattle.ParticipantService {
 func (c *TestContainer) SolutionService() mathbattle.SolutionService {
 	if c.solutionService == nil {
 		c.solutionService = &application.SolutionService{
-			Rep:    c.SolutionRepository(),
-			Rounds: c.RoundRepository(),
+			Rep:          c.SolutionRepository(),
+			Rounds:       c.RoundRepository(),
+			Teams:        c.TeamRepository(),
+			Participants: c.ParticipantRepository(),
 		}
 	}
 
 	return c.solutionService
 }
 
+func (c *TestContainer) TeamService() mathbattle.TeamService {
+	if c.teamService == nil {
+		c.teamService = &application.TeamService{
+			Rep:          c.TeamRepository(),
+			Participants: c.ParticipantRepository(),
+			Rounds:       c.RoundRepository(),
+		}
+	}
+
+	return c.teamService
+}
+
+func (c *TestContainer) Repliers() application.Repliers {
+	if c.repliers == nil {
+		var err error
+		c.repliers, err = replier.NewRepliers(replier.DefaultLanguage, nil, mlog.Default())
+		if err != nil {
+			log.Fatalf("Failed to get repliers, error: %v", err)
+		}
+	}
+
+	return c.repliers
+}
+
 func (c *TestContainer) Replier() application.Replier {
-	if c.replier == nil {
-		c.replier = &replier.RussianReplier{}
+	return c.Repliers().Default()
+}
+
+func (c *TestContainer) RegistrationForm() mathbattle.RegistrationForm {
+	if c.registrationForm == nil {
+		form, err := newRegistrationForm(config.Registration{})
+		if err != nil {
+			log.Fatalf("Failed to load registration form, error: %v", err)
+		}
+		c.registrationForm = &form
 	}
 
-	return c.replier
+	return *c.registrationForm
+}
+
+func (c *TestContainer) TimeZone() *time.Location {
+	if c.timeZone == nil {
+		var err error
+		c.timeZone, err = mathbattle.LoadTimeZone(mathbattle.DefaultTimeZone)
+		if err != nil {
+			log.Fatalf("Failed to load time zone, error: %v", err)
+		}
+	}
+
+	return c.timeZone
 }
 
 func (c *TestContainer) UserRepository() mathbattle.UserRepository {
 	if c.userRepository == nil {
 		var err error
-		c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, mlog.Default())
 		if err != nil {
 			log.Fatalf("Failed to get user repository, error: %v", err)
 		}
 
-		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository)
+		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository, mlog.Default())
 		if err != nil {
 			log.Fatalf("Failed to get participant repository, error: %v", err)
 		}
@@ -152,7 +209,7 @@ func (c *TestContainer) UserRepository() mathbattle.UserRepository {
 func (c *TestContainer) RoundRepository() mathbattle.RoundRepository {
 	if c.roundRepository == nil {
 		var err error
-		c.roundRepository, err = sqldb.NewRoundRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.roundRepository, err = sqldb.NewRoundRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, mlog.Default())
 		if err != nil {
 			log.Fatalf("Failed to get round repository, error: %v", err)
 		}
@@ -165,14 +222,14 @@ func (c *TestContainer) ParticipantRepository() mathbattle.ParticipantRepository
 	if c.participantRepsitory == nil {
 		if c.userRepository == nil {
 			var err error
-			c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+			c.userRepository, err = sqldb.NewUserRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, mlog.Default())
 			if err != nil {
 				log.Fatalf("TestContainer::ParticipantRepository(), failed to initialize user repository, error: %v", err)
 			}
 		}
 
 		var err error
-		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository)
+		c.participantRepsitory, err = sqldb.NewParticipantRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.userRepository, mlog.Default())
 		if err != nil {
 			log.Fatalf("TestContainer::ParticipantRepository(), failed to get participant repository, error: %v", err)
 		}
@@ -184,7 +241,11 @@ func (c *TestContainer) ParticipantRepository() mathbattle.ParticipantRepository
 func (c *TestContainer) ProblemRepository() mathbattle.ProblemRepository {
 	if c.problemRepository == nil {
 		var err error
-		c.problemRepository, err = sqldb.NewProblemRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Config().ProblemsPath)
+		blobs, err := blobstore.NewLocalStore(c.Config().ProblemsPath)
+		if err != nil {
+			log.Fatalf("Failed to get problems blob store, error: %v", err)
+		}
+		c.problemRepository, err = sqldb.NewProblemRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, blobs, c.Config().ProblemsPath, mlog.Default())
 		if err != nil {
 			log.Fatalf("Failed to get problems repository, error: %v", err)
 		}
@@ -196,7 +257,11 @@ func (c *TestContainer) ProblemRepository() mathbattle.ProblemRepository {
 func (c *TestContainer) SolutionRepository() mathbattle.SolutionRepository {
 	if c.solutionRepository == nil {
 		var err error
-		c.solutionRepository, err = sqldb.NewSolutionRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, c.Config().SolutionsPath)
+		blobs, err := blobstore.NewLocalStore(c.Config().SolutionsPath)
+		if err != nil {
+			log.Fatalf("Failed to get solutions blob store, error: %v", err)
+		}
+		c.solutionRepository, err = sqldb.NewSolutionRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, blobs, c.Config().SolutionsPath, mlog.Default())
 		if err != nil {
 			log.Fatalf("Failed to get solutions repository, error: %v", err)
 		}
@@ -208,7 +273,7 @@ func (c *TestContainer) SolutionRepository() mathbattle.SolutionRepository {
 func (c *TestContainer) ReviewRepository() mathbattle.ReviewRepository {
 	if c.reviewRepository == nil {
 		var err error
-		c.reviewRepository, err = sqldb.NewReviewRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString)
+		c.reviewRepository, err = sqldb.NewReviewRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, mlog.Default())
 		if err != nil {
 			log.Fatalf("Failed to get review repository, error: %v", err)
 		}
@@ -217,9 +282,32 @@ func (c *TestContainer) ReviewRepository() mathbattle.ReviewRepository {
 	return c.reviewRepository
 }
 
+func (c *TestContainer) TeamRepository() mathbattle.TeamRepository {
+	if c.teamRepository == nil {
+		var err error
+		c.teamRepository, err = sqldb.NewTeamRepository(c.Config().DatabaseType, c.Config().DatabaseConnectionString, mlog.Default())
+		if err != nil {
+			log.Fatalf("Failed to get team repository, error: %v", err)
+		}
+	}
+
+	return c.teamRepository
+}
+
+func (c *TestContainer) Messenger() *FakeMessenger {
+	if c.messenger == nil {
+		c.messenger = NewFakeMessenger()
+	}
+
+	return c.messenger
+}
+
 func (c *TestContainer) Postman() mathbattle.PostmanService {
 	if c.postman == nil {
-		c.postman = nil
+		c.postman = &application.PostmanService{
+			Users:   c.UserRepository(),
+			Postman: NewTelegramPostmanWithMessenger(c.Messenger()),
+		}
 	}
 
 	return c.postman
@@ -248,7 +336,6 @@ func (c *TestContainer) CreateUsers(count int) {
 		_, err := c.UserRepository().Store(mathbattle.User{
 			TelegramID:       int64(i),
 			TelegramUsername: fmt.Sprintf("FakeTelegramUserName_%d", i),
-			IsAdmin:          false,
 			RegistrationTime: time.Now(),
 		})
 		if err != nil {
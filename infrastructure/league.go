@@ -0,0 +1,14 @@
+package infrastructure
+
+import (
+	"mathbattle/config"
+	"mathbattle/models/mathbattle"
+)
+
+func newLeagues(cfg []config.League) mathbattle.Leagues {
+	result := mathbattle.Leagues{}
+	for _, league := range cfg {
+		result = append(result, mathbattle.League{Name: league.Name, Grades: league.Grades})
+	}
+	return result
+}
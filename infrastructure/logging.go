@@ -0,0 +1,48 @@
+package infrastructure
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"mathbattle/config"
+	"mathbattle/libs/mlog"
+)
+
+// newLogger - логгер программы name: пишет в stdout и в logs/<name>.log с ротацией.
+// Стандартный log и mlog.Default тоже пишут через него
+func newLogger(cfg config.Logging, name string) *mlog.Logger {
+	level, err := mlog.ParseLevel(cfg.Level)
+	if err != nil {
+		log.Fatalf("Failed to parse logging level, error: %v", err)
+	}
+
+	format, err := mlog.ParseFormat(cfg.Format)
+	if err != nil {
+		log.Fatalf("Failed to parse logging format, error: %v", err)
+	}
+
+	var maxAge time.Duration
+	if cfg.MaxAge != "" {
+		maxAge, err = time.ParseDuration(cfg.MaxAge)
+		if err != nil {
+			log.Fatalf("Failed to parse logging max_age, error: %v", err)
+		}
+	}
+
+	file := &mlog.RotatingFile{
+		Path:       filepath.Join("logs", name+".log"),
+		MaxSize:    cfg.MaxSizeMB * 1024 * 1024,
+		MaxAge:     maxAge,
+		MaxBackups: cfg.MaxBackups,
+	}
+
+	logger := mlog.New(io.MultiWriter(os.Stdout, file), level, format)
+	mlog.SetDefault(logger)
+	log.SetFlags(0)
+	log.SetOutput(logger.Writer())
+
+	return logger
+}
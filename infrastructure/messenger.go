@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// MessageRef указывает на отправленное сообщение, чтобы его можно было потом изменить
+type MessageRef struct {
+	ChatID    int64
+	MessageID int
+}
+
+// Messenger - всё, что бот и почтальон умеют отправлять в телеграм.
+// keyboard может быть nil, обычной или inline клавиатурой
+type Messenger interface {
+	SendText(chatID int64, text string, keyboard *tb.ReplyMarkup) (MessageRef, error)
+	SendPhoto(chatID int64, caption string, image []byte, keyboard *tb.ReplyMarkup) (MessageRef, error)
+	SendAlbum(chatID int64, caption string, images [][]byte) ([]MessageRef, error)
+	SendDocument(chatID int64, fileName string, caption string, content []byte, keyboard *tb.ReplyMarkup) (MessageRef, error)
+	EditText(ref MessageRef, text string, keyboard *tb.ReplyMarkup) error
+	Delete(ref MessageRef) error
+}
+
+var ErrEmptyAlbum = errors.New("Not enough items to send")
+
+type TelegramMessenger struct {
+	bot       *tb.Bot
+	parseMode tb.ParseMode
+}
+
+// NewTelegramMessenger - parseMode применяется ко всем текстам и подписям, tb.ModeDefault - без разметки
+func NewTelegramMessenger(bot *tb.Bot, parseMode tb.ParseMode) *TelegramMessenger {
+	return &TelegramMessenger{bot: bot, parseMode: parseMode}
+}
+
+func (m *TelegramMessenger) sendOptions(keyboard *tb.ReplyMarkup) []interface{} {
+	options := []interface{}{m.parseMode}
+	if keyboard != nil {
+		options = append(options, keyboard)
+	}
+	return options
+}
+
+func messageRef(msg *tb.Message) MessageRef {
+	result := MessageRef{MessageID: msg.ID}
+	if msg.Chat != nil {
+		result.ChatID = msg.Chat.ID
+	}
+	return result
+}
+
+func (m *TelegramMessenger) SendText(chatID int64, text string, keyboard *tb.ReplyMarkup) (MessageRef, error) {
+	msg, err := m.bot.Send(tb.ChatID(chatID), text, m.sendOptions(keyboard)...)
+	if err != nil {
+		return MessageRef{}, err
+	}
+	return messageRef(msg), nil
+}
+
+func (m *TelegramMessenger) SendPhoto(chatID int64, caption string, image []byte, keyboard *tb.ReplyMarkup) (MessageRef, error) {
+	msg, err := m.bot.Send(tb.ChatID(chatID), &tb.Photo{
+		Caption: caption,
+		File:    tb.FromReader(bytes.NewReader(image)),
+	}, m.sendOptions(keyboard)...)
+	if err != nil {
+		return MessageRef{}, err
+	}
+	return messageRef(msg), nil
+}
+
+func (m *TelegramMessenger) SendAlbum(chatID int64, caption string, images [][]byte) ([]MessageRef, error) {
+	if len(images) < 1 {
+		return nil, ErrEmptyAlbum
+	}
+
+	inputMedia := []tb.InputMedia{}
+	for i, image := range images {
+		photo := &tb.Photo{File: tb.FromReader(bytes.NewReader(image))}
+		if i == 0 {
+			photo.Caption = caption
+		}
+		inputMedia = append(inputMedia, photo)
+	}
+
+	msgs, err := m.bot.SendAlbum(tb.ChatID(chatID), inputMedia)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []MessageRef{}
+	for i := range msgs {
+		result = append(result, messageRef(&msgs[i]))
+	}
+	return result, nil
+}
+
+func (m *TelegramMessenger) SendDocument(chatID int64, fileName string, caption string, content []byte, keyboard *tb.ReplyMarkup) (MessageRef, error) {
+	msg, err := m.bot.Send(tb.ChatID(chatID), &tb.Document{
+		File:     tb.FromReader(bytes.NewReader(content)),
+		FileName: fileName,
+		Caption:  caption,
+	}, m.sendOptions(keyboard)...)
+	if err != nil {
+		return MessageRef{}, err
+	}
+	return messageRef(msg), nil
+}
+
+func storedMessage(ref MessageRef) tb.StoredMessage {
+	return tb.StoredMessage{
+		MessageID: strconv.Itoa(ref.MessageID),
+		ChatID:    ref.ChatID,
+	}
+}
+
+func (m *TelegramMessenger) EditText(ref MessageRef, text string, keyboard *tb.ReplyMarkup) error {
+	_, err := m.bot.Edit(storedMessage(ref), text, m.sendOptions(keyboard)...)
+	return err
+}
+
+func (m *TelegramMessenger) Delete(ref MessageRef) error {
+	return m.bot.Delete(storedMessage(ref))
+}
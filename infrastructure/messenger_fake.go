@@ -0,0 +1,134 @@
+package infrastructure
+
+import (
+	"errors"
+	"sync"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// FakeMessage - сообщение, "отправленное" через FakeMessenger
+type FakeMessage struct {
+	Ref      MessageRef
+	Kind     string // text, photo, album, document
+	Text     string
+	FileName string
+	Files    [][]byte
+	Keyboard *tb.ReplyMarkup
+	Edited   bool
+}
+
+// FakeMessenger ничего не отправляет, а запоминает сообщения для проверки в тестах.
+// Если задан FailChatIDs, отправка в эти чаты завершается ошибкой, как для заблокировавших бота пользователей
+type FakeMessenger struct {
+	mutex         sync.Mutex
+	messages      []FakeMessage
+	nextMessageID int
+
+	FailChatIDs map[int64]bool
+}
+
+var ErrFakeSendFailed = errors.New("Fake messenger: send failed")
+
+func NewFakeMessenger() *FakeMessenger {
+	return &FakeMessenger{
+		nextMessageID: 1,
+		FailChatIDs:   make(map[int64]bool),
+	}
+}
+
+func (m *FakeMessenger) Messages() []FakeMessage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]FakeMessage{}, m.messages...)
+}
+
+func (m *FakeMessenger) MessagesTo(chatID int64) []FakeMessage {
+	result := []FakeMessage{}
+	for _, msg := range m.Messages() {
+		if msg.Ref.ChatID == chatID {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func (m *FakeMessenger) add(chatID int64, msg FakeMessage) (MessageRef, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.FailChatIDs[chatID] {
+		return MessageRef{}, ErrFakeSendFailed
+	}
+
+	msg.Ref = MessageRef{ChatID: chatID, MessageID: m.nextMessageID}
+	m.nextMessageID++
+	m.messages = append(m.messages, msg)
+	return msg.Ref, nil
+}
+
+func (m *FakeMessenger) SendText(chatID int64, text string, keyboard *tb.ReplyMarkup) (MessageRef, error) {
+	return m.add(chatID, FakeMessage{Kind: "text", Text: text, Keyboard: keyboard})
+}
+
+func (m *FakeMessenger) SendPhoto(chatID int64, caption string, image []byte, keyboard *tb.ReplyMarkup) (MessageRef, error) {
+	return m.add(chatID, FakeMessage{Kind: "photo", Text: caption, Files: [][]byte{image}, Keyboard: keyboard})
+}
+
+func (m *FakeMessenger) SendAlbum(chatID int64, caption string, images [][]byte) ([]MessageRef, error) {
+	if len(images) < 1 {
+		return nil, ErrEmptyAlbum
+	}
+
+	result := []MessageRef{}
+	for i, image := range images {
+		msg := FakeMessage{Kind: "album", Files: [][]byte{image}}
+		if i == 0 {
+			msg.Text = caption
+		}
+
+		ref, err := m.add(chatID, msg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ref)
+	}
+	return result, nil
+}
+
+func (m *FakeMessenger) SendDocument(chatID int64, fileName string, caption string, content []byte, keyboard *tb.ReplyMarkup) (MessageRef, error) {
+	return m.add(chatID, FakeMessage{Kind: "document", Text: caption, FileName: fileName, Files: [][]byte{content}, Keyboard: keyboard})
+}
+
+func (m *FakeMessenger) EditText(ref MessageRef, text string, keyboard *tb.ReplyMarkup) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.FailChatIDs[ref.ChatID] {
+		return ErrFakeSendFailed
+	}
+
+	for i := range m.messages {
+		if m.messages[i].Ref == ref {
+			m.messages[i].Text = text
+			m.messages[i].Keyboard = keyboard
+			m.messages[i].Edited = true
+			return nil
+		}
+	}
+	return errors.New("Fake messenger: message to edit not found")
+}
+
+func (m *FakeMessenger) Delete(ref MessageRef) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.messages {
+		if m.messages[i].Ref == ref {
+			m.messages = append(m.messages[:i], m.messages[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("Fake messenger: message to delete not found")
+}
@@ -1,7 +1,6 @@
 package infrastructure
 
 import (
-	"bytes"
 	"errors"
 	"mathbattle/models/mathbattle"
 
@@ -9,7 +8,7 @@ import (
 )
 
 type TelegramPostman struct {
-	bot *tb.Bot
+	messenger Messenger
 }
 
 func NewTelegramPostman(APIToken string) (*TelegramPostman, error) {
@@ -18,7 +17,11 @@ func NewTelegramPostman(APIToken string) (*TelegramPostman, error) {
 		return nil, err
 	}
 
-	return &TelegramPostman{bot: bot}, nil
+	return NewTelegramPostmanWithMessenger(NewTelegramMessenger(bot, tb.ModeDefault)), nil
+}
+
+func NewTelegramPostmanWithMessenger(messenger Messenger) *TelegramPostman {
+	return &TelegramPostman{messenger: messenger}
 }
 
 func (pm *TelegramPostman) SendSimpleToUsers(msg mathbattle.SimpleMessage) error {
@@ -26,34 +29,21 @@ func (pm *TelegramPostman) SendSimpleToUsers(msg mathbattle.SimpleMessage) error
 }
 
 func (pm *TelegramPostman) SendSimpleMessage(chatID int64, message string) error {
-	_, err := pm.bot.Send(tb.ChatID(chatID), message)
+	_, err := pm.messenger.SendText(chatID, message, nil)
 	return err
 }
 
 func (pm *TelegramPostman) SendImage(chatID int64, caption string, image []byte) error {
-	_, err := pm.bot.Send(tb.ChatID(chatID), &tb.Photo{
-		Caption: caption,
-		File:    tb.FromReader(bytes.NewReader(image)),
-	})
+	_, err := pm.messenger.SendPhoto(chatID, caption, image, nil)
 	return err
 }
 
 func (pm *TelegramPostman) SendAlbum(chatID int64, caption string, images [][]byte) error {
-	if len(images) < 1 {
-		return errors.New("Not enough items to send")
-	}
-
-	inputMedia := []tb.InputMedia{}
-	inputMedia = append(inputMedia, &tb.Photo{
-		Caption: caption,
-		File:    tb.FromReader(bytes.NewReader(images[0])),
-	})
-	for i := 1; i < len(images); i++ {
-		inputMedia = append(inputMedia, &tb.Photo{
-			File: tb.FromReader(bytes.NewReader(images[i])),
-		})
-	}
+	_, err := pm.messenger.SendAlbum(chatID, caption, images)
+	return err
+}
 
-	_, err := pm.bot.SendAlbum(tb.ChatID(chatID), inputMedia)
+func (pm *TelegramPostman) SendDocument(chatID int64, fileName string, caption string, content []byte) error {
+	_, err := pm.messenger.SendDocument(chatID, fileName, caption, content, nil)
 	return err
 }
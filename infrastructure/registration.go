@@ -0,0 +1,96 @@
+package infrastructure
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"mathbattle/config"
+	"mathbattle/models/mathbattle"
+)
+
+func newRegistrationForm(cfg config.Registration) (mathbattle.RegistrationForm, error) {
+	return mathbattle.NewRegistrationForm(cfg.School, cfg.Region, cfg.TeacherContact, cfg.ConsentMaxGrade)
+}
+
+// SchoolDirectory - справочник школ в памяти
+type SchoolDirectory struct {
+	names      []string
+	normalized []string
+}
+
+func NewSchoolDirectory(names []string) *SchoolDirectory {
+	result := &SchoolDirectory{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		result.names = append(result.names, name)
+		result.normalized = append(result.normalized, mathbattle.NormalizeSchoolName(name))
+	}
+
+	return result
+}
+
+// LoadSchoolDirectory читает справочник из текстового файла: по школе на строку, строки с # пропускаются
+func LoadSchoolDirectory(path string) (*SchoolDirectory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	names := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewSchoolDirectory(names), nil
+}
+
+// Suggest ищет школы, в названии которых есть все слова запроса. Сначала точное совпадение,
+// затем названия, начинающиеся с запроса, затем остальные - в порядке справочника
+func (d *SchoolDirectory) Suggest(query string, limit int) []string {
+	query = mathbattle.NormalizeSchoolName(query)
+	words := strings.Fields(query)
+	if len(words) == 0 || limit <= 0 {
+		return []string{}
+	}
+
+	exact, prefixed, other := []string{}, []string{}, []string{}
+	for i, normalized := range d.normalized {
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(normalized, word) {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		switch {
+		case normalized == query:
+			exact = append(exact, d.names[i])
+		case strings.HasPrefix(normalized, query):
+			prefixed = append(prefixed, d.names[i])
+		default:
+			other = append(other, d.names[i])
+		}
+	}
+
+	result := append(append(exact, prefixed...), other...)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
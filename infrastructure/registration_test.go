@@ -0,0 +1,45 @@
+package infrastructure
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchoolDirectorySuggest(t *testing.T) {
+	req := require.New(t)
+
+	directory := NewSchoolDirectory([]string{
+		"Школа №2, Пермь",
+		"Лицей №1, Пермь",
+		"Лицей №1",
+		"Гимназия «Ёлочка», Москва",
+	})
+
+	req.Equal([]string{"Лицей №1", "Лицей №1, Пермь"}, directory.Suggest("лицей 1", 10))
+	req.Equal([]string{"Школа №2, Пермь", "Лицей №1, Пермь"}, directory.Suggest("пермь", 10))
+	req.Equal([]string{"Гимназия «Ёлочка», Москва"}, directory.Suggest("ГИМНАЗИЯ ЕЛОЧКА", 10))
+	req.Equal([]string{"Лицей №1"}, directory.Suggest("Лицей №1", 1))
+	req.Empty(directory.Suggest("колледж", 10))
+	req.Empty(directory.Suggest("  ", 10))
+}
+
+func TestLoadSchoolDirectory(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "schools")
+	req.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "schools.txt")
+	req.Nil(ioutil.WriteFile(path, []byte("# Пермь\nЛицей №1\n\nШкола №2\n"), 0666))
+
+	directory, err := LoadSchoolDirectory(path)
+	req.Nil(err)
+	req.Equal([]string{"Лицей №1"}, directory.Suggest("лицей", 10))
+	req.Equal([]string{"Школа №2"}, directory.Suggest("школа", 10))
+	req.Empty(directory.Suggest("пермь", 10))
+}
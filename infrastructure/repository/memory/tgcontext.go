@@ -1,13 +1,14 @@
 package memory
 
 import (
-	"mathbattle/infrastructure"
-	"time"
+	"sync"
 
+	"mathbattle/infrastructure"
 	"mathbattle/models/mathbattle"
 )
 
 type TelegramContextRepository struct {
+	mutex          sync.Mutex
 	userContexts   map[int64]infrastructure.TelegramUserContext
 	userRepository mathbattle.UserRepository
 }
@@ -20,29 +21,18 @@ func NewTelegramContextRepository(userRepository mathbattle.UserRepository) (*Te
 }
 
 func (r *TelegramContextRepository) GetByUserData(userData infrastructure.TelegramUserData) (infrastructure.TelegramUserContext, error) {
-	if ctx, isExist := r.userContexts[userData.ChatID]; isExist {
-		return ctx, nil
-	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
-	user, err := r.userRepository.GetByTelegramID(userData.ChatID)
+	// Пользователь перечитывается каждый раз: команды могут менять его настройки, например язык
+	user, err := infrastructure.GetOrCreateTelegramUser(r.userRepository, userData)
 	if err != nil {
-		if err != mathbattle.ErrNotFound {
-			return infrastructure.TelegramUserContext{}, err
-		}
-
-		newUser := mathbattle.User{
-			TelegramID:        userData.ChatID,
-			TelegramFirstName: userData.FirstName,
-			TelegramLastName:  userData.LastName,
-			TelegramUsername:  userData.Username,
-			IsAdmin:           false,
-		}
-		newUser.SetRegistrationTime(time.Now())
-
-		user, err = r.userRepository.Store(newUser)
-		if err != nil {
-			return infrastructure.TelegramUserContext{}, err
-		}
+		return infrastructure.TelegramUserContext{}, err
+	}
+
+	if ctx, isExist := r.userContexts[userData.ChatID]; isExist {
+		ctx.User = user
+		return ctx, nil
 	}
 
 	newCtx := infrastructure.TelegramUserContext{
@@ -57,6 +47,9 @@ func (r *TelegramContextRepository) GetByUserData(userData infrastructure.Telegr
 }
 
 func (r *TelegramContextRepository) Update(chatID int64, ctx infrastructure.TelegramUserContext) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
 	r.userContexts[chatID] = ctx
 	return nil
 }
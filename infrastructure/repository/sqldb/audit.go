@@ -0,0 +1,153 @@
+package sqldb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+// AuditRepository - журнал аудита. Записи только добавляются: изменять и удалять их нечем
+type AuditRepository struct {
+	sqlRepository
+}
+
+func NewAuditRepository(dbType, connectionString string, logger *mlog.Logger) (*AuditRepository, error) {
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuditRepository{
+		sqlRepository: sqlRepository,
+	}
+
+	if err := result.CreateTable(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *AuditRepository) CreateTable() error {
+	var createStmt string
+
+	switch r.dbType {
+	case "sqlite3":
+		createStmt = `CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+			time DATETIME,
+			actor_id BIGINT,
+			action VARCHAR(64),
+			target VARCHAR(100),
+			before_value TEXT,
+			after_value TEXT
+		)`
+	case "postgres":
+		createStmt = `CREATE TABLE IF NOT EXISTS audit_log (
+			id SERIAL UNIQUE,
+			time TIMESTAMP,
+			actor_id BIGINT,
+			action VARCHAR(64),
+			target VARCHAR(100),
+			before_value TEXT,
+			after_value TEXT
+		)`
+	}
+
+	_, err := r.db.Exec(createStmt)
+	return err
+}
+
+func (r *AuditRepository) Store(record mathbattle.AuditRecord) (mathbattle.AuditRecord, error) {
+	result := record
+
+	switch r.dbType {
+	case "sqlite3":
+		res, err := r.db.Exec(`INSERT INTO audit_log (time, actor_id, action, target, before_value, after_value)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			record.Time, record.ActorID, string(record.Action), record.Target, record.Before, record.After)
+		if err != nil {
+			return result, err
+		}
+
+		insertedID, err := res.LastInsertId()
+		if err != nil {
+			return result, err
+		}
+		result.ID = strconv.FormatInt(insertedID, 10)
+
+		return result, nil
+	case "postgres":
+		query := `INSERT INTO audit_log (time, actor_id, action, target, before_value, after_value)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return result, err
+		}
+		defer stmt.Close()
+
+		err = stmt.QueryRow(record.Time, record.ActorID, string(record.Action), record.Target, record.Before,
+			record.After).Scan(&result.ID)
+		if err != nil {
+			return result, err
+		}
+
+		return result, nil
+	default:
+		return result, fmt.Errorf("Unknown dbtype")
+	}
+}
+
+// Find - записи по фильтру в порядке добавления
+func (r *AuditRepository) Find(filter mathbattle.AuditFilter) ([]mathbattle.AuditRecord, error) {
+	result := []mathbattle.AuditRecord{}
+
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", string(filter.Action))
+	}
+	if filter.ActorID != 0 {
+		addCondition("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Target != "" {
+		addCondition("target = $%d", filter.Target)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("time >= $%d", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		addCondition("time < $%d", filter.Until.UTC())
+	}
+
+	query := "SELECT id, time, actor_id, action, target, before_value, after_value FROM audit_log"
+	if len(conditions) != 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cur mathbattle.AuditRecord
+		if err := rows.Scan(&cur.ID, &cur.Time, &cur.ActorID, &cur.Action, &cur.Target, &cur.Before, &cur.After); err != nil {
+			return result, err
+		}
+		cur.Time = cur.Time.UTC()
+
+		result = append(result, cur)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,197 @@
+package sqldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+type BattleRepository struct {
+	sqlRepository
+}
+
+func NewBattleRepository(dbType, connectionString string, logger *mlog.Logger) (*BattleRepository, error) {
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BattleRepository{
+		sqlRepository: sqlRepository,
+	}
+
+	if err := result.CreateTable(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *BattleRepository) CreateTable() error {
+	var createStmt string
+
+	switch r.dbType {
+	case "sqlite3":
+		createStmt = `CREATE TABLE IF NOT EXISTS battles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+			round_id INTEGER,
+			team_ids TEXT,
+			team_names TEXT,
+			problems TEXT,
+			state VARCHAR(32),
+			turn VARCHAR(32),
+			fights TEXT,
+			passed_team_id VARCHAR(32)
+		)`
+	case "postgres":
+		createStmt = `CREATE TABLE IF NOT EXISTS battles (
+			id SERIAL UNIQUE,
+			round_id INTEGER,
+			team_ids TEXT,
+			team_names TEXT,
+			problems TEXT,
+			state VARCHAR(32),
+			turn VARCHAR(32),
+			fights TEXT,
+			passed_team_id VARCHAR(32)
+		)`
+	}
+
+	_, err := r.db.Exec(createStmt)
+	return err
+}
+
+// serializeBattle - списки боя хранятся в колонках json строками: team_ids, team_names, problems, fights
+func serializeBattle(battle mathbattle.Battle) ([]string, error) {
+	result := []string{}
+	for _, value := range []interface{}{battle.TeamIDs, battle.TeamNames, battle.Problems, battle.Fights} {
+		serialized, err := json.Marshal(value)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, string(serialized))
+	}
+
+	return result, nil
+}
+
+func (r *BattleRepository) Store(battle mathbattle.Battle) (mathbattle.Battle, error) {
+	result := battle
+
+	lists, err := serializeBattle(battle)
+	if err != nil {
+		return result, err
+	}
+
+	switch r.dbType {
+	case "sqlite3":
+		res, err := r.db.Exec(`INSERT INTO battles (round_id, team_ids, team_names, problems, state, turn, fights,
+			passed_team_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			battle.RoundID, lists[0], lists[1], lists[2], string(battle.State), battle.Turn, lists[3], battle.PassedTeamID)
+		if err != nil {
+			return result, err
+		}
+
+		insertedID, err := res.LastInsertId()
+		if err != nil {
+			return result, err
+		}
+		result.ID = strconv.FormatInt(insertedID, 10)
+
+		return result, nil
+	case "postgres":
+		query := `INSERT INTO battles (round_id, team_ids, team_names, problems, state, turn, fights, passed_team_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return result, err
+		}
+		defer stmt.Close()
+
+		err = stmt.QueryRow(battle.RoundID, lists[0], lists[1], lists[2], string(battle.State), battle.Turn,
+			lists[3], battle.PassedTeamID).Scan(&result.ID)
+		if err != nil {
+			return result, err
+		}
+
+		return result, nil
+	default:
+		return result, fmt.Errorf("Unknown dbtype")
+	}
+}
+
+func (r *BattleRepository) getManyWhere(whereStr string, whereArgs ...interface{}) ([]mathbattle.Battle, error) {
+	result := []mathbattle.Battle{}
+
+	rows, err := r.db.Query("SELECT id, round_id, team_ids, team_names, problems, state, turn, fights, passed_team_id "+
+		"FROM battles WHERE "+whereStr+" ORDER BY id", whereArgs...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cur mathbattle.Battle
+		var teamIDs, teamNames, problems, state, fights string
+		if err := rows.Scan(&cur.ID, &cur.RoundID, &teamIDs, &teamNames, &problems, &state, &cur.Turn, &fights,
+			&cur.PassedTeamID); err != nil {
+			return result, err
+		}
+		cur.State = mathbattle.BattleState(state)
+
+		if err := json.Unmarshal([]byte(teamIDs), &cur.TeamIDs); err != nil {
+			return result, err
+		}
+		if err := json.Unmarshal([]byte(teamNames), &cur.TeamNames); err != nil {
+			return result, err
+		}
+		if err := json.Unmarshal([]byte(problems), &cur.Problems); err != nil {
+			return result, err
+		}
+		if err := json.Unmarshal([]byte(fights), &cur.Fights); err != nil {
+			return result, err
+		}
+
+		result = append(result, cur)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *BattleRepository) Get(ID string) (mathbattle.Battle, error) {
+	res, err := r.getManyWhere("id = $1", ID)
+	if err != nil {
+		return mathbattle.Battle{}, err
+	}
+
+	if len(res) == 0 {
+		return mathbattle.Battle{}, mathbattle.ErrNotFound
+	}
+
+	return res[0], nil
+}
+
+func (r *BattleRepository) GetByRound(roundID string) ([]mathbattle.Battle, error) {
+	return r.getManyWhere("round_id = $1", roundID)
+}
+
+func (r *BattleRepository) Update(battle mathbattle.Battle) error {
+	lists, err := serializeBattle(battle)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`UPDATE battles SET round_id = $1, team_ids = $2, team_names = $3, problems = $4, state = $5,
+		turn = $6, fights = $7, passed_team_id = $8 WHERE id = $9`,
+		battle.RoundID, lists[0], lists[1], lists[2], string(battle.State), battle.Turn, lists[3], battle.PassedTeamID,
+		battle.ID)
+	return err
+}
+
+func (r *BattleRepository) Delete(ID string) error {
+	_, err := r.db.Exec("DELETE FROM battles WHERE id = $1", ID)
+	return err
+}
@@ -6,16 +6,21 @@ import (
 	"fmt"
 	"strconv"
 
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
+const participantColumns = "id, user_id, name, school, grade, is_active, reminders_off, region, teacher_contact, parental_consent, grade_year, league"
+
 type ParticipantRepository struct {
 	sqlRepository
 	userRepository *UserRepository
 }
 
-func NewParticipantRepository(dbType, connectionString string, userRepository *UserRepository) (*ParticipantRepository, error) {
-	sqlRepository, err := newSqlRepository(dbType, connectionString)
+func NewParticipantRepository(dbType, connectionString string, userRepository *UserRepository,
+	logger *mlog.Logger) (*ParticipantRepository, error) {
+
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +48,11 @@ func (r *ParticipantRepository) CreateTable() error {
 			school VARCHAR(256),
 			grade INTEGER,
 			is_active BOOL,
+			reminders_off BOOL DEFAULT FALSE,
+			region VARCHAR(100) DEFAULT '',
+			teacher_contact VARCHAR(256) DEFAULT '',
+			parental_consent BOOL DEFAULT FALSE,
+			grade_year INTEGER DEFAULT 0,
 			FOREIGN KEY(user_id) REFERENCES users(id)
 		)`
 	case "postgres":
@@ -53,13 +63,74 @@ func (r *ParticipantRepository) CreateTable() error {
 			school VARCHAR(256),
 			grade INTEGER,
 			is_active BOOL,
+			reminders_off BOOL DEFAULT FALSE,
+			region VARCHAR(100) DEFAULT '',
+			teacher_contact VARCHAR(256) DEFAULT '',
+			parental_consent BOOL DEFAULT FALSE,
+			grade_year INTEGER DEFAULT 0,
 			FOREIGN KEY(user_id) REFERENCES users(id)
 		)`
 	}
 
-	_, err := r.db.Exec(createStmt)
+	if _, err := r.db.Exec(createStmt); err != nil {
+		return err
+	}
 
-	return err
+	if err := r.addColumnIfNotExists("participants", "reminders_off", "BOOL DEFAULT FALSE"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfNotExists("participants", "region", "VARCHAR(100) DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfNotExists("participants", "teacher_contact", "VARCHAR(256) DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfNotExists("participants", "parental_consent", "BOOL DEFAULT FALSE"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfNotExists("participants", "grade_year", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfNotExists("participants", "league", "VARCHAR(100) DEFAULT ''"); err != nil {
+		return err
+	}
+	return r.fillGradeYears()
+}
+
+// fillGradeYears - у записанных до появления grade_year класс указан на учебный год регистрации
+func (r *ParticipantRepository) fillGradeYears() error {
+	rows, err := r.db.Query(`SELECT participants.id, users.registration_time FROM participants
+	JOIN users ON users.id = participants.user_id WHERE participants.grade_year = 0 OR participants.grade_year IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	gradeYears := map[string]int{}
+	for rows.Next() {
+		var ID string
+		var registrationTime sql.NullTime
+		if err := rows.Scan(&ID, &registrationTime); err != nil {
+			rows.Close()
+			return err
+		}
+		if !registrationTime.Valid || registrationTime.Time.IsZero() {
+			r.logger.Warnf("Participant %s has no registration time, grade year is left unknown", ID)
+			continue
+		}
+		gradeYears[ID] = mathbattle.SchoolYear(registrationTime.Time)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for ID, gradeYear := range gradeYears {
+		if _, err := r.db.Exec("UPDATE participants SET grade_year = $1 WHERE id = $2", gradeYear, ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *ParticipantRepository) Store(participant mathbattle.Participant) (mathbattle.Participant, error) {
@@ -67,8 +138,11 @@ func (r *ParticipantRepository) Store(participant mathbattle.Participant) (mathb
 
 	switch r.dbType {
 	case "sqlite3":
-		res, err := r.db.Exec("INSERT INTO participants (user_id, name, school, grade, is_active) VALUES (?, ?, ?, ?, ?)",
-			participant.User.ID, participant.Name, participant.School, participant.Grade, participant.IsActive)
+		res, err := r.db.Exec("INSERT INTO participants (user_id, name, school, grade, is_active, reminders_off, region, teacher_contact, parental_consent, "+
+			"grade_year, league) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			participant.User.ID, participant.Name, participant.School, participant.Grade, participant.IsActive,
+			participant.RemindersOff, participant.Region, participant.TeacherContact, participant.ParentalConsent,
+			participant.GradeYear, participant.League)
 
 		if err != nil {
 			return result, err
@@ -81,14 +155,17 @@ func (r *ParticipantRepository) Store(participant mathbattle.Participant) (mathb
 
 		return result, nil
 	case "postgres":
-		query := "INSERT INTO participants (user_id, name, school, grade, is_active) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		query := "INSERT INTO participants (user_id, name, school, grade, is_active, reminders_off, region, teacher_contact, parental_consent, " +
+			"grade_year, league) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id"
 		stmt, err := r.db.Prepare(query)
 		if err != nil {
 			return result, err
 		}
 		defer stmt.Close()
 
-		err = stmt.QueryRow(participant.User.ID, participant.Name, participant.School, participant.Grade, participant.IsActive).Scan(&result.ID)
+		err = stmt.QueryRow(participant.User.ID, participant.Name, participant.School, participant.Grade, participant.IsActive,
+			participant.RemindersOff, participant.Region, participant.TeacherContact, participant.ParentalConsent,
+			participant.GradeYear, participant.League).Scan(&result.ID)
 		if err != nil {
 			return result, err
 		}
@@ -101,8 +178,9 @@ func (r *ParticipantRepository) Store(participant mathbattle.Participant) (mathb
 
 func (r *ParticipantRepository) getWhere(whereStr string, whereArgs ...interface{}) (mathbattle.Participant, error) {
 	result := mathbattle.Participant{}
-	row := r.db.QueryRow("SELECT id, user_id, name, school, grade, is_active FROM participants WHERE "+whereStr, whereArgs...)
-	err := row.Scan(&result.ID, &result.User.ID, &result.Name, &result.School, &result.Grade, &result.IsActive)
+	row := r.db.QueryRow("SELECT "+participantColumns+" FROM participants WHERE "+whereStr, whereArgs...)
+	err := row.Scan(&result.ID, &result.User.ID, &result.Name, &result.School, &result.Grade, &result.IsActive, &result.RemindersOff,
+		&result.Region, &result.TeacherContact, &result.ParentalConsent, &result.GradeYear, &result.League)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return result, mathbattle.ErrNotFound
@@ -138,37 +216,48 @@ func (r *ParticipantRepository) GetByUserID(userID string) (mathbattle.Participa
 }
 
 func (r *ParticipantRepository) GetAll() ([]mathbattle.Participant, error) {
-	rows, err := r.db.Query("SELECT id, user_id, name, school, grade, is_active FROM participants")
+	rows, err := r.db.Query("SELECT " + participantColumns + " FROM participants")
 	if err != nil {
 		return []mathbattle.Participant{}, err
 	}
-	defer rows.Close()
 
+	// Пользователи читаются после закрытия rows: у sqlite одно соединение, вложенный запрос его не дождётся
 	result := []mathbattle.Participant{}
 	for rows.Next() {
 		curParticipant := mathbattle.Participant{}
 		err = rows.Scan(&curParticipant.ID, &curParticipant.User.ID, &curParticipant.Name, &curParticipant.School,
-			&curParticipant.Grade, &curParticipant.IsActive)
+			&curParticipant.Grade, &curParticipant.IsActive, &curParticipant.RemindersOff, &curParticipant.Region,
+			&curParticipant.TeacherContact, &curParticipant.ParentalConsent, &curParticipant.GradeYear, &curParticipant.League)
 		if err != nil {
+			rows.Close()
 			return []mathbattle.Participant{}, err
 		}
 
-		user, err := r.userRepository.GetByID(curParticipant.User.ID)
+		result = append(result, curParticipant)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return []mathbattle.Participant{}, err
+	}
+
+	for i := range result {
+		user, err := r.userRepository.GetByID(result[i].User.ID)
 		if err != nil {
 			return []mathbattle.Participant{}, err
 		}
 
-		curParticipant.User = user
-
-		result = append(result, curParticipant)
+		result[i].User = user
 	}
+
 	return result, nil
 }
 
 func (r *ParticipantRepository) Update(participant mathbattle.Participant) error {
-	_, err := r.db.Exec("UPDATE participants SET user_id = $1, name = $2, grade = $3, school = $4, is_active = $5 WHERE id = $6",
+	_, err := r.db.Exec("UPDATE participants SET user_id = $1, name = $2, grade = $3, school = $4, is_active = $5, reminders_off = $6, "+
+		"region = $7, teacher_contact = $8, parental_consent = $9, grade_year = $10, league = $11 WHERE id = $12",
 		participant.User.ID, participant.Name, participant.Grade, participant.School, participant.IsActive,
-		participant.ID)
+		participant.RemindersOff, participant.Region, participant.TeacherContact, participant.ParentalConsent,
+		participant.GradeYear, participant.League, participant.ID)
 	if err != nil {
 		return err
 	}
@@ -2,47 +2,44 @@ package sqldb
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strconv"
 
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
 type ProblemRepository struct {
 	sqlRepository
-	problemFolder string
+	blobs mathbattle.BlobStore
+	// Папка, куда задачи сохранялись до появления BlobStore. Оттуда они переносятся при запуске
+	legacyFolder string
 }
 
-func NewProblemRepository(dbType, connectionString, problemPath string) (*ProblemRepository, error) {
-	sqlRepository, err := newSqlRepository(dbType, connectionString)
+func NewProblemRepository(dbType, connectionString string, blobs mathbattle.BlobStore, legacyPath string,
+	logger *mlog.Logger) (*ProblemRepository, error) {
+
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
 	if err != nil {
-		log.Printf("Fld to get sql rep, err: %v", err)
 		return nil, err
 	}
 
-	if _, err := os.Stat(problemPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(problemPath, 0777); err != nil {
-			log.Printf("Fld to create %v rep, err: %v", problemPath, err)
-			return nil, err
-		}
-	}
-
 	result := &ProblemRepository{
 		sqlRepository: sqlRepository,
-		problemFolder: problemPath,
+		blobs:         blobs,
+		legacyFolder:  legacyPath,
 	}
 
 	if err := result.CreateTable(); err != nil {
 		return nil, err
 	}
 
+	if err := result.migrateLegacyFiles(); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -74,22 +71,62 @@ func (r *ProblemRepository) CreateTable() error {
 	return err
 }
 
-func (r *ProblemRepository) getFilePathFromProblem(problem mathbattle.Problem) string {
-	return filepath.Join(r.problemFolder, fmt.Sprintf("%d_%d_%s%s",
+func (r *ProblemRepository) getLegacyFilePath(problem mathbattle.Problem) string {
+	return filepath.Join(r.legacyFolder, fmt.Sprintf("%d_%d_%s%s",
 		problem.MinGrade, problem.MaxGrade, problem.Sha256sum, problem.Extension))
 }
 
-func (r *ProblemRepository) Store(problem mathbattle.Problem) (mathbattle.Problem, error) {
-	h := sha256.New()
-	if _, err := io.Copy(h, bytes.NewReader(problem.Content)); err != nil {
-		return problem, err
+// migrateLegacyFiles переносит в BlobStore задачи, сохранённые файлами <grade_min>_<grade_max>_<sha256><ext>.
+// Ключ в BlobStore - тот же sha256, поэтому в базе ничего менять не нужно. Перенесённый файл удаляется
+func (r *ProblemRepository) migrateLegacyFiles() error {
+	problems, err := r.getAllWithoutContent()
+	if err != nil {
+		return err
 	}
-	problem.Sha256sum = hex.EncodeToString(h.Sum(nil))
 
-	err := ioutil.WriteFile(r.getFilePathFromProblem(problem), problem.Content, 0666)
+	for _, problem := range problems {
+		exists, err := r.blobs.Exists(problem.Sha256sum)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		path := r.getLegacyFilePath(problem)
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			// Задачу без файла всё равно не показать, запуск из-за неё не прерываем
+			r.logger.Warnf("Problem %s file is missing, skipping it, error: %v", problem.ID, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		key, err := r.blobs.Put(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if key != problem.Sha256sum {
+			return fmt.Errorf("problem %s file is corrupted, sha256 %s != %s", problem.ID, key, problem.Sha256sum)
+		}
+		r.logger.Infof("Problem %s moved to blob store", problem.ID)
+
+		if err := os.Remove(path); err != nil {
+			r.logger.Errorf("Failed to remove migrated file %s, error: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ProblemRepository) Store(problem mathbattle.Problem) (mathbattle.Problem, error) {
+	key, err := r.blobs.Put(bytes.NewReader(problem.Content))
 	if err != nil {
 		return problem, err
 	}
+	problem.Sha256sum = key
 
 	switch r.dbType {
 	case "sqlite3":
@@ -130,7 +167,7 @@ func (r *ProblemRepository) GetByID(ID string) (mathbattle.Problem, error) {
 		return mathbattle.Problem{}, err
 	}
 	result.ID = ID
-	content, err := ioutil.ReadFile(r.getFilePathFromProblem(result))
+	content, err := mathbattle.ReadBlob(r.blobs, result.Sha256sum)
 	if err != nil {
 		return mathbattle.Problem{}, err
 	}
@@ -139,6 +176,22 @@ func (r *ProblemRepository) GetByID(ID string) (mathbattle.Problem, error) {
 }
 
 func (r *ProblemRepository) GetAll() ([]mathbattle.Problem, error) {
+	result, err := r.getAllWithoutContent()
+	if err != nil {
+		return []mathbattle.Problem{}, err
+	}
+
+	for i := range result {
+		result[i].Content, err = mathbattle.ReadBlob(r.blobs, result[i].Sha256sum)
+		if err != nil {
+			return []mathbattle.Problem{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *ProblemRepository) getAllWithoutContent() ([]mathbattle.Problem, error) {
 	rows, err := r.db.Query("SELECT id, sha256sum, grade_min, grade_max, extension FROM problems ORDER BY id")
 	if err != nil {
 		return []mathbattle.Problem{}, err
@@ -153,12 +206,6 @@ func (r *ProblemRepository) GetAll() ([]mathbattle.Problem, error) {
 			return []mathbattle.Problem{}, err
 		}
 
-		content, err := ioutil.ReadFile(r.getFilePathFromProblem(curProblem))
-		if err != nil {
-			return []mathbattle.Problem{}, err
-		}
-		curProblem.Content = content
-
 		result = append(result, curProblem)
 	}
 
@@ -3,11 +3,12 @@ package sqldb
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"mathbattle/libs/mlog"
+
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
@@ -15,22 +16,24 @@ import (
 
 var gDB *sql.DB = nil
 
-func initSqliteDb(dbPath string) error {
-	log.Printf("Init db: %v", dbPath)
+func initSqliteDb(dbPath string, logger *mlog.Logger) error {
+	logger.Infof("Init db: %v", dbPath)
 	if gDB == nil {
 		if _, err := os.Stat(filepath.Dir(dbPath)); os.IsNotExist(err) {
-			log.Printf("%v not exist, creating.", filepath.Dir(dbPath))
+			logger.Infof("%v not exist, creating.", filepath.Dir(dbPath))
 			if err := os.MkdirAll(filepath.Dir(dbPath), 0766); err != nil {
-				log.Printf("Fld to create %v, error: %v", filepath.Dir(dbPath), err)
+				logger.Errorf("Failed to create %v, error: %v", filepath.Dir(dbPath), err)
 				return err
 			}
 		}
 
 		db, err := sql.Open("sqlite3", dbPath)
 		if err != nil {
-			log.Printf("Init, sql.Open() error: %v", err)
+			logger.Errorf("Init, sql.Open() error: %v", err)
 			return err
 		}
+		// sqlite не умеет писать из нескольких соединений одновременно, а бот обрабатывает сообщения параллельно
+		db.SetMaxOpenConns(1)
 
 		gDB = db
 	}
@@ -38,7 +41,28 @@ func initSqliteDb(dbPath string) error {
 	return nil
 }
 
-func initPostgresDb(connectionString string) error {
+// selectIDs - первая колонка всех строк запроса. rows закрываются до возврата, поэтому по ID можно
+// делать следующие запросы: у sqlite одно соединение, и вложенный запрос при открытых rows ждал бы вечно
+func selectIDs(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []string{}
+	for rows.Next() {
+		var ID string
+		if err := rows.Scan(&ID); err != nil {
+			return nil, err
+		}
+		result = append(result, ID)
+	}
+
+	return result, rows.Err()
+}
+
+func initPostgresDb(connectionString string, logger *mlog.Logger) error {
 	if gDB == nil {
 		dbName, err := getDbNameFromConnString(connectionString)
 		if err != nil {
@@ -49,7 +73,7 @@ func initPostgresDb(connectionString string) error {
 
 		db, err := sql.Open("postgres", genericConnString)
 		if err != nil {
-			log.Printf("Init, sql.Open() error: %v", err)
+			logger.Errorf("Init, sql.Open() error: %v", err)
 			return err
 		}
 
@@ -61,11 +85,11 @@ func initPostgresDb(connectionString string) error {
 			}
 
 			if pgerr.Code != "42P04" { // Duplicate database
-				log.Printf("Init, failed to create database error: %v", err)
+				logger.Errorf("Init, failed to create database error: %v", err)
 				return err
 			}
 
-			log.Printf("Don't need to create database, already exists")
+			logger.Infof("Don't need to create database, already exists")
 		}
 
 		err = db.Close()
@@ -75,7 +99,7 @@ func initPostgresDb(connectionString string) error {
 
 		gDB, err = sql.Open("postgres", connectionString)
 		if err != nil {
-			log.Printf("Init, sql.Open() error: %v", err)
+			logger.Errorf("Init, sql.Open() error: %v", err)
 			return err
 		}
 	}
@@ -110,8 +134,8 @@ func removeDbNameFromConnString(connectionString string) string {
 	return strings.Join(newParts, " ")
 }
 
-func Deinit() error {
-	log.Printf("Deinit db")
+func Deinit(logger *mlog.Logger) error {
+	loggerOrDefault(logger).Infof("Deinit db")
 	if gDB != nil {
 		err := gDB.Close()
 		gDB = nil
@@ -121,7 +145,8 @@ func Deinit() error {
 	return nil
 }
 
-func DeinitAndRemove(dbType, connectionString string) error {
+func DeinitAndRemove(dbType, connectionString string, logger *mlog.Logger) error {
+	logger = loggerOrDefault(logger)
 	if gDB != nil {
 		err := gDB.Close()
 		if err != nil {
@@ -137,7 +162,7 @@ func DeinitAndRemove(dbType, connectionString string) error {
 		genericConnString := removeDbNameFromConnString(connectionString)
 		db, err := sql.Open("postgres", genericConnString)
 		if err != nil {
-			log.Printf("Init, sql.Open() error: %v", err)
+			logger.Errorf("Init, sql.Open() error: %v", err)
 			return err
 		}
 
@@ -146,7 +171,7 @@ func DeinitAndRemove(dbType, connectionString string) error {
 			return err
 		}
 
-		log.Printf("Removing database %v", dbName)
+		logger.Infof("Removing database %v", dbName)
 		_, err = db.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", dbName))
 
 		return err
@@ -158,17 +183,27 @@ func DeinitAndRemove(dbType, connectionString string) error {
 type sqlRepository struct {
 	db     *sql.DB
 	dbType string
+	logger *mlog.Logger
+}
+
+// loggerOrDefault - логгер, переданный репозиторию, или mlog.Default, если его не передали
+func loggerOrDefault(l *mlog.Logger) *mlog.Logger {
+	if l == nil {
+		return mlog.Default()
+	}
+	return l
 }
 
-func newSqlRepository(dbType, connectionString string) (sqlRepository, error) {
+func newSqlRepository(dbType, connectionString string, logger *mlog.Logger) (sqlRepository, error) {
+	logger = loggerOrDefault(logger)
 	if gDB == nil {
 		switch dbType {
 		case "sqlite3":
-			if err := initSqliteDb(connectionString); err != nil {
+			if err := initSqliteDb(connectionString, logger); err != nil {
 				return sqlRepository{}, err
 			}
 		case "postgres":
-			if err := initPostgresDb(connectionString); err != nil {
+			if err := initPostgresDb(connectionString, logger); err != nil {
 				return sqlRepository{}, err
 			}
 		default:
@@ -179,9 +214,33 @@ func newSqlRepository(dbType, connectionString string) (sqlRepository, error) {
 	return sqlRepository{
 		db:     gDB,
 		dbType: dbType,
+		logger: logger,
 	}, nil
 }
 
+// addColumnIfNotExists нужен для баз, созданных до появления колонки: CREATE TABLE IF NOT EXISTS их не трогает
+func (r *sqlRepository) addColumnIfNotExists(table, column, definition string) error {
+	switch r.dbType {
+	case "sqlite3":
+		var count int
+		err := r.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM pragma_table_info('%s') WHERE name = $1", table), column).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count != 0 {
+			return nil
+		}
+
+		_, err = r.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+		return err
+	case "postgres":
+		_, err := r.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, definition))
+		return err
+	default:
+		return fmt.Errorf("Unknown repository type")
+	}
+}
+
 type whereDescriptor struct {
 	ParamName  string
 	ParamValue string
@@ -206,3 +265,11 @@ func joinWhereOmitEmpty(whereDescriptors []whereDescriptor) (string, []interface
 
 	return finalWhere, whereArgs
 }
+
+// nullableID - пустой ID пишется в базу как NULL, чтобы в INTEGER колонке не было пустых строк
+func nullableID(ID string) interface{} {
+	if ID == "" {
+		return nil
+	}
+	return ID
+}
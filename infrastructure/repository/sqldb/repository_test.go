@@ -0,0 +1,215 @@
+package sqldb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mathbattle/infrastructure/blobstore"
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDbPath - база тестов пакета. Соединение с базой одно на процесс, см. gDB
+var testDbPath string
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "sqldb")
+	if err != nil {
+		panic(err)
+	}
+	testDbPath = filepath.Join(dir, "test.sqlite")
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// withTimeout падает, если f не закончилась за секунду: у sqlite одно соединение, и запрос,
+// сделанный при открытых rows, ждёт вечно
+func withTimeout(t *testing.T, f func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("query deadlocked")
+	}
+}
+
+func TestGetAllDoesNotDeadlock(t *testing.T) {
+	req := require.New(t)
+
+	users, err := NewUserRepository("sqlite3", testDbPath, mlog.Default())
+	req.Nil(err)
+	participants, err := NewParticipantRepository("sqlite3", testDbPath, users, mlog.Default())
+	req.Nil(err)
+	rounds, err := NewRoundRepository("sqlite3", testDbPath, mlog.Default())
+	req.Nil(err)
+
+	for i := 0; i < 2; i++ {
+		user, err := users.Store(mathbattle.User{TelegramID: int64(1000 + i)})
+		req.Nil(err)
+		_, err = participants.Store(mathbattle.Participant{User: user, Name: "Участник", Grade: 7})
+		req.Nil(err)
+		_, err = rounds.Store(mathbattle.NewRoundFromEnd(time.Now().Add(time.Hour)))
+		req.Nil(err)
+	}
+
+	var allUsers []mathbattle.User
+	var allParticipants []mathbattle.Participant
+	var allRounds []mathbattle.Round
+	var usersErr, participantsErr, roundsErr error
+	withTimeout(t, func() {
+		allUsers, usersErr = users.GetAll()
+		allParticipants, participantsErr = participants.GetAll()
+		allRounds, roundsErr = rounds.GetAll()
+	})
+
+	req.Nil(usersErr)
+	req.True(len(allUsers) >= 2)
+	req.Nil(participantsErr)
+	req.True(len(allParticipants) >= 2)
+	for _, participant := range allParticipants {
+		req.NotEmpty(participant.User.ID)
+	}
+	req.Nil(roundsErr)
+	req.True(len(allRounds) >= 2)
+}
+
+func TestUpdateKeepsIdentityReveals(t *testing.T) {
+	req := require.New(t)
+
+	rounds, err := NewRoundRepository("sqlite3", testDbPath, mlog.Default())
+	req.Nil(err)
+	round, err := rounds.Store(mathbattle.NewRoundFromEnd(time.Now().Add(time.Hour)))
+	req.Nil(err)
+
+	reveal := mathbattle.NewIdentityReveal("admin", "appeal")
+	req.Nil(rounds.AppendIdentityReveal(round.ID, reveal))
+	req.Nil(rounds.AppendIdentityReveal(round.ID, mathbattle.NewIdentityReveal("admin", "second appeal")))
+
+	// round прочитан до раскрытий
+	round.ResultsPublished = true
+	req.Nil(rounds.Update(round))
+
+	stored, err := rounds.Get(round.ID)
+	req.Nil(err)
+	req.True(stored.ResultsPublished)
+	req.Len(stored.IdentityReveals, 2)
+	req.Equal(reveal, stored.IdentityReveals[0])
+
+	req.Equal(mathbattle.ErrNotFound, rounds.AppendIdentityReveal("-1", reveal))
+}
+
+func TestMigrateLegacyParts(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "legacy")
+	req.Nil(err)
+	defer os.RemoveAll(dir)
+	blobs, err := blobstore.NewLocalStore(filepath.Join(dir, "blobs"))
+	req.Nil(err)
+
+	solutions, err := NewSolutionRepository("sqlite3", testDbPath, blobs, dir, mlog.Default())
+	req.Nil(err)
+	res, err := solutions.db.Exec(`INSERT INTO solutions (round_id, participant_id, problem_id, juri_comment, mark, parts, parts_mime_types)
+	VALUES (1, 2, 3, '', 0, '.jpg,.pdf', ',application/pdf')`)
+	req.Nil(err)
+	ID, err := res.LastInsertId()
+	req.Nil(err)
+
+	// Файла второй части нет - её пропускаем, остальное переносим
+	legacyPath := filepath.Join(dir, "1_2_3_0.jpg")
+	req.Nil(ioutil.WriteFile(legacyPath, []byte{1, 2, 3}, 0666))
+
+	solutions, err = NewSolutionRepository("sqlite3", testDbPath, blobs, dir, mlog.Default())
+	req.Nil(err)
+	solution, err := solutions.Get(fmt.Sprint(ID))
+	req.Nil(err)
+	req.Len(solution.Parts, 1)
+	req.Equal(mathbattle.MimeTypeJPEG, solution.Parts[0].MimeType)
+	exists, err := blobs.Exists(solution.Parts[0].Key)
+	req.Nil(err)
+	req.True(exists)
+	_, err = os.Stat(legacyPath)
+	req.True(os.IsNotExist(err))
+
+	// Перенесённая часть учтена в solution_part_keys: пока на неё ссылается другое решение, файл не удаляется
+	copied, err := solutions.Store(mathbattle.Solution{RoundID: "1", ParticipantID: "4", ProblemID: "3", Parts: solution.Parts})
+	req.Nil(err)
+	req.Nil(solutions.Delete(copied.ID))
+	exists, err = blobs.Exists(solution.Parts[0].Key)
+	req.Nil(err)
+	req.True(exists)
+
+	req.Nil(solutions.Delete(solution.ID))
+	exists, err = blobs.Exists(solution.Parts[0].Key)
+	req.Nil(err)
+	req.False(exists)
+}
+
+func TestMigrateLegacyProblemsSkipsMissingFiles(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "legacy")
+	req.Nil(err)
+	defer os.RemoveAll(dir)
+	blobs, err := blobstore.NewLocalStore(filepath.Join(dir, "blobs"))
+	req.Nil(err)
+
+	problems, err := NewProblemRepository("sqlite3", testDbPath, blobs, dir, mlog.Default())
+	req.Nil(err)
+	content := []byte("problem")
+	problem, err := problems.Store(mathbattle.Problem{MinGrade: 5, MaxGrade: 7, Extension: ".png", Content: content})
+	req.Nil(err)
+	_, err = problems.db.Exec(`INSERT INTO problems (sha256sum, grade_min, grade_max, extension)
+	VALUES ('0000000000000000000000000000000000000000000000000000000000000000', 5, 7, '.png')`)
+	req.Nil(err)
+
+	// Задача сохранена файлом до появления BlobStore
+	req.Nil(blobs.Delete(problem.Sha256sum))
+	legacyPath := filepath.Join(dir, "5_7_"+problem.Sha256sum+".png")
+	req.Nil(ioutil.WriteFile(legacyPath, content, 0666))
+
+	_, err = NewProblemRepository("sqlite3", testDbPath, blobs, dir, mlog.Default())
+	req.Nil(err)
+	exists, err := blobs.Exists(problem.Sha256sum)
+	req.Nil(err)
+	req.True(exists)
+	_, err = os.Stat(legacyPath)
+	req.True(os.IsNotExist(err))
+}
+
+func TestFillGradeYears(t *testing.T) {
+	req := require.New(t)
+
+	users, err := NewUserRepository("sqlite3", testDbPath, mlog.Default())
+	req.Nil(err)
+	participants, err := NewParticipantRepository("sqlite3", testDbPath, users, mlog.Default())
+	req.Nil(err)
+
+	user := mathbattle.User{TelegramID: 2000}
+	user.SetRegistrationTime(time.Date(2024, time.October, 1, 12, 0, 0, 0, time.UTC))
+	user, err = users.Store(user)
+	req.Nil(err)
+	// Записан до появления grade_year
+	participant, err := participants.Store(mathbattle.Participant{User: user, Name: "Участник", Grade: 7})
+	req.Nil(err)
+
+	participants, err = NewParticipantRepository("sqlite3", testDbPath, users, mlog.Default())
+	req.Nil(err)
+	participant, err = participants.GetByID(participant.ID)
+	req.Nil(err)
+	req.Equal(2024, participant.GradeYear)
+	req.Equal(7, participant.Grade)
+}
@@ -1,9 +1,11 @@
 package sqldb
 
 import (
+	"database/sql"
 	"fmt"
 	"strconv"
 
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
@@ -11,8 +13,8 @@ type ReviewRepository struct {
 	sqlRepository
 }
 
-func NewReviewRepository(dbType, connectionString string) (*ReviewRepository, error) {
-	sqlRepository, err := newSqlRepository(dbType, connectionString)
+func NewReviewRepository(dbType, connectionString string, logger *mlog.Logger) (*ReviewRepository, error) {
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -51,8 +53,11 @@ func (r *ReviewRepository) CreateTable() error {
 			mark INTEGER
 		)`
 	}
-	_, err := r.db.Exec(createStmt)
-	return err
+	if _, err := r.db.Exec(createStmt); err != nil {
+		return err
+	}
+
+	return r.addColumnIfNotExists("reviews", "reviewer_team_id", "INTEGER")
 }
 
 func (r *ReviewRepository) Store(review mathbattle.Review) (mathbattle.Review, error) {
@@ -60,8 +65,8 @@ func (r *ReviewRepository) Store(review mathbattle.Review) (mathbattle.Review, e
 
 	switch r.dbType {
 	case "sqlite3":
-		res, err := r.db.Exec("INSERT INTO reviews (reviewer_id, solution_id, content, juri_comment, mark) VALUES (?, ?, ?, ?, ?)",
-			review.ReviewerID, review.SolutionID, review.Content, review.JuriComment, review.Mark)
+		res, err := r.db.Exec("INSERT INTO reviews (reviewer_id, solution_id, content, juri_comment, mark, reviewer_team_id) VALUES (?, ?, ?, ?, ?, ?)",
+			review.ReviewerID, review.SolutionID, review.Content, review.JuriComment, review.Mark, nullableID(review.ReviewerTeamID))
 
 		if err != nil {
 			return result, err
@@ -72,14 +77,14 @@ func (r *ReviewRepository) Store(review mathbattle.Review) (mathbattle.Review, e
 		}
 		result.ID = strconv.FormatInt(insertedID, 10)
 	case "postgres":
-		query := "INSERT INTO reviews (reviewer_id, solution_id, content, juri_comment, mark) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+		query := "INSERT INTO reviews (reviewer_id, solution_id, content, juri_comment, mark, reviewer_team_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
 		stmt, err := r.db.Prepare(query)
 		if err != nil {
 			return result, err
 		}
 		defer stmt.Close()
 
-		err = stmt.QueryRow(review.ReviewerID, review.SolutionID, review.Content, review.JuriComment, review.Mark).Scan(&result.ID)
+		err = stmt.QueryRow(review.ReviewerID, review.SolutionID, review.Content, review.JuriComment, review.Mark, nullableID(review.ReviewerTeamID)).Scan(&result.ID)
 		if err != nil {
 			return result, err
 		}
@@ -96,7 +101,7 @@ func (r *ReviewRepository) Store(review mathbattle.Review) (mathbattle.Review, e
 func (r *ReviewRepository) getManyWhere(whereStr string, whereArgs ...interface{}) ([]mathbattle.Review, error) {
 	result := []mathbattle.Review{}
 
-	rows, err := r.db.Query("SELECT id, reviewer_id, solution_id, content, juri_comment, mark FROM reviews WHERE "+whereStr, whereArgs...)
+	rows, err := r.db.Query("SELECT id, reviewer_id, solution_id, content, juri_comment, mark, reviewer_team_id FROM reviews WHERE "+whereStr, whereArgs...)
 	if err != nil {
 		return result, err
 	}
@@ -104,10 +109,12 @@ func (r *ReviewRepository) getManyWhere(whereStr string, whereArgs ...interface{
 
 	for rows.Next() {
 		var cur mathbattle.Review
-		err = rows.Scan(&cur.ID, &cur.ReviewerID, &cur.SolutionID, &cur.Content, &cur.JuriComment, &cur.Mark)
+		var reviewerTeamID sql.NullString
+		err = rows.Scan(&cur.ID, &cur.ReviewerID, &cur.SolutionID, &cur.Content, &cur.JuriComment, &cur.Mark, &reviewerTeamID)
 		if err != nil {
 			return result, err
 		}
+		cur.ReviewerTeamID = reviewerTeamID.String
 
 		result = append(result, cur)
 	}
@@ -139,13 +146,20 @@ func (r *ReviewRepository) FindMany(reviewerID, solutionID string) ([]mathbattle
 	return r.getManyWhere(whereClause, whereArgs...)
 }
 
+func (r *ReviewRepository) FindManyByTeam(reviewerTeamID, solutionID string) ([]mathbattle.Review, error) {
+	whereClause, whereArgs := joinWhereOmitEmpty([]whereDescriptor{
+		{"reviewer_team_id", reviewerTeamID},
+		{"solution_id", solutionID}})
+	return r.getManyWhere(whereClause, whereArgs...)
+}
+
 func (r *ReviewRepository) Update(review mathbattle.Review) error {
 	_, err := r.db.Exec(`
 	UPDATE reviews SET
-		reviewer_id = $1, solution_id = $2, content = $3, juri_comment = $4, mark = $5
+		reviewer_id = $1, solution_id = $2, content = $3, juri_comment = $4, mark = $5, reviewer_team_id = $6
 	WHERE 
-		id = $6`,
-		review.ReviewerID, review.SolutionID, review.Content, review.JuriComment, review.Mark, review.ID)
+		id = $7`,
+		review.ReviewerID, review.SolutionID, review.Content, review.JuriComment, review.Mark, nullableID(review.ReviewerTeamID), review.ID)
 	return err
 }
 
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
@@ -14,8 +15,8 @@ type RoundRepository struct {
 	sqlRepository
 }
 
-func NewRoundRepository(dbType, connectionString string) (*RoundRepository, error) {
-	sqlRepository, err := newSqlRepository(dbType, connectionString)
+func NewRoundRepository(dbType, connectionString string, logger *mlog.Logger) (*RoundRepository, error) {
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +59,56 @@ func (r *RoundRepository) CreateTable() error {
 	}
 
 	_, err := r.db.Exec(createStmt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := r.addColumnIfNotExists("rounds", "results_published", "BOOL DEFAULT FALSE"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("rounds", "identity_reveals", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("rounds", "mode", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	return r.addColumnIfNotExists("rounds", "league", "TEXT DEFAULT ''")
+}
+
+type IdentityReveal struct {
+	Date   time.Time `json:"date"`
+	By     string    `json:"by"`
+	Reason string    `json:"reason"`
+}
+
+func serializeIdentityReveals(reveals []mathbattle.IdentityReveal) (string, error) {
+	local := []IdentityReveal{}
+	for _, item := range reveals {
+		local = append(local, IdentityReveal(item))
+	}
+
+	serialized, err := json.Marshal(local)
+	return string(serialized), err
+}
+
+func deserializeIdentityReveals(serialized string) ([]mathbattle.IdentityReveal, error) {
+	var result []mathbattle.IdentityReveal
+	if serialized == "" {
+		return result, nil
+	}
+
+	local := []IdentityReveal{}
+	if err := json.Unmarshal([]byte(serialized), &local); err != nil {
+		return result, err
+	}
+	for _, item := range local {
+		result = append(result, mathbattle.IdentityReveal(item))
+	}
+
+	return result, nil
 }
 
 type ProblemDescriptor struct {
@@ -107,15 +156,45 @@ func deserializeProblemsDistribution(input string) (mathbattle.RoundDistribution
 	return result, nil
 }
 
+type ReviewReassignment struct {
+	Date              time.Time `json:"date"`
+	SolutionID        string    `json:"solution_id"`
+	FromParticipantID string    `json:"from_participant_id"`
+	ToParticipantID   string    `json:"to_participant_id"`
+	Reason            string    `json:"reason"`
+}
+
+type ReviewOverride struct {
+	Action            string `json:"action"`
+	SolutionID        string `json:"solution_id"`
+	FromParticipantID string `json:"from_participant_id"`
+	ToParticipantID   string `json:"to_participant_id"`
+}
+
 type ReviewDistribution struct {
-	BetweenParticipants map[string][]string `json:"between_participants"`
-	ToOrganizers        []string            `json:"to_organizers"`
+	BetweenParticipants map[string][]string  `json:"between_participants"`
+	ToOrganizers        []string             `json:"to_organizers"`
+	Reassignments       []ReviewReassignment `json:"reassignments,omitempty"`
+	PreviewSeed         int64                `json:"preview_seed,omitempty"`
+	PreviewOverrides    []ReviewOverride     `json:"preview_overrides,omitempty"`
 }
 
 func serializeReviewDistribution(rd mathbattle.ReviewDistribution) (string, error) {
 	localRd := ReviewDistribution{
 		BetweenParticipants: rd.BetweenParticipants,
 		ToOrganizers:        rd.ToOrganizers,
+		PreviewSeed:         rd.PreviewSeed,
+	}
+	for _, item := range rd.Reassignments {
+		localRd.Reassignments = append(localRd.Reassignments, ReviewReassignment(item))
+	}
+	for _, item := range rd.PreviewOverrides {
+		localRd.PreviewOverrides = append(localRd.PreviewOverrides, ReviewOverride{
+			Action:            item.Action,
+			SolutionID:        item.SolutionID,
+			FromParticipantID: item.FromParticipantID,
+			ToParticipantID:   item.ToParticipantID,
+		})
 	}
 
 	serialized, err := json.Marshal(localRd)
@@ -129,10 +208,24 @@ func deserializeReviewDistribution(input string) (mathbattle.ReviewDistribution,
 		return mathbattle.ReviewDistribution{}, err
 	}
 
-	return mathbattle.ReviewDistribution{
+	result := mathbattle.ReviewDistribution{
 		BetweenParticipants: rd.BetweenParticipants,
 		ToOrganizers:        rd.ToOrganizers,
-	}, nil
+		PreviewSeed:         rd.PreviewSeed,
+	}
+	for _, item := range rd.Reassignments {
+		result.Reassignments = append(result.Reassignments, mathbattle.ReviewReassignment(item))
+	}
+	for _, item := range rd.PreviewOverrides {
+		result.PreviewOverrides = append(result.PreviewOverrides, mathbattle.ReassignOrder{
+			Action:            item.Action,
+			SolutionID:        item.SolutionID,
+			FromParticipantID: item.FromParticipantID,
+			ToParticipantID:   item.ToParticipantID,
+		})
+	}
+
+	return result, nil
 }
 
 func (r *RoundRepository) Store(round mathbattle.Round) (mathbattle.Round, error) {
@@ -144,14 +237,19 @@ func (r *RoundRepository) Store(round mathbattle.Round) (mathbattle.Round, error
 	if err != nil {
 		return round, err
 	}
+	serializedIdentityReveals, err := serializeIdentityReveals(round.IdentityReveals)
+	if err != nil {
+		return round, err
+	}
 
 	switch r.dbType {
 	case "sqlite3":
 		res, err := r.db.Exec(`INSERT INTO rounds (solve_start, solve_end, review_start, review_end,
-		problems_distribution, solutions_distribution) VALUES ($1,$2,$3,$4,$5,$6)`,
+		problems_distribution, solutions_distribution, results_published, identity_reveals, mode, league) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
 			round.GetSolveStartDate(), round.GetSolveEndDate(),
 			round.GetReviewStartDate(), round.GetReviewEndDate(),
-			serializedRoundDistribution, serializedSolutionDistribution)
+			serializedRoundDistribution, serializedSolutionDistribution,
+			round.ResultsPublished, serializedIdentityReveals, round.Mode, round.League)
 		if err != nil {
 			return round, err
 		}
@@ -163,7 +261,7 @@ func (r *RoundRepository) Store(round mathbattle.Round) (mathbattle.Round, error
 		round.ID = strconv.FormatInt(roundID, 10)
 	case "postgres":
 		query := `INSERT INTO rounds (solve_start, solve_end, review_start, review_end,
-		problems_distribution, solutions_distribution) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`
+		problems_distribution, solutions_distribution, results_published, identity_reveals, mode, league) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING id`
 		stmt, err := r.db.Prepare(query)
 		if err != nil {
 			return round, err
@@ -172,7 +270,8 @@ func (r *RoundRepository) Store(round mathbattle.Round) (mathbattle.Round, error
 
 		err = stmt.QueryRow(round.GetSolveStartDate(), round.GetSolveEndDate(),
 			round.GetReviewStartDate(), round.GetReviewEndDate(),
-			serializedRoundDistribution, serializedSolutionDistribution).Scan(&round.ID)
+			serializedRoundDistribution, serializedSolutionDistribution,
+			round.ResultsPublished, serializedIdentityReveals, round.Mode, round.League).Scan(&round.ID)
 		if err != nil {
 			return round, err
 		}
@@ -185,12 +284,16 @@ func (r *RoundRepository) Store(round mathbattle.Round) (mathbattle.Round, error
 func (r *RoundRepository) getWhere(whereStr string, whereArgs ...interface{}) (mathbattle.Round, error) {
 	result := mathbattle.Round{}
 	res := r.db.QueryRow(`SELECT id, solve_start, solve_end, review_start, review_end, 
-	problems_distribution, solutions_distribution FROM rounds WHERE `+whereStr, whereArgs...)
+	problems_distribution, solutions_distribution, results_published, identity_reveals, mode, league FROM rounds WHERE `+whereStr, whereArgs...)
 	var problemsDistribution string
 	var solutionsDistribution string
+	var resultsPublished sql.NullBool
+	var identityReveals sql.NullString
+	var mode sql.NullString
+	var league sql.NullString
 	err := res.Scan(&result.ID, &result.SolveStartDate, &result.SolveEndDate,
 		&result.ReviewStartDate, &result.ReviewEndDate,
-		&problemsDistribution, &solutionsDistribution)
+		&problemsDistribution, &solutionsDistribution, &resultsPublished, &identityReveals, &mode, &league)
 	result.SetSolveStartDate(result.SolveStartDate)
 	result.SetSolveEndDate(result.SolveEndDate)
 	result.SetReviewStartDate(result.ReviewStartDate)
@@ -212,6 +315,14 @@ func (r *RoundRepository) getWhere(whereStr string, whereArgs ...interface{}) (m
 		return result, err
 	}
 
+	result.ResultsPublished = resultsPublished.Bool
+	result.Mode = mathbattle.RoundMode(mode.String)
+	result.League = league.String
+	result.IdentityReveals, err = deserializeIdentityReveals(identityReveals.String)
+	if err != nil {
+		return result, err
+	}
+
 	return result, nil
 }
 
@@ -221,19 +332,12 @@ func (r *RoundRepository) Get(ID string) (mathbattle.Round, error) {
 
 func (r *RoundRepository) GetAll() ([]mathbattle.Round, error) {
 	result := []mathbattle.Round{}
-	rows, err := r.db.Query("SELECT id FROM rounds")
+	IDs, err := selectIDs(r.db, "SELECT id FROM rounds")
 	if err != nil {
 		return result, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var curID string
-		err = rows.Scan(&curID)
-		if err != nil {
-			return result, err
-		}
 
+	for _, curID := range IDs {
 		cur, err := r.Get(curID)
 		if err != nil {
 			return result, err
@@ -245,8 +349,8 @@ func (r *RoundRepository) GetAll() ([]mathbattle.Round, error) {
 	return result, nil
 }
 
-func (r *RoundRepository) GetRunning() (mathbattle.Round, error) {
-	round, err := r.GetSolveRunning()
+func (r *RoundRepository) GetRunning(league string) (mathbattle.Round, error) {
+	round, err := r.GetSolveRunning(league)
 	if err == nil {
 		return round, nil
 	}
@@ -255,7 +359,7 @@ func (r *RoundRepository) GetRunning() (mathbattle.Round, error) {
 		return mathbattle.Round{}, err
 	}
 
-	round, err = r.GetReviewPending()
+	round, err = r.GetReviewPending(league)
 	if err == nil {
 		return round, nil
 	}
@@ -264,30 +368,47 @@ func (r *RoundRepository) GetRunning() (mathbattle.Round, error) {
 		return mathbattle.Round{}, err
 	}
 
-	return r.GetReviewRunning()
+	return r.GetReviewRunning(league)
 }
 
-func (r *RoundRepository) GetSolveRunning() (mathbattle.Round, error) {
-	return r.getWhere("solve_end = $1 OR solve_end >= $2",
-		time.Time{}, time.Now().Round(0).UTC())
+func (r *RoundRepository) GetSolveRunning(league string) (mathbattle.Round, error) {
+	return r.getWhere("(solve_end = $1 OR solve_end >= $2) AND league = $3",
+		time.Time{}, time.Now().Round(0).UTC(), league)
 }
 
-func (r *RoundRepository) GetReviewPending() (mathbattle.Round, error) {
-	return r.getWhere("solve_end <= $1 AND review_start = $2",
-		time.Now().Round(0).UTC(), time.Time{})
+func (r *RoundRepository) GetReviewPending(league string) (mathbattle.Round, error) {
+	return r.getWhere("solve_end <= $1 AND review_start = $2 AND league = $3",
+		time.Now().Round(0).UTC(), time.Time{}, league)
 }
 
-func (r *RoundRepository) GetReviewRunning() (mathbattle.Round, error) {
+func (r *RoundRepository) GetReviewRunning(league string) (mathbattle.Round, error) {
 	return r.getWhere(`solve_end <= $1 AND
 			(review_start != $2 AND review_start <= $3) AND
-			(review_end = $4 OR review_end >= $5)`,
+			(review_end = $4 OR review_end >= $5) AND league = $6`,
 		time.Now().Round(0).UTC(),
 		time.Time{}, time.Now().Round(0).UTC(),
-		time.Time{}, time.Now().Round(0).UTC())
+		time.Time{}, time.Now().Round(0).UTC(), league)
 }
 
-func (r *RoundRepository) GetLast() (mathbattle.Round, error) {
-	res := r.db.QueryRow("SELECT id FROM rounds ORDER BY ID DESC LIMIT 1")
+func (r *RoundRepository) GetAllRunning() ([]mathbattle.Round, error) {
+	result := []mathbattle.Round{}
+
+	all, err := r.GetAll()
+	if err != nil {
+		return result, err
+	}
+
+	for _, round := range all {
+		if round.IsActive() {
+			result = append(result, round)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *RoundRepository) GetLast(league string) (mathbattle.Round, error) {
+	res := r.db.QueryRow("SELECT id FROM rounds WHERE league = $1 ORDER BY ID DESC LIMIT 1", league)
 
 	var ID string
 	err := res.Scan(&ID)
@@ -310,12 +431,47 @@ func (r *RoundRepository) Update(round mathbattle.Round) error {
 	if err != nil {
 		return err
 	}
+	// identity_reveals не трогаем, см. AppendIdentityReveal
 	_, err = r.db.Exec(`UPDATE rounds SET solve_start = $1, solve_end = $2, review_start = $3, review_end = $4,
-	problems_distribution = $5, solutions_distribution = $6 WHERE id = $7`, round.GetSolveStartDate(), round.GetSolveEndDate(),
-		round.GetReviewStartDate(), round.GetReviewEndDate(), serializedRoundDistribution, serializedSolutionDistribution, round.ID)
+	problems_distribution = $5, solutions_distribution = $6, results_published = $7, mode = $8,
+	league = $9 WHERE id = $10`,
+		round.GetSolveStartDate(), round.GetSolveEndDate(), round.GetReviewStartDate(), round.GetReviewEndDate(),
+		serializedRoundDistribution, serializedSolutionDistribution, round.ResultsPublished, round.Mode,
+		round.League, round.ID)
 	return err
 }
 
+func (r *RoundRepository) AppendIdentityReveal(roundID string, reveal mathbattle.IdentityReveal) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var identityReveals sql.NullString
+	err = tx.QueryRow("SELECT identity_reveals FROM rounds WHERE id = $1", roundID).Scan(&identityReveals)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return mathbattle.ErrNotFound
+		}
+		return err
+	}
+
+	reveals, err := deserializeIdentityReveals(identityReveals.String)
+	if err != nil {
+		return err
+	}
+	serialized, err := serializeIdentityReveals(append(reveals, reveal))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE rounds SET identity_reveals = $1 WHERE id = $2", serialized, roundID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (r *RoundRepository) Delete(ID string) error {
 	_, err := r.db.Exec("DELETE FROM rounds WHERE id = $1", ID)
 	if err != nil {
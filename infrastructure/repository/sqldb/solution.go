@@ -1,43 +1,54 @@
 package sqldb
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"mime"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
 type SolutionRepository struct {
 	sqlRepository
-	solutionFolder string
+	blobs mathbattle.BlobStore
+	// Папка, куда части решений сохранялись до появления BlobStore. Оттуда они переносятся при запуске
+	legacyFolder string
 }
 
-func NewSolutionRepository(dbType, connectionString, solutionPath string) (*SolutionRepository, error) {
-	sqlRepository, err := newSqlRepository(dbType, connectionString)
+func NewSolutionRepository(dbType, connectionString string, blobs mathbattle.BlobStore, legacyPath string,
+	logger *mlog.Logger) (*SolutionRepository, error) {
+
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := os.Stat(solutionPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(solutionPath, 0777); err != nil {
-			return nil, err
-		}
-	}
-
 	result := &SolutionRepository{
-		sqlRepository:  sqlRepository,
-		solutionFolder: solutionPath,
+		sqlRepository: sqlRepository,
+		blobs:         blobs,
+		legacyFolder:  legacyPath,
 	}
 
 	if err := result.CreateTable(); err != nil {
 		return nil, err
 	}
 
+	if err := result.migrateLegacyParts(); err != nil {
+		return nil, err
+	}
+
+	if err := result.migratePartKeys(); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -68,37 +79,381 @@ func (r *SolutionRepository) CreateTable() error {
 	}
 
 	_, err := r.db.Exec(createStmt)
+	if err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("solutions", "parts_mime_types", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("solutions", "pseudonym", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("solutions", "parts_blobs", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("solutions", "team_id", "INTEGER"); err != nil {
+		return err
+	}
+
+	// Ключи частей в BlobStore по решениям, по ним removeParts узнаёт, нужен ли файл другим решениям
+	_, err = r.db.Exec(`CREATE TABLE IF NOT EXISTS solution_part_keys (
+		solution_id INTEGER,
+		blob_key TEXT
+	)`)
+	if err != nil {
+		return err
+	}
 
+	_, err = r.db.Exec("CREATE INDEX IF NOT EXISTS solution_part_keys_blob_key ON solution_part_keys (blob_key)")
 	return err
 }
 
-func (r *SolutionRepository) getPartPath(solution mathbattle.Solution, i int, extension string) string {
+// partsColumns - расширения и MIME типы частей через запятую, сами части лежат в файлах
+func partsColumns(parts []mathbattle.Image) (string, string) {
+	extensions := []string{}
+	mimeTypes := []string{}
+	for _, part := range parts {
+		extensions = append(extensions, part.Extension)
+		mimeTypes = append(mimeTypes, part.MimeType)
+	}
+
+	return strings.Join(extensions, ","), strings.Join(mimeTypes, ",")
+}
+
+// PartBlobs - где в BlobStore лежит часть решения, её исходник и превью
+type PartBlobs struct {
+	Key               string `json:"key"`
+	Extension         string `json:"extension"`
+	MimeType          string `json:"mime_type"`
+	OriginalKey       string `json:"original_key,omitempty"`
+	OriginalExtension string `json:"original_extension,omitempty"`
+	OriginalMimeType  string `json:"original_mime_type,omitempty"`
+	ThumbnailKey      string `json:"thumbnail_key,omitempty"`
+}
+
+func serializePartsBlobs(parts []mathbattle.Image) (string, error) {
+	local := []PartBlobs{}
+	for _, part := range parts {
+		item := PartBlobs{
+			Key:          part.Key,
+			Extension:    part.Extension,
+			MimeType:     part.MimeType,
+			ThumbnailKey: part.ThumbnailKey,
+		}
+		if part.Original != nil {
+			item.OriginalKey = part.Original.Key
+			item.OriginalExtension = part.Original.Extension
+			item.OriginalMimeType = part.Original.MimeType
+		}
+		local = append(local, item)
+	}
+
+	serialized, err := json.Marshal(local)
+	return string(serialized), err
+}
+
+// legacyPartMimeType - MIME тип части, сохранённой до появления MIME типов. Тогда принимались только фотографии
+func legacyPartMimeType(extension string) string {
+	mimeType := mime.TypeByExtension(extension)
+	if strings.HasPrefix(mimeType, "image/") {
+		return strings.Split(mimeType, ";")[0]
+	}
+	return mathbattle.MimeTypeJPEG
+}
+
+func deserializePartsBlobs(serialized string) ([]mathbattle.Image, error) {
+	var result []mathbattle.Image
+	if serialized == "" {
+		return result, nil
+	}
+
+	local := []PartBlobs{}
+	if err := json.Unmarshal([]byte(serialized), &local); err != nil {
+		return result, err
+	}
+	for _, item := range local {
+		part := mathbattle.Image{
+			Key:          item.Key,
+			Extension:    item.Extension,
+			MimeType:     item.MimeType,
+			ThumbnailKey: item.ThumbnailKey,
+		}
+		// Перенесены из файлов до появления MIME типов
+		if part.MimeType == "" {
+			part.MimeType = legacyPartMimeType(part.Extension)
+		}
+		if item.OriginalKey != "" {
+			part.Original = &mathbattle.Image{
+				Key:       item.OriginalKey,
+				Extension: item.OriginalExtension,
+				MimeType:  item.OriginalMimeType,
+			}
+		}
+		result = append(result, part)
+	}
+
+	return result, nil
+}
+
+// storeParts кладёт в BlobStore содержимое частей, у которых ещё нет ключа
+func (r *SolutionRepository) storeParts(parts []mathbattle.Image) ([]mathbattle.Image, error) {
+	result := []mathbattle.Image{}
+	for _, part := range parts {
+		var err error
+		if part.Key == "" {
+			if part.Key, err = r.blobs.Put(bytes.NewReader(part.Content)); err != nil {
+				return result, err
+			}
+		}
+
+		if part.Original != nil && part.Original.Key == "" {
+			original := *part.Original
+			if original.Key, err = r.blobs.Put(bytes.NewReader(original.Content)); err != nil {
+				return result, err
+			}
+			part.Original = &original
+		}
+
+		if len(part.Thumbnail) > 0 && part.ThumbnailKey == "" {
+			if part.ThumbnailKey, err = r.blobs.Put(bytes.NewReader(part.Thumbnail)); err != nil {
+				return result, err
+			}
+		}
+
+		result = append(result, part)
+	}
+
+	return result, nil
+}
+
+// partKeys - ключи в BlobStore частей, их исходников и превью
+func partKeys(parts []mathbattle.Image) []string {
+	keys := []string{}
+	for _, part := range parts {
+		keys = append(keys, part.Key, part.ThumbnailKey)
+		if part.Original != nil {
+			keys = append(keys, part.Original.Key)
+		}
+	}
+
+	result := []string{}
+	for _, key := range keys {
+		if key != "" {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// setPartKeys переписывает solution_part_keys решения, вызывается при каждом изменении его частей
+func (r *SolutionRepository) setPartKeys(solutionID string, parts []mathbattle.Image) error {
+	if _, err := r.db.Exec("DELETE FROM solution_part_keys WHERE solution_id = $1", solutionID); err != nil {
+		return err
+	}
+
+	for _, key := range partKeys(parts) {
+		_, err := r.db.Exec("INSERT INTO solution_part_keys (solution_id, blob_key) VALUES ($1, $2)", solutionID, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migratePartKeys заполняет solution_part_keys для решений, сохранённых до её появления
+func (r *SolutionRepository) migratePartKeys() error {
+	IDs, err := selectIDs(r.db, `SELECT id FROM solutions WHERE parts_blobs != '' AND parts_blobs != '[]'
+	AND id NOT IN (SELECT solution_id FROM solution_part_keys)`)
+	if err != nil {
+		return err
+	}
+
+	for _, ID := range IDs {
+		solution, err := r.Get(ID)
+		if err != nil {
+			return err
+		}
+		if err := r.setPartKeys(solution.ID, solution.Parts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeParts удаляет файлы частей, если на них не ссылаются другие решения: одинаковые файлы хранятся один раз
+func (r *SolutionRepository) removeParts(solutionID string, parts []mathbattle.Image) error {
+	for _, key := range partKeys(parts) {
+		var references int
+		err := r.db.QueryRow("SELECT COUNT(*) FROM solution_part_keys WHERE solution_id != $1 AND blob_key = $2",
+			solutionID, key).Scan(&references)
+		if err != nil {
+			return err
+		}
+		if references > 0 {
+			continue
+		}
+
+		if err := r.blobs.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SolutionRepository) getLegacyPartPath(solution mathbattle.Solution, i int, extension string) string {
 	fileName := fmt.Sprintf("%s_%s_%s_%d%s", solution.RoundID, solution.ParticipantID,
 		solution.ProblemID, i, extension)
-	result := filepath.Join(r.solutionFolder, fileName)
-	return result
+	return filepath.Join(r.legacyFolder, fileName)
 }
 
-func (r *SolutionRepository) Store(solution mathbattle.Solution) (mathbattle.Solution, error) {
-	result := solution
+func (r *SolutionRepository) putLegacyFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return r.blobs.Put(f)
+}
+
+// migrateLegacyPart переносит в BlobStore часть, сохранённую файлом <round>_<participant>_<problem>_<i><ext>,
+// вместе с исходником <...>_original<ext> и превью <...>_thumbnail.jpg, если они есть.
+// Возвращает перенесённые файлы, их удаляют после сохранения решения
+func (r *SolutionRepository) migrateLegacyPart(solution mathbattle.Solution, i int, part mathbattle.Image) (mathbattle.Image, []string, error) {
+	var err error
+	path := r.getLegacyPartPath(solution, i, part.Extension)
+	part.Key, err = r.putLegacyFile(path)
+	if err != nil {
+		return part, nil, err
+	}
+	paths := []string{path}
 
-	extensions := ""
-	if len(solution.Parts) > 0 {
-		for i := 0; i < len(solution.Parts); i++ {
-			partPath := r.getPartPath(solution, i, solution.Parts[i].Extension)
-			err := ioutil.WriteFile(partPath, solution.Parts[i].Content, 0666)
+	originals, err := filepath.Glob(r.getLegacyPartPath(solution, i, "_original.*"))
+	if err != nil {
+		return part, nil, err
+	}
+	if len(originals) > 0 {
+		key, err := r.putLegacyFile(originals[0])
+		if err != nil {
+			return part, nil, err
+		}
+		part.Original = &mathbattle.Image{Key: key, Extension: filepath.Ext(originals[0])}
+		paths = append(paths, originals[0])
+	}
+
+	thumbnailPath := r.getLegacyPartPath(solution, i, "_thumbnail.jpg")
+	if _, err := os.Stat(thumbnailPath); err == nil {
+		if part.ThumbnailKey, err = r.putLegacyFile(thumbnailPath); err != nil {
+			return part, nil, err
+		}
+		paths = append(paths, thumbnailPath)
+	}
+
+	return part, paths, nil
+}
+
+// migrateLegacyParts - решения, сохранённые до появления BlobStore, знают только расширения и MIME типы частей
+func (r *SolutionRepository) migrateLegacyParts() error {
+	rows, err := r.db.Query(`SELECT id, round_id, participant_id, problem_id, parts, parts_mime_types FROM solutions
+	WHERE parts != '' AND (parts_blobs IS NULL OR parts_blobs = '')`)
+	if err != nil {
+		return err
+	}
+
+	legacy := []mathbattle.Solution{}
+	for rows.Next() {
+		var cur mathbattle.Solution
+		var partsExtensions string
+		var partsMimeTypes sql.NullString
+		if err := rows.Scan(&cur.ID, &cur.RoundID, &cur.ParticipantID, &cur.ProblemID, &partsExtensions, &partsMimeTypes); err != nil {
+			rows.Close()
+			return err
+		}
+
+		extensions := strings.Split(partsExtensions, ",")
+		// У решений, сохранённых до появления MIME типов, их нет - это фотографии
+		mimeTypes := strings.Split(partsMimeTypes.String, ",")
+		for i := 0; i < len(extensions); i++ {
+			part := mathbattle.Image{Extension: extensions[i]}
+			if len(mimeTypes) == len(extensions) {
+				part.MimeType = mimeTypes[i]
+			}
+			if part.MimeType == "" {
+				part.MimeType = legacyPartMimeType(part.Extension)
+			}
+			cur.Parts = append(cur.Parts, part)
+		}
+		legacy = append(legacy, cur)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, solution := range legacy {
+		parts := []mathbattle.Image{}
+		migrated := []string{}
+		for i := range solution.Parts {
+			part, paths, err := r.migrateLegacyPart(solution, i, solution.Parts[i])
+			if os.IsNotExist(err) {
+				// Файл потерян, переносить нечего. Остальные части и решения переносим
+				r.logger.Warnf("Solution %s part %d file is missing, skipping it, error: %v", solution.ID, i, err)
+				continue
+			}
 			if err != nil {
-				return result, err
+				return err
+			}
+			parts = append(parts, part)
+			migrated = append(migrated, paths...)
+		}
+
+		partsBlobs, err := serializePartsBlobs(parts)
+		if err != nil {
+			return err
+		}
+		if _, err := r.db.Exec("UPDATE solutions SET parts_blobs = $1 WHERE id = $2", partsBlobs, solution.ID); err != nil {
+			return err
+		}
+		r.logger.Infof("Solution %s parts moved to blob store", solution.ID)
+
+		for _, path := range migrated {
+			if err := os.Remove(path); err != nil {
+				r.logger.Errorf("Failed to remove migrated file %s, error: %v", path, err)
 			}
-			extensions = extensions + solution.Parts[i].Extension + ","
 		}
-		extensions = extensions[:len(extensions)-1]
+	}
+
+	return nil
+}
+
+func (r *SolutionRepository) Store(solution mathbattle.Solution) (mathbattle.Solution, error) {
+	result := solution
+
+	parts, err := r.storeParts(solution.Parts)
+	if err != nil {
+		return result, err
+	}
+	if len(parts) > 0 {
+		result.Parts = parts
+	}
+	extensions, mimeTypes := partsColumns(parts)
+	partsBlobs, err := serializePartsBlobs(parts)
+	if err != nil {
+		return result, err
 	}
 
 	switch r.dbType {
 	case "sqlite3":
-		res, err := r.db.Exec("INSERT INTO solutions (round_id, participant_id, problem_id, juri_comment, mark, parts) VALUES (?,?,?,?,?,?)",
-			solution.RoundID, solution.ParticipantID, solution.ProblemID, solution.JuriComment, extensions)
+		res, err := r.db.Exec("INSERT INTO solutions (round_id, participant_id, problem_id, juri_comment, mark, parts, parts_mime_types, pseudonym, parts_blobs, team_id) VALUES (?,?,?,?,?,?,?,?,?,?)",
+			solution.RoundID, solution.ParticipantID, solution.ProblemID, solution.JuriComment, solution.Mark, extensions, mimeTypes, solution.Pseudonym, partsBlobs,
+			nullableID(solution.TeamID))
 		if err != nil {
 			return result, err
 		}
@@ -108,32 +463,31 @@ func (r *SolutionRepository) Store(solution mathbattle.Solution) (mathbattle.Sol
 			return result, err
 		}
 		result.ID = strconv.FormatInt(newID, 10)
-
-		return result, nil
 	case "postgres":
-		query := "INSERT INTO solutions (round_id, participant_id, problem_id, juri_comment, mark, parts) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id"
+		query := "INSERT INTO solutions (round_id, participant_id, problem_id, juri_comment, mark, parts, parts_mime_types, pseudonym, parts_blobs, team_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING id"
 		stmt, err := r.db.Prepare(query)
 		if err != nil {
 			return result, err
 		}
 		defer stmt.Close()
 
-		err = stmt.QueryRow(solution.RoundID, solution.ParticipantID, solution.ProblemID, solution.JuriComment, solution.Mark, extensions).Scan(&result.ID)
+		err = stmt.QueryRow(solution.RoundID, solution.ParticipantID, solution.ProblemID, solution.JuriComment, solution.Mark, extensions, mimeTypes, solution.Pseudonym, partsBlobs,
+			nullableID(solution.TeamID)).Scan(&result.ID)
 		if err != nil {
 			return result, err
 		}
-
-		return result, nil
 	default:
 		return result, fmt.Errorf("Unknown dbtype")
 	}
+
+	return result, r.setPartKeys(result.ID, parts)
 }
 
 func (r *SolutionRepository) getManyWhere(whereStr string, whereArgs ...interface{}) ([]mathbattle.Solution, error) {
 	result := []mathbattle.Solution{}
 
 	rows, err := r.db.Query(`
-	SELECT id, round_id, participant_id, problem_id, juri_comment, mark, parts
+	SELECT id, round_id, participant_id, problem_id, juri_comment, mark, pseudonym, parts_blobs, team_id
 	FROM solutions
 	WHERE `+whereStr, whereArgs...)
 	if err != nil {
@@ -143,25 +497,20 @@ func (r *SolutionRepository) getManyWhere(whereStr string, whereArgs ...interfac
 
 	for rows.Next() {
 		var cur mathbattle.Solution
-		var partsExtensions string
-		err := rows.Scan(&cur.ID, &cur.RoundID, &cur.ParticipantID, &cur.ProblemID, &cur.JuriComment, &cur.Mark, &partsExtensions)
+		var pseudonym sql.NullString
+		var partsBlobs sql.NullString
+		var teamID sql.NullString
+		err := rows.Scan(&cur.ID, &cur.RoundID, &cur.ParticipantID, &cur.ProblemID, &cur.JuriComment, &cur.Mark, &pseudonym, &partsBlobs, &teamID)
 		if err != nil {
 			return result, err
 		}
+		cur.Pseudonym = pseudonym.String
+		cur.TeamID = teamID.String
 
-		if len(partsExtensions) != 0 {
-			extensions := strings.Split(partsExtensions, ",")
-			for i := 0; i < len(extensions); i++ {
-				partPath := r.getPartPath(cur, i, extensions[i])
-				content, err := ioutil.ReadFile(partPath)
-				if err != nil {
-					return result, err
-				}
-				cur.Parts = append(cur.Parts, mathbattle.Image{
-					Extension: extensions[i],
-					Content:   content,
-				})
-			}
+		// Содержимое частей не читаем - оно может быть большим, его читают через OpenPart
+		cur.Parts, err = deserializePartsBlobs(partsBlobs.String)
+		if err != nil {
+			return result, err
 		}
 
 		result = append(result, cur)
@@ -201,6 +550,15 @@ func (r *SolutionRepository) FindMany(roundID string, participantID string, prob
 	return r.getManyWhere(whereClause, whereArgs...)
 }
 
+func (r *SolutionRepository) FindManyByTeam(roundID string, teamID string, problemID string) ([]mathbattle.Solution, error) {
+	whereClause, whereArgs := joinWhereOmitEmpty([]whereDescriptor{
+		{"round_id", roundID},
+		{"team_id", teamID},
+		{"problem_id", problemID},
+	})
+	return r.getManyWhere(whereClause, whereArgs...)
+}
+
 func (r *SolutionRepository) FindOrCreate(roundID string, participantID string, problemID string) (mathbattle.Solution, error) {
 	s, err := r.Find(roundID, participantID, problemID)
 	if err == nil {
@@ -224,47 +582,61 @@ func (r *SolutionRepository) AppendPart(ID string, item mathbattle.Image) error
 		return err
 	}
 
-	newPartID := len(solution.Parts)
-	solution.Parts = append(solution.Parts, item)
-	partPath := r.getPartPath(solution, newPartID, item.Extension)
-	err = ioutil.WriteFile(partPath, item.Content, 0666)
+	stored, err := r.storeParts([]mathbattle.Image{item})
 	if err != nil {
 		return err
 	}
+	solution.Parts = append(solution.Parts, stored...)
+
+	return r.updateParts(solution)
+}
 
-	extensions := ""
-	for i := 0; i < len(solution.Parts); i++ {
-		extensions = extensions + solution.Parts[i].Extension + ","
+func (r *SolutionRepository) updateParts(solution mathbattle.Solution) error {
+	extensions, mimeTypes := partsColumns(solution.Parts)
+	partsBlobs, err := serializePartsBlobs(solution.Parts)
+	if err != nil {
+		return err
 	}
-	extensions = extensions[:len(extensions)-1]
 
-	intID, err := strconv.Atoi(ID)
+	intID, err := strconv.Atoi(solution.ID)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("UPDATE solutions SET parts = $1, parts_mime_types = $2, parts_blobs = $3 WHERE id = $4",
+		extensions, mimeTypes, partsBlobs, intID)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Exec("UPDATE solutions SET parts = $1 WHERE id = $2", extensions, intID)
 
-	return err
+	return r.setPartKeys(solution.ID, solution.Parts)
+}
+
+func (r *SolutionRepository) OpenPart(key string) (io.ReadCloser, error) {
+	return r.blobs.Open(key)
 }
 
 func (r *SolutionRepository) Update(solution mathbattle.Solution) error {
-	extensions := ""
-	for i := 0; i < len(solution.Parts); i++ {
-		extensions = extensions + solution.Parts[i].Extension + ","
+	parts, err := r.storeParts(solution.Parts)
+	if err != nil {
+		return err
+	}
+	extensions, mimeTypes := partsColumns(parts)
+	partsBlobs, err := serializePartsBlobs(parts)
+	if err != nil {
+		return err
 	}
-	extensions = extensions[:len(extensions)-1]
 
-	_, err := r.db.Exec(`
+	_, err = r.db.Exec(`
 	UPDATE solutions
-	SET round_id = $1, participant_id = $2, problem_id = $3, juri_comment=$4, mark=$5, parts = $6
-	WHERE id = $7`,
-		solution.RoundID, solution.ParticipantID, solution.ProblemID, solution.JuriComment, solution.Mark, extensions, solution.ID)
-
-	if err == sql.ErrNoRows {
-		return mathbattle.ErrNotFound
+	SET round_id = $1, participant_id = $2, problem_id = $3, juri_comment=$4, mark=$5, parts = $6, parts_mime_types = $7, pseudonym = $8, parts_blobs = $9, team_id = $10
+	WHERE id = $11`,
+		solution.RoundID, solution.ParticipantID, solution.ProblemID, solution.JuriComment, solution.Mark, extensions, mimeTypes, solution.Pseudonym, partsBlobs,
+		nullableID(solution.TeamID), solution.ID)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return r.setPartKeys(solution.ID, parts)
 }
 
 func (r *SolutionRepository) Delete(ID string) error {
@@ -273,15 +645,13 @@ func (r *SolutionRepository) Delete(ID string) error {
 		return err
 	}
 
-	for i := 0; i < len(solution.Parts); i++ {
-		partPath := r.getPartPath(solution, i, solution.Parts[i].Extension)
-		err = os.Remove(partPath)
-		if err != nil {
-			return err
-		}
-	}
-
 	_, err = r.db.Exec("DELETE FROM solutions WHERE id = $1", solution.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := r.db.Exec("DELETE FROM solution_part_keys WHERE solution_id = $1", solution.ID); err != nil {
+		return err
+	}
 
-	return err
+	return r.removeParts(solution.ID, solution.Parts)
 }
@@ -0,0 +1,186 @@
+package sqldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+type TeamRepository struct {
+	sqlRepository
+}
+
+func NewTeamRepository(dbType, connectionString string, logger *mlog.Logger) (*TeamRepository, error) {
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TeamRepository{
+		sqlRepository: sqlRepository,
+	}
+
+	if err := result.CreateTable(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *TeamRepository) CreateTable() error {
+	var createStmt string
+
+	switch r.dbType {
+	case "sqlite3":
+		createStmt = `CREATE TABLE IF NOT EXISTS teams (
+			id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+			name VARCHAR(100),
+			captain_id INTEGER,
+			member_ids TEXT,
+			invite_code VARCHAR(32) UNIQUE
+		)`
+	case "postgres":
+		createStmt = `CREATE TABLE IF NOT EXISTS teams (
+			id SERIAL UNIQUE,
+			name VARCHAR(100),
+			captain_id INTEGER,
+			member_ids TEXT,
+			invite_code VARCHAR(32) UNIQUE
+		)`
+	}
+
+	_, err := r.db.Exec(createStmt)
+	return err
+}
+
+func (r *TeamRepository) Store(team mathbattle.Team) (mathbattle.Team, error) {
+	result := team
+
+	memberIDs, err := json.Marshal(team.MemberIDs)
+	if err != nil {
+		return result, err
+	}
+
+	switch r.dbType {
+	case "sqlite3":
+		res, err := r.db.Exec("INSERT INTO teams (name, captain_id, member_ids, invite_code) VALUES (?, ?, ?, ?)",
+			team.Name, team.CaptainID, string(memberIDs), team.InviteCode)
+		if err != nil {
+			return result, err
+		}
+
+		insertedID, err := res.LastInsertId()
+		if err != nil {
+			return result, err
+		}
+		result.ID = strconv.FormatInt(insertedID, 10)
+
+		return result, nil
+	case "postgres":
+		query := "INSERT INTO teams (name, captain_id, member_ids, invite_code) VALUES ($1, $2, $3, $4) RETURNING id"
+		stmt, err := r.db.Prepare(query)
+		if err != nil {
+			return result, err
+		}
+		defer stmt.Close()
+
+		err = stmt.QueryRow(team.Name, team.CaptainID, string(memberIDs), team.InviteCode).Scan(&result.ID)
+		if err != nil {
+			return result, err
+		}
+
+		return result, nil
+	default:
+		return result, fmt.Errorf("Unknown dbtype")
+	}
+}
+
+func (r *TeamRepository) getManyWhere(whereStr string, whereArgs ...interface{}) ([]mathbattle.Team, error) {
+	result := []mathbattle.Team{}
+
+	query := "SELECT id, name, captain_id, member_ids, invite_code FROM teams"
+	if whereStr != "" {
+		query += " WHERE " + whereStr
+	}
+	rows, err := r.db.Query(query, whereArgs...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cur mathbattle.Team
+		var memberIDs string
+		if err := rows.Scan(&cur.ID, &cur.Name, &cur.CaptainID, &memberIDs, &cur.InviteCode); err != nil {
+			return result, err
+		}
+
+		if err := json.Unmarshal([]byte(memberIDs), &cur.MemberIDs); err != nil {
+			return result, err
+		}
+
+		result = append(result, cur)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *TeamRepository) getOneWhere(whereStr string, whereArgs ...interface{}) (mathbattle.Team, error) {
+	res, err := r.getManyWhere(whereStr, whereArgs...)
+	if err != nil {
+		return mathbattle.Team{}, err
+	}
+
+	if len(res) == 0 {
+		return mathbattle.Team{}, mathbattle.ErrNotFound
+	}
+
+	return res[0], nil
+}
+
+func (r *TeamRepository) Get(ID string) (mathbattle.Team, error) {
+	return r.getOneWhere("id = $1", ID)
+}
+
+// GetByMember - участники хранятся списком в одной колонке, поэтому ищем среди всех команд
+func (r *TeamRepository) GetByMember(participantID string) (mathbattle.Team, error) {
+	teams, err := r.GetAll()
+	if err != nil {
+		return mathbattle.Team{}, err
+	}
+
+	for _, team := range teams {
+		if team.IsMember(participantID) {
+			return team, nil
+		}
+	}
+
+	return mathbattle.Team{}, mathbattle.ErrNotFound
+}
+
+func (r *TeamRepository) GetByInviteCode(inviteCode string) (mathbattle.Team, error) {
+	return r.getOneWhere("invite_code = $1", inviteCode)
+}
+
+func (r *TeamRepository) GetAll() ([]mathbattle.Team, error) {
+	return r.getManyWhere("")
+}
+
+func (r *TeamRepository) Update(team mathbattle.Team) error {
+	memberIDs, err := json.Marshal(team.MemberIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("UPDATE teams SET name = $1, captain_id = $2, member_ids = $3, invite_code = $4 WHERE id = $5",
+		team.Name, team.CaptainID, string(memberIDs), team.InviteCode, team.ID)
+	return err
+}
+
+func (r *TeamRepository) Delete(ID string) error {
+	_, err := r.db.Exec("DELETE FROM teams WHERE id = $1", ID)
+	return err
+}
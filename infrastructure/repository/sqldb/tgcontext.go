@@ -0,0 +1,103 @@
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+)
+
+// TelegramContextRecord - сохранённое состояние диалога с пользователем бота
+type TelegramContextRecord struct {
+	ChatID         int64
+	CurrentCommand string
+	CurrentStep    int
+	Variables      map[string]string
+	UpdatedAt      time.Time
+}
+
+type TelegramContextRepository struct {
+	sqlRepository
+}
+
+func NewTelegramContextRepository(dbType, connectionString string, logger *mlog.Logger) (*TelegramContextRepository, error) {
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TelegramContextRepository{
+		sqlRepository: sqlRepository,
+	}
+
+	if err := result.CreateTable(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *TelegramContextRepository) CreateTable() error {
+	var createStmt string
+
+	switch r.dbType {
+	case "sqlite3":
+		createStmt = `CREATE TABLE IF NOT EXISTS telegram_contexts (
+			chat_id INTEGER PRIMARY KEY NOT NULL,
+			command VARCHAR(100),
+			step INTEGER,
+			variables TEXT,
+			updated_at DATETIME
+		)`
+	case "postgres":
+		createStmt = `CREATE TABLE IF NOT EXISTS telegram_contexts (
+			chat_id BIGINT PRIMARY KEY NOT NULL,
+			command VARCHAR(100),
+			step INTEGER,
+			variables TEXT,
+			updated_at TIMESTAMP
+		)`
+	}
+
+	_, err := r.db.Exec(createStmt)
+	return err
+}
+
+func (r *TelegramContextRepository) Get(chatID int64) (TelegramContextRecord, error) {
+	result := TelegramContextRecord{ChatID: chatID}
+
+	var variables string
+	err := r.db.QueryRow("SELECT command, step, variables, updated_at FROM telegram_contexts WHERE chat_id = $1", chatID).
+		Scan(&result.CurrentCommand, &result.CurrentStep, &variables, &result.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, mathbattle.ErrNotFound
+		}
+		return result, err
+	}
+
+	err = json.Unmarshal([]byte(variables), &result.Variables)
+	return result, err
+}
+
+func (r *TelegramContextRepository) Save(record TelegramContextRecord) error {
+	variables, err := json.Marshal(record.Variables)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`INSERT INTO telegram_contexts (chat_id, command, step, variables, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (chat_id) DO UPDATE SET command = $2, step = $3, variables = $4, updated_at = $5`,
+		record.ChatID, record.CurrentCommand, record.CurrentStep, string(variables), time.Now().Round(0).UTC())
+	return err
+}
+
+// DeleteOlderThan удаляет диалоги, которые не обновлялись с момента olderThan
+func (r *TelegramContextRepository) DeleteOlderThan(olderThan time.Time) error {
+	_, err := r.db.Exec("DELETE FROM telegram_contexts WHERE updated_at < $1", olderThan.Round(0).UTC())
+	return err
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
@@ -14,8 +16,8 @@ type UserRepository struct {
 	participantRepository *ParticipantRepository
 }
 
-func NewUserRepository(dbType, connectionString string) (*UserRepository, error) {
-	sqlRepository, err := newSqlRepository(dbType, connectionString)
+func NewUserRepository(dbType, connectionString string, logger *mlog.Logger) (*UserRepository, error) {
+	sqlRepository, err := newSqlRepository(dbType, connectionString, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +49,8 @@ func (r *UserRepository) CreateTable() error {
 			tg_lastname VARCHAR(100),
 			tg_username VARCHAR(100),
 			is_admin BOOL,
-			registration_time DATETIME
+			registration_time DATETIME,
+			language VARCHAR(16) DEFAULT ''
 		)`
 	case "postgres":
 		createStmt = `CREATE TABLE IF NOT EXISTS users (
@@ -57,11 +60,35 @@ func (r *UserRepository) CreateTable() error {
 			tg_lastname VARCHAR(100),
 			tg_username VARCHAR(100),
 			is_admin BOOL,
-			registration_time TIMESTAMP
+			registration_time TIMESTAMP,
+			language VARCHAR(16) DEFAULT ''
 		)`
 	}
 
-	_, err := r.db.Exec(createStmt)
+	if _, err := r.db.Exec(createStmt); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("users", "language", "VARCHAR(16) DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("users", "time_zone", "VARCHAR(64) DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("users", "league", "VARCHAR(100) DEFAULT ''"); err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfNotExists("users", "role", "VARCHAR(32) DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// До появления ролей администраторы отмечались только is_admin. Роль пользователя, у которого её
+	// забрали, тоже пустая, но is_admin у него сброшен
+	_, err := r.db.Exec("UPDATE users SET role = $1 WHERE is_admin = $2 AND (role = '' OR role IS NULL)",
+		string(mathbattle.RoleOwner), true)
 	return err
 }
 
@@ -71,9 +98,10 @@ func (r *UserRepository) Store(user mathbattle.User) (mathbattle.User, error) {
 	switch r.dbType {
 	case "sqlite3":
 		res, err := r.db.Exec(`
-			INSERT INTO users (tg_chat_id, tg_firstname, tg_lastname, tg_username, is_admin, registration_time)
-			VALUES (?, ?, ?, ?, ?, ?)`,
-			user.TelegramID, user.TelegramFirstName, user.TelegramLastName, user.TelegramUsername, user.IsAdmin, user.RegistrationTime)
+			INSERT INTO users (tg_chat_id, tg_firstname, tg_lastname, tg_username, is_admin, registration_time, language, time_zone,
+			league, role) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			user.TelegramID, user.TelegramFirstName, user.TelegramLastName, user.TelegramUsername, user.IsStaff(), user.RegistrationTime,
+			user.Language, user.TimeZone, user.League, string(user.Role))
 		if err != nil {
 			return result, err
 		}
@@ -86,8 +114,8 @@ func (r *UserRepository) Store(user mathbattle.User) (mathbattle.User, error) {
 
 		return result, nil
 	case "postgres":
-		query := `INSERT INTO users (tg_chat_id, tg_firstname, tg_lastname, tg_username, is_admin, registration_time)
-		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+		query := `INSERT INTO users (tg_chat_id, tg_firstname, tg_lastname, tg_username, is_admin, registration_time, language, time_zone,
+		league, role) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
 		stmt, err := r.db.Prepare(query)
 		if err != nil {
 			return result, err
@@ -95,7 +123,7 @@ func (r *UserRepository) Store(user mathbattle.User) (mathbattle.User, error) {
 		defer stmt.Close()
 
 		err = stmt.QueryRow(user.TelegramID, user.TelegramFirstName, user.TelegramLastName, user.TelegramUsername,
-			user.IsAdmin, user.RegistrationTime).Scan(&result.ID)
+			user.IsStaff(), user.RegistrationTime, user.Language, user.TimeZone, user.League, string(user.Role)).Scan(&result.ID)
 		if err != nil {
 			return result, err
 		}
@@ -109,10 +137,10 @@ func (r *UserRepository) Store(user mathbattle.User) (mathbattle.User, error) {
 func (r *UserRepository) getWhere(whereStr string, whereArgs ...interface{}) (mathbattle.User, error) {
 	result := mathbattle.User{}
 	row := r.db.QueryRow(`SELECT
-		id, tg_chat_id, tg_firstname, tg_lastname, tg_username, is_admin, registration_time
+		id, tg_chat_id, tg_firstname, tg_lastname, tg_username, registration_time, language, time_zone, league, role
 	FROM users WHERE `+whereStr, whereArgs...)
 	err := row.Scan(&result.ID, &result.TelegramID, &result.TelegramFirstName, &result.TelegramLastName, &result.TelegramUsername,
-		&result.IsAdmin, &result.RegistrationTime)
+		&result.RegistrationTime, &result.Language, &result.TimeZone, &result.League, &result.Role)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return result, mathbattle.ErrNotFound
@@ -126,19 +154,12 @@ func (r *UserRepository) getWhere(whereStr string, whereArgs ...interface{}) (ma
 
 func (r *UserRepository) GetAll() ([]mathbattle.User, error) {
 	result := []mathbattle.User{}
-	rows, err := r.db.Query("SELECT id FROM users")
+	IDs, err := selectIDs(r.db, "SELECT id FROM users")
 	if err != nil {
 		return result, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var curID string
-		err = rows.Scan(&curID)
-		if err != nil {
-			return result, err
-		}
 
+	for _, curID := range IDs {
 		cur, err := r.GetByID(curID)
 		if err != nil {
 			return result, err
@@ -158,15 +179,17 @@ func (r *UserRepository) GetByTelegramID(ID int64) (mathbattle.User, error) {
 	return r.getWhere("tg_chat_id = $1", ID)
 }
 
+// GetByTelegramName ищет по имени пользователя в телеграме, с @ или без, без учёта регистра
 func (r *UserRepository) GetByTelegramName(name string) (mathbattle.User, error) {
-	return r.getWhere("tg_name = $1", name)
+	return r.getWhere("LOWER(tg_username) = LOWER($1)", strings.TrimPrefix(strings.TrimSpace(name), "@"))
 }
 
 func (r *UserRepository) Update(user mathbattle.User) error {
 	_, err := r.db.Exec(`UPDATE users SET 
-		tg_chat_id = $1, tg_firstname=$2, tg_lastname=$3, tg_username = $4, is_admin = $5, registration_time = $6 WHERE id = $7`,
+		tg_chat_id = $1, tg_firstname=$2, tg_lastname=$3, tg_username = $4, is_admin = $5, registration_time = $6,
+		language = $7, time_zone = $8, league = $9, role = $10 WHERE id = $11`,
 		user.TelegramID, user.TelegramFirstName, user.TelegramLastName, user.TelegramUsername,
-		user.IsAdmin, user.RegistrationTime, user.ID)
+		user.IsStaff(), user.RegistrationTime, user.Language, user.TimeZone, user.League, string(user.Role), user.ID)
 	return err
 }
 
@@ -60,6 +60,10 @@ func (s *roundTs) TestSetGetUpdateDelete() {
 	round.ProblemDistribution["5"] = []mathbattle.ProblemDescriptor{{"A", "problem1"}, {"B", "problem2"}, {"C", "problem3"}}
 	round.ReviewDistribution.BetweenParticipants["4"] = []string{"s5", "s6"}
 	round.ReviewDistribution.ToOrganizers = append(round.ReviewDistribution.ToOrganizers, "s8", "s9", "s10")
+	round.ResultsPublished = true
+	reveal := mathbattle.NewIdentityReveal("admin", "appeal")
+	s.Require().Nil(s.rep.AppendIdentityReveal(round.ID, reveal))
+	round.IdentityReveals = append(round.IdentityReveals, reveal)
 	s.Require().Nil(s.rep.Update(round))
 
 	updatedRound, err := s.rep.Get(round.ID)
@@ -20,8 +20,18 @@ func (s *solutionTs) SetupSuite() {
 	s.rep = container.SolutionRepository()
 }
 
+// withContent - решение с прочитанными частями, репозиторий отдаёт их только ключами
+func (s *solutionTs) withContent(solution mathbattle.Solution) mathbattle.Solution {
+	parts, err := mathbattle.WithContent(s.rep, solution.Parts)
+	s.Require().Nil(err)
+	if len(parts) > 0 {
+		solution.Parts = parts
+	}
+	return solution
+}
+
 func (s *solutionTs) TestCreateNewEmpty() {
-	newEmptySolution := mathbattle.Solution{RoundID: "1", ParticipantID: "1", ProblemID: "1"}
+	newEmptySolution := mathbattle.Solution{RoundID: "1", ParticipantID: "1", ProblemID: "1", Pseudonym: "0A1B2C3D"}
 	solution, err := s.rep.Store(newEmptySolution)
 	newEmptySolution.ID = solution.ID
 	s.Require().Nil(err)
@@ -64,6 +74,10 @@ func (s *solutionTs) TestFindMany() {
 	for i, solution := range allSolutions {
 		sl, err := s.rep.Store(solution)
 		s.Require().Nil(err)
+		for j := range sl.Parts {
+			s.Require().NotEqual("", sl.Parts[j].Key)
+			allSolutions[i].Parts[j].Key = sl.Parts[j].Key
+		}
 		allSolutions[i].ID = sl.ID
 		s.Require().Equal(allSolutions[i], sl)
 	}
@@ -71,17 +85,18 @@ func (s *solutionTs) TestFindMany() {
 	solutions, err := s.rep.FindMany("1", "", "")
 	s.Require().Nil(err)
 	s.Require().Equal(2, len(solutions))
-	s.Require().Equal(allSolutions, solutions)
+	s.Require().Nil(solutions[0].Parts[0].Content)
+	s.Require().Equal(allSolutions, []mathbattle.Solution{s.withContent(solutions[0]), s.withContent(solutions[1])})
 
 	solutions, err = s.rep.FindMany("1", "", "1")
 	s.Require().Nil(err)
 	s.Require().Equal(1, len(solutions))
-	s.Require().Equal(allSolutions[0], solutions[0])
+	s.Require().Equal(allSolutions[0], s.withContent(solutions[0]))
 
 	solutions, err = s.rep.FindMany("1", "", "2")
 	s.Require().Nil(err)
 	s.Require().Equal(1, len(solutions))
-	s.Require().Equal(allSolutions[1], solutions[0])
+	s.Require().Equal(allSolutions[1], s.withContent(solutions[0]))
 }
 
 func (s *solutionTs) TestCreateNewNotEmptyAndAppend() {
@@ -97,24 +112,23 @@ func (s *solutionTs) TestCreateNewNotEmptyAndAppend() {
 		Mark:        10,
 	}
 	solution, err := s.rep.Store(newSolution)
-	newSolution.ID = solution.ID
 	s.Require().Nil(err)
-	s.Require().Equal(newSolution, solution)
 	newSolution = solution
 
 	solution, err = s.rep.Find("1", "1", "1")
 	s.Require().Nil(err)
-	s.Require().Equal(newSolution, solution)
+	s.Require().Equal(newSolution, s.withContent(solution))
 
 	newPart := mathbattle.Image{Extension: ".jpg", Content: []byte("55555")}
-	newSolution.Parts = append(newSolution.Parts, newPart)
-
 	err = s.rep.AppendPart(solution.ID, newPart)
 	s.Require().Nil(err)
 
 	solution, err = s.rep.Get(solution.ID)
 	s.Require().Nil(err)
-	s.Require().Equal(solution, newSolution)
+	solution = s.withContent(solution)
+	s.Require().Equal(3, len(solution.Parts))
+	s.Require().Equal(newSolution.Parts, solution.Parts[:2])
+	s.Require().Equal(newPart.Content, solution.Parts[2].Content)
 
 	s.Require().Nil(s.rep.Delete(solution.ID))
 	solution, err = s.rep.Get(solution.ID)
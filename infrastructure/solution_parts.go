@@ -0,0 +1,125 @@
+package infrastructure
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"mathbattle/config"
+	"mathbattle/libs/imageproc"
+	"mathbattle/models/mathbattle"
+)
+
+// ImageNormalizer - поворот по EXIF, уменьшение и перекодирование в jpeg, см. imageproc
+type ImageNormalizer struct {
+	Options imageproc.Options
+}
+
+func NewImageNormalizer(cfg config.ImageProcessing) *ImageNormalizer {
+	return &ImageNormalizer{
+		Options: imageproc.Options{
+			MaxSize:       cfg.MaxSize,
+			Quality:       cfg.Quality,
+			Grayscale:     cfg.Grayscale,
+			Contrast:      cfg.Contrast,
+			ThumbnailSize: cfg.ThumbnailSize,
+		},
+	}
+}
+
+// defaultThumbnailSize - размер превью, если thumbnail_size в конфиге не задан
+const defaultThumbnailSize = 320
+
+// NewThumbnailNormalizer уменьшает картинки до превью, например условия задач в банке для /start_round
+func NewThumbnailNormalizer(cfg config.ImageProcessing) *ImageNormalizer {
+	size := cfg.ThumbnailSize
+	if size <= 0 {
+		size = defaultThumbnailSize
+	}
+
+	return &ImageNormalizer{
+		Options: imageproc.Options{
+			MaxSize: size,
+			Quality: cfg.Quality,
+		},
+	}
+}
+
+func (n *ImageNormalizer) Normalize(part mathbattle.Image) (mathbattle.Image, error) {
+	processed, err := imageproc.Process(part.Content, n.Options)
+	if err == imageproc.ErrTooManyPixels {
+		return part, mathbattle.ErrSolutionPartTooLarge
+	}
+	if err != nil {
+		return part, err
+	}
+
+	original := part
+	return mathbattle.Image{
+		Extension: ".jpg",
+		MimeType:  mathbattle.MimeTypeJPEG,
+		Content:   processed.Processed,
+		Original:  &original,
+		Thumbnail: processed.Thumbnail,
+	}, nil
+}
+
+func NewSolutionPartLimits(cfg config.SolutionLimits) mathbattle.SolutionPartLimits {
+	return mathbattle.SolutionPartLimits{
+		MaxImageSize:    cfg.ImageMaxSize,
+		MaxDocumentSize: cfg.DocumentMaxSize,
+		DocumentTypes:   cfg.DocumentTypes,
+	}
+}
+
+// PdftoppmPreviewer рисует первые страницы pdf с помощью pdftoppm из poppler-utils
+type PdftoppmPreviewer struct {
+	Command string // путь к pdftoppm
+	Pages   int
+	DPI     int
+}
+
+func (p *PdftoppmPreviewer) Preview(document mathbattle.Image) ([][]byte, error) {
+	if !document.IsPDF() {
+		return nil, mathbattle.ErrSolutionPartUnsupported
+	}
+
+	dir, err := ioutil.TempDir("", "mathbattle_preview")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	documentPath := filepath.Join(dir, "document.pdf")
+	if err := ioutil.WriteFile(documentPath, document.Content, 0666); err != nil {
+		return nil, err
+	}
+
+	output, err := exec.Command(p.Command, "-png", "-r", strconv.Itoa(p.DPI),
+		"-f", "1", "-l", strconv.Itoa(p.Pages), documentPath, filepath.Join(dir, "page")).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, output)
+	}
+
+	// pdftoppm дополняет номер страницы нулями до одной длины, поэтому сортировка имён совпадает с порядком страниц
+	pagePaths, err := filepath.Glob(filepath.Join(dir, "page*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(pagePaths)
+
+	result := [][]byte{}
+	for _, pagePath := range pagePaths {
+		content, err := ioutil.ReadFile(pagePath)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, content)
+	}
+
+	return result, nil
+}
@@ -2,7 +2,10 @@ package infrastructure
 
 import (
 	"strconv"
+	"time"
 
+	"mathbattle/application"
+	"mathbattle/interfaces/replier"
 	"mathbattle/models/mathbattle"
 )
 
@@ -11,6 +14,9 @@ type TelegramUserContext struct {
 	Variables      map[string]ContextVariable
 	CurrentStep    int
 	CurrentCommand string
+	// Ответы на языке пользователя и его часовой пояс. Выбираются роутером на каждое сообщение и не сохраняются
+	Replier  application.Replier
+	TimeZone *time.Location
 }
 
 type TelegramUserData struct {
@@ -18,6 +24,8 @@ type TelegramUserData struct {
 	FirstName string
 	LastName  string
 	Username  string
+	// Язык из настроек телеграма, например "en-US"
+	LanguageCode string
 }
 
 type TelegramContextRepository interface {
@@ -32,7 +40,6 @@ func NewTelegramUserContext(userData TelegramUserData) TelegramUserContext {
 			TelegramFirstName: userData.FirstName,
 			TelegramLastName:  userData.LastName,
 			TelegramUsername:  userData.Username,
-			IsAdmin:           false,
 		},
 		Variables:      make(map[string]ContextVariable),
 		CurrentStep:    0,
@@ -40,6 +47,48 @@ func NewTelegramUserContext(userData TelegramUserData) TelegramUserContext {
 	}
 }
 
+// GetOrCreateTelegramUser находит пользователя по chat ID, а если его нет - регистрирует.
+// Язык пользователя, который его ещё не выбирал, берётся из настроек телеграма. Имя в телеграме
+// обновляется, чтобы пользователя можно было найти по нему, см. UserRepository.GetByTelegramName
+func GetOrCreateTelegramUser(users mathbattle.UserRepository, userData TelegramUserData) (mathbattle.User, error) {
+	language := replier.NormalizeLanguage(userData.LanguageCode)
+
+	user, err := users.GetByTelegramID(userData.ChatID)
+	if err == nil {
+		isChanged := false
+		if user.Language == "" && language != "" {
+			user.Language = language
+			isChanged = true
+		}
+		if userData.Username != "" && user.TelegramUsername != userData.Username {
+			user.TelegramUsername = userData.Username
+			isChanged = true
+		}
+
+		if isChanged {
+			if err := users.Update(user); err != nil {
+				return user, err
+			}
+		}
+		return user, nil
+	}
+
+	if err != mathbattle.ErrNotFound {
+		return user, err
+	}
+
+	newUser := mathbattle.User{
+		TelegramID:        userData.ChatID,
+		TelegramFirstName: userData.FirstName,
+		TelegramLastName:  userData.LastName,
+		TelegramUsername:  userData.Username,
+		Language:          language,
+	}
+	newUser.SetRegistrationTime(time.Now())
+
+	return users.Store(newUser)
+}
+
 func NewTelegramUserContextByChatID(chatID int64) TelegramUserContext {
 	return NewTelegramUserContext(TelegramUserData{
 		ChatID:   chatID,
@@ -0,0 +1,81 @@
+package infrastructure
+
+import (
+	"time"
+
+	"mathbattle/infrastructure/repository/sqldb"
+	"mathbattle/models/mathbattle"
+)
+
+// PersistentTelegramContextRepository хранит состояние диалогов в базе, чтобы пользователь мог
+// продолжить начатую команду после перезапуска бота
+type PersistentTelegramContextRepository struct {
+	storage *sqldb.TelegramContextRepository
+	users   mathbattle.UserRepository
+	// Диалог, который не обновлялся дольше ttl, начинается заново. 0 - не устаревает
+	ttl time.Duration
+}
+
+func NewPersistentTelegramContextRepository(storage *sqldb.TelegramContextRepository, users mathbattle.UserRepository,
+	ttl time.Duration) (*PersistentTelegramContextRepository, error) {
+
+	if ttl > 0 {
+		if err := storage.DeleteOlderThan(time.Now().Add(-ttl)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PersistentTelegramContextRepository{
+		storage: storage,
+		users:   users,
+		ttl:     ttl,
+	}, nil
+}
+
+func (r *PersistentTelegramContextRepository) GetByUserData(userData TelegramUserData) (TelegramUserContext, error) {
+	user, err := GetOrCreateTelegramUser(r.users, userData)
+	if err != nil {
+		return TelegramUserContext{}, err
+	}
+
+	result := TelegramUserContext{
+		User:           user,
+		Variables:      make(map[string]ContextVariable),
+		CurrentStep:    0,
+		CurrentCommand: "",
+	}
+
+	record, err := r.storage.Get(userData.ChatID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return result, nil
+		}
+		return result, err
+	}
+
+	if r.ttl > 0 && time.Since(record.UpdatedAt) > r.ttl {
+		return result, nil
+	}
+
+	result.CurrentCommand = record.CurrentCommand
+	result.CurrentStep = record.CurrentStep
+	for name, value := range record.Variables {
+		result.Variables[name] = NewContextVariableStr(value)
+	}
+
+	return result, nil
+}
+
+func (r *PersistentTelegramContextRepository) Update(chatID int64, ctx TelegramUserContext) error {
+	record := sqldb.TelegramContextRecord{
+		ChatID:         chatID,
+		CurrentCommand: ctx.CurrentCommand,
+		CurrentStep:    ctx.CurrentStep,
+		Variables:      make(map[string]string),
+	}
+	for name, value := range ctx.Variables {
+		record.Variables[name] = value.AsString()
+	}
+
+	return r.storage.Save(record)
+}
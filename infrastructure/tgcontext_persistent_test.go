@@ -0,0 +1,88 @@
+package infrastructure
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mathbattle/infrastructure/repository/sqldb"
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Соединение с базой одно на процесс (см. sqldb), поэтому вся проверка в одном тесте
+func TestPersistentTelegramContext(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "tgcontext")
+	req.Nil(err)
+	defer os.RemoveAll(dir)
+	dbPath := filepath.Join(dir, "test.sqlite")
+
+	storage, err := sqldb.NewTelegramContextRepository("sqlite3", dbPath, mlog.Default())
+	req.Nil(err)
+	users, err := sqldb.NewUserRepository("sqlite3", dbPath, mlog.Default())
+	req.Nil(err)
+
+	contexts, err := NewPersistentTelegramContextRepository(storage, users, time.Hour)
+	req.Nil(err)
+
+	userData := TelegramUserData{ChatID: 1001, LanguageCode: "en"}
+	ctx, err := contexts.GetByUserData(userData)
+	req.Nil(err)
+	req.Equal("", ctx.CurrentCommand)
+	req.Equal(int64(1001), ctx.User.TelegramID)
+
+	ctx.CurrentCommand = "/start_round"
+	ctx.CurrentStep = 3
+	ctx.Variables["until_date"] = NewContextVariableStr("01.02.2030 18:00")
+	ctx.Variables["problems_ids"] = NewContextVariableStr("1,2")
+	req.Nil(contexts.Update(userData.ChatID, ctx))
+
+	// После перезапуска бота диалог продолжается с того же места
+	restarted, err := NewPersistentTelegramContextRepository(storage, users, time.Hour)
+	req.Nil(err)
+	restored, err := restarted.GetByUserData(userData)
+	req.Nil(err)
+	req.Equal("/start_round", restored.CurrentCommand)
+	req.Equal(3, restored.CurrentStep)
+	req.Equal("01.02.2030 18:00", restored.Variables["until_date"].AsString())
+	req.Equal("1,2", restored.Variables["problems_ids"].AsString())
+	req.Equal(ctx.User.ID, restored.User.ID)
+
+	// Диалог можно закончить
+	restored.CurrentCommand = ""
+	restored.CurrentStep = 0
+	restored.Variables = map[string]ContextVariable{}
+	req.Nil(restarted.Update(userData.ChatID, restored))
+	finished, err := restarted.GetByUserData(userData)
+	req.Nil(err)
+	req.Equal("", finished.CurrentCommand)
+	req.Empty(finished.Variables)
+
+	// Устаревший диалог начинается заново, а при запуске бота удаляется из базы
+	req.Nil(contexts.Update(userData.ChatID, ctx))
+	time.Sleep(20 * time.Millisecond)
+
+	expiring, err := NewPersistentTelegramContextRepository(storage, users, 0)
+	req.Nil(err)
+	kept, err := expiring.GetByUserData(userData)
+	req.Nil(err)
+	req.Equal("/start_round", kept.CurrentCommand)
+
+	expiring.ttl = 10 * time.Millisecond
+	expired, err := expiring.GetByUserData(userData)
+	req.Nil(err)
+	req.Equal("", expired.CurrentCommand)
+	req.Equal(0, expired.CurrentStep)
+	req.Empty(expired.Variables)
+
+	_, err = NewPersistentTelegramContextRepository(storage, users, 10*time.Millisecond)
+	req.Nil(err)
+	_, err = storage.Get(userData.ChatID)
+	req.Equal(mathbattle.ErrNotFound, err)
+}
@@ -1,128 +1,241 @@
 package bot
 
 import (
+	"mathbattle/application"
 	"mathbattle/infrastructure"
 	"mathbattle/interfaces/bot/handlers"
 )
 
-func createCommands(container infrastructure.MBotContainer) []handlers.TelegramCommandHandler {
-	commandStart := &handlers.Start{
-		Handler: handlers.Handler{Name: "/start", Description: ""},
-		Replier: container.Replier(),
-	}
+// commandFactory создаёт команду, которая обращается к сервисам container
+type commandFactory func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler
 
-	result := []handlers.TelegramCommandHandler{
-		&handlers.Help{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdHelpName(),
-				Description: container.Replier().CmdHelpDesc(),
-			},
-			Replier: container.Replier(),
-		},
-		&handlers.SendServiceMessage{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdServiceMsgName(),
-				Description: container.Replier().CmdGetMyResultsDesc(),
-			},
-			Replier:        container.Replier(),
-			PostmanService: container.Postman(),
-		},
-		&handlers.StartReviewStage{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdStartReviewStageName(),
-				Description: container.Replier().CmdStartReviewStageDesc(),
-			},
-			Replier:      container.Replier(),
-			RoundService: container.RoundService(),
-		},
-		&handlers.StartRound{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdStartRoundName(),
-				Description: container.Replier().CmdStartRoundDesc(),
-			},
-			Replier:      container.Replier(),
-			RoundService: container.RoundService(),
-		},
-		&handlers.Stat{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdStatName(),
-				Description: container.Replier().CmdStatDesc(),
-			},
-			Replier:     container.Replier(),
-			StatService: container.StatService(),
-		},
-		&handlers.Subscribe{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdSubscribeName(),
-				Description: container.Replier().CmdSubscribeDesc(),
-			},
-			Replier:            container.Replier(),
-			ParticipantService: container.ParticipantService(),
-			RoundService:       container.RoundService(),
-		},
-		&handlers.Unsubscribe{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdUnsubscribeName(),
-				Description: container.Replier().CmdUnsubscribeDesc(),
-			},
-			Replier:            container.Replier(),
-			ParticipantService: container.ParticipantService(),
-			RoundService:       container.RoundService(),
-		},
-		&handlers.SubmitSolution{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdSubmitSolutionName(),
-				Description: container.Replier().CmdSubmitSolutionDesc(),
-			},
-			Replier:            container.Replier(),
-			ParticipantService: container.ParticipantService(),
-			RoundService:       container.RoundService(),
-			SolutionService:    container.SolutionService(),
-		},
-		&handlers.SubmitReview{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdSubmitReviewName(),
-				Description: container.Replier().CmdSubmitReviewDesc(),
-			},
-			Replier:            container.Replier(),
-			ParticipantService: container.ParticipantService(),
-			RoundService:       container.RoundService(),
-			ReviewService:      container.ReviewService(),
-		},
-		&handlers.GetReviews{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdGetReviewsName(),
-				Description: container.Replier().CmdGetReviewsDesc(),
-			},
-			Replier:            container.Replier(),
-			ParticipantService: container.ParticipantService(),
-			ReviewService:      container.ReviewService(),
-			RoundService:       container.RoundService(),
-			SolutionService:    container.SolutionService(),
-		},
-		&handlers.GetProblems{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdGetProblemsName(),
-				Description: container.Replier().CmdGetProblemsDesc(),
-			},
-			Replier:            container.Replier(),
-			ParticipantService: container.ParticipantService(),
-			RoundService:       container.RoundService(),
-			ProblemService:     container.ProblemService(),
-		},
-		&handlers.GetMyResults{
-			Handler: handlers.Handler{
-				Name:        container.Replier().CmdGetMyResultsName(),
-				Description: container.Replier().CmdGetMyResultsDesc(),
-			},
-			Replier:            container.Replier(),
-			RoundService:       container.RoundService(),
-			SolutionService:    container.SolutionService(),
-			ParticipantService: container.ParticipantService(),
-			ReviewService:      container.ReviewService(),
-		},
-		commandStart,
+// commandFactories - конструкторы всех команд в порядке, в котором команды показываются пользователю
+func commandFactories() []commandFactory {
+	return []commandFactory{
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Help{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdHelpName(),
+					Description: application.Replier.CmdHelpDesc,
+				},
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.SendServiceMessage{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdServiceMsgName(),
+					Description: application.Replier.CmdServiceMsgDesc,
+				},
+				PostmanService: container.Postman(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.StartReviewStage{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdStartReviewStageName(),
+					Description: application.Replier.CmdStartReviewStageDesc,
+				},
+				RoundService: container.RoundService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.ReassignReview{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdReassignReviewName(),
+					Description: application.Replier.CmdReassignReviewDesc,
+				},
+				RoundService: container.RoundService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.StartRound{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdStartRoundName(),
+					Description: application.Replier.CmdStartRoundDesc,
+				},
+				RoundService:   container.RoundService(),
+				ProblemService: container.ProblemService(),
+				Thumbnails:     infrastructure.NewThumbnailNormalizer(container.Config().ImageProcessing),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Stat{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdStatName(),
+					Description: application.Replier.CmdStatDesc,
+				},
+				StatService: container.StatService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Subscribe{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdSubscribeName(),
+					Description: application.Replier.CmdSubscribeDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				RoundService:       container.RoundService(),
+				Form:               container.RegistrationForm(),
+				Schools:            container.SchoolDirectory(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Team{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdTeamName(),
+					Description: application.Replier.CmdTeamDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				TeamService:        container.TeamService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Battle{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdBattleName(),
+					Description: application.Replier.CmdBattleDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				TeamService:        container.TeamService(),
+				BattleService:      container.BattleService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Profile{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdProfileName(),
+					Description: application.Replier.CmdProfileDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				Form:               container.RegistrationForm(),
+				Schools:            container.SchoolDirectory(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Unsubscribe{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdUnsubscribeName(),
+					Description: application.Replier.CmdUnsubscribeDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				RoundService:       container.RoundService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Reminders{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdRemindersName(),
+					Description: application.Replier.CmdRemindersDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.SubmitSolution{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdSubmitSolutionName(),
+					Description: application.Replier.CmdSubmitSolutionDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				RoundService:       container.RoundService(),
+				SolutionService:    container.SolutionService(),
+				TeamService:        container.TeamService(),
+				PartLimits:         infrastructure.NewSolutionPartLimits(container.Config().SolutionLimits),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.SubmitReview{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdSubmitReviewName(),
+					Description: application.Replier.CmdSubmitReviewDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				RoundService:       container.RoundService(),
+				ReviewService:      container.ReviewService(),
+				TeamService:        container.TeamService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.GetReviews{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdGetReviewsName(),
+					Description: application.Replier.CmdGetReviewsDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				ReviewService:      container.ReviewService(),
+				RoundService:       container.RoundService(),
+				SolutionService:    container.SolutionService(),
+				TeamService:        container.TeamService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.GetProblems{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdGetProblemsName(),
+					Description: application.Replier.CmdGetProblemsDesc,
+				},
+				ParticipantService: container.ParticipantService(),
+				RoundService:       container.RoundService(),
+				ProblemService:     container.ProblemService(),
+				TeamService:        container.TeamService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.GetMyResults{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdGetMyResultsName(),
+					Description: application.Replier.CmdGetMyResultsDesc,
+				},
+				RoundService:       container.RoundService(),
+				SolutionService:    container.SolutionService(),
+				ParticipantService: container.ParticipantService(),
+				ReviewService:      container.ReviewService(),
+				TeamService:        container.TeamService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Language{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdLanguageName(),
+					Description: application.Replier.CmdLanguageDesc,
+				},
+				Users:    container.UserRepository(),
+				Repliers: container.Repliers(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.TimeZone{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdTimeZoneName(),
+					Description: application.Replier.CmdTimeZoneDesc,
+				},
+				Users:         container.UserRepository(),
+				EventTimeZone: container.TimeZone(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.League{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdLeagueName(),
+					Description: application.Replier.CmdLeagueDesc,
+				},
+				Users:   container.UserRepository(),
+				Leagues: container.Leagues(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Role{
+				Handler: handlers.Handler{
+					Name:        container.Replier().CmdRoleName(),
+					Description: application.Replier.CmdRoleDesc,
+				},
+				RoleService: container.RoleService(),
+			}
+		},
+		func(container *infrastructure.MBotContainer) handlers.TelegramCommandHandler {
+			return &handlers.Start{
+				Handler: handlers.Handler{Name: "/start", Description: handlers.NoDescription},
+			}
+		},
 	}
-
-	return result
 }
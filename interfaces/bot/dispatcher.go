@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"mathbattle/libs/mlog"
+)
+
+const defaultWorkersCount = 8
+
+// dispatcher обрабатывает сообщения разных чатов параллельно, но не больше чем workersCount одновременно.
+// Сообщения одного чата обрабатываются строго по очереди, чтобы шаги команды не перемешались
+type dispatcher struct {
+	mutex   sync.Mutex
+	queues  map[int64][]func()
+	workers chan struct{}
+	pending sync.WaitGroup
+}
+
+func newDispatcher(workersCount int) *dispatcher {
+	if workersCount <= 0 {
+		workersCount = defaultWorkersCount
+	}
+
+	return &dispatcher{
+		queues:  make(map[int64][]func()),
+		workers: make(chan struct{}, workersCount),
+	}
+}
+
+// Dispatch ставит job в очередь чата chatID. Если все обработчики заняты, ждёт пока один освободится
+func (d *dispatcher) Dispatch(chatID int64, job func()) {
+	d.pending.Add(1)
+
+	d.mutex.Lock()
+	queue, isRunning := d.queues[chatID]
+	d.queues[chatID] = append(queue, job)
+	d.mutex.Unlock()
+
+	if isRunning {
+		return
+	}
+
+	d.workers <- struct{}{}
+	go d.run(chatID)
+}
+
+// Wait ждёт, пока будут обработаны все сообщения, поставленные в очередь
+func (d *dispatcher) Wait() {
+	d.pending.Wait()
+}
+
+// runJob выполняет job и ловит её панику: иначе упал бы весь бот, а очередь чата так и осталась бы занятой
+func runJob(chatID int64, job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			mlog.Default().With("chat_id", chatID).Errorf("Update handler panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+
+	job()
+}
+
+func (d *dispatcher) run(chatID int64) {
+	defer func() { <-d.workers }()
+
+	for {
+		d.mutex.Lock()
+		job := d.queues[chatID][0]
+		d.mutex.Unlock()
+
+		runJob(chatID, job)
+		d.pending.Done()
+
+		d.mutex.Lock()
+		d.queues[chatID] = d.queues[chatID][1:]
+		if len(d.queues[chatID]) == 0 {
+			delete(d.queues, chatID)
+			d.mutex.Unlock()
+			return
+		}
+		d.mutex.Unlock()
+	}
+}
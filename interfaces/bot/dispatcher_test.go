@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcherKeepsChatOrder(t *testing.T) {
+	req := require.New(t)
+
+	d := newDispatcher(4)
+	wg := sync.WaitGroup{}
+	mutex := sync.Mutex{}
+	results := make(map[int64][]int)
+
+	for i := 0; i < 50; i++ {
+		for chatID := int64(0); chatID < 5; chatID++ {
+			wg.Add(1)
+			i, chatID := i, chatID
+			d.Dispatch(chatID, func() {
+				defer wg.Done()
+				mutex.Lock()
+				results[chatID] = append(results[chatID], i)
+				mutex.Unlock()
+			})
+		}
+	}
+	wg.Wait()
+
+	for chatID := int64(0); chatID < 5; chatID++ {
+		req.Len(results[chatID], 50)
+		for i, value := range results[chatID] {
+			req.Equal(i, value)
+		}
+	}
+}
+
+func TestDispatcherLimitsWorkers(t *testing.T) {
+	req := require.New(t)
+
+	d := newDispatcher(2)
+	wg := sync.WaitGroup{}
+	var running, maxRunning int32
+
+	for chatID := int64(0); chatID < 10; chatID++ {
+		wg.Add(1)
+		d.Dispatch(chatID, func() {
+			defer wg.Done()
+			cur := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&maxRunning)
+				if cur <= old || atomic.CompareAndSwapInt32(&maxRunning, old, cur) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	wg.Wait()
+
+	req.True(maxRunning <= 2)
+}
+
+func TestDispatcherSurvivesPanic(t *testing.T) {
+	req := require.New(t)
+
+	d := newDispatcher(1)
+	handled := int32(0)
+	d.Dispatch(1, func() { panic("handler bug") })
+	d.Dispatch(1, func() { atomic.AddInt32(&handled, 1) })
+	d.Dispatch(2, func() { atomic.AddInt32(&handled, 1) })
+	d.Wait()
+
+	// Очередь чата после паники продолжает работать, единственный обработчик освободился
+	req.Equal(int32(2), atomic.LoadInt32(&handled))
+	d.Dispatch(1, func() { atomic.AddInt32(&handled, 1) })
+	d.Wait()
+	req.Equal(int32(3), atomic.LoadInt32(&handled))
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Battle - ходы капитана в матбое. Остальные участники команды видят только состояние боя
+type Battle struct {
+	Handler
+	ParticipantService mathbattle.ParticipantService
+	TeamService        mathbattle.TeamService
+	BattleService      mathbattle.BattleService
+}
+
+func (h *Battle) Name() string {
+	return h.Handler.Name
+}
+
+func (h *Battle) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *Battle) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	res, _, _ := h.IsCommandSuitable(ctx)
+	return res
+}
+
+func (h *Battle) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, ctx.Replier.NotParticipant(), nil
+		}
+
+		return false, "", err
+	}
+
+	_, err = h.BattleService.GetRunning(participant.ID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, ctx.Replier.BattleNone(), nil
+		}
+
+		return false, "", err
+	}
+
+	return true, "", nil
+}
+
+func (h *Battle) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
+}
+
+func (h *Battle) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	battle, err := h.BattleService.GetRunning(participant.ID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return -1, OneTextResp(ctx.Replier.BattleNone()), nil
+		}
+		return -1, noResponse(), err
+	}
+
+	switch ctx.CurrentStep {
+	case 0:
+		return h.stepShow(ctx, participant, battle)
+	case 1:
+		return h.stepMove(ctx, participant, battle, m)
+	default:
+		return -1, noResponse(), nil
+	}
+}
+
+func (h *Battle) stepShow(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant,
+	battle mathbattle.Battle) (int, []TelegramResponse, error) {
+
+	team, err := h.TeamService.GetByMember(participant.ID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	status := ctx.Replier.BattleStatus(battle, team.ID)
+	if team.CaptainID != participant.ID || battle.Turn != team.ID {
+		return -1, OneTextResp(status), nil
+	}
+
+	switch battle.State {
+	case mathbattle.BattleChallenge:
+		buttons := mathbattle.ProblemsCaptions(battle.OpenProblems())
+		buttons = append(buttons, ctx.Replier.BattlePass())
+		return 1, OneWithKb(status, buttons...), nil
+	case mathbattle.BattleResponse:
+		return 1, OneWithKb(status, ctx.Replier.BattleAccept(), ctx.Replier.BattleRefuse()), nil
+	default:
+		return -1, OneTextResp(status), nil
+	}
+}
+
+func (h *Battle) stepMove(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant,
+	battle mathbattle.Battle, m *tb.Message) (int, []TelegramResponse, error) {
+
+	move := mathbattle.BattleMove{
+		BattleID:      battle.ID,
+		ParticipantID: participant.ID,
+		ActorID:       ctx.User.TelegramID,
+	}
+
+	switch m.Text {
+	case ctx.Replier.BattlePass():
+		move.Action = mathbattle.BattleMovePass
+	case ctx.Replier.BattleAccept():
+		move.Action = mathbattle.BattleMoveAccept
+	case ctx.Replier.BattleRefuse():
+		move.Action = mathbattle.BattleMoveRefuse
+	default:
+		open := battle.OpenProblems()
+		index, isOk := mathbattle.ValidateCaptions(m.Text, open)
+		if !isOk {
+			return -1, OneTextResp(ctx.Replier.BattleMoveWrong()), nil
+		}
+		move.Action = mathbattle.BattleMoveChallenge
+		move.ProblemID = open[index].ProblemID
+	}
+
+	// Новое состояние боя BattleService сам разошлёт обеим командам
+	_, err := h.BattleService.Move(move)
+	if err != nil {
+		if err == mathbattle.ErrWrongUserInput {
+			return -1, OneTextResp(ctx.Replier.BattleMoveWrong()), nil
+		}
+		return -1, noResponse(), err
+	}
+
+	return -1, OneTextResp(ctx.Replier.BattleMoveDone()), nil
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"mathbattle/infrastructure"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Данные inline кнопки имеют вид "<команда>|<данные>", чтобы роутер мог отдать нажатие той команде,
+// которая отправила кнопку. Телеграм ограничивает callback data 64 байтами
+const callbackDataSeparator = "|"
+
+type InlineButton struct {
+	Text string
+	Data string
+}
+
+// TelegramCallbackHandler - команды, которые сами разбирают нажатия inline кнопок.
+// Нажатия кнопок остальных команд приходят в Handle как текстовое сообщение с данными кнопки
+type TelegramCallbackHandler interface {
+	HandleCallback(ctx infrastructure.TelegramUserContext, cb *tb.Callback) (int, []TelegramResponse, error)
+}
+
+func CallbackData(command string, data string) string {
+	return command + callbackDataSeparator + data
+}
+
+func ParseCallbackData(raw string) (command string, data string) {
+	parts := strings.SplitN(raw, callbackDataSeparator, 2)
+	if len(parts) != 2 {
+		return "", raw
+	}
+	return parts[0], parts[1]
+}
+
+// CallbackMessageRef - сообщение, под которым нажали кнопку
+func CallbackMessageRef(cb *tb.Callback) infrastructure.MessageRef {
+	if cb.Message == nil || cb.Message.Chat == nil {
+		return infrastructure.MessageRef{}
+	}
+	return infrastructure.MessageRef{ChatID: cb.Message.Chat.ID, MessageID: cb.Message.ID}
+}
+
+// RememberMessage сохраняет ссылку на отправленное сообщение в контексте под именем name
+func RememberMessage(ctx infrastructure.TelegramUserContext, name string, ref infrastructure.MessageRef) {
+	ctx.Variables[name] = infrastructure.NewContextVariableStr(fmt.Sprintf("%d:%d", ref.ChatID, ref.MessageID))
+}
+
+// RememberedMessage возвращает сообщение, отправленное с RememberAs == name
+func RememberedMessage(ctx infrastructure.TelegramUserContext, name string) (infrastructure.MessageRef, bool) {
+	variable, exists := ctx.Variables[name]
+	if !exists {
+		return infrastructure.MessageRef{}, false
+	}
+
+	parts := strings.Split(variable.AsString(), ":")
+	if len(parts) != 2 {
+		return infrastructure.MessageRef{}, false
+	}
+	chatID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return infrastructure.MessageRef{}, false
+	}
+	messageID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return infrastructure.MessageRef{}, false
+	}
+
+	return infrastructure.MessageRef{ChatID: chatID, MessageID: messageID}, true
+}
@@ -10,19 +10,19 @@ import (
 
 type GetMyResults struct {
 	Handler
-	Replier            mreplier.Replier
 	RoundService       mathbattle.RoundService
 	SolutionService    mathbattle.SolutionService
 	ParticipantService mathbattle.ParticipantService
 	ReviewService      mathbattle.ReviewService
+	TeamService        mathbattle.TeamService
 }
 
 func (h *GetMyResults) Name() string {
 	return h.Handler.Name
 }
 
-func (h *GetMyResults) Description() string {
-	return h.Handler.Description
+func (h *GetMyResults) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *GetMyResults) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -31,20 +31,20 @@ func (h *GetMyResults) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool
 }
 
 func (h *GetMyResults) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
-	_, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NotParticipant(), nil
+			return false, ctx.Replier.NotParticipant(), nil
 		}
 		return false, "", err
 	}
 
-	_, err = h.RoundService.GetRunning()
+	_, err = h.RoundService.GetRunning(participant.League)
 	if err != mathbattle.ErrNotFound {
 		return false, "", nil
 	}
 
-	_, err = h.RoundService.GetLast()
+	_, err = h.RoundService.GetLast(participant.League)
 	if err != nil {
 		return false, "", nil
 	}
@@ -52,37 +52,45 @@ func (h *GetMyResults) IsCommandSuitable(ctx infrastructure.TelegramUserContext)
 	return true, "", nil
 }
 
-func (h *GetMyResults) IsAdminOnly() bool {
-	return false
+func (h *GetMyResults) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *GetMyResults) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
 	switch ctx.CurrentStep {
 	case 0:
-		round, err := h.RoundService.GetLast()
+		participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 		if err != nil {
 			return -1, noResponse(), nil
 		}
 
-		participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+		round, err := h.RoundService.GetLast(participant.League)
+		if err != nil {
+			return -1, noResponse(), nil
+		}
+
+		// В командном раунде результаты общие для всей команды
+		findDescriptor, err := solutionFindDescriptor(h.TeamService, round, participant.ID, "")
 		if err != nil {
 			return -1, noResponse(), nil
 		}
+		competitorID := participant.ID
+		if findDescriptor.TeamID != "" {
+			competitorID = findDescriptor.TeamID
+		}
 
 		allResps := []string{}
 
-		for _, problemDesc := range round.ProblemDistribution[participant.ID] {
-			solutions, err := h.SolutionService.Find(mathbattle.FindDescriptor{
-				RoundID:       round.ID,
-				ParticipantID: participant.ID,
-				ProblemID:     problemDesc.ProblemID,
-			})
+		for _, problemDesc := range round.ProblemDistribution[competitorID] {
+			findDescriptor.ProblemID = problemDesc.ProblemID
+			solutions, err := h.SolutionService.Find(findDescriptor)
 			if err != nil {
 				return -1, noResponse(), nil
 			}
 
 			if len(solutions) == 0 {
-				allResps = append(allResps, h.Replier.MyResultsProblemNotSolved(problemDesc.Caption))
+				allResps = append(allResps, ctx.Replier.MyResultsProblemNotSolved(problemDesc.Caption))
+				continue
 			}
 			solution := solutions[0]
 
@@ -93,7 +101,7 @@ func (h *GetMyResults) Handle(ctx infrastructure.TelegramUserContext, m *tb.Mess
 				return -1, noResponse(), nil
 			}
 
-			response := h.Replier.MyResultsProblemResults(problemDesc.Caption, solution.JuriComment, solution.Mark,
+			response := ctx.Replier.MyResultsProblemResults(problemDesc.Caption, solution.JuriComment, solution.Mark,
 				otherParticipantReviews)
 
 			allResps = append(allResps, response)
@@ -106,8 +114,9 @@ func (h *GetMyResults) Handle(ctx infrastructure.TelegramUserContext, m *tb.Mess
 
 		for _, desc := range reviewDescriptors {
 			reviews, err := h.ReviewService.FindMany(mathbattle.ReviewFindDescriptor{
-				ReviewerID: participant.ID,
-				SolutionID: desc.SolutionID,
+				ReviewerID:     participant.ID,
+				SolutionID:     desc.SolutionID,
+				ReviewerTeamID: findDescriptor.TeamID,
 			})
 			if err != nil {
 				return -1, noResponse(), nil
@@ -120,11 +129,11 @@ func (h *GetMyResults) Handle(ctx infrastructure.TelegramUserContext, m *tb.Mess
 				}
 
 				allResps = append(allResps,
-					h.Replier.MyResultsReviewResults(desc.ProblemCaption, desc.SolutionNumber, true,
+					ctx.Replier.MyResultsReviewResults(desc.ProblemCaption, desc.SolutionNumber, true,
 						solution.JuriComment, reviews[0].Mark))
 			} else {
 				allResps = append(allResps,
-					h.Replier.MyResultsReviewResults(desc.ProblemCaption, desc.SolutionNumber, false, "", -1))
+					ctx.Replier.MyResultsReviewResults(desc.ProblemCaption, desc.SolutionNumber, false, "", -1))
 			}
 
 		}
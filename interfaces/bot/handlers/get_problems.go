@@ -11,18 +11,18 @@ import (
 type GetProblems struct {
 	Handler
 
-	Replier            application.Replier
 	ParticipantService mathbattle.ParticipantService
 	RoundService       mathbattle.RoundService
 	ProblemService     mathbattle.ProblemService
+	TeamService        mathbattle.TeamService
 }
 
 func (h *GetProblems) Name() string {
 	return h.Handler.Name
 }
 
-func (h *GetProblems) Description() string {
-	return h.Handler.Description
+func (h *GetProblems) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *GetProblems) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -31,24 +31,24 @@ func (h *GetProblems) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool
 }
 
 func (h *GetProblems) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
-	_, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		return false, "", err
 	}
 
-	_, err = h.RoundService.GetRunning()
+	round, err := h.RoundService.GetRunning(participant.League)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NoRoundRunning(), nil
+			return false, ctx.Replier.NoRoundRunning(), nil
 		}
 		return false, "", err
 	}
 
-	return true, "", nil
+	return isInRoundTeam(ctx, h.TeamService, round, participant.ID)
 }
 
-func (h *GetProblems) IsAdminOnly() bool {
-	return false
+func (h *GetProblems) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *GetProblems) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	mreplier "mathbattle/application"
 	"mathbattle/infrastructure"
 	"mathbattle/models/mathbattle"
@@ -11,19 +10,19 @@ import (
 
 type GetReviews struct {
 	Handler
-	Replier            mreplier.Replier
 	ParticipantService mathbattle.ParticipantService
 	ReviewService      mathbattle.ReviewService
 	RoundService       mathbattle.RoundService
 	SolutionService    mathbattle.SolutionService
+	TeamService        mathbattle.TeamService
 }
 
 func (h *GetReviews) Name() string {
 	return h.Handler.Name
 }
 
-func (h *GetReviews) Description() string {
-	return h.Handler.Description
+func (h *GetReviews) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *GetReviews) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -32,15 +31,15 @@ func (h *GetReviews) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
 }
 
 func (h *GetReviews) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
-	_, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NotParticipant(), nil
+			return false, ctx.Replier.NotParticipant(), nil
 		}
 		return false, "", err
 	}
 
-	round, err := h.RoundService.GetLast()
+	round, err := h.RoundService.GetLast(participant.League)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
 			return false, "", nil
@@ -55,30 +54,40 @@ func (h *GetReviews) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (
 	return true, "", err
 }
 
-func (h *GetReviews) IsAdminOnly() bool {
-	return false
+func (h *GetReviews) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *GetReviews) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	lastRound, err := h.RoundService.GetLast()
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	lastRound, err := h.RoundService.GetLast(participant.League)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	findDescriptor, err := solutionFindDescriptor(h.TeamService, lastRound, participant.ID, "")
+	if err == mathbattle.ErrNotFound {
+		// Не был в команде во время командного раунда
+		return -1, noResponse(), nil
+	}
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	solutions, err := h.SolutionService.Find(mathbattle.FindDescriptor{
-		RoundID:       lastRound.ID,
-		ParticipantID: participant.ID,
-		ProblemID:     "",
-	})
+	solutions, err := h.SolutionService.Find(findDescriptor)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
+	competitorID := participant.ID
+	if findDescriptor.TeamID != "" {
+		competitorID = findDescriptor.TeamID
+	}
+
 	result := []TelegramResponse{}
 	for _, solution := range solutions {
 		reviews, err := h.ReviewService.FindMany(mathbattle.ReviewFindDescriptor{
@@ -90,16 +99,13 @@ func (h *GetReviews) Handle(ctx infrastructure.TelegramUserContext, m *tb.Messag
 		}
 		for i, review := range reviews {
 			problemCaption := ""
-			for _, desc := range lastRound.ProblemDistribution[participant.ID] {
+			for _, desc := range lastRound.ProblemDistribution[competitorID] {
 				if desc.ProblemID == solution.ProblemID {
 					problemCaption = desc.Caption
 				}
 			}
 
-			msg := fmt.Sprintf("Комментарий №%d на задачу %s", i+1, problemCaption)
-			msg += "\n"
-			msg += review.Content
-			result = append(result, NewResp(msg))
+			result = append(result, NewResp(ctx.Replier.GetReviewsComment(i+1, problemCaption, review.Content)))
 		}
 	}
 
@@ -1,8 +1,16 @@
 package handlers
 
+import "mathbattle/application"
+
 type Handler struct {
-	Name        string
-	Description string
+	Name string
+	// Описание зависит от языка пользователя, например application.Replier.CmdHelpDesc
+	Description func(replier application.Replier) string
+}
+
+// NoDescription - для команд, которые не показываются в списке команд
+func NoDescription(replier application.Replier) string {
+	return ""
 }
 
 func noResponse() []TelegramResponse {
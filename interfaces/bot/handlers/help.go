@@ -3,21 +3,21 @@ package handlers
 import (
 	mreplier "mathbattle/application"
 	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
 
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
 type Help struct {
 	Handler
-	Replier mreplier.Replier
 }
 
 func (h *Help) Name() string {
 	return h.Handler.Name
 }
 
-func (h *Help) Description() string {
-	return h.Handler.Description
+func (h *Help) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *Help) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -28,10 +28,10 @@ func (h *Help) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool,
 	return true, "", nil
 }
 
-func (h *Help) IsAdminOnly() bool {
-	return false
+func (h *Help) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *Help) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	return -1, NewResps(h.Replier.GetHelpMessages()...), nil
+	return -1, NewResps(ctx.Replier.GetHelpMessages()...), nil
 }
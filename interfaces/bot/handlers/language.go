@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type Language struct {
+	Handler
+	Users    mathbattle.UserRepository
+	Repliers application.Repliers
+}
+
+func (h *Language) Name() string {
+	return h.Handler.Name
+}
+
+func (h *Language) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *Language) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	return true
+}
+
+func (h *Language) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	return true, "", nil
+}
+
+func (h *Language) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
+}
+
+func (h *Language) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	switch ctx.CurrentStep {
+	case 0:
+		buttons := []InlineButton{}
+		for _, language := range h.Repliers.Languages() {
+			buttons = append(buttons, InlineButton{Text: h.Repliers.ForLanguage(language).LanguageName(), Data: language})
+		}
+
+		return 1, []TelegramResponse{NewRespWithInlineKeyboard(ctx.Replier.LanguageAsk(), h.Name(), buttons)}, nil
+	case 1:
+		// Ответ текстом вместо нажатия кнопки: название языка или его код
+		for _, language := range h.Repliers.Languages() {
+			if m.Text == language || m.Text == h.Repliers.ForLanguage(language).LanguageName() {
+				return h.change(ctx, language, nil)
+			}
+		}
+		return 1, OneTextResp(ctx.Replier.LanguageWrong()), nil
+	default:
+		return -1, noResponse(), nil
+	}
+}
+
+func (h *Language) HandleCallback(ctx infrastructure.TelegramUserContext, cb *tb.Callback) (int, []TelegramResponse, error) {
+	ref := CallbackMessageRef(cb)
+	return h.change(ctx, cb.Data, &ref)
+}
+
+// change сохраняет выбранный язык. Подтверждение уже на новом языке
+func (h *Language) change(ctx infrastructure.TelegramUserContext, language string,
+	buttonsMessage *infrastructure.MessageRef) (int, []TelegramResponse, error) {
+
+	replier := h.Repliers.ForLanguage(language)
+	user := ctx.User
+	user.Language = replier.Language()
+	if err := h.Users.Update(user); err != nil {
+		return -1, noResponse(), err
+	}
+
+	if buttonsMessage != nil {
+		return -1, []TelegramResponse{NewRespEdit(*buttonsMessage, replier.LanguageChanged())}, nil
+	}
+	return -1, OneTextResp(replier.LanguageChanged()), nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// League - администратор выбирает лигу, раундами которой управляет: /start_round, /stat и остальные
+// админские команды работают с раундом этой лиги
+type League struct {
+	Handler
+	Users   mathbattle.UserRepository
+	Leagues mathbattle.Leagues
+}
+
+func (h *League) Name() string {
+	return h.Handler.Name
+}
+
+func (h *League) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *League) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	return len(h.Leagues) != 0
+}
+
+func (h *League) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	return len(h.Leagues) != 0, "", nil
+}
+
+func (h *League) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionManageRounds
+}
+
+func (h *League) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	switch ctx.CurrentStep {
+	case 0:
+		buttons := append([]string{ctx.Replier.LeagueMain()}, h.Leagues.Names()...)
+		return 1, []TelegramResponse{NewRespWithKeyboard(ctx.Replier.LeagueAsk(ctx.User.League), buttons...)}, nil
+	case 1:
+		// Пустое название - основная лига
+		league := m.Text
+		if league == ctx.Replier.LeagueMain() {
+			league = ""
+		}
+		if !h.Leagues.IsExist(league) {
+			return 1, OneTextResp(ctx.Replier.LeagueWrong()), nil
+		}
+
+		user := ctx.User
+		user.League = league
+		if err := h.Users.Update(user); err != nil {
+			return -1, noResponse(), err
+		}
+
+		return -1, OneTextResp(ctx.Replier.LeagueChanged(league)), nil
+	default:
+		return -1, noResponse(), nil
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type Profile struct {
+	Handler
+	ParticipantService mathbattle.ParticipantService
+	Form               mathbattle.RegistrationForm
+	Schools            mathbattle.SchoolDirectory
+}
+
+func (h *Profile) Name() string {
+	return h.Handler.Name
+}
+
+func (h *Profile) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *Profile) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	res, _, _ := h.IsCommandSuitable(ctx)
+	return res
+}
+
+func (h *Profile) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, ctx.Replier.NotParticipant(), nil
+		}
+
+		return false, "", err
+	}
+
+	if !participant.IsActive {
+		return false, ctx.Replier.NotParticipant(), nil
+	}
+
+	return true, "", nil
+}
+
+func (h *Profile) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
+}
+
+func (h *Profile) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	switch ctx.CurrentStep {
+	case 0:
+		return h.stepShow(ctx, participant)
+	case 1:
+		return h.stepChooseField(ctx, participant, m)
+	case 2:
+		return h.stepAcceptValue(ctx, participant, m)
+	default:
+		return -1, noResponse(), nil
+	}
+}
+
+// profileFields - поля, которые участник может изменить: имя, класс и поля анкеты
+func (h *Profile) profileFields(grade int) []formField {
+	return append([]formField{formFieldName, formFieldGrade}, askedFormFields(h.Form, grade)...)
+}
+
+func (h *Profile) stepShow(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant) (int, []TelegramResponse, error) {
+	captions := []string{}
+	for _, field := range h.profileFields(participant.Grade) {
+		captions = append(captions, formFieldCaption(ctx, field))
+	}
+
+	return 1, []TelegramResponse{
+		NewResp(ctx.Replier.Profile(participant, h.Form)),
+		NewRespWithKeyboardRows(ctx.Replier.ProfileChooseField(), 2, captions...),
+	}, nil
+}
+
+func (h *Profile) stepChooseField(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant,
+	m *tb.Message) (int, []TelegramResponse, error) {
+
+	for _, field := range h.profileFields(participant.Grade) {
+		if m.Text == formFieldCaption(ctx, field) {
+			ctx.Variables["field"] = infrastructure.NewContextVariableStr(string(field))
+			return 2, []TelegramResponse{askFormField(ctx, h.Form, field, participant.Grade)}, nil
+		}
+	}
+
+	return -1, OneTextResp(ctx.Replier.ProfileNotChanged()), nil
+}
+
+func (h *Profile) stepAcceptValue(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant,
+	m *tb.Message) (int, []TelegramResponse, error) {
+
+	field := formField(ctx.Variables["field"].AsString())
+	value, answer, resp := acceptFormField(ctx, h.Form, h.Schools, field, participant.Grade, m.Text)
+	switch answer {
+	case formAnswerRetry:
+		return 2, resp, nil
+	case formAnswerRefused:
+		return -1, resp, nil
+	}
+
+	setFormField(&participant, field, value)
+	if err := h.ParticipantService.Update(participant); err != nil {
+		return -1, noResponse(), err
+	}
+
+	// В новом классе может понадобиться согласие родителей
+	if field == formFieldGrade && h.Form.IsConsentRequired(participant.Grade) && !participant.ParentalConsent {
+		ctx.Variables["field"] = infrastructure.NewContextVariableStr(string(formFieldParentalConsent))
+		return 2, []TelegramResponse{
+			NewResp(ctx.Replier.ProfileUpdated()),
+			askFormField(ctx, h.Form, formFieldParentalConsent, participant.Grade),
+		}, nil
+	}
+
+	return -1, NewResps(ctx.Replier.ProfileUpdated(), ctx.Replier.Profile(participant, h.Form)), nil
+}
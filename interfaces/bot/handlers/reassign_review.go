@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type ReassignReview struct {
+	Handler
+
+	RoundService mathbattle.RoundService
+}
+
+// Данные inline кнопок. Решения и участники выбираются из списков, которые отдаёт RoundService.ReassignOptions
+const (
+	reassignActionPrefix   = "action:"
+	reassignProblemPrefix  = "problem:"
+	reassignSolutionPrefix = "solution:"
+	reassignFromPrefix     = "from:"
+	reassignToPrefix       = "to:"
+	reassignYes            = "yes"
+	reassignAbort          = "abort"
+	// Больше кандидатов не показываем, первыми идут самые свободные
+	reassignMaxCandidates = 30
+)
+
+func (h *ReassignReview) Name() string {
+	return h.Handler.Name
+}
+
+func (h *ReassignReview) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *ReassignReview) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	res, _, _ := h.IsCommandSuitable(ctx)
+	return res
+}
+
+// IsCommandSuitable - распределение можно менять в идущем этапе ревью и до его начала, в предпросмотре
+func (h *ReassignReview) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	_, err := h.RoundService.GetReviewRunning(ctx.User.League)
+	if err == mathbattle.ErrNotFound {
+		_, err = h.RoundService.GetReviewPending(ctx.User.League)
+	}
+	if err == nil {
+		return true, "", nil
+	}
+
+	return false, "", err
+}
+
+func (h *ReassignReview) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionModerate
+}
+
+func (h *ReassignReview) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	switch ctx.CurrentStep {
+	case 0:
+		return h.stepAskAction(ctx, m)
+	case 1, 2, 3, 4, 5, 6:
+		// Всё выбирается кнопками, см. HandleCallback
+		return ctx.CurrentStep, OneTextResp(ctx.Replier.ReassignUseButtons()), nil
+	default:
+		return -1, noResponse(), nil
+	}
+}
+
+func (h *ReassignReview) stepAskAction(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	options, err := h.RoundService.ReassignOptions(ctx.User.League, 0)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	if len(options.Solutions) == 0 {
+		return -1, OneTextResp(ctx.Replier.ReassignNothing()), nil
+	}
+
+	// Все шаги показывают один и тот же предпросмотр
+	ctx.Variables["seed"] = infrastructure.NewContextVariableStr(strconv.FormatInt(options.Seed, 10))
+	for _, name := range []string{"action", "problem", "solution_id", "from", "to"} {
+		ctx.Variables[name] = infrastructure.NewContextVariableStr("")
+	}
+
+	return 1, []TelegramResponse{NewRespWithInlineKeyboard(ctx.Replier.ReassignAskAction(options.IsPreview), h.Name(),
+		[]InlineButton{{Text: ctx.Replier.ReassignActionMove(), Data: reassignActionPrefix + mathbattle.ReassignMove}},
+		[]InlineButton{{Text: ctx.Replier.ReassignActionAddReviewer(), Data: reassignActionPrefix + mathbattle.ReassignAddReviewer}},
+		[]InlineButton{{Text: ctx.Replier.ReassignActionToOrganizers(), Data: reassignActionPrefix + mathbattle.ReassignToOrganizers}},
+		h.abortRow(ctx))}, nil
+}
+
+func (h *ReassignReview) HandleCallback(ctx infrastructure.TelegramUserContext, cb *tb.Callback) (int, []TelegramResponse, error) {
+	ref := CallbackMessageRef(cb)
+
+	if cb.Data == reassignAbort {
+		return -1, []TelegramResponse{NewRespEdit(ref, ctx.Replier.Cancel())}, nil
+	}
+
+	options, err := h.options(ctx)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	switch {
+	case ctx.CurrentStep == 1 && strings.HasPrefix(cb.Data, reassignActionPrefix):
+		return h.acceptAction(ctx, ref, options, strings.TrimPrefix(cb.Data, reassignActionPrefix))
+	case ctx.CurrentStep == 2 && strings.HasPrefix(cb.Data, reassignProblemPrefix):
+		return h.acceptProblem(ctx, ref, options, strings.TrimPrefix(cb.Data, reassignProblemPrefix))
+	case ctx.CurrentStep == 3 && strings.HasPrefix(cb.Data, reassignSolutionPrefix):
+		return h.acceptSolution(ctx, ref, options, strings.TrimPrefix(cb.Data, reassignSolutionPrefix))
+	case ctx.CurrentStep == 4 && strings.HasPrefix(cb.Data, reassignFromPrefix):
+		return h.acceptFrom(ctx, ref, options, strings.TrimPrefix(cb.Data, reassignFromPrefix))
+	case ctx.CurrentStep == 5 && strings.HasPrefix(cb.Data, reassignToPrefix):
+		return h.acceptTo(ctx, ref, options, strings.TrimPrefix(cb.Data, reassignToPrefix))
+	case ctx.CurrentStep == 6 && cb.Data == reassignYes:
+		return h.reassign(ctx, ref, options)
+	}
+
+	// Кнопка с прошлого шага
+	return ctx.CurrentStep, noResponse(), nil
+}
+
+func (h *ReassignReview) acceptAction(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	options mathbattle.ReassignOptions, action string) (int, []TelegramResponse, error) {
+
+	if action != mathbattle.ReassignMove && action != mathbattle.ReassignAddReviewer && action != mathbattle.ReassignToOrganizers {
+		return 1, noResponse(), nil
+	}
+	ctx.Variables["action"] = infrastructure.NewContextVariableStr(action)
+
+	problemIDs := []string{}
+	for _, solution := range options.Solutions {
+		if len(problemIDs) == 0 || problemIDs[len(problemIDs)-1] != solution.ProblemID {
+			problemIDs = append(problemIDs, solution.ProblemID)
+		}
+	}
+
+	buttons := []InlineButton{}
+	for _, problemID := range problemIDs {
+		buttons = append(buttons, InlineButton{Text: ctx.Replier.ReassignProblemButton(problemID), Data: reassignProblemPrefix + problemID})
+	}
+
+	return 2, []TelegramResponse{h.keyboardEdit(ctx, ref, ctx.Replier.ReassignChooseProblem(), buttons)}, nil
+}
+
+func (h *ReassignReview) solutionsKeyboard(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	options mathbattle.ReassignOptions, messageText string) TelegramResponse {
+
+	buttons := []InlineButton{}
+	for _, solution := range options.Solutions {
+		if solution.ProblemID == ctx.Variables["problem"].AsString() {
+			buttons = append(buttons, InlineButton{Text: solution.Label, Data: reassignSolutionPrefix + solution.ID})
+		}
+	}
+	return h.keyboardEdit(ctx, ref, messageText, buttons)
+}
+
+func (h *ReassignReview) acceptProblem(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	options mathbattle.ReassignOptions, problemID string) (int, []TelegramResponse, error) {
+
+	ctx.Variables["problem"] = infrastructure.NewContextVariableStr(problemID)
+	return 3, []TelegramResponse{h.solutionsKeyboard(ctx, ref, options, ctx.Replier.ReassignChooseSolution())}, nil
+}
+
+func (h *ReassignReview) acceptSolution(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	options mathbattle.ReassignOptions, solutionID string) (int, []TelegramResponse, error) {
+
+	solution, isExist := findReassignSolution(options, solutionID)
+	if !isExist {
+		return 3, []TelegramResponse{h.solutionsKeyboard(ctx, ref, options, ctx.Replier.ReassignWrong())}, nil
+	}
+	ctx.Variables["solution_id"] = infrastructure.NewContextVariableStr(solution.ID)
+
+	switch ctx.Variables["action"].AsString() {
+	case mathbattle.ReassignMove:
+		// Решение, на которое уже прислано ревью, забрать нельзя
+		buttons := []InlineButton{}
+		for _, reviewer := range solution.Reviewers {
+			if !reviewer.Submitted {
+				buttons = append(buttons, InlineButton{Text: reviewer.Label, Data: reassignFromPrefix + reviewer.ID})
+			}
+		}
+		if len(buttons) == 0 || len(solution.Candidates) == 0 {
+			return 3, []TelegramResponse{h.solutionsKeyboard(ctx, ref, options, ctx.Replier.ReassignNoChoice())}, nil
+		}
+		return 4, []TelegramResponse{h.keyboardEdit(ctx, ref, ctx.Replier.ReassignChooseFrom(), buttons)}, nil
+	case mathbattle.ReassignAddReviewer:
+		if len(solution.Candidates) == 0 {
+			return 3, []TelegramResponse{h.solutionsKeyboard(ctx, ref, options, ctx.Replier.ReassignNoChoice())}, nil
+		}
+		return 5, []TelegramResponse{h.candidatesKeyboard(ctx, ref, solution)}, nil
+	default:
+		return 6, []TelegramResponse{h.confirm(ctx, ref, solution)}, nil
+	}
+}
+
+func (h *ReassignReview) acceptFrom(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	options mathbattle.ReassignOptions, participantID string) (int, []TelegramResponse, error) {
+
+	solution, isExist := findReassignSolution(options, ctx.Variables["solution_id"].AsString())
+	if !isExist {
+		return 3, []TelegramResponse{h.solutionsKeyboard(ctx, ref, options, ctx.Replier.ReassignWrong())}, nil
+	}
+
+	ctx.Variables["from"] = infrastructure.NewContextVariableStr(participantID)
+	return 5, []TelegramResponse{h.candidatesKeyboard(ctx, ref, solution)}, nil
+}
+
+func (h *ReassignReview) acceptTo(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	options mathbattle.ReassignOptions, participantID string) (int, []TelegramResponse, error) {
+
+	solution, isExist := findReassignSolution(options, ctx.Variables["solution_id"].AsString())
+	if !isExist {
+		return 3, []TelegramResponse{h.solutionsKeyboard(ctx, ref, options, ctx.Replier.ReassignWrong())}, nil
+	}
+
+	ctx.Variables["to"] = infrastructure.NewContextVariableStr(participantID)
+	return 6, []TelegramResponse{h.confirm(ctx, ref, solution)}, nil
+}
+
+func (h *ReassignReview) reassign(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	options mathbattle.ReassignOptions) (int, []TelegramResponse, error) {
+
+	order, err := h.order(ctx)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+	order.Seed = options.Seed
+
+	result, err := h.RoundService.ReassignReview(order)
+	if err != nil {
+		switch err {
+		case mathbattle.ErrWrongUserInput:
+			return -1, []TelegramResponse{NewRespEdit(ref, ctx.Replier.ReassignWrong())}, nil
+		case mathbattle.ErrReviewSubmitted:
+			return -1, []TelegramResponse{NewRespEdit(ref, ctx.Replier.ReassignReviewSubmitted())}, nil
+		}
+		return -1, noResponse(), err
+	}
+
+	return -1, []TelegramResponse{NewRespEdit(ref, ctx.Replier.ReassignSuccess(result))}, nil
+}
+
+func (h *ReassignReview) candidatesKeyboard(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	solution mathbattle.ReassignSolution) TelegramResponse {
+
+	buttons := []InlineButton{}
+	for i, candidate := range solution.Candidates {
+		if i == reassignMaxCandidates {
+			break
+		}
+		buttons = append(buttons, InlineButton{Text: candidate.Label, Data: reassignToPrefix + candidate.ID})
+	}
+	return h.keyboardEdit(ctx, ref, ctx.Replier.ReassignChooseTo(), buttons)
+}
+
+func (h *ReassignReview) confirm(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	solution mathbattle.ReassignSolution) TelegramResponse {
+
+	label := func(choices []mathbattle.ReassignChoice, ID string) string {
+		for _, choice := range choices {
+			if choice.ID == ID {
+				return choice.Label
+			}
+		}
+		return ID
+	}
+
+	edit := NewRespWithInlineKeyboard(ctx.Replier.ReassignConfirm(ctx.Variables["action"].AsString(), solution.Label,
+		label(solution.Reviewers, ctx.Variables["from"].AsString()), label(solution.Candidates, ctx.Variables["to"].AsString())),
+		h.Name(), []InlineButton{{Text: ctx.Replier.Yes(), Data: reassignYes}, {Text: ctx.Replier.No(), Data: reassignAbort}})
+	edit.Edit = &ref
+	return edit
+}
+
+// keyboardEdit заменяет сообщение с кнопками на новый выбор, по две кнопки в ряд
+func (h *ReassignReview) keyboardEdit(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef,
+	messageText string, buttons []InlineButton) TelegramResponse {
+
+	rows := [][]InlineButton{}
+	for i := 0; i < len(buttons); i += 2 {
+		end := i + 2
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		rows = append(rows, buttons[i:end])
+	}
+	rows = append(rows, h.abortRow(ctx))
+
+	edit := NewRespWithInlineKeyboard(messageText, h.Name(), rows...)
+	edit.Edit = &ref
+	return edit
+}
+
+func (h *ReassignReview) abortRow(ctx infrastructure.TelegramUserContext) []InlineButton {
+	return []InlineButton{{Text: ctx.Replier.ReassignAbort(), Data: reassignAbort}}
+}
+
+// options - решения и участники того же предпросмотра, что и на первом шаге
+func (h *ReassignReview) options(ctx infrastructure.TelegramUserContext) (mathbattle.ReassignOptions, error) {
+	seed, exist := ctx.Variables["seed"]
+	if !exist {
+		return mathbattle.ReassignOptions{}, errors.New("Can't find seed")
+	}
+
+	seedValue, err := strconv.ParseInt(seed.AsString(), 10, 64)
+	if err != nil {
+		return mathbattle.ReassignOptions{}, err
+	}
+
+	return h.RoundService.ReassignOptions(ctx.User.League, seedValue)
+}
+
+func findReassignSolution(options mathbattle.ReassignOptions, solutionID string) (mathbattle.ReassignSolution, bool) {
+	for _, solution := range options.Solutions {
+		if solution.ID == solutionID {
+			return solution, true
+		}
+	}
+	return mathbattle.ReassignSolution{}, false
+}
+
+func (h *ReassignReview) order(ctx infrastructure.TelegramUserContext) (mathbattle.ReassignOrder, error) {
+	values := make(map[string]string)
+	for _, name := range []string{"action", "solution_id", "from", "to"} {
+		variable, exist := ctx.Variables[name]
+		if !exist {
+			return mathbattle.ReassignOrder{}, errors.New("Can't find " + name)
+		}
+		values[name] = variable.AsString()
+	}
+
+	return mathbattle.ReassignOrder{
+		Action:            values["action"],
+		SolutionID:        values["solution_id"],
+		FromParticipantID: values["from"],
+		ToParticipantID:   values["to"],
+		League:            ctx.User.League,
+		ActorID:           ctx.User.TelegramID,
+	}, nil
+}
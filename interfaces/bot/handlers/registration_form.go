@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+)
+
+// formField - поле анкеты, которое спрашивается при регистрации и меняется в /profile
+type formField string
+
+const (
+	// Имя и класс спрашиваются при регистрации отдельно, здесь они нужны для /profile
+	formFieldName  formField = "name"
+	formFieldGrade formField = "grade"
+
+	formFieldSchool          formField = "school"
+	formFieldRegion          formField = "region"
+	formFieldTeacherContact  formField = "teacher_contact"
+	formFieldParentalConsent formField = "parental_consent"
+)
+
+// formFields - поля анкеты в порядке вопросов
+var formFields = []formField{formFieldSchool, formFieldRegion, formFieldTeacherContact, formFieldParentalConsent}
+
+const schoolSuggestionsLimit = 8
+
+type formAnswer int
+
+const (
+	formAnswerAccepted formAnswer = iota
+	formAnswerRetry
+	// Участник отказался дать согласие родителей
+	formAnswerRefused
+)
+
+func formFieldMode(form mathbattle.RegistrationForm, field formField, grade int) mathbattle.FieldMode {
+	switch field {
+	case formFieldName, formFieldGrade:
+		return mathbattle.FieldRequired
+	case formFieldSchool:
+		return form.School
+	case formFieldRegion:
+		return form.Region
+	case formFieldTeacherContact:
+		return form.TeacherContact
+	case formFieldParentalConsent:
+		if form.IsConsentRequired(grade) {
+			return mathbattle.FieldRequired
+		}
+	}
+	return mathbattle.FieldOff
+}
+
+// askedFormFields - поля, которые спрашиваются у участника из класса grade
+func askedFormFields(form mathbattle.RegistrationForm, grade int) []formField {
+	result := []formField{}
+	for _, field := range formFields {
+		if formFieldMode(form, field, grade).IsAsked() {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// nextFormField - следующее после after поле, которое нужно спросить. Пустой after - с начала анкеты
+func nextFormField(form mathbattle.RegistrationForm, grade int, after formField) (formField, bool) {
+	passed := after == ""
+	for _, field := range askedFormFields(form, grade) {
+		if passed {
+			return field, true
+		}
+		passed = field == after
+	}
+	return "", false
+}
+
+// setFormField записывает принятое acceptFormField значение. Новый класс относится к текущему учебному году
+func setFormField(participant *mathbattle.Participant, field formField, value string) {
+	switch field {
+	case formFieldName:
+		participant.Name = value
+	case formFieldGrade:
+		participant.Grade, _ = strconv.Atoi(value)
+		participant.GradeYear = mathbattle.SchoolYear(time.Now())
+	case formFieldSchool:
+		participant.School = value
+	case formFieldRegion:
+		participant.Region = value
+	case formFieldTeacherContact:
+		participant.TeacherContact = value
+	case formFieldParentalConsent:
+		participant.ParentalConsent = value != ""
+	}
+}
+
+func formFieldCaption(ctx infrastructure.TelegramUserContext, field formField) string {
+	switch field {
+	case formFieldName:
+		return ctx.Replier.ProfileFieldName()
+	case formFieldGrade:
+		return ctx.Replier.ProfileFieldGrade()
+	case formFieldSchool:
+		return ctx.Replier.ProfileFieldSchool()
+	case formFieldRegion:
+		return ctx.Replier.ProfileFieldRegion()
+	case formFieldTeacherContact:
+		return ctx.Replier.ProfileFieldTeacherContact()
+	default:
+		return ctx.Replier.ProfileFieldParentalConsent()
+	}
+}
+
+// askFormField - вопрос о поле. У необязательных полей есть кнопка "Пропустить"
+func askFormField(ctx infrastructure.TelegramUserContext, form mathbattle.RegistrationForm, field formField, grade int) TelegramResponse {
+	question := ""
+	switch field {
+	case formFieldName:
+		question = ctx.Replier.RegisterNameExpect()
+	case formFieldGrade:
+		question = ctx.Replier.ProfileGradeExpect()
+	case formFieldSchool:
+		question = ctx.Replier.RegisterSchoolExpect()
+	case formFieldRegion:
+		question = ctx.Replier.RegisterRegionExpect()
+	case formFieldTeacherContact:
+		question = ctx.Replier.RegisterTeacherContactExpect()
+	case formFieldParentalConsent:
+		return NewRespWithKeyboard(ctx.Replier.RegisterConsentExpect(), ctx.Replier.Yes(), ctx.Replier.No())
+	}
+
+	if formFieldMode(form, field, grade) == mathbattle.FieldOptional {
+		return NewRespWithKeyboard(question, ctx.Replier.RegisterSkip())
+	}
+	return NewResp(question)
+}
+
+// acceptFormField разбирает ответ на вопрос о поле. Значение согласия родителей - непустая строка.
+// Если введённой школы нет в справочнике, участнику предлагаются похожие, а повторно
+// отправленное название принимается как есть
+func acceptFormField(ctx infrastructure.TelegramUserContext, form mathbattle.RegistrationForm, schools mathbattle.SchoolDirectory,
+	field formField, grade int, text string) (string, formAnswer, []TelegramResponse) {
+
+	if field != formFieldParentalConsent && text == ctx.Replier.RegisterSkip() &&
+		formFieldMode(form, field, grade) == mathbattle.FieldOptional {
+		return "", formAnswerAccepted, noResponse()
+	}
+
+	switch field {
+	case formFieldName:
+		name, ok := mathbattle.ValidateUserName(text)
+		if !ok {
+			return "", formAnswerRetry, OneTextResp(ctx.Replier.RegisterNameWrong())
+		}
+		return name, formAnswerAccepted, noResponse()
+	case formFieldGrade:
+		grade, ok := mathbattle.ValidateUserGrade(text)
+		if !ok {
+			return "", formAnswerRetry, OneTextResp(ctx.Replier.RegisterGradeWrong())
+		}
+		return strconv.Itoa(grade), formAnswerAccepted, noResponse()
+	case formFieldSchool:
+		school, ok := mathbattle.ValidateSchool(text)
+		if !ok {
+			return "", formAnswerRetry, OneTextResp(ctx.Replier.RegisterSchoolWrong())
+		}
+
+		previousQuery := ctx.Variables["school_query"].AsString()
+		delete(ctx.Variables, "school_query")
+
+		suggestions := schools.Suggest(school, schoolSuggestionsLimit)
+		if len(suggestions) != 0 && mathbattle.IsSameSchool(suggestions[0], school) {
+			return suggestions[0], formAnswerAccepted, noResponse()
+		}
+		if len(suggestions) == 0 || previousQuery == school {
+			return school, formAnswerAccepted, noResponse()
+		}
+
+		ctx.Variables["school_query"] = infrastructure.NewContextVariableStr(school)
+		return "", formAnswerRetry, []TelegramResponse{
+			NewRespWithKeyboardRows(ctx.Replier.RegisterSchoolChoose(), 1, append(suggestions, school)...),
+		}
+	case formFieldRegion:
+		region, ok := mathbattle.ValidateRegion(text)
+		if !ok {
+			return "", formAnswerRetry, OneTextResp(ctx.Replier.RegisterRegionWrong())
+		}
+		return region, formAnswerAccepted, noResponse()
+	case formFieldTeacherContact:
+		contact, ok := mathbattle.ValidateTeacherContact(text)
+		if !ok {
+			return "", formAnswerRetry, OneTextResp(ctx.Replier.RegisterTeacherContactWrong())
+		}
+		return contact, formAnswerAccepted, noResponse()
+	default:
+		switch text {
+		case ctx.Replier.Yes():
+			return "1", formAnswerAccepted, noResponse()
+		case ctx.Replier.No():
+			return "", formAnswerRefused, OneTextResp(ctx.Replier.RegisterConsentRequired())
+		default:
+			return "", formAnswerRetry, []TelegramResponse{askFormField(ctx, form, field, grade)}
+		}
+	}
+}
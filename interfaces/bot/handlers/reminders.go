@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type Reminders struct {
+	Handler
+	ParticipantService mathbattle.ParticipantService
+}
+
+func (h *Reminders) Name() string {
+	return h.Handler.Name
+}
+
+func (h *Reminders) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *Reminders) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	res, _, _ := h.IsCommandSuitable(ctx)
+	return res
+}
+
+func (h *Reminders) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, ctx.Replier.NotParticipant(), nil
+		}
+
+		return false, "", err
+	}
+
+	if !participant.IsActive {
+		return false, ctx.Replier.NotParticipant(), nil
+	}
+
+	return true, "", nil
+}
+
+func (h *Reminders) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
+}
+
+func (h *Reminders) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	switch ctx.CurrentStep {
+	case 0:
+		toggle := InlineButton{Text: ctx.Replier.RemindersTurnOff(), Data: remindersOff}
+		if participant.RemindersOff {
+			toggle = InlineButton{Text: ctx.Replier.RemindersTurnOn(), Data: remindersOn}
+		}
+		cancel := InlineButton{Text: ctx.Replier.No(), Data: remindersKeep}
+
+		return 1, []TelegramResponse{
+			NewRespWithInlineKeyboard(ctx.Replier.RemindersStatus(participant.RemindersOff), h.Name(), []InlineButton{toggle, cancel}),
+		}, nil
+	case 1:
+		// Ответ текстом вместо нажатия кнопки
+		switch m.Text {
+		case ctx.Replier.RemindersTurnOn():
+			return h.change(ctx, participant, remindersOn, nil)
+		case ctx.Replier.RemindersTurnOff():
+			return h.change(ctx, participant, remindersOff, nil)
+		default:
+			return h.change(ctx, participant, remindersKeep, nil)
+		}
+	default:
+		return -1, noResponse(), nil
+	}
+}
+
+const (
+	remindersOn   = "on"
+	remindersOff  = "off"
+	remindersKeep = "keep"
+)
+
+func (h *Reminders) HandleCallback(ctx infrastructure.TelegramUserContext, cb *tb.Callback) (int, []TelegramResponse, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	ref := CallbackMessageRef(cb)
+	return h.change(ctx, participant, cb.Data, &ref)
+}
+
+// change применяет выбор пользователя. Если выбор сделан кнопкой, результат заменяет сообщение с кнопками
+func (h *Reminders) change(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant, action string,
+	buttonsMessage *infrastructure.MessageRef) (int, []TelegramResponse, error) {
+
+	reply := ctx.Replier.Cancel()
+	if action == remindersOn || action == remindersOff {
+		participant.RemindersOff = action == remindersOff
+		if err := h.ParticipantService.Update(participant); err != nil {
+			return -1, noResponse(), err
+		}
+		reply = ctx.Replier.RemindersChanged(participant.RemindersOff)
+	}
+
+	if buttonsMessage != nil {
+		return -1, []TelegramResponse{NewRespEdit(*buttonsMessage, reply)}, nil
+	}
+	return -1, OneTextResp(reply), nil
+}
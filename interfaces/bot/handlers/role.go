@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Role - владелец выдаёт пользователю роль или забирает её. Пользователь указывается именем
+// в телеграме или Telegram ID
+type Role struct {
+	Handler
+	RoleService mathbattle.RoleService
+}
+
+func (h *Role) Name() string {
+	return h.Handler.Name
+}
+
+func (h *Role) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *Role) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	return true
+}
+
+func (h *Role) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	return true, "", nil
+}
+
+func (h *Role) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionManageRoles
+}
+
+func (h *Role) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	switch ctx.CurrentStep {
+	case 0:
+		staff, err := h.RoleService.GetStaff()
+		if err != nil {
+			return -1, noResponse(), err
+		}
+		return 1, OneTextResp(ctx.Replier.RoleAskUser(staff)), nil
+	case 1:
+		ctx.Variables["user"] = infrastructure.NewContextVariableStr(strings.TrimSpace(m.Text))
+
+		buttons := []string{}
+		for _, role := range mathbattle.Roles() {
+			buttons = append(buttons, ctx.Replier.RoleName(role))
+		}
+		buttons = append(buttons, ctx.Replier.RoleName(mathbattle.RoleNone))
+		return 2, []TelegramResponse{NewRespWithKeyboard(ctx.Replier.RoleAskRole(), buttons...)}, nil
+	case 2:
+		return h.stepGrant(ctx, m)
+	default:
+		return -1, noResponse(), nil
+	}
+}
+
+func (h *Role) stepGrant(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	role, isFound := mathbattle.RoleNone, m.Text == ctx.Replier.RoleName(mathbattle.RoleNone)
+	for _, candidate := range mathbattle.Roles() {
+		if m.Text == ctx.Replier.RoleName(candidate) {
+			role, isFound = candidate, true
+		}
+	}
+	if !isFound {
+		return 2, OneTextResp(ctx.Replier.RoleWrongRole()), nil
+	}
+
+	userStr, exist := ctx.Variables["user"]
+	if !exist {
+		return -1, noResponse(), errors.New("Can't find user")
+	}
+
+	order := mathbattle.RoleOrder{
+		ActorID: ctx.User.TelegramID,
+		Role:    role,
+	}
+	if telegramID, err := strconv.ParseInt(userStr.AsString(), 10, 64); err == nil {
+		order.TelegramID = telegramID
+	} else {
+		order.TelegramName = userStr.AsString()
+	}
+
+	user, err := h.RoleService.Grant(order)
+	if err != nil {
+		if err == mathbattle.ErrWrongUserInput {
+			return -1, OneTextResp(ctx.Replier.RoleFailed()), nil
+		}
+		return -1, noResponse(), err
+	}
+
+	return -1, OneTextResp(ctx.Replier.RoleChanged(user)), nil
+}
@@ -12,7 +12,6 @@ import (
 
 type SendServiceMessage struct {
 	Handler
-	Replier        mreplier.Replier
 	PostmanService mathbattle.PostmanService
 }
 
@@ -20,8 +19,8 @@ func (h *SendServiceMessage) Name() string {
 	return h.Handler.Name
 }
 
-func (h *SendServiceMessage) Description() string {
-	return h.Handler.Description
+func (h *SendServiceMessage) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *SendServiceMessage) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -33,14 +32,14 @@ func (h *SendServiceMessage) IsCommandSuitable(ctx infrastructure.TelegramUserCo
 	return true, "", nil
 }
 
-func (h *SendServiceMessage) IsAdminOnly() bool {
-	return true
+func (h *SendServiceMessage) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionModerate
 }
 
 func (h *SendServiceMessage) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
 	switch ctx.CurrentStep {
 	case 0:
-		return 1, OneWithKb(h.Replier.ServiceMsgGetText(), h.Replier.ServiceMsgCancelSend()), nil
+		return 1, OneWithKb(ctx.Replier.ServiceMsgGetText(), ctx.Replier.ServiceMsgCancelSend()), nil
 	case 1:
 		return h.stepAcceptText(ctx, m)
 	case 2:
@@ -55,41 +54,41 @@ func (h *SendServiceMessage) Handle(ctx infrastructure.TelegramUserContext, m *t
 }
 
 func (h *SendServiceMessage) stepAcceptText(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	if m.Text == h.Replier.ServiceMsgCancelSend() {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
+	if m.Text == ctx.Replier.ServiceMsgCancelSend() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
 	}
 
 	if m.Text == "" {
-		return 1, OneWithKb(h.Replier.ServiceMsgTextIsEmpty(), h.Replier.ServiceMsgCancelSend()), nil
+		return 1, OneWithKb(ctx.Replier.ServiceMsgTextIsEmpty(), ctx.Replier.ServiceMsgCancelSend()), nil
 	}
 
 	ctx.Variables["msg_text"] = infrastructure.NewContextVariableStr(m.Text)
 
-	return 2, OneWithKb(h.Replier.ServiceMsgAskRecieversType(), h.Replier.ServiceMsgCancelSend(),
-		h.Replier.ServiceMsgRecieversTypeAll(), h.Replier.ServiceMsgRecieversTypeSome()), nil
+	return 2, OneWithKb(ctx.Replier.ServiceMsgAskRecieversType(), ctx.Replier.ServiceMsgCancelSend(),
+		ctx.Replier.ServiceMsgRecieversTypeAll(), ctx.Replier.ServiceMsgRecieversTypeSome()), nil
 }
 
 func (h *SendServiceMessage) stepAcceptRecievers(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	if m.Text == h.Replier.ServiceMsgCancelSend() {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
+	if m.Text == ctx.Replier.ServiceMsgCancelSend() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
 	}
 
-	if m.Text == h.Replier.ServiceMsgRecieversTypeAll() {
+	if m.Text == ctx.Replier.ServiceMsgRecieversTypeAll() {
 		ctx.Variables["recievers"] = infrastructure.NewContextVariableStr("all")
-		return 4, OneWithKb(h.Replier.ServiceMsgFinalAsk(h.Replier.ServiceMsgRecieversTypeAll()),
-			h.Replier.ServiceMsgCancelSend(), h.Replier.Yes()), nil
+		return 4, OneWithKb(ctx.Replier.ServiceMsgFinalAsk(ctx.Replier.ServiceMsgRecieversTypeAll()),
+			ctx.Replier.ServiceMsgCancelSend(), ctx.Replier.Yes()), nil
 	}
 
-	if m.Text == h.Replier.ServiceMsgRecieversTypeSome() {
-		return 3, OneWithKb(h.Replier.ServiceMsgInputRecievers(), h.Replier.ServiceMsgCancelSend()), nil
+	if m.Text == ctx.Replier.ServiceMsgRecieversTypeSome() {
+		return 3, OneWithKb(ctx.Replier.ServiceMsgInputRecievers(), ctx.Replier.ServiceMsgCancelSend()), nil
 	}
 
-	return 3, OneWithKb(h.Replier.ServiceMsgWrongRecieversType(), h.Replier.ServiceMsgCancelSend()), nil
+	return 3, OneWithKb(ctx.Replier.ServiceMsgWrongRecieversType(), ctx.Replier.ServiceMsgCancelSend()), nil
 }
 
 func (h *SendServiceMessage) stepAcceptParticularRecievers(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	if m.Text == h.Replier.ServiceMsgCancelSend() {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
+	if m.Text == ctx.Replier.ServiceMsgCancelSend() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
 	}
 
 	recievers := []string{}
@@ -99,13 +98,13 @@ func (h *SendServiceMessage) stepAcceptParticularRecievers(ctx infrastructure.Te
 
 	ctx.Variables["recievers"] = infrastructure.NewContextVariableStr(strings.Join(recievers, ","))
 
-	return 4, OneWithKb(h.Replier.ServiceMsgFinalAsk(h.Replier.ServiceMsgRecieversTypeSome(), recievers...),
-		h.Replier.ServiceMsgCancelSend(), h.Replier.Yes()), nil
+	return 4, OneWithKb(ctx.Replier.ServiceMsgFinalAsk(ctx.Replier.ServiceMsgRecieversTypeSome(), recievers...),
+		ctx.Replier.ServiceMsgCancelSend(), ctx.Replier.Yes()), nil
 }
 
 func (h *SendServiceMessage) send(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	if m.Text == h.Replier.ServiceMsgCancelSend() {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
+	if m.Text == ctx.Replier.ServiceMsgCancelSend() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
 	}
 
 	msgText, exist := ctx.Variables["msg_text"]
@@ -121,12 +120,14 @@ func (h *SendServiceMessage) send(ctx infrastructure.TelegramUserContext, m *tb.
 	var err error
 	if recievers.AsString() == "all" {
 		err = h.PostmanService.SendSimpleToUsers(mathbattle.SimpleMessage{
-			Text: msgText.AsString(),
+			Text:    msgText.AsString(),
+			ActorID: ctx.User.TelegramID,
 		})
 	} else {
 		err = h.PostmanService.SendSimpleToUsers(mathbattle.SimpleMessage{
 			Text:     msgText.AsString(),
 			UsersIDS: strings.Split(recievers.AsString(), ","),
+			ActorID:  ctx.User.TelegramID,
 		})
 	}
 
@@ -134,5 +135,5 @@ func (h *SendServiceMessage) send(ctx infrastructure.TelegramUserContext, m *tb.
 		return -1, noResponse(), errors.New("Failed to send")
 	}
 
-	return -1, OneTextResp(h.Replier.ServiceMsgSendSuccess()), nil
+	return -1, OneTextResp(ctx.Replier.ServiceMsgSendSuccess()), nil
 }
@@ -3,21 +3,21 @@ package handlers
 import (
 	mreplier "mathbattle/application"
 	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
 
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
 type Start struct {
 	Handler
-	Replier mreplier.Replier
 }
 
 func (h *Start) Name() string {
 	return h.Handler.Name
 }
 
-func (h *Start) Description() string {
-	return h.Handler.Description
+func (h *Start) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *Start) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -28,10 +28,10 @@ func (h *Start) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool,
 	return true, "", nil
 }
 
-func (h *Start) IsAdminOnly() bool {
-	return false
+func (h *Start) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *Start) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	return -1, OneTextResp(h.Replier.GetStartMessage()), nil
+	return -1, OneTextResp(ctx.Replier.GetStartMessage()), nil
 }
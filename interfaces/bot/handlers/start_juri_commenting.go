@@ -10,7 +10,6 @@ import (
 
 type StartJuriCommenting struct {
 	Handler
-	Replier         mreplier.Replier
 	RoundService    mathbattle.RoundService
 	SolutionService mathbattle.SolutionService
 }
@@ -19,8 +18,8 @@ func (h *StartJuriCommenting) Name() string {
 	return h.Handler.Name
 }
 
-func (h *StartJuriCommenting) Description() string {
-	return h.Handler.Description
+func (h *StartJuriCommenting) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *StartJuriCommenting) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -29,10 +28,10 @@ func (h *StartJuriCommenting) IsShowInHelp(ctx infrastructure.TelegramUserContex
 }
 
 func (h *StartJuriCommenting) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
-	_, err := h.RoundService.GetRunning()
+	_, err := h.RoundService.GetRunning(ctx.User.League)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NoRoundRunning(), nil
+			return false, ctx.Replier.NoRoundRunning(), nil
 		}
 
 		return false, "", err
@@ -41,14 +40,14 @@ func (h *StartJuriCommenting) IsCommandSuitable(ctx infrastructure.TelegramUserC
 	return true, "", nil
 }
 
-func (h *StartJuriCommenting) IsAdminOnly() bool {
-	return true
+func (h *StartJuriCommenting) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionJudge
 }
 
 func (h *StartJuriCommenting) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
 	switch ctx.CurrentStep {
 	case 0:
-		round, err := h.RoundService.GetRunning()
+		round, err := h.RoundService.GetRunning(ctx.User.League)
 		if err != nil {
 			return -1, noResponse(), nil
 		}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"strconv"
 
 	"mathbattle/application"
 	"mathbattle/infrastructure"
@@ -13,7 +14,6 @@ import (
 type StartReviewStage struct {
 	Handler
 
-	Replier      application.Replier
 	RoundService mathbattle.RoundService
 }
 
@@ -21,8 +21,8 @@ func (h *StartReviewStage) Name() string {
 	return h.Handler.Name
 }
 
-func (h *StartReviewStage) Description() string {
-	return h.Handler.Description
+func (h *StartReviewStage) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *StartReviewStage) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -31,7 +31,7 @@ func (h *StartReviewStage) IsShowInHelp(ctx infrastructure.TelegramUserContext)
 }
 
 func (h *StartReviewStage) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
-	_, err := h.RoundService.GetReviewPending()
+	_, err := h.RoundService.GetReviewPending(ctx.User.League)
 	if err == nil {
 		return true, "", nil
 	}
@@ -39,58 +39,61 @@ func (h *StartReviewStage) IsCommandSuitable(ctx infrastructure.TelegramUserCont
 	return false, "", err
 }
 
-func (h *StartReviewStage) IsAdminOnly() bool {
-	return true
+func (h *StartReviewStage) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionManageRounds
 }
 
 func (h *StartReviewStage) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
 	switch ctx.CurrentStep {
 	case 0:
-		return h.stepConfirmDistribution(ctx, m)
-	case 1:
 		return h.stepAskDuration(ctx, m)
+	case 1:
+		return h.stepDryRun(ctx, m)
 	case 2:
-		return h.stepConfirmDuration(ctx, m)
-	case 3:
 		return h.stepDistribute(ctx, m)
 	default:
 		return -1, noResponse(), nil
 	}
 }
 
-func (h *StartReviewStage) stepConfirmDistribution(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	distribution, err := h.RoundService.ReviewStageDistributionDesc()
-	if err != nil {
-		return -1, noResponse(), err
-	}
-
-	return 1, OneWithKb(distribution.Desc, h.Replier.Yes(), h.Replier.No()), nil
-}
-
 func (h *StartReviewStage) stepAskDuration(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	if m.Text != h.Replier.Yes() {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
-	}
-
-	return 2, OneTextResp(h.Replier.StartReviewGetDuration()), nil
+	return 1, OneTextResp(ctx.Replier.StartReviewGetDuration(ctx.TimeZone)), nil
 }
 
-func (h *StartReviewStage) stepConfirmDuration(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	ctx.Variables["until_date"] = infrastructure.NewContextVariableStr(m.Text)
-	untilDate, err := mathbattle.ParseStageEndDate(m.Text)
+// stepDryRun показывает распределение пробного запуска этапа. При подтверждении этап начнётся ровно с ним
+func (h *StartReviewStage) stepDryRun(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	untilDate, err := mathbattle.ParseStageEndDate(m.Text, ctx.TimeZone)
 	if err != nil {
 		if err == mathbattle.ErrWrongUserInput {
-			return 2, OneTextResp(h.Replier.StartReviewWrongDuration()), nil
+			return 1, OneTextResp(ctx.Replier.StartReviewWrongDuration()), nil
 		}
-		return -1, noResponse(), nil
+		return -1, noResponse(), err
+	}
+
+	dryRun, err := h.RoundService.StartReviewStage(mathbattle.StartOrder{
+		StageEnd: m.Text,
+		TimeZone: ctx.TimeZone.String(),
+		DryRun:   true,
+		League:   ctx.User.League,
+		ActorID:  ctx.User.TelegramID,
+	})
+	if err != nil {
+		return -1, noResponse(), err
 	}
 
-	return 3, OneWithKb(h.Replier.StartReviewConfirmDuration(untilDate), h.Replier.Yes(), h.Replier.No()), nil
+	ctx.Variables["until_date"] = infrastructure.NewContextVariableStr(m.Text)
+	ctx.Variables["seed"] = infrastructure.NewContextVariableStr(strconv.FormatInt(dryRun.Seed, 10))
+	ctx.Variables["distribution_hash"] = infrastructure.NewContextVariableStr(dryRun.DistributionHash)
+
+	return 2, []TelegramResponse{
+		NewResp(dryRun.Desc),
+		NewRespWithKeyboard(ctx.Replier.StartReviewConfirmDuration(untilDate), ctx.Replier.Yes(), ctx.Replier.No()),
+	}, nil
 }
 
 func (h *StartReviewStage) stepDistribute(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	if m.Text != h.Replier.Yes() {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
+	if m.Text != ctx.Replier.Yes() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
 	}
 
 	untilDateStr, exist := ctx.Variables["until_date"]
@@ -98,10 +101,35 @@ func (h *StartReviewStage) stepDistribute(ctx infrastructure.TelegramUserContext
 		return -1, noResponse(), errors.New("Can't find until_date")
 	}
 
-	cssResult, err := h.RoundService.StartReviewStage(mathbattle.StartOrder{StageEnd: untilDateStr.AsString()})
+	seedStr, exist := ctx.Variables["seed"]
+	if !exist {
+		return -1, noResponse(), errors.New("Can't find seed")
+	}
+
+	seed, err := strconv.ParseInt(seedStr.AsString(), 10, 64)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	return -1, OneTextResp(h.Replier.StartReviewSuccess(cssResult.FailedParticipants)), nil
+	hash, exist := ctx.Variables["distribution_hash"]
+	if !exist {
+		return -1, noResponse(), errors.New("Can't find distribution_hash")
+	}
+
+	cssResult, err := h.RoundService.StartReviewStage(mathbattle.StartOrder{
+		StageEnd:         untilDateStr.AsString(),
+		TimeZone:         ctx.TimeZone.String(),
+		Seed:             seed,
+		DistributionHash: hash.AsString(),
+		League:           ctx.User.League,
+		ActorID:          ctx.User.TelegramID,
+	})
+	if err != nil {
+		if err == mathbattle.ErrDistributionChanged {
+			return -1, OneTextResp(ctx.Replier.StartReviewDistributionChanged()), nil
+		}
+		return -1, noResponse(), err
+	}
+
+	return -1, OneTextResp(ctx.Replier.StartReviewSuccess(cssResult.FailedParticipants)), nil
 }
@@ -3,9 +3,11 @@ package handlers
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"mathbattle/application"
 	"mathbattle/infrastructure"
+	"mathbattle/libs/mstd"
 	"mathbattle/models/mathbattle"
 
 	tb "gopkg.in/tucnak/telebot.v2"
@@ -13,16 +15,18 @@ import (
 
 type StartRound struct {
 	Handler
-	Replier      application.Replier
-	RoundService mathbattle.RoundService
+	RoundService   mathbattle.RoundService
+	ProblemService mathbattle.ProblemService
+	// Thumbnails уменьшает условия задач при выборе из банка, nil - отправлять целиком
+	Thumbnails mathbattle.ImageNormalizer
 }
 
 func (h *StartRound) Name() string {
 	return h.Handler.Name
 }
 
-func (h *StartRound) Description() string {
-	return h.Handler.Description
+func (h *StartRound) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *StartRound) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -31,7 +35,7 @@ func (h *StartRound) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
 }
 
 func (h *StartRound) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
-	_, err := h.RoundService.GetRunning()
+	_, err := h.RoundService.GetRunning(ctx.User.League)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
 			return true, "", nil
@@ -42,8 +46,8 @@ func (h *StartRound) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (
 	return false, "", nil
 }
 
-func (h *StartRound) IsAdminOnly() bool {
-	return true
+func (h *StartRound) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionManageRounds
 }
 
 func (h *StartRound) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
@@ -53,8 +57,12 @@ func (h *StartRound) Handle(ctx infrastructure.TelegramUserContext, m *tb.Messag
 	case 1:
 		return h.stepConfirmDuration(ctx, m)
 	case 2:
-		return h.stepAskProblems(ctx, m)
+		return h.stepShowProblemBank(ctx, m)
 	case 3:
+		return h.stepPickProblem(ctx, m)
+	case 4:
+		return h.stepReorder(ctx, m)
+	case 5:
 		return h.stepStart(ctx, m)
 	default:
 		return -1, noResponse(), nil
@@ -62,45 +70,365 @@ func (h *StartRound) Handle(ctx infrastructure.TelegramUserContext, m *tb.Messag
 }
 
 func (h *StartRound) stepAskDuration(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	return 1, OneTextResp(h.Replier.StartRoundGetDuration()), nil
+	return 1, OneTextResp(ctx.Replier.StartRoundGetDuration(ctx.TimeZone)), nil
 }
 
 func (h *StartRound) stepConfirmDuration(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
 	ctx.Variables["until_date"] = infrastructure.NewContextVariableStr(m.Text)
-	untilDate, err := mathbattle.ParseStageEndDate(m.Text)
+	untilDate, err := mathbattle.ParseStageEndDate(m.Text, ctx.TimeZone)
 	if err != nil {
 		if err == mathbattle.ErrWrongUserInput {
-			return 1, OneTextResp(h.Replier.StartRoundWrongDuration()), nil
+			return 1, OneTextResp(ctx.Replier.StartRoundWrongDuration()), nil
 		}
 		return -1, noResponse(), nil
 	}
 
-	return 2, OneWithKb(h.Replier.StartRoundConfirmDuration(untilDate), h.Replier.Yes(), h.Replier.No()), nil
+	return 2, OneWithKb(ctx.Replier.StartRoundConfirmDuration(untilDate), ctx.Replier.Yes(), ctx.Replier.No()), nil
 }
 
-func (h *StartRound) stepAskProblems(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	if m.Text != h.Replier.Yes() {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
+func (h *StartRound) stepShowProblemBank(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	if m.Text != ctx.Replier.Yes() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
 	}
 
-	return 3, OneTextResp(h.Replier.StartRoundAskProblemsIDs()), nil
+	allProblems, err := h.ProblemService.GetAll()
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	if len(allProblems) == 0 {
+		return -1, OneTextResp(ctx.Replier.StartRoundProblemBankEmpty()), nil
+	}
+
+	ctx.Variables["problems_ids"] = infrastructure.NewContextVariableStr("")
+
+	result := []TelegramResponse{}
+	for _, problem := range allProblems {
+		msg := NewRespImage(h.thumbnail(problem))
+		msg.Text = ctx.Replier.StartRoundProblemCaption(problem)
+		result = append(result, msg)
+	}
+	result = append(result, h.pickKeyboard(ctx, ctx.Replier.StartRoundPickProblems(), allProblems, []mathbattle.Problem{}))
+
+	return 3, result, nil
+}
+
+// stepPickProblem - задачи выбираются кнопками под сообщением, см. HandleCallback
+func (h *StartRound) stepPickProblem(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	if m.Text == ctx.Replier.StartRoundAbort() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
+	}
+
+	allProblems, picked, err := h.problems(ctx)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	return 3, []TelegramResponse{h.pickKeyboard(ctx, ctx.Replier.StartRoundPickWrong(), allProblems, picked)}, nil
+}
+
+func (h *StartRound) stepReorder(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	if m.Text == ctx.Replier.StartRoundAbort() {
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
+	}
+
+	allProblems, picked, err := h.problems(ctx)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	captions := []string{}
+	for i := range picked {
+		captions = append(captions, mstd.IndexToLetter(i))
+	}
+
+	order, isOk := mathbattle.ValidateCaptionsOrder(m.Text, captions)
+	if !isOk {
+		return 4, []TelegramResponse{NewRespWithInlineKeyboard(ctx.Replier.StartRoundReorderWrong(), h.Name(),
+			[]InlineButton{{Text: ctx.Replier.StartRoundAbort(), Data: startRoundAbort}})}, nil
+	}
+
+	reordered := []mathbattle.Problem{}
+	for _, index := range order {
+		reordered = append(reordered, picked[index])
+	}
+	h.setPickedProblems(ctx, reordered)
+
+	return 3, []TelegramResponse{h.pickKeyboard(ctx, ctx.Replier.StartRoundPickedProblems(reordered), allProblems, reordered)}, nil
 }
 
 func (h *StartRound) stepStart(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	problemsIDs := strings.Split(m.Text, ",")
+	for _, data := range []string{startRoundModeIndividual, startRoundModeTeam, startRoundModeMatboi} {
+		if m.Text == h.modeButton(ctx, data) {
+			return h.start(ctx, data, nil)
+		}
+	}
+	return -1, OneTextResp(ctx.Replier.Cancel()), nil
+}
+
+// Данные inline кнопок выбора задач и режима раунда
+const (
+	startRoundPickPrefix     = "pick:"
+	startRoundDone           = "done"
+	startRoundReorder        = "reorder"
+	startRoundAbort          = "abort"
+	startRoundModeIndividual = "mode:individual"
+	startRoundModeTeam       = "mode:team"
+	startRoundModeMatboi     = "mode:matboi"
+)
+
+func (h *StartRound) HandleCallback(ctx infrastructure.TelegramUserContext, cb *tb.Callback) (int, []TelegramResponse, error) {
+	ref := CallbackMessageRef(cb)
+
+	if cb.Data == startRoundAbort {
+		return -1, []TelegramResponse{NewRespEdit(ref, ctx.Replier.Cancel())}, nil
+	}
+
+	switch ctx.CurrentStep {
+	case 3:
+		return h.pickCallback(ctx, cb.Data, ref)
+	case 5:
+		if cb.Data == startRoundModeIndividual || cb.Data == startRoundModeTeam || cb.Data == startRoundModeMatboi {
+			return h.start(ctx, cb.Data, &ref)
+		}
+	}
+
+	// Кнопка с прошлого шага, например задача после нажатия "Готово"
+	return ctx.CurrentStep, noResponse(), nil
+}
+
+func (h *StartRound) pickCallback(ctx infrastructure.TelegramUserContext, data string,
+	ref infrastructure.MessageRef) (int, []TelegramResponse, error) {
+
+	allProblems, picked, err := h.problems(ctx)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	switch data {
+	case startRoundDone:
+		if len(picked) == 0 {
+			return 3, []TelegramResponse{h.pickKeyboardEdit(ctx, ref, ctx.Replier.StartRoundPickNothing(), allProblems, picked)}, nil
+		}
+		step, result, err := h.preview(ctx, picked)
+		return step, append([]TelegramResponse{NewRespEdit(ref, ctx.Replier.StartRoundPickedProblems(picked))}, result...), err
+	case startRoundReorder:
+		if len(picked) < 2 {
+			return 3, []TelegramResponse{h.pickKeyboardEdit(ctx, ref, ctx.Replier.StartRoundPickedProblems(picked), allProblems, picked)}, nil
+		}
+		edit := NewRespWithInlineKeyboard(ctx.Replier.StartRoundReorderExpect(picked), h.Name(),
+			[]InlineButton{{Text: ctx.Replier.StartRoundAbort(), Data: startRoundAbort}})
+		edit.Edit = &ref
+		return 4, []TelegramResponse{edit}, nil
+	}
+
+	if !strings.HasPrefix(data, startRoundPickPrefix) {
+		return 3, noResponse(), nil
+	}
+
+	problem, isExist := mathbattle.FindProblemByID(allProblems, strings.TrimPrefix(data, startRoundPickPrefix))
+	if !isExist {
+		return 3, []TelegramResponse{h.pickKeyboardEdit(ctx, ref, ctx.Replier.StartRoundPickWrong(), allProblems, picked)}, nil
+	}
+
+	if _, isPicked := mathbattle.FindProblemByID(picked, problem.ID); isPicked {
+		updated := []mathbattle.Problem{}
+		for _, p := range picked {
+			if p.ID != problem.ID {
+				updated = append(updated, p)
+			}
+		}
+		picked = updated
+	} else {
+		picked = append(picked, problem)
+	}
+	h.setPickedProblems(ctx, picked)
+
+	return 3, []TelegramResponse{h.pickKeyboardEdit(ctx, ref, ctx.Replier.StartRoundPickedProblems(picked), allProblems, picked)}, nil
+}
 
+// preview показывает начало раунда так, как его увидят участники: условия задач в полном размере
+func (h *StartRound) preview(ctx infrastructure.TelegramUserContext, picked []mathbattle.Problem) (int, []TelegramResponse, error) {
 	untilDateStr, exist := ctx.Variables["until_date"]
 	if !exist {
 		return -1, noResponse(), errors.New("Can't find until_date")
 	}
 
+	untilDate, err := mathbattle.ParseStageEndDate(untilDateStr.AsString(), ctx.TimeZone)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	result := []TelegramResponse{
+		NewResp(ctx.Replier.StartRoundPreview()),
+		NewResp(ctx.Replier.ProblemsPostBefore(time.Until(untilDate), untilDate)),
+	}
+	for i, problem := range picked {
+		msg := NewRespImage(mathbattle.Image{
+			Extension: problem.Extension,
+			Content:   problem.Content,
+		})
+		msg.Text = mstd.IndexToLetter(i)
+		result = append(result, msg)
+	}
+	result = append(result, NewResp(ctx.Replier.ProblemsPostAfter()))
+	// Подтверждение заодно выбирает режим раунда
+	result = append(result, NewRespWithInlineKeyboard(ctx.Replier.StartRoundConfirmStart(picked), h.Name(),
+		[]InlineButton{
+			{Text: h.modeButton(ctx, startRoundModeIndividual), Data: startRoundModeIndividual},
+			{Text: h.modeButton(ctx, startRoundModeTeam), Data: startRoundModeTeam},
+			{Text: h.modeButton(ctx, startRoundModeMatboi), Data: startRoundModeMatboi},
+		},
+		[]InlineButton{{Text: ctx.Replier.No(), Data: startRoundAbort}}))
+
+	return 5, result, nil
+}
+
+func (h *StartRound) modeButton(ctx infrastructure.TelegramUserContext, data string) string {
+	switch data {
+	case startRoundModeTeam:
+		return ctx.Replier.StartRoundModeTeam()
+	case startRoundModeMatboi:
+		return ctx.Replier.StartRoundModeMatboi()
+	default:
+		return ctx.Replier.StartRoundModeIndividual()
+	}
+}
+
+// start начинает раунд. Если режим выбран кнопкой, результат заменяет сообщение с кнопками
+func (h *StartRound) start(ctx infrastructure.TelegramUserContext, modeData string,
+	buttonsMessage *infrastructure.MessageRef) (int, []TelegramResponse, error) {
+
+	mode := mathbattle.RoundIndividual
+	switch modeData {
+	case startRoundModeTeam:
+		mode = mathbattle.RoundTeam
+	case startRoundModeMatboi:
+		mode = mathbattle.RoundMatboi
+	}
+
+	untilDateStr, exist := ctx.Variables["until_date"]
+	if !exist {
+		return -1, noResponse(), errors.New("Can't find until_date")
+	}
+
+	problemsIDs, exist := ctx.Variables["problems_ids"]
+	if !exist {
+		return -1, noResponse(), errors.New("Can't find problems_ids")
+	}
+
 	startResult, err := h.RoundService.StartNew(mathbattle.StartOrder{
-		ProblemsIDs: problemsIDs,
+		ProblemsIDs: strings.Split(problemsIDs.AsString(), ","),
 		StageEnd:    untilDateStr.AsString(),
+		TimeZone:    ctx.TimeZone.String(),
+		Mode:        mode,
+		League:      ctx.User.League,
+		ActorID:     ctx.User.TelegramID,
 	})
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	return -1, OneTextResp(h.Replier.StartRoundSuccess(startResult)), nil
+	if buttonsMessage != nil {
+		return -1, []TelegramResponse{NewRespEdit(*buttonsMessage, ctx.Replier.StartRoundSuccess(startResult))}, nil
+	}
+	return -1, OneTextResp(ctx.Replier.StartRoundSuccess(startResult)), nil
+}
+
+// thumbnail - уменьшенное условие задачи. Если уменьшить не удалось, отправляется условие целиком
+func (h *StartRound) thumbnail(problem mathbattle.Problem) mathbattle.Image {
+	original := mathbattle.Image{
+		Extension: problem.Extension,
+		Content:   problem.Content,
+	}
+	if h.Thumbnails == nil {
+		return original
+	}
+
+	thumbnail, err := h.Thumbnails.Normalize(original)
+	if err != nil {
+		return original
+	}
+	return thumbnail
+}
+
+// pickKeyboard - сообщение с inline кнопкой на каждую задачу банка и кнопками Готово, Изменить порядок, Отменить
+func (h *StartRound) pickKeyboard(ctx infrastructure.TelegramUserContext, messageText string,
+	allProblems []mathbattle.Problem, picked []mathbattle.Problem) TelegramResponse {
+
+	rows := [][]InlineButton{}
+	row := []InlineButton{}
+	for _, problem := range allProblems {
+		_, isPicked := mathbattle.FindProblemByID(picked, problem.ID)
+		row = append(row, InlineButton{
+			Text: ctx.Replier.StartRoundProblemButton(problem, isPicked),
+			Data: startRoundPickPrefix + problem.ID,
+		})
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = []InlineButton{}
+		}
+	}
+	if len(row) != 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows,
+		[]InlineButton{
+			{Text: ctx.Replier.StartRoundPickDone(), Data: startRoundDone},
+			{Text: ctx.Replier.StartRoundReorder(), Data: startRoundReorder},
+		},
+		[]InlineButton{{Text: ctx.Replier.StartRoundAbort(), Data: startRoundAbort}})
+
+	return NewRespWithInlineKeyboard(messageText, h.Name(), rows...)
+}
+
+// pickKeyboardEdit - то же, что pickKeyboard, но заменяет уже отправленное сообщение с кнопками
+func (h *StartRound) pickKeyboardEdit(ctx infrastructure.TelegramUserContext, ref infrastructure.MessageRef, messageText string,
+	allProblems []mathbattle.Problem, picked []mathbattle.Problem) TelegramResponse {
+
+	result := h.pickKeyboard(ctx, messageText, allProblems, picked)
+	result.Edit = &ref
+	return result
+}
+
+// problems возвращает все задачи банка и уже выбранные в раунд
+func (h *StartRound) problems(ctx infrastructure.TelegramUserContext) ([]mathbattle.Problem, []mathbattle.Problem, error) {
+	allProblems, err := h.ProblemService.GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	picked, err := h.pickedProblems(ctx, allProblems)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return allProblems, picked, nil
+}
+
+func (h *StartRound) pickedProblems(ctx infrastructure.TelegramUserContext, allProblems []mathbattle.Problem) ([]mathbattle.Problem, error) {
+	result := []mathbattle.Problem{}
+
+	problemsIDs, exist := ctx.Variables["problems_ids"]
+	if !exist {
+		return result, errors.New("Can't find problems_ids")
+	}
+
+	if problemsIDs.AsString() == "" {
+		return result, nil
+	}
+
+	for _, ID := range strings.Split(problemsIDs.AsString(), ",") {
+		problem, isExist := mathbattle.FindProblemByID(allProblems, ID)
+		if !isExist {
+			return result, mathbattle.ErrNotFound
+		}
+		result = append(result, problem)
+	}
+
+	return result, nil
+}
+
+func (h *StartRound) setPickedProblems(ctx infrastructure.TelegramUserContext, picked []mathbattle.Problem) {
+	ctx.Variables["problems_ids"] = infrastructure.NewContextVariableStr(strings.Join(mathbattle.GetProblemIDs(picked), ","))
 }
@@ -10,7 +10,6 @@ import (
 
 type Stat struct {
 	Handler
-	Replier     application.Replier
 	StatService mathbattle.StatService
 }
 
@@ -18,8 +17,8 @@ func (h *Stat) Name() string {
 	return h.Handler.Name
 }
 
-func (h *Stat) Description() string {
-	return h.Handler.Description
+func (h *Stat) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *Stat) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -31,15 +30,15 @@ func (h *Stat) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool,
 	return true, "", nil
 }
 
-func (h *Stat) IsAdminOnly() bool {
-	return true
+func (h *Stat) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionViewStat
 }
 
 func (h *Stat) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	stat, err := h.StatService.Stat()
+	stat, err := h.StatService.Stat(ctx.User.League)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	return -1, OneTextResp(h.Replier.FormatStat(stat)), nil
+	return -1, OneTextResp(ctx.Replier.FormatStat(stat)), nil
 }
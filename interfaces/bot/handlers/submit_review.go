@@ -11,18 +11,18 @@ import (
 type SubmitReview struct {
 	Handler
 
-	Replier            mreplier.Replier
 	ReviewService      mathbattle.ReviewService
-	ParticipantService mathbattle.ParticipantRepository
+	ParticipantService mathbattle.ParticipantService
 	RoundService       mathbattle.RoundService
+	TeamService        mathbattle.TeamService
 }
 
 func (h *SubmitReview) Name() string {
 	return h.Handler.Name
 }
 
-func (h *SubmitReview) Description() string {
-	return h.Handler.Description
+func (h *SubmitReview) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *SubmitReview) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -34,20 +34,31 @@ func (h *SubmitReview) IsCommandSuitable(ctx infrastructure.TelegramUserContext)
 	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NotParticipant(), nil
+			return false, ctx.Replier.NotParticipant(), nil
 		}
 		return false, "", err
 	}
 
-	round, err := h.RoundService.GetReviewRunning()
+	round, err := h.RoundService.GetReviewRunning(participant.League)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NoRoundRunning(), nil
+			return false, ctx.Replier.NoRoundRunning(), nil
 		}
 		return false, "", err
 	}
 
-	_, isExist := round.ReviewDistribution.BetweenParticipants[participant.ID]
+	competitorID, err := roundTeamID(h.TeamService, round, participant.ID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, ctx.Replier.TeamRoundNoTeam(), nil
+		}
+		return false, "", err
+	}
+	if competitorID == "" {
+		competitorID = participant.ID
+	}
+
+	_, isExist := round.ReviewDistribution.BetweenParticipants[competitorID]
 	if !isExist {
 		return false, "", nil
 	}
@@ -55,17 +66,17 @@ func (h *SubmitReview) IsCommandSuitable(ctx infrastructure.TelegramUserContext)
 	return true, "", nil
 }
 
-func (h *SubmitReview) IsAdminOnly() bool {
-	return false
+func (h *SubmitReview) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *SubmitReview) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	r, err := h.RoundService.GetReviewRunning()
+	p, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	p, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	r, err := h.RoundService.GetReviewRunning(p.League)
 	if err != nil {
 		return -1, noResponse(), err
 	}
@@ -92,9 +103,9 @@ func (h *SubmitReview) stepStart(ctx infrastructure.TelegramUserContext, m *tb.M
 		return -1, noResponse(), err
 	}
 
-	captions := h.Replier.ReviewGetSolutionCaptions(descriptors)
+	captions := ctx.Replier.ReviewGetSolutionCaptions(descriptors)
 
-	return 1, OneWithKb(h.Replier.ReviewExpectSolutionCaption(), captions...), nil
+	return 1, OneWithKb(ctx.Replier.ReviewExpectSolutionCaption(), captions...), nil
 }
 
 func (h *SubmitReview) stepExpectSolutionCaption(ctx infrastructure.TelegramUserContext, m *tb.Message,
@@ -105,43 +116,47 @@ func (h *SubmitReview) stepExpectSolutionCaption(ctx infrastructure.TelegramUser
 		return -1, noResponse(), err
 	}
 
-	captions := h.Replier.ReviewGetSolutionCaptions(descriptors)
+	captions := ctx.Replier.ReviewGetSolutionCaptions(descriptors)
 
-	descriptor, isOk := h.Replier.ReviewGetDescriptor(m.Text)
+	descriptor, isOk := ctx.Replier.ReviewGetDescriptor(m.Text)
 	if !isOk {
-		return 1, OneWithKb(h.Replier.ReviewWrongSolutionCaption(), captions...), nil
+		return 1, OneWithKb(ctx.Replier.ReviewWrongSolutionCaption(), captions...), nil
 	}
 
 	solutionID, isOk := mathbattle.FindSolutionIDbyDescriptor(descriptor, descriptors)
 	if !isOk {
-		return 1, OneWithKb(h.Replier.ReviewWrongSolutionCaption(), captions...), nil
+		return 1, OneWithKb(ctx.Replier.ReviewWrongSolutionCaption(), captions...), nil
 	}
 
 	ctx.Variables["solution_id"] = infrastructure.NewContextVariableStr(solutionID)
-	reviews, err := h.ReviewService.FindMany(mathbattle.ReviewFindDescriptor{
-		ReviewerID: participant.ID,
-		SolutionID: solutionID,
-	})
+	findDescriptor, err := reviewFindDescriptor(h.TeamService, round, participant.ID, solutionID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	reviews, err := h.ReviewService.FindMany(findDescriptor)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
 	if len(reviews) == 0 {
-		return 3, OneTextResp(h.Replier.ReviewExpectContent()), nil
+		return 3, OneTextResp(ctx.Replier.ReviewExpectContent()), nil
 	}
 
-	return 2, OneWithKb(h.Replier.ReviewIsRewriteOld(), h.Replier.Yes(), h.Replier.No()), nil
+	return 2, OneWithKb(ctx.Replier.ReviewIsRewriteOld(), ctx.Replier.Yes(), ctx.Replier.No()), nil
 }
 
 func (h *SubmitReview) stepAlreadySubmitted(ctx infrastructure.TelegramUserContext, m *tb.Message,
 	round mathbattle.Round, participant mathbattle.Participant) (int, []TelegramResponse, error) {
 
-	if m.Text == h.Replier.Yes() {
+	if m.Text == ctx.Replier.Yes() {
 		solutionID := ctx.Variables["solution_id"].AsString()
-		reviews, err := h.ReviewService.FindMany(mathbattle.ReviewFindDescriptor{
-			ReviewerID: participant.ID,
-			SolutionID: solutionID,
-		})
+		findDescriptor, err := reviewFindDescriptor(h.TeamService, round, participant.ID, solutionID)
+		if err != nil {
+			return -1, noResponse(), err
+		}
+
+		reviews, err := h.ReviewService.FindMany(findDescriptor)
 		if err != nil {
 			return -1, noResponse(), err
 		}
@@ -150,13 +165,13 @@ func (h *SubmitReview) stepAlreadySubmitted(ctx infrastructure.TelegramUserConte
 			return -1, noResponse(), err
 		}
 
-		if err := h.ReviewService.Delete(reviews[0].ID); err != nil {
+		if err := h.ReviewService.Delete(reviews[0].ID, ctx.User.TelegramID); err != nil {
 			return -1, noResponse(), err
 		}
 
-		return 3, OneTextResp(h.Replier.ReviewExpectContent()), nil
+		return 3, OneTextResp(ctx.Replier.ReviewExpectContent()), nil
 	} else {
-		return -1, OneTextResp(h.Replier.Cancel()), nil
+		return -1, OneTextResp(ctx.Replier.Cancel()), nil
 	}
 }
 
@@ -164,15 +179,21 @@ func (h *SubmitReview) stepAcceptReview(ctx infrastructure.TelegramUserContext,
 	round mathbattle.Round, participant mathbattle.Participant) (int, []TelegramResponse, error) {
 
 	solutionID := ctx.Variables["solution_id"].AsString()
-	_, err := h.ReviewService.Store(mathbattle.Review{
-		ReviewerID: participant.ID,
-		SolutionID: solutionID,
-		Content:    m.Text,
-		Mark:       -1,
+	teamID, err := roundTeamID(h.TeamService, round, participant.ID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	_, err = h.ReviewService.Store(mathbattle.Review{
+		ReviewerID:     participant.ID,
+		ReviewerTeamID: teamID,
+		SolutionID:     solutionID,
+		Content:        m.Text,
+		Mark:           -1,
 	})
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	return -1, OneTextResp(h.Replier.ReviewUploadSuccess()), nil
+	return -1, OneTextResp(ctx.Replier.ReviewUploadSuccess()), nil
 }
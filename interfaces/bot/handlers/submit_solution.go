@@ -13,18 +13,19 @@ import (
 
 type SubmitSolution struct {
 	Handler
-	Replier            application.Replier
 	ParticipantService mathbattle.ParticipantService
 	RoundService       mathbattle.RoundService
 	SolutionService    mathbattle.SolutionService
+	TeamService        mathbattle.TeamService
+	PartLimits         mathbattle.SolutionPartLimits
 }
 
 func (h *SubmitSolution) Name() string {
 	return h.Handler.Name
 }
 
-func (h *SubmitSolution) Description() string {
-	return h.Handler.Description
+func (h *SubmitSolution) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *SubmitSolution) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -33,40 +34,40 @@ func (h *SubmitSolution) IsShowInHelp(ctx infrastructure.TelegramUserContext) bo
 }
 
 func (h *SubmitSolution) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
-	_, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NotParticipant(), nil
+			return false, ctx.Replier.NotParticipant(), nil
 		}
 		return false, "", err
 	}
 
-	round, err := h.RoundService.GetRunning()
+	round, err := h.RoundService.GetRunning(participant.League)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NoRoundRunning(), nil
+			return false, ctx.Replier.NoRoundRunning(), nil
 		}
 		return false, "", err
 	}
 
 	if mathbattle.GetRoundStage(round) != mathbattle.StageSolve {
-		return false, h.Replier.NoRoundRunning(), nil
+		return false, ctx.Replier.NoRoundRunning(), nil
 	}
 
-	return true, "", nil
+	return isInRoundTeam(ctx, h.TeamService, round, participant.ID)
 }
 
-func (h *SubmitSolution) IsAdminOnly() bool {
-	return false
+func (h *SubmitSolution) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *SubmitSolution) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
-	r, err := h.RoundService.GetRunning()
+	p, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	p, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	r, err := h.RoundService.GetRunning(p.League)
 	if err != nil {
 		return -1, noResponse(), err
 	}
@@ -95,7 +96,7 @@ func (h *SubmitSolution) stepStart(ctx infrastructure.TelegramUserContext, m *tb
 
 	captions := mathbattle.ProblemsCaptions(descriptors)
 
-	return 1, OneWithKb(h.Replier.SolutionExpectProblemCaption(), captions...), nil
+	return 1, OneWithKb(ctx.Replier.SolutionExpectProblemCaption(), captions...), nil
 }
 
 func (h *SubmitSolution) stepExpectProblemNumber(ctx infrastructure.TelegramUserContext, m *tb.Message,
@@ -109,59 +110,72 @@ func (h *SubmitSolution) stepExpectProblemNumber(ctx infrastructure.TelegramUser
 
 	problemNumber, isOk := mathbattle.ValidateCaptions(m.Text, descriptors)
 	if !isOk {
-		return 1, OneWithKb(h.Replier.SolutionWrongProblemCaption(), captions...), nil
+		return 1, OneWithKb(ctx.Replier.SolutionWrongProblemCaption(), captions...), nil
 	}
 
-	problemID := round.ProblemDistribution[participant.ID][problemNumber].ProblemID
+	problemID := descriptors[problemNumber].ProblemID
 	ctx.Variables["problem_id"] = infrastructure.NewContextVariableStr(problemID)
 	ctx.Variables["total_uploaded"] = infrastructure.NewContextVariableInt(0)
 
-	solutions, err := h.SolutionService.Find(mathbattle.FindDescriptor{
-		RoundID:       round.ID,
-		ParticipantID: participant.ID,
-		ProblemID:     problemID,
-	})
+	descriptor, err := solutionFindDescriptor(h.TeamService, round, participant.ID, problemID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	solutions, err := h.SolutionService.Find(descriptor)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
 	if len(solutions) == 0 || len(solutions[0].Parts) == 0 {
+		// В командном раунде решение одно на команду: пустое решение, начатое другим участником, заменяется
+		for _, solution := range solutions {
+			if err := h.SolutionService.Delete(solution.ID, ctx.User.TelegramID); err != nil {
+				return -1, noResponse(), err
+			}
+		}
+
 		_, err = h.SolutionService.Create(mathbattle.Solution{
 			ParticipantID: participant.ID,
-			ProblemID:     ctx.Variables["problem_id"].AsString(),
+			TeamID:        descriptor.TeamID,
+			ProblemID:     problemID,
 			RoundID:       round.ID,
 			Mark:          -1,
 		})
 		if err != nil {
 			return -1, noResponse(), err
 		}
-		return 3, OneWithKb(h.Replier.SolutionExpectPart(), h.Replier.SolutionFinishUploading()), nil
+		return 3, OneWithKb(ctx.Replier.SolutionExpectPart(), ctx.Replier.SolutionFinishUploading()), nil
 	}
 
-	return 2, OneWithKb(h.Replier.SolutionIsRewriteOld(), h.Replier.Yes(), h.Replier.No()), nil
+	return 2, OneWithKb(ctx.Replier.SolutionIsRewriteOld(), ctx.Replier.Yes(), ctx.Replier.No()), nil
 }
 
 func (h *SubmitSolution) stepAlreadySubmitted(ctx infrastructure.TelegramUserContext, m *tb.Message,
 	round mathbattle.Round, participant mathbattle.Participant) (int, []TelegramResponse, error) {
 
-	if m.Text == h.Replier.Yes() {
+	if m.Text == ctx.Replier.Yes() {
 		problemID := ctx.Variables["problem_id"].AsString()
-		solutions, err := h.SolutionService.Find(mathbattle.FindDescriptor{
-			RoundID:       round.ID,
-			ParticipantID: participant.ID,
-			ProblemID:     problemID,
-		})
+		descriptor, err := solutionFindDescriptor(h.TeamService, round, participant.ID, problemID)
 		if err != nil {
 			return -1, noResponse(), err
 		}
 
-		if err := h.SolutionService.Delete(solutions[0].ID); err != nil {
+		solutions, err := h.SolutionService.Find(descriptor)
+		if err != nil {
 			return -1, noResponse(), err
 		}
 
+		for _, solution := range solutions {
+			if err := h.SolutionService.Delete(solution.ID, ctx.User.TelegramID); err != nil {
+				return -1, noResponse(), err
+			}
+		}
+
 		_, err = h.SolutionService.Create(mathbattle.Solution{
 			ParticipantID: participant.ID,
-			ProblemID:     ctx.Variables["problem_id"].AsString(),
+			TeamID:        descriptor.TeamID,
+			ProblemID:     problemID,
 			RoundID:       round.ID,
 			Mark:          -1,
 		})
@@ -169,50 +183,47 @@ func (h *SubmitSolution) stepAlreadySubmitted(ctx infrastructure.TelegramUserCon
 			return -1, noResponse(), err
 		}
 
-		return 3, OneWithKb(h.Replier.SolutionExpectPart(), h.Replier.SolutionFinishUploading()), nil
+		return 3, OneWithKb(ctx.Replier.SolutionExpectPart(), ctx.Replier.SolutionFinishUploading()), nil
 	} else {
-		return -1, OneWithKb(h.Replier.SolutionDeclineRewriteOld()), nil
+		return -1, OneWithKb(ctx.Replier.SolutionDeclineRewriteOld()), nil
 	}
 }
 
 func (h *SubmitSolution) stepAcceptSolutionPart(ctx infrastructure.TelegramUserContext, m *tb.Message,
 	round mathbattle.Round, participant mathbattle.Participant) (int, []TelegramResponse, error) {
 
-	if m.Text == h.Replier.SolutionFinishUploading() {
+	if m.Text == ctx.Replier.SolutionFinishUploading() {
 		totalUploaded, _ := ctx.Variables["total_uploaded"].AsInt()
 		if totalUploaded == 0 {
 			// Удалить пустое решение
-			problemID := ctx.Variables["problem_id"].AsString()
-			s, err := h.SolutionService.Find(mathbattle.FindDescriptor{
-				RoundID:       round.ID,
-				ParticipantID: participant.ID,
-				ProblemID:     problemID,
-			})
+			s, err := h.currentSolution(ctx, round, participant)
 			if err != nil {
 				return -1, noResponse(), err
 			}
 
-			err = h.SolutionService.Delete(s[0].ID)
+			err = h.SolutionService.Delete(s.ID, ctx.User.TelegramID)
 			if err != nil {
 				return -1, noResponse(), err
 			}
 
-			return -1, OneTextResp(h.Replier.SolutionEmpty()), nil
+			return -1, OneTextResp(ctx.Replier.SolutionEmpty()), nil
 		} else {
-			return -1, OneTextResp(h.Replier.SolutionUploadSuccess(totalUploaded)), nil
+			return -1, OneTextResp(ctx.Replier.SolutionUploadSuccess(totalUploaded)), nil
 		}
 	}
 
 	if m.Photo == nil && m.Document == nil {
-		return 3, OneWithKb(h.Replier.SolutionWrongFormat(), h.Replier.SolutionFinishUploading()), nil
+		return 3, OneWithKb(ctx.Replier.SolutionWrongFormat(), ctx.Replier.SolutionFinishUploading()), nil
 	}
 
 	var uploadedFile tb.File
+	mimeType := mathbattle.MimeTypeJPEG // телеграм пересжимает фотографии в jpeg
 	if m.Photo != nil {
 		uploadedFile = m.Photo.File
 	} else {
 		if m.Document != nil {
 			uploadedFile = m.Document.File
+			mimeType = m.Document.MIME
 		}
 	}
 
@@ -221,22 +232,29 @@ func (h *SubmitSolution) stepAcceptSolutionPart(ctx infrastructure.TelegramUserC
 		return -1, noResponse(), err
 	}
 	extension := filepath.Ext(uploadedFile.FilePath)
+	if extension == "" && m.Document != nil {
+		extension = filepath.Ext(m.Document.FileName)
+	}
+	part := mathbattle.Image{
+		Extension: extension,
+		MimeType:  mimeType,
+		Content:   content,
+	}
+
+	// Сервер проверяет то же самое, здесь - чтобы не отправлять ему заведомо неподходящий файл
+	if err := h.PartLimits.Check(part); err != nil {
+		return h.partRejected(ctx, part, err)
+	}
 
-	problemID := ctx.Variables["problem_id"].AsString()
-	s, err := h.SolutionService.Find(mathbattle.FindDescriptor{
-		RoundID:       round.ID,
-		ParticipantID: participant.ID,
-		ProblemID:     problemID,
-	})
+	curSolution, err := h.currentSolution(ctx, round, participant)
 	if err != nil {
 		return -1, noResponse(), err
 	}
-	curSolution := s[0]
 
-	err = h.SolutionService.AppendPart(curSolution.ID, mathbattle.Image{
-		Extension: extension,
-		Content:   content,
-	})
+	err = h.SolutionService.AppendPart(curSolution.ID, part)
+	if err == mathbattle.ErrSolutionPartTooLarge || err == mathbattle.ErrSolutionPartUnsupported {
+		return h.partRejected(ctx, part, err)
+	}
 	if err != nil {
 		return -1, noResponse(), err
 	}
@@ -248,6 +266,37 @@ func (h *SubmitSolution) stepAcceptSolutionPart(ctx infrastructure.TelegramUserC
 	totalUploaded++
 	ctx.Variables["total_uploaded"] = infrastructure.NewContextVariableInt(totalUploaded)
 
-	return 3, OneWithKb(h.Replier.SolutionPartUploaded(totalUploaded),
-		h.Replier.SolutionFinishUploading()), nil
+	return 3, OneWithKb(ctx.Replier.SolutionPartUploaded(totalUploaded),
+		ctx.Replier.SolutionFinishUploading()), nil
+}
+
+// currentSolution - решение, которое сейчас загружает участник
+func (h *SubmitSolution) currentSolution(ctx infrastructure.TelegramUserContext, round mathbattle.Round,
+	participant mathbattle.Participant) (mathbattle.Solution, error) {
+
+	descriptor, err := solutionFindDescriptor(h.TeamService, round, participant.ID, ctx.Variables["problem_id"].AsString())
+	if err != nil {
+		return mathbattle.Solution{}, err
+	}
+
+	solutions, err := h.SolutionService.Find(descriptor)
+	if err != nil {
+		return mathbattle.Solution{}, err
+	}
+	if len(solutions) == 0 {
+		return mathbattle.Solution{}, mathbattle.ErrNotFound
+	}
+
+	return solutions[0], nil
+}
+
+func (h *SubmitSolution) partRejected(ctx infrastructure.TelegramUserContext, part mathbattle.Image,
+	err error) (int, []TelegramResponse, error) {
+
+	msg := ctx.Replier.SolutionPartUnsupported()
+	if err == mathbattle.ErrSolutionPartTooLarge {
+		msg = ctx.Replier.SolutionPartTooLarge(h.PartLimits.MaxSize(part))
+	}
+
+	return 3, OneWithKb(msg, ctx.Replier.SolutionFinishUploading()), nil
 }
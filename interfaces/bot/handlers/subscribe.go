@@ -10,17 +10,18 @@ import (
 
 type Subscribe struct {
 	Handler
-	Replier            application.Replier
 	ParticipantService mathbattle.ParticipantService
 	RoundService       mathbattle.RoundService
+	Form               mathbattle.RegistrationForm
+	Schools            mathbattle.SchoolDirectory
 }
 
 func (h *Subscribe) Name() string {
 	return h.Handler.Name
 }
 
-func (h *Subscribe) Description() string {
-	return h.Handler.Description
+func (h *Subscribe) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *Subscribe) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -39,14 +40,14 @@ func (h *Subscribe) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (b
 	}
 
 	if participant.IsActive {
-		return false, h.Replier.AlreadyRegistered(), nil
+		return false, ctx.Replier.AlreadyRegistered(), nil
 	}
 
 	return true, "", nil
 }
 
-func (h *Subscribe) IsAdminOnly() bool {
-	return false
+func (h *Subscribe) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *Subscribe) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
@@ -56,7 +57,9 @@ func (h *Subscribe) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message
 	case 1:
 		return h.stepAcceptName(ctx, m)
 	case 2:
-		return h.stepAcceptGradeAndFinish(ctx, m)
+		return h.stepAcceptGrade(ctx, m)
+	case 3:
+		return h.stepAcceptFormField(ctx, m)
 	default:
 		return -1, noResponse(), nil
 	}
@@ -69,7 +72,7 @@ func (h *Subscribe) stepCheckExistance(ctx infrastructure.TelegramUserContext, m
 	}
 
 	if err == mathbattle.ErrNotFound {
-		return 1, OneTextResp(h.Replier.RegisterNameExpect()), nil
+		return 1, OneTextResp(ctx.Replier.RegisterNameExpect()), nil
 	}
 
 	participant.IsActive = true
@@ -78,47 +81,89 @@ func (h *Subscribe) stepCheckExistance(ctx infrastructure.TelegramUserContext, m
 		return -1, noResponse(), err
 	}
 
-	return -1, OneTextResp(h.Replier.RegisterSuccess()), nil
+	return -1, OneTextResp(ctx.Replier.RegisterSuccess()), nil
 }
 
 func (h *Subscribe) stepAcceptName(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
 	name, ok := mathbattle.ValidateUserName(m.Text)
 	if !ok {
-		return 1, OneTextResp(h.Replier.RegisterNameWrong()), nil
+		return 1, OneTextResp(ctx.Replier.RegisterNameWrong()), nil
 	}
 
 	ctx.Variables["name"] = infrastructure.NewContextVariableStr(name)
 
-	return 2, OneTextResp(h.Replier.RegisterGradeExpect()), nil
+	return 2, OneTextResp(ctx.Replier.RegisterGradeExpect()), nil
 }
 
-func (h *Subscribe) stepAcceptGradeAndFinish(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+func (h *Subscribe) stepAcceptGrade(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
 	grade, ok := mathbattle.ValidateUserGrade(m.Text)
 	if !ok {
-		return 2, OneTextResp(h.Replier.RegisterGradeWrong()), nil
+		return 2, OneTextResp(ctx.Replier.RegisterGradeWrong()), nil
 	}
 
-	_, err := h.ParticipantService.Store(mathbattle.Participant{
+	ctx.Variables["grade"] = infrastructure.NewContextVariableInt(grade)
+
+	return h.askNextFormField(ctx, "")
+}
+
+func (h *Subscribe) stepAcceptFormField(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	grade, err := ctx.Variables["grade"].AsInt()
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	field := formField(ctx.Variables["field"].AsString())
+	value, answer, resp := acceptFormField(ctx, h.Form, h.Schools, field, grade, m.Text)
+	switch answer {
+	case formAnswerRetry:
+		return 3, resp, nil
+	case formAnswerRefused:
+		return -1, resp, nil
+	}
+
+	ctx.Variables[string(field)] = infrastructure.NewContextVariableStr(value)
+
+	return h.askNextFormField(ctx, field)
+}
+
+// askNextFormField спрашивает следующее поле анкеты, а когда анкета заполнена - регистрирует участника
+func (h *Subscribe) askNextFormField(ctx infrastructure.TelegramUserContext, after formField) (int, []TelegramResponse, error) {
+	grade, err := ctx.Variables["grade"].AsInt()
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	field, ok := nextFormField(h.Form, grade, after)
+	if !ok {
+		return h.finish(ctx, grade)
+	}
+
+	ctx.Variables["field"] = infrastructure.NewContextVariableStr(string(field))
+	return 3, []TelegramResponse{askFormField(ctx, h.Form, field, grade)}, nil
+}
+
+func (h *Subscribe) finish(ctx infrastructure.TelegramUserContext, grade int) (int, []TelegramResponse, error) {
+	participant := mathbattle.Participant{
 		User:     ctx.User,
 		Name:     ctx.Variables["name"].AsString(),
-		School:   ctx.Variables["school"].AsString(),
 		Grade:    grade,
 		IsActive: true,
-	})
+	}
+	for _, field := range askedFormFields(h.Form, grade) {
+		setFormField(&participant, field, ctx.Variables[string(field)].AsString())
+	}
 
+	participant, err := h.ParticipantService.Store(participant)
 	if err != nil {
 		return -1, noResponse(), err
 	}
 
-	round, err := h.RoundService.GetRunning()
+	round, err := h.RoundService.GetRunning(participant.League)
 	if err == nil {
 		stageDuration := round.GetSolveStageDuration()
-		stageEnd, err := round.GetSolveEndDateMsk()
-		if err != nil {
-			return -1, noResponse(), err
-		}
-		return -1, OneTextResp(h.Replier.RegisterSuccessRoundRunning(stageDuration, stageEnd)), nil
+		stageEnd := round.GetSolveEndDate().In(ctx.TimeZone)
+		return -1, OneTextResp(ctx.Replier.RegisterSuccessRoundRunning(stageDuration, stageEnd)), nil
 	} else {
-		return -1, OneTextResp(h.Replier.RegisterSuccess()), nil
+		return -1, OneTextResp(ctx.Replier.RegisterSuccess()), nil
 	}
 }
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type Team struct {
+	Handler
+	ParticipantService mathbattle.ParticipantService
+	TeamService        mathbattle.TeamService
+}
+
+func (h *Team) Name() string {
+	return h.Handler.Name
+}
+
+func (h *Team) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *Team) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	res, _, _ := h.IsCommandSuitable(ctx)
+	return res
+}
+
+func (h *Team) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, ctx.Replier.NotParticipant(), nil
+		}
+
+		return false, "", err
+	}
+
+	if !participant.IsActive {
+		return false, ctx.Replier.NotParticipant(), nil
+	}
+
+	return true, "", nil
+}
+
+func (h *Team) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
+}
+
+func (h *Team) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
+	if err != nil {
+		return -1, noResponse(), err
+	}
+
+	switch ctx.CurrentStep {
+	case 0:
+		return h.stepShow(ctx, participant)
+	case 1:
+		return h.stepChooseAction(ctx, participant, m)
+	case 2:
+		return h.stepAcceptName(ctx, participant, m)
+	case 3:
+		return h.stepAcceptInviteCode(ctx, participant, m)
+	default:
+		return -1, noResponse(), nil
+	}
+}
+
+func (h *Team) stepShow(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant) (int, []TelegramResponse, error) {
+	team, err := h.TeamService.GetByMember(participant.ID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return 1, OneWithKb(ctx.Replier.TeamNone(), ctx.Replier.TeamCreate(), ctx.Replier.TeamJoin()), nil
+		}
+		return -1, noResponse(), err
+	}
+
+	memberNames := []string{}
+	for _, memberID := range team.MemberIDs {
+		member, err := h.ParticipantService.GetByID(memberID)
+		if err != nil {
+			return -1, noResponse(), err
+		}
+		memberNames = append(memberNames, member.Name)
+	}
+
+	return 1, OneWithKb(ctx.Replier.Team(team, memberNames, team.CaptainID == participant.ID), ctx.Replier.TeamLeave()), nil
+}
+
+func (h *Team) stepChooseAction(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant,
+	m *tb.Message) (int, []TelegramResponse, error) {
+
+	switch m.Text {
+	case ctx.Replier.TeamCreate():
+		return 2, OneTextResp(ctx.Replier.TeamExpectName()), nil
+	case ctx.Replier.TeamJoin():
+		return 3, OneTextResp(ctx.Replier.TeamExpectInviteCode()), nil
+	case ctx.Replier.TeamLeave():
+		err := h.TeamService.Leave(participant.ID)
+		if err == mathbattle.ErrTeamLocked {
+			return -1, OneTextResp(ctx.Replier.TeamLocked()), nil
+		}
+		if err != nil {
+			return -1, noResponse(), err
+		}
+		return -1, OneTextResp(ctx.Replier.TeamLeft()), nil
+	default:
+		return -1, OneTextResp(ctx.Replier.TeamNotChanged()), nil
+	}
+}
+
+func (h *Team) stepAcceptName(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant,
+	m *tb.Message) (int, []TelegramResponse, error) {
+
+	name, isOk := mathbattle.ValidateTeamName(m.Text)
+	if !isOk {
+		return 2, OneTextResp(ctx.Replier.TeamWrongName()), nil
+	}
+
+	team, err := h.TeamService.Create(mathbattle.Team{Name: name, CaptainID: participant.ID})
+	switch err {
+	case nil:
+		return -1, OneTextResp(ctx.Replier.TeamCreated(team)), nil
+	case mathbattle.ErrTeamLocked:
+		return -1, OneTextResp(ctx.Replier.TeamLocked()), nil
+	case mathbattle.ErrWrongUserInput:
+		return -1, OneTextResp(ctx.Replier.TeamNotChanged()), nil
+	default:
+		return -1, noResponse(), err
+	}
+}
+
+func (h *Team) stepAcceptInviteCode(ctx infrastructure.TelegramUserContext, participant mathbattle.Participant,
+	m *tb.Message) (int, []TelegramResponse, error) {
+
+	team, err := h.TeamService.Join(mathbattle.JoinOrder{ParticipantID: participant.ID, InviteCode: m.Text})
+	switch err {
+	case nil:
+		return -1, OneTextResp(ctx.Replier.TeamJoined(team)), nil
+	case mathbattle.ErrTeamLocked:
+		return -1, OneTextResp(ctx.Replier.TeamLocked()), nil
+	case mathbattle.ErrNotFound, mathbattle.ErrWrongUserInput:
+		return 3, OneTextResp(ctx.Replier.TeamWrongInviteCode()), nil
+	default:
+		return -1, noResponse(), err
+	}
+}
+
+// roundTeamID - команда, за которую участник выступает в раунде. Пусто в индивидуальном раунде
+func roundTeamID(teams mathbattle.TeamService, round mathbattle.Round, participantID string) (string, error) {
+	if !round.IsTeam() {
+		return "", nil
+	}
+
+	team, err := teams.GetByMember(participantID)
+	if err != nil {
+		return "", err
+	}
+	return team.ID, nil
+}
+
+// isInRoundTeam - в командном раунде решения и ревью сдают только участники команд
+func isInRoundTeam(ctx infrastructure.TelegramUserContext, teams mathbattle.TeamService, round mathbattle.Round,
+	participantID string) (bool, string, error) {
+
+	_, err := roundTeamID(teams, round, participantID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, ctx.Replier.TeamRoundNoTeam(), nil
+		}
+		return false, "", err
+	}
+
+	return true, "", nil
+}
+
+// solutionFindDescriptor - решения участника или, в командном раунде, его команды
+func solutionFindDescriptor(teams mathbattle.TeamService, round mathbattle.Round, participantID string,
+	problemID string) (mathbattle.FindDescriptor, error) {
+
+	teamID, err := roundTeamID(teams, round, participantID)
+	if err != nil {
+		return mathbattle.FindDescriptor{}, err
+	}
+
+	return mathbattle.FindDescriptor{
+		RoundID:       round.ID,
+		ParticipantID: participantID,
+		ProblemID:     problemID,
+		TeamID:        teamID,
+	}, nil
+}
+
+// reviewFindDescriptor - ревью участника или, в командном раунде, его команды
+func reviewFindDescriptor(teams mathbattle.TeamService, round mathbattle.Round, participantID string,
+	solutionID string) (mathbattle.ReviewFindDescriptor, error) {
+
+	teamID, err := roundTeamID(teams, round, participantID)
+	if err != nil {
+		return mathbattle.ReviewFindDescriptor{}, err
+	}
+
+	return mathbattle.ReviewFindDescriptor{
+		ReviewerID:     participantID,
+		SolutionID:     solutionID,
+		ReviewerTeamID: teamID,
+	}, nil
+}
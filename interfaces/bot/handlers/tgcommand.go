@@ -3,6 +3,7 @@ package handlers
 import (
 	"mathbattle/application"
 	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
 
 	tb "gopkg.in/tucnak/telebot.v2"
 )
@@ -17,10 +18,11 @@ var (
 
 type TelegramCommandHandler interface {
 	Name() string
-	Description() string
+	Description(replier application.Replier) string
 	IsShowInHelp(ctx infrastructure.TelegramUserContext) bool
 	IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error)
-	IsAdminOnly() bool
+	// RequiredPermission - право, без которого команда недоступна. PermissionNone - доступна всем
+	RequiredPermission() mathbattle.Permission
 	Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error)
 }
 
@@ -28,14 +30,14 @@ func FilterCommandsToShow(allCommands []TelegramCommandHandler, ctx infrastructu
 	result := []application.TelegramCommandHelp{}
 
 	for _, cmd := range allCommands {
-		if !ctx.User.IsAdmin && cmd.IsAdminOnly() {
+		if !ctx.User.Can(cmd.RequiredPermission()) {
 			continue
 		}
 
 		if cmd.IsShowInHelp(ctx) {
 			result = append(result, application.TelegramCommandHelp{
 				Name: cmd.Name(),
-				Desc: cmd.Description(),
+				Desc: cmd.Description(ctx.Replier),
 			})
 		}
 	}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"mathbattle/infrastructure"
 	"mathbattle/models/mathbattle"
 
 	tb "gopkg.in/tucnak/telebot.v2"
@@ -10,6 +11,13 @@ type TelegramResponse struct {
 	Text     string
 	Img      mathbattle.Image
 	Keyboard *tb.ReplyMarkup
+
+	// Edit - вместо нового сообщения изменить текст и inline клавиатуру уже отправленного
+	Edit *infrastructure.MessageRef
+	// Delete - удалить уже отправленное сообщение, остальные поля не используются
+	Delete *infrastructure.MessageRef
+	// RememberAs - сохранить отправленное сообщение в контексте, см. RememberedMessage
+	RememberAs string
 }
 
 func NewResp(messageText string) TelegramResponse {
@@ -48,6 +56,68 @@ func NewRespWithKeyboard(messageText string, buttonTexts ...string) TelegramResp
 	}
 }
 
+// NewRespWithKeyboardRows is the same as NewRespWithKeyboard, but places at most rowSize buttons in each row
+func NewRespWithKeyboardRows(messageText string, rowSize int, buttonTexts ...string) TelegramResponse {
+	keyboard := &tb.ReplyMarkup{
+		ResizeReplyKeyboard: true,
+	}
+
+	rows := []tb.Row{}
+	buttons := []tb.Btn{}
+	for _, txt := range buttonTexts {
+		buttons = append(buttons, keyboard.Text(txt))
+		if len(buttons) == rowSize {
+			rows = append(rows, keyboard.Row(buttons...))
+			buttons = []tb.Btn{}
+		}
+	}
+	if len(buttons) != 0 {
+		rows = append(rows, keyboard.Row(buttons...))
+	}
+
+	keyboard.Reply(rows...)
+
+	return TelegramResponse{
+		Text:     messageText,
+		Keyboard: keyboard,
+	}
+}
+
+// NewRespWithInlineKeyboard - каждый ряд кнопок отдельным аргументом, нажатия придут команде command
+func NewRespWithInlineKeyboard(messageText string, command string, rows ...[]InlineButton) TelegramResponse {
+	keyboard := &tb.ReplyMarkup{}
+
+	inlineRows := []tb.Row{}
+	for _, row := range rows {
+		buttons := []tb.Btn{}
+		for _, button := range row {
+			buttons = append(buttons, keyboard.Data(button.Text, "", CallbackData(command, button.Data)))
+		}
+		inlineRows = append(inlineRows, keyboard.Row(buttons...))
+	}
+
+	keyboard.Inline(inlineRows...)
+
+	return TelegramResponse{
+		Text:     messageText,
+		Keyboard: keyboard,
+	}
+}
+
+// NewRespEdit заменяет текст сообщения, inline клавиатура при этом убирается
+func NewRespEdit(ref infrastructure.MessageRef, messageText string) TelegramResponse {
+	return TelegramResponse{
+		Text: messageText,
+		Edit: &ref,
+	}
+}
+
+func NewRespDelete(ref infrastructure.MessageRef) TelegramResponse {
+	return TelegramResponse{
+		Delete: &ref,
+	}
+}
+
 func NewResps(messageTexts ...string) []TelegramResponse {
 	result := []TelegramResponse{}
 
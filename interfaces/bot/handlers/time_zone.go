@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"time"
+
+	"mathbattle/application"
+	"mathbattle/infrastructure"
+	"mathbattle/models/mathbattle"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type TimeZone struct {
+	Handler
+	Users         mathbattle.UserRepository
+	EventTimeZone *time.Location
+}
+
+func (h *TimeZone) Name() string {
+	return h.Handler.Name
+}
+
+func (h *TimeZone) Description(replier application.Replier) string {
+	return h.Handler.Description(replier)
+}
+
+func (h *TimeZone) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
+	return true
+}
+
+func (h *TimeZone) IsCommandSuitable(ctx infrastructure.TelegramUserContext) (bool, string, error) {
+	return true, "", nil
+}
+
+func (h *TimeZone) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
+}
+
+func (h *TimeZone) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
+	switch ctx.CurrentStep {
+	case 0:
+		return 1, []TelegramResponse{NewRespWithKeyboard(ctx.Replier.TimeZoneAsk(time.Now().In(ctx.TimeZone)),
+			ctx.Replier.TimeZoneEventDefault())}, nil
+	case 1:
+		// Пустой часовой пояс у пользователя означает часовой пояс мероприятия
+		userTimeZone := ""
+		location := h.EventTimeZone
+		if m.Text != ctx.Replier.TimeZoneEventDefault() {
+			var err error
+			location, err = mathbattle.LoadTimeZone(m.Text)
+			if err != nil {
+				return 1, OneTextResp(ctx.Replier.TimeZoneWrong()), nil
+			}
+			userTimeZone = location.String()
+		}
+
+		user := ctx.User
+		user.TimeZone = userTimeZone
+		if err := h.Users.Update(user); err != nil {
+			return -1, noResponse(), err
+		}
+
+		return -1, OneTextResp(ctx.Replier.TimeZoneChanged(time.Now().In(location))), nil
+	default:
+		return -1, noResponse(), nil
+	}
+}
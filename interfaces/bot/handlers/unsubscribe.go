@@ -10,7 +10,6 @@ import (
 
 type Unsubscribe struct {
 	Handler
-	Replier            mreplier.Replier
 	ParticipantService mathbattle.ParticipantService
 	RoundService       mathbattle.RoundService
 }
@@ -19,8 +18,8 @@ func (h *Unsubscribe) Name() string {
 	return h.Handler.Name
 }
 
-func (h *Unsubscribe) Description() string {
-	return h.Handler.Description
+func (h *Unsubscribe) Description(replier mreplier.Replier) string {
+	return h.Handler.Description(replier)
 }
 
 func (h *Unsubscribe) IsShowInHelp(ctx infrastructure.TelegramUserContext) bool {
@@ -32,17 +31,17 @@ func (h *Unsubscribe) IsCommandSuitable(ctx infrastructure.TelegramUserContext)
 	participant, err := h.ParticipantService.GetByTelegramID(ctx.User.TelegramID)
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
-			return false, h.Replier.NotSubscribed(), nil
+			return false, ctx.Replier.NotSubscribed(), nil
 		}
 
 		return false, "", err
 	}
 
 	if !participant.IsActive {
-		return false, h.Replier.NotSubscribed(), nil
+		return false, ctx.Replier.NotSubscribed(), nil
 	}
 
-	_, err = h.RoundService.GetRunning()
+	_, err = h.RoundService.GetRunning(participant.League)
 	if err != nil {
 		if err != mathbattle.ErrNotFound {
 			return false, "", err
@@ -54,8 +53,8 @@ func (h *Unsubscribe) IsCommandSuitable(ctx infrastructure.TelegramUserContext)
 	return false, "", nil
 }
 
-func (h *Unsubscribe) IsAdminOnly() bool {
-	return false
+func (h *Unsubscribe) RequiredPermission() mathbattle.Permission {
+	return mathbattle.PermissionNone
 }
 
 func (h *Unsubscribe) Handle(ctx infrastructure.TelegramUserContext, m *tb.Message) (int, []TelegramResponse, error) {
@@ -69,5 +68,5 @@ func (h *Unsubscribe) Handle(ctx infrastructure.TelegramUserContext, m *tb.Messa
 		return -1, noResponse(), err
 	}
 
-	return -1, OneTextResp(h.Replier.UnsubscribeSuccess()), nil
+	return -1, OneTextResp(ctx.Replier.UnsubscribeSuccess()), nil
 }
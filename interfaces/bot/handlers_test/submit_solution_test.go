@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"mathbattle/application"
 	"mathbattle/infrastructure"
 	"mathbattle/interfaces/bot/handlers"
 	"mathbattle/models/mathbattle"
@@ -16,6 +17,7 @@ type submitSolutionTestSuite struct {
 	suite.Suite
 
 	handler        handlers.SubmitSolution
+	replier        application.Replier
 	chatID         int64
 	curRound       mathbattle.Round
 	curParticipant mathbattle.Participant
@@ -24,8 +26,8 @@ type submitSolutionTestSuite struct {
 func (s *submitSolutionTestSuite) SetupTest() {
 	container := infrastructure.NewTestContainer()
 
+	s.replier = container.Replier()
 	s.handler = handlers.SubmitSolution{
-		Replier:            container.Replier(),
 		ParticipantService: container.ParticipantService(),
 		RoundService:       container.RoundService(),
 		SolutionService:    container.SolutionService(),
@@ -45,7 +47,7 @@ func (s *submitSolutionTestSuite) sendSolutionParts(ctx infrastructure.TelegramU
 		fakePhotoPath := fmt.Sprintf("fake/photo/p%d.%s", i, part.Extension)
 		testSequence = append(testSequence, reqRespSequence{
 			request:  photo("", fakePhotoPath, part.Content),
-			response: handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(i+1), s.handler.Replier.SolutionFinishUploading()),
+			response: handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(i+1), s.replier.SolutionFinishUploading()),
 			step:     3,
 		})
 	}
@@ -55,19 +57,20 @@ func (s *submitSolutionTestSuite) sendSolutionParts(ctx infrastructure.TelegramU
 
 func (s *submitSolutionTestSuite) sendPhotos(photos []tb.Message) infrastructure.TelegramUserContext {
 	ctx := infrastructure.NewTelegramUserContextByChatID(s.chatID)
+	ctx.Replier = s.replier
 
 	testSequence := []reqRespSequence{
-		{text(""), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectProblemCaption(), "A", "B"), 1},
-		{text("A"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectPart(), s.handler.Replier.SolutionFinishUploading()), 3},
+		{text(""), handlers.NewRespWithKeyboard(s.replier.SolutionExpectProblemCaption(), "A", "B"), 1},
+		{text("A"), handlers.NewRespWithKeyboard(s.replier.SolutionExpectPart(), s.replier.SolutionFinishUploading()), 3},
 	}
 
 	for i, photo := range photos {
 		testSequence = append(testSequence,
-			reqRespSequence{photo, handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(i+1), s.handler.Replier.SolutionFinishUploading()), 3})
+			reqRespSequence{photo, handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(i+1), s.replier.SolutionFinishUploading()), 3})
 	}
 
 	testSequence = append(testSequence,
-		reqRespSequence{text(s.handler.Replier.SolutionFinishUploading()), handlers.NewResp(s.handler.Replier.SolutionUploadSuccess(len(photos))), -1})
+		reqRespSequence{text(s.replier.SolutionFinishUploading()), handlers.NewResp(s.replier.SolutionUploadSuccess(len(photos))), -1})
 
 	return sendReqExpectRespSequence(s.Require(), &s.handler, ctx, testSequence)
 }
@@ -99,51 +102,54 @@ func (s *submitSolutionTestSuite) sendPhotosTestDatabase(images []mathbattle.Ima
 
 func (s *submitSolutionTestSuite) TestSendNoSolution() {
 	ctx := infrastructure.NewTelegramUserContextByChatID(s.chatID)
+	ctx.Replier = s.replier
 	sendReqExpectRespSequence(s.Require(), &s.handler, ctx, []reqRespSequence{
-		{text(""), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectProblemCaption(), "A", "B"), 1},
-		{text("A"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectPart(), s.handler.Replier.SolutionFinishUploading()), 3},
-		{text(s.handler.Replier.SolutionFinishUploading()), handlers.NewResp(s.handler.Replier.SolutionEmpty()), -1},
+		{text(""), handlers.NewRespWithKeyboard(s.replier.SolutionExpectProblemCaption(), "A", "B"), 1},
+		{text("A"), handlers.NewRespWithKeyboard(s.replier.SolutionExpectPart(), s.replier.SolutionFinishUploading()), 3},
+		{text(s.replier.SolutionFinishUploading()), handlers.NewResp(s.replier.SolutionEmpty()), -1},
 	})
 }
 
 func (s *submitSolutionTestSuite) TestSendSolutionFirstTime() {
 	ctx := infrastructure.NewTelegramUserContextByChatID(s.chatID)
+	ctx.Replier = s.replier
 	sendReqExpectRespSequence(s.Require(), &s.handler, ctx, []reqRespSequence{
-		{text(""), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectProblemCaption(), "A", "B"), 1},
-		{text("A"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectPart(), s.handler.Replier.SolutionFinishUploading()), 3},
-		{text("BlahBlah"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionWrongFormat(), s.handler.Replier.SolutionFinishUploading()), 3},
+		{text(""), handlers.NewRespWithKeyboard(s.replier.SolutionExpectProblemCaption(), "A", "B"), 1},
+		{text("A"), handlers.NewRespWithKeyboard(s.replier.SolutionExpectPart(), s.replier.SolutionFinishUploading()), 3},
+		{text("BlahBlah"), handlers.NewRespWithKeyboard(s.replier.SolutionWrongFormat(), s.replier.SolutionFinishUploading()), 3},
 		{photo("", "fake/path/p1.jpg", []byte("12345")),
-			handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(1), s.handler.Replier.SolutionFinishUploading()), 3},
-		{text("AsdfAsdf"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionWrongFormat(), s.handler.Replier.SolutionFinishUploading()), 3},
+			handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(1), s.replier.SolutionFinishUploading()), 3},
+		{text("AsdfAsdf"), handlers.NewRespWithKeyboard(s.replier.SolutionWrongFormat(), s.replier.SolutionFinishUploading()), 3},
 		{photo("", "fake/path/p2.jpg", []byte("54321")),
-			handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(2), s.handler.Replier.SolutionFinishUploading()), 3},
-		{text(s.handler.Replier.SolutionFinishUploading()), handlers.NewResp(s.handler.Replier.SolutionUploadSuccess(2)), -1},
+			handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(2), s.replier.SolutionFinishUploading()), 3},
+		{text(s.replier.SolutionFinishUploading()), handlers.NewResp(s.replier.SolutionUploadSuccess(2)), -1},
 	})
 }
 
 func (s *submitSolutionTestSuite) TestSendSolutionSecondTime() {
 	ctx := infrastructure.NewTelegramUserContextByChatID(s.chatID)
+	ctx.Replier = s.replier
 	sendReqExpectRespSequence(s.Require(), &s.handler, ctx, []reqRespSequence{
-		{text(""), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectProblemCaption(), "A", "B"), 1},
-		{text("A"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectPart(), s.handler.Replier.SolutionFinishUploading()), 3},
+		{text(""), handlers.NewRespWithKeyboard(s.replier.SolutionExpectProblemCaption(), "A", "B"), 1},
+		{text("A"), handlers.NewRespWithKeyboard(s.replier.SolutionExpectPart(), s.replier.SolutionFinishUploading()), 3},
 		{photo("", "fake/path/p1.jpg", []byte("12345")),
-			handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(1), s.handler.Replier.SolutionFinishUploading()), 3},
+			handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(1), s.replier.SolutionFinishUploading()), 3},
 		{photo("", "fake/path/p2.jpg", []byte("54321")),
-			handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(2), s.handler.Replier.SolutionFinishUploading()), 3},
-		{text(s.handler.Replier.SolutionFinishUploading()), handlers.NewResp(s.handler.Replier.SolutionUploadSuccess(2)), -1},
+			handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(2), s.replier.SolutionFinishUploading()), 3},
+		{text(s.replier.SolutionFinishUploading()), handlers.NewResp(s.replier.SolutionUploadSuccess(2)), -1},
 		// Send again, but refuse to rewrite
-		{text(""), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectProblemCaption(), "A", "B"), 1},
-		{text("A"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionIsRewriteOld(), s.handler.Replier.Yes(), s.handler.Replier.No()), 2},
-		{text(s.handler.Replier.No()), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionDeclineRewriteOld()), -1},
+		{text(""), handlers.NewRespWithKeyboard(s.replier.SolutionExpectProblemCaption(), "A", "B"), 1},
+		{text("A"), handlers.NewRespWithKeyboard(s.replier.SolutionIsRewriteOld(), s.replier.Yes(), s.replier.No()), 2},
+		{text(s.replier.No()), handlers.NewRespWithKeyboard(s.replier.SolutionDeclineRewriteOld()), -1},
 		// Send again, but agree to rewrite
-		{text(""), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectProblemCaption(), "A", "B"), 1},
-		{text("A"), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionIsRewriteOld(), s.handler.Replier.Yes(), s.handler.Replier.No()), 2},
-		{text(s.handler.Replier.Yes()), handlers.NewRespWithKeyboard(s.handler.Replier.SolutionExpectPart(), s.handler.Replier.SolutionFinishUploading()), 3},
+		{text(""), handlers.NewRespWithKeyboard(s.replier.SolutionExpectProblemCaption(), "A", "B"), 1},
+		{text("A"), handlers.NewRespWithKeyboard(s.replier.SolutionIsRewriteOld(), s.replier.Yes(), s.replier.No()), 2},
+		{text(s.replier.Yes()), handlers.NewRespWithKeyboard(s.replier.SolutionExpectPart(), s.replier.SolutionFinishUploading()), 3},
 		{photo("", "fake/path/p1.jpg", []byte("12345")),
-			handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(1), s.handler.Replier.SolutionFinishUploading()), 3},
+			handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(1), s.replier.SolutionFinishUploading()), 3},
 		{photo("", "fake/path/p2.jpg", []byte("54321")),
-			handlers.NewRespWithKeyboard(s.handler.Replier.SolutionPartUploaded(2), s.handler.Replier.SolutionFinishUploading()), 3},
-		{text(s.handler.Replier.SolutionFinishUploading()), handlers.NewResp(s.handler.Replier.SolutionUploadSuccess(2)), -1},
+			handlers.NewRespWithKeyboard(s.replier.SolutionPartUploaded(2), s.replier.SolutionFinishUploading()), 3},
+		{text(s.replier.SolutionFinishUploading()), handlers.NewResp(s.replier.SolutionUploadSuccess(2)), -1},
 	})
 }
 
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"testing"
 
+	"mathbattle/application"
 	"mathbattle/infrastructure"
 	"mathbattle/infrastructure/repository/memory"
 	"mathbattle/interfaces/bot/handlers"
@@ -16,6 +17,7 @@ type subscribeTs struct {
 	suite.Suite
 
 	handler handlers.Subscribe
+	replier application.Replier
 	ctx     infrastructure.TelegramUserContext
 }
 
@@ -30,35 +32,42 @@ func (s *subscribeTs) SetupTest() {
 		Username: "FakeUser",
 	})
 	s.Require().Nil(err)
+	s.replier = container.Replier()
+	s.ctx.Replier = s.replier
+	s.ctx.TimeZone = container.TimeZone()
 
 	s.handler = handlers.Subscribe{
-		Replier:            container.Replier(),
 		ParticipantService: container.ParticipantService(),
+		Form:               container.RegistrationForm(),
+		Schools:            infrastructure.NewSchoolDirectory([]string{"Лицей №1", "Лицей №2"}),
 	}
 }
 
 func (s *subscribeTs) TestSubscribeNew() {
 	testParticipant := mathbattle.Participant{
 		Name:     "JackDaniels",
-		School:   "",
+		School:   "Лицей №1",
 		Grade:    7,
 		IsActive: true,
 	}
 
 	sendTextExpectTextSequence(s.Require(), &s.handler, s.ctx, []reqRespTextSequence{
-		{"", s.handler.Replier.RegisterNameExpect(), 1},
+		{"", s.replier.RegisterNameExpect(), 1},
 		// Try incorrect name
-		{"123455~!!", s.handler.Replier.RegisterNameWrong(), 1},
-		{"718317+-++", s.handler.Replier.RegisterNameWrong(), 1},
+		{"123455~!!", s.replier.RegisterNameWrong(), 1},
+		{"718317+-++", s.replier.RegisterNameWrong(), 1},
 		// Correct name
-		{testParticipant.Name, s.handler.Replier.RegisterGradeExpect(), 2},
+		{testParticipant.Name, s.replier.RegisterGradeExpect(), 2},
 		// Try incorrect grade
-		{"Jack", s.handler.Replier.RegisterGradeWrong(), 2},
-		{"asdfsadf", s.handler.Replier.RegisterGradeWrong(), 2},
-		{"-1", s.handler.Replier.RegisterGradeWrong(), 2},
-		{"12", s.handler.Replier.RegisterGradeWrong(), 2},
+		{"Jack", s.replier.RegisterGradeWrong(), 2},
+		{"asdfsadf", s.replier.RegisterGradeWrong(), 2},
+		{"-1", s.replier.RegisterGradeWrong(), 2},
+		{"12", s.replier.RegisterGradeWrong(), 2},
 		// Correct grade
-		{strconv.Itoa(testParticipant.Grade), s.handler.Replier.RegisterSuccess(), -1},
+		{strconv.Itoa(testParticipant.Grade), s.replier.RegisterSchoolExpect(), 3},
+		// School from the directory
+		{"лицей", s.replier.RegisterSchoolChoose(), 3},
+		{"лицей 1", s.replier.RegisterSuccess(), -1},
 	})
 
 	p, err := s.handler.ParticipantService.GetByTelegramID(s.ctx.User.TelegramID)
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"mathbattle/infrastructure"
+	"mathbattle/interfaces/bot/handlers"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func removeKeyboard() *tb.ReplyMarkup {
+	return &tb.ReplyMarkup{
+		ReplyKeyboardRemove: true,
+	}
+}
+
+// sendResponses отправляет ответы команды по порядку и останавливается на первой ошибке:
+// следующие сообщения обычно не имеют смысла без предыдущих
+func sendResponses(messenger infrastructure.Messenger, ctx infrastructure.TelegramUserContext,
+	chatID int64, responses []handlers.TelegramResponse) error {
+
+	for _, item := range responses {
+		var ref infrastructure.MessageRef
+		var err error
+		switch {
+		case item.Delete != nil:
+			err = messenger.Delete(*item.Delete)
+		case item.Edit != nil:
+			ref = *item.Edit
+			err = messenger.EditText(ref, item.Text, item.Keyboard)
+		case len(item.Img.Content) > 0:
+			ref, err = messenger.SendPhoto(chatID, item.Text, item.Img.Content, item.Keyboard)
+		default:
+			ref, err = messenger.SendText(chatID, item.Text, item.Keyboard)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if item.RememberAs != "" {
+			handlers.RememberMessage(ctx, item.RememberAs, ref)
+		}
+	}
+
+	return nil
+}
+
+// respond отправляет ответы команды и возвращает шаг, на котором команда останется. Если ответы не дошли,
+// пользователь не видел следующего шага, и команда остаётся на шаге prevStep. Завершённая команда (-1)
+// всё равно завершается: то, что она делала, уже сделано
+func respond(messenger infrastructure.Messenger, ctx infrastructure.TelegramUserContext, chatID int64,
+	prevStep int, newStep int, responses []handlers.TelegramResponse) (int, error) {
+
+	if err := sendResponses(messenger, ctx, chatID, responses); err != nil {
+		if newStep == -1 {
+			return newStep, err
+		}
+		return prevStep, err
+	}
+	return newStep, nil
+}
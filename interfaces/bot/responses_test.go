@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"testing"
+
+	"mathbattle/infrastructure"
+	"mathbattle/interfaces/bot/handlers"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContext() infrastructure.TelegramUserContext {
+	return infrastructure.TelegramUserContext{
+		Variables: make(map[string]infrastructure.ContextVariable),
+	}
+}
+
+func TestSendResponses(t *testing.T) {
+	req := require.New(t)
+
+	messenger := infrastructure.NewFakeMessenger()
+	image := handlers.NewRespImage(mathbattle.Image{Extension: ".png", Content: []byte{1, 2, 3}})
+	image.Text = "caption"
+	withKb := handlers.NewRespWithKeyboard("pick", "A", "B")
+	image.Keyboard = withKb.Keyboard
+
+	err := sendResponses(messenger, newTestContext(), 42, []handlers.TelegramResponse{handlers.NewResp("first"), image})
+	req.NoError(err)
+
+	sent := messenger.MessagesTo(42)
+	req.Len(sent, 2)
+	req.Equal("text", sent[0].Kind)
+	req.Equal("first", sent[0].Text)
+	req.Equal("photo", sent[1].Kind)
+	req.Equal("caption", sent[1].Text)
+	req.Equal(withKb.Keyboard, sent[1].Keyboard)
+}
+
+func TestSendResponsesReportsError(t *testing.T) {
+	req := require.New(t)
+
+	messenger := infrastructure.NewFakeMessenger()
+	messenger.FailChatIDs[42] = true
+
+	err := sendResponses(messenger, newTestContext(), 42, handlers.OneTextResp("hello"))
+	req.Equal(infrastructure.ErrFakeSendFailed, err)
+	req.Len(messenger.Messages(), 0)
+}
+
+func TestSendResponsesEditAndDelete(t *testing.T) {
+	req := require.New(t)
+
+	messenger := infrastructure.NewFakeMessenger()
+	ctx := newTestContext()
+
+	menu := handlers.NewRespWithInlineKeyboard("menu", "/cmd", []handlers.InlineButton{{Text: "A", Data: "a"}})
+	menu.RememberAs = "menu"
+	req.NoError(sendResponses(messenger, ctx, 42, []handlers.TelegramResponse{menu, handlers.NewResp("temp")}))
+
+	ref, exists := handlers.RememberedMessage(ctx, "menu")
+	req.True(exists)
+	req.Equal(int64(42), ref.ChatID)
+	tempRef := messenger.MessagesTo(42)[1].Ref
+
+	req.NoError(sendResponses(messenger, ctx, 42, []handlers.TelegramResponse{
+		handlers.NewRespEdit(ref, "edited"),
+		handlers.NewRespDelete(tempRef),
+	}))
+
+	sent := messenger.MessagesTo(42)
+	req.Len(sent, 1)
+	req.Equal("edited", sent[0].Text)
+	req.True(sent[0].Edited)
+	req.Nil(sent[0].Keyboard)
+}
+
+func TestCallbackData(t *testing.T) {
+	req := require.New(t)
+
+	command, data := handlers.ParseCallbackData(handlers.CallbackData("/reminders", "on|x"))
+	req.Equal("/reminders", command)
+	req.Equal("on|x", data)
+
+	command, data = handlers.ParseCallbackData("legacy")
+	req.Equal("", command)
+	req.Equal("legacy", data)
+}
+
+func TestRespondKeepsStepWhenSendFails(t *testing.T) {
+	req := require.New(t)
+
+	messenger := infrastructure.NewFakeMessenger()
+	step, err := respond(messenger, newTestContext(), 42, 1, 2, handlers.OneTextResp("next question"))
+	req.NoError(err)
+	req.Equal(2, step)
+
+	// Пользователь не увидел следующий вопрос, поэтому команда ждёт ответа на предыдущий
+	messenger.FailChatIDs[42] = true
+	step, err = respond(messenger, newTestContext(), 42, 1, 2, handlers.OneTextResp("next question"))
+	req.Equal(infrastructure.ErrFakeSendFailed, err)
+	req.Equal(1, step)
+
+	// Завершённая команда всё равно завершается
+	step, err = respond(messenger, newTestContext(), 42, 1, -1, handlers.OneTextResp("done"))
+	req.Equal(infrastructure.ErrFakeSendFailed, err)
+	req.Equal(-1, step)
+}
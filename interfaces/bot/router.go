@@ -2,31 +2,70 @@ package bot
 
 import (
 	"encoding/hex"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"mathbattle/infrastructure"
-	"mathbattle/infrastructure/repository/memory"
 	"mathbattle/interfaces/bot/handlers"
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
 
-	tgbotapi "gopkg.in/telegram-bot-api.v4"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
-func TelegramContextRepository(container infrastructure.MBotContainer) *memory.TelegramContextRepository {
-	telegramContextRepository, err := memory.NewTelegramContextRepository(container.UserRepository())
-	if err != nil {
-		log.Fatalf("Failed to get telegram context repository, error: %v", err)
+func newPoller(container infrastructure.MBotContainer) tb.Poller {
+	switch container.Config().BotMode {
+	case "", "polling":
+		return &tb.LongPoller{Timeout: 10 * time.Second}
+	case "webhook":
+		webhook := container.Config().Webhook
+		if webhook.Secret == "" {
+			log.Fatalf("Webhook secret is required in webhook mode")
+		}
+
+		return &secretWebhook{
+			Listen:    webhook.Listen,
+			PublicURL: webhook.PublicURL,
+			Secret:    webhook.Secret,
+			TLSCert:   webhook.TLSCert,
+			TLSKey:    webhook.TLSKey,
+			Register:  webhook.Register,
+			Logger:    container.Logger(),
+		}
+	default:
+		log.Fatalf("Unknown bot mode: '%s'", container.Config().BotMode)
+		return nil
 	}
+}
 
-	return telegramContextRepository
+// userData - данные автора обновления для загрузки его контекста
+func userData(sender *tb.User) infrastructure.TelegramUserData {
+	return infrastructure.TelegramUserData{
+		ChatID:       int64(sender.ID),
+		FirstName:    sender.FirstName,
+		LastName:     sender.LastName,
+		Username:     sender.Username,
+		LanguageCode: sender.LanguageCode,
+	}
 }
 
 func Start(container infrastructure.MBotContainer) {
+	httpClient, err := newTelegramHTTPClient(container.Config().TelegramAPIUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Поллер только раскладывает сообщения по очередям, а обрабатывает их dispatcher
+	poller := newPoller(container)
 	b, err := tb.NewBot(tb.Settings{
 		Token:       container.Config().TelegramToken,
-		Poller:      &tb.LongPoller{Timeout: 10 * time.Second},
+		Poller:      poller,
 		Synchronous: true,
+		Client:      httpClient,
 		//Verbose:     true,
 	})
 
@@ -35,103 +74,116 @@ func Start(container infrastructure.MBotContainer) {
 		return
 	}
 
-	b2, err := tgbotapi.NewBotAPI(container.Config().TelegramToken)
-	if err != nil {
-		log.Fatal(err)
+	// Если раньше бот работал через вебхук, телеграм не отдаст обновления через getUpdates, пока вебхук не удалён
+	if _, isPolling := poller.(*tb.LongPoller); isPolling {
+		if err := b.RemoveWebhook(); err != nil {
+			container.Logger().Errorf("Failed to remove webhook, error: %v", err)
+		}
 	}
 
-	allCommands := createCommands(container)
-	ctxRepository := TelegramContextRepository(container)
+	// Ответы команд размечены markdown, служебные сообщения (список команд с "_" в названиях) - нет
+	messenger := infrastructure.NewTelegramMessenger(b, tb.ModeMarkdown)
+	plainMessenger := infrastructure.NewTelegramMessenger(b, tb.ModeDefault)
+	sendPlain := func(chatID int64, text string) error {
+		_, err := plainMessenger.SendText(chatID, text, removeKeyboard())
+		return err
+	}
 
-	commandHandler := func(handler handlers.TelegramCommandHandler, m *tb.Message, startType handlers.CommandStep) {
-		ctx, err := ctxRepository.GetByUserData(infrastructure.TelegramUserData{
-			ChatID:    int64(m.Sender.ID),
-			FirstName: m.Sender.FirstName,
-			LastName:  m.Sender.LastName,
-			Username:  m.Sender.Username,
+	// Имена команд не зависят от контейнера, поэтому конструктор команды по имени находим один раз
+	allCommands := []handlers.TelegramCommandHandler{}
+	factories := map[string]commandFactory{}
+	for _, create := range commandFactories() {
+		command := create(&container)
+		allCommands = append(allCommands, command)
+		factories[command.Name()] = create
+	}
+	ctxRepository := container.TelegramContextRepository()
+	updatesDispatcher := newDispatcher(container.Config().BotWorkers)
+	// dispatch ставит обработку обновления в очередь чата. Ошибки обработки и отправки ответов доходят сюда
+	dispatch := func(chatID int64, job func() error) {
+		updatesDispatcher.Dispatch(chatID, func() {
+			if err := job(); err != nil {
+				container.Logger().With("chat_id", chatID).Errorf("Update failed: %v", err)
+			}
 		})
+	}
+
+	// getContext загружает контекст пользователя и выбирает ответы на его языке и его часовой пояс
+	getContext := func(userData infrastructure.TelegramUserData) (infrastructure.TelegramUserContext, error) {
+		ctx, err := ctxRepository.GetByUserData(userData)
+		ctx.Replier = container.Repliers().ForLanguage(ctx.User.Language)
+		ctx.TimeZone = mathbattle.TimeZoneOr(ctx.User.TimeZone, container.TimeZone())
+		return ctx, err
+	}
+
+	// runHandler проверяет, доступна ли команда, вызывает handle и отправляет ответы.
+	// ctx - уже загруженный контекст автора обновления.
+	// handle - это Handle для сообщений или HandleCallback для нажатий inline кнопок.
+	// Обновление обрабатывается своим экземпляром команды, чьи запросы к серверу несут ID обновления.
+	// Возвращает ошибку команды или отправки её ответов
+	runHandler := func(command handlers.TelegramCommandHandler, sender *tb.User, ctx infrastructure.TelegramUserContext, startType handlers.CommandStep,
+		handle func(handler handlers.TelegramCommandHandler, ctx infrastructure.TelegramUserContext) (int, []handlers.TelegramResponse, error)) error {
+
+		chatID := int64(sender.ID)
+		requestID := mlog.NewRequestID()
+		requestContainer := container.ForRequest(requestID, chatID)
+		handler := factories[command.Name()](&requestContainer)
+		startTime := time.Now()
+
+		// Шаг команды, который обработает это обновление. Он есть в каждой записи лога обновления
+		step := ctx.CurrentStep
+		if startType == handlers.StepStart {
+			step = 0
+		}
+		if startType == handlers.StepNext {
+			step = step + 1
+		}
+		logger := requestContainer.Logger().With("command", handler.Name(), "step", step)
+
+		// Пользователь должен узнать, что что-то пошло не так, даже если ответ команды не дошёл
+		fail := func(err error) error {
+			sendPlain(chatID, ctx.Replier.InternalError())
+			return fmt.Errorf("%s, request %s: %w", handler.Name(), requestID, err)
+		}
 
 		isSuitable, reason, err := handler.IsCommandSuitable(ctx)
 		if err != nil {
-			b.Send(m.Sender, container.Replier().InternalError(), &tb.ReplyMarkup{
-				ReplyKeyboardRemove: true,
-			})
-			log.Printf("Failed to get user context: %v", err)
-			return
+			return fail(fmt.Errorf("failed to check command: %w", err))
 		}
 
 		if !isSuitable {
 			if reason != "" {
-				b.Send(m.Sender, reason, &tb.ReplyMarkup{
-					ReplyKeyboardRemove: true,
-				})
+				if err := sendPlain(chatID, reason); err != nil {
+					return fail(err)
+				}
 			}
-
-			b.Send(m.Sender,
-				container.Replier().GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)),
-				&tb.ReplyMarkup{
-					ReplyKeyboardRemove: true,
-				})
-
-			return
+			return sendPlain(chatID, ctx.Replier.GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)))
 		}
 
-		if !ctx.User.IsAdmin && handler.IsAdminOnly() {
-			b.Send(m.Sender,
-				container.Replier().GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)),
-				&tb.ReplyMarkup{
-					ReplyKeyboardRemove: true,
-				})
-			return
+		if !ctx.User.Can(handler.RequiredPermission()) {
+			return sendPlain(chatID, ctx.Replier.GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)))
 		}
 
-		if err != nil {
-			b.Send(m.Sender, container.Replier().InternalError(), &tb.ReplyMarkup{
-				ReplyKeyboardRemove: true,
-			})
-			log.Printf("Failed to get user context: %v", err)
-			return
-		}
 		defer func() {
-			ctxRepository.Update(int64(m.Sender.ID), ctx)
+			if err := ctxRepository.Update(chatID, ctx); err != nil {
+				logger.Errorf("Failed to save user context: %v", err)
+			}
 		}()
 
-		if startType == handlers.StepStart {
-			ctx.CurrentStep = 0
-		}
-		if startType == handlers.StepNext {
-			ctx.CurrentStep = ctx.CurrentStep + 1
-		}
+		ctx.CurrentStep = step
 		ctx.CurrentCommand = handler.Name()
-		newStep, response, err := handler.Handle(ctx, m)
+		newStep, response, err := handle(handler, ctx)
 		if err != nil {
-			b.Send(m.Sender, container.Replier().InternalError())
-			log.Printf("Failed to handle command: %s : %v", handler.Name(), err)
-		}
-		if len(response) != 0 {
-			for _, item := range response {
-				if len(item.Img.Content) > 0 {
-					// message with photo
-					msg := tgbotapi.NewPhotoUpload(ctx.User.TelegramID, tgbotapi.FileBytes{Name: "", Bytes: item.Img.Content})
-					msg.Caption = item.Text
-					// msg.ReplyMarkup = item.Keyboard
-					b2.Send(msg)
-				} else {
-					// text message only
-					_, err = b.Send(m.Sender, item.Text, item.Keyboard, tb.ModeMarkdown)
-					if err != nil {
-						log.Printf("Failed to send message, error: %v", err)
-					}
-				}
-			}
-
-			if newStep == -1 && err == nil { // Command finished
-				b.Send(m.Sender,
-					container.Replier().GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)),
-					&tb.ReplyMarkup{
-						ReplyKeyboardRemove: true,
-					})
+			err = fail(fmt.Errorf("failed to handle command: %w", err))
+		} else if newStep, err = respond(messenger, ctx, chatID, ctx.CurrentStep, newStep, response); err != nil {
+			err = fail(fmt.Errorf("failed to send response: %w", err))
+		} else if newStep == -1 && len(response) != 0 { // Command finished
+			// Команда могла поменять язык или часовой пояс пользователя
+			if user, userErr := container.UserRepository().GetByTelegramID(chatID); userErr == nil {
+				ctx.Replier = container.Repliers().ForLanguage(user.Language)
+				ctx.TimeZone = mathbattle.TimeZoneOr(user.TimeZone, container.TimeZone())
 			}
+			err = sendPlain(chatID, ctx.Replier.GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)))
 		}
 
 		if newStep == -1 {
@@ -140,29 +192,78 @@ func Start(container infrastructure.MBotContainer) {
 		} else {
 			ctx.CurrentStep = newStep
 		}
+		logger.With("next_step", newStep, "duration", time.Since(startTime)).Infof("Update handled")
+		return err
+	}
+
+	commandHandler := func(handler handlers.TelegramCommandHandler, m *tb.Message, ctx infrastructure.TelegramUserContext, startType handlers.CommandStep) error {
+		return runHandler(handler, m.Sender, ctx, startType, func(handler handlers.TelegramCommandHandler, ctx infrastructure.TelegramUserContext) (int, []handlers.TelegramResponse, error) {
+			return handler.Handle(ctx, m)
+		})
+	}
+
+	callbackHandler := func(cb *tb.Callback) error {
+		command, data := handlers.ParseCallbackData(cb.Data)
+
+		ctx, err := getContext(userData(cb.Sender))
+		if err != nil {
+			b.Respond(cb, &tb.CallbackResponse{Text: ctx.Replier.InternalError()})
+			return fmt.Errorf("failed to get user context: %w", err)
+		}
+
+		// Кнопки из старых сообщений: команда уже завершена или пользователь перешёл к другой
+		if command == "" || command != ctx.CurrentCommand {
+			return b.Respond(cb, &tb.CallbackResponse{Text: ctx.Replier.CallbackOutdated()})
+		}
+		if err := b.Respond(cb, &tb.CallbackResponse{}); err != nil {
+			return err
+		}
+
+		for _, handler := range allCommands {
+			if handler.Name() != command {
+				continue
+			}
+
+			callback := *cb
+			callback.Data = data
+			return runHandler(handler, cb.Sender, ctx, handlers.StepSame, func(handler handlers.TelegramCommandHandler, ctx infrastructure.TelegramUserContext) (int, []handlers.TelegramResponse, error) {
+				if cbHandler, isCallbackHandler := handler.(handlers.TelegramCallbackHandler); isCallbackHandler {
+					return cbHandler.HandleCallback(ctx, &callback)
+				}
+				return handler.Handle(ctx, &tb.Message{Sender: cb.Sender, Text: data})
+			})
+		}
+		return nil
 	}
 
 	for _, handler := range allCommands {
 		b.Handle(handler.Name(), func(handler handlers.TelegramCommandHandler) func(m *tb.Message) {
 			return func(m *tb.Message) {
-				commandHandler(handler, m, handlers.StepStart)
+				dispatch(int64(m.Sender.ID), func() error {
+					ctx, err := getContext(userData(m.Sender))
+					if err != nil {
+						sendPlain(int64(m.Sender.ID), ctx.Replier.InternalError())
+						return fmt.Errorf("failed to get user context: %w", err)
+					}
+					return commandHandler(handler, m, ctx, handlers.StepStart)
+				})
 			}
 		}(handler))
 	}
 
-	genericMessagesHandler := func(m *tb.Message) {
+	genericMessagesHandler := func(m *tb.Message) error {
 		hm, _ := hex.DecodeString("f09f9281f09f8fbce2808de29980efb88f")
 
-		ctx, err := ctxRepository.GetByUserData(infrastructure.TelegramUserData{
-			ChatID:    int64(m.Sender.ID),
-			FirstName: string(hm),
-			LastName:  string(hm),
-			Username:  m.Sender.Username,
+		ctx, err := getContext(infrastructure.TelegramUserData{
+			ChatID:       int64(m.Sender.ID),
+			FirstName:    string(hm),
+			LastName:     string(hm),
+			Username:     m.Sender.Username,
+			LanguageCode: m.Sender.LanguageCode,
 		})
 		if err != nil {
-			b.Send(m.Sender, container.Replier().InternalError())
-			log.Printf("Failed to get user context: %v", err)
-			return
+			sendPlain(int64(m.Sender.ID), ctx.Replier.InternalError())
+			return fmt.Errorf("failed to get user context: %w", err)
 		}
 
 		for _, handler := range allCommands {
@@ -189,37 +290,54 @@ func Start(container infrastructure.MBotContainer) {
 				if m.Photo != nil {
 					m.Photo.File, err = fillFileStruct(m.Photo.File)
 					if err != nil {
-						b.Send(m.Sender, container.Replier().InternalError())
-						log.Printf("Failed to fill photo structure: %v", err)
+						sendPlain(int64(m.Sender.ID), ctx.Replier.InternalError())
+						return fmt.Errorf("failed to fill photo structure: %w", err)
 					}
 				}
 
 				if m.Document != nil {
 					m.Document.File, err = fillFileStruct(m.Document.File)
 					if err != nil {
-						b.Send(m.Sender, container.Replier().InternalError())
-						log.Printf("Failed to fill document structure: %v", err)
+						sendPlain(int64(m.Sender.ID), ctx.Replier.InternalError())
+						return fmt.Errorf("failed to fill document structure: %w", err)
 					}
 				}
 
-				commandHandler(handler, m, handlers.StepSame)
-
-				return
+				return commandHandler(handler, m, ctx, handlers.StepSame)
 			}
 		}
 
-		b.Send(m.Sender,
-			container.Replier().GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)),
-			&tb.ReplyMarkup{
-				ReplyKeyboardRemove: true,
-			})
+		return sendPlain(int64(m.Sender.ID), ctx.Replier.GetAvailableCommands(handlers.FilterCommandsToShow(allCommands, ctx)))
 	}
 
-	b.Handle(tb.OnPhoto, genericMessagesHandler)
-	b.Handle(tb.OnText, genericMessagesHandler)
-	b.Handle(tb.OnDocument, genericMessagesHandler)
+	dispatchGeneric := func(m *tb.Message) {
+		dispatch(int64(m.Sender.ID), func() error {
+			return genericMessagesHandler(m)
+		})
+	}
 
-	log.Printf("Bot started")
+	b.Handle(tb.OnPhoto, dispatchGeneric)
+	b.Handle(tb.OnText, dispatchGeneric)
+	b.Handle(tb.OnDocument, dispatchGeneric)
+	b.Handle(tb.OnCallback, func(cb *tb.Callback) {
+		dispatch(int64(cb.Sender.ID), func() error {
+			return callbackHandler(cb)
+		})
+	})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		container.Logger().Infof("Stopping bot")
+		b.Stop()
+	}()
+
+	container.Logger().Infof("Bot started")
 
 	b.Start()
+
+	// Дожидаемся сообщений, которые уже начали обрабатываться, чтобы не потерять состояние диалога
+	updatesDispatcher.Wait()
+	container.Logger().Infof("Bot stopped")
 }
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"mathbattle/infrastructure"
+	"mathbattle/interfaces/bot/handlers"
+	"mathbattle/interfaces/replier"
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type fakeProblems struct {
+	problems []mathbattle.Problem
+}
+
+func (f *fakeProblems) GetByID(ID string) (mathbattle.Problem, error) {
+	problem, isExist := mathbattle.FindProblemByID(f.problems, ID)
+	if !isExist {
+		return problem, mathbattle.ErrNotFound
+	}
+	return problem, nil
+}
+
+func (f *fakeProblems) GetAll() ([]mathbattle.Problem, error) {
+	return f.problems, nil
+}
+
+type fakeStartRounds struct {
+	mathbattle.RoundService
+	orders []mathbattle.StartOrder
+}
+
+func (f *fakeStartRounds) StartNew(order mathbattle.StartOrder) (mathbattle.SSStartResult, error) {
+	f.orders = append(f.orders, order)
+	return mathbattle.SSStartResult{}, nil
+}
+
+type fakeThumbnails struct{}
+
+func (f fakeThumbnails) Normalize(image mathbattle.Image) (mathbattle.Image, error) {
+	return mathbattle.Image{Extension: ".jpg", Content: []byte("thumbnail")}, nil
+}
+
+func newStartRoundContext(req *require.Assertions) infrastructure.TelegramUserContext {
+	repliers, err := replier.NewRepliers("en", nil, mlog.Default())
+	req.NoError(err)
+
+	ctx := newTestContext()
+	ctx.Replier = repliers.ForLanguage("en")
+	ctx.TimeZone = time.UTC
+	ctx.User = mathbattle.User{TelegramID: 42}
+	ctx.Variables["until_date"] = infrastructure.NewContextVariableStr(time.Now().AddDate(0, 0, 7).Format("02.01.2006"))
+	return ctx
+}
+
+func pressButton(req *require.Assertions, h *handlers.StartRound, ctx *infrastructure.TelegramUserContext,
+	data string) []handlers.TelegramResponse {
+
+	step, responses, err := h.HandleCallback(*ctx, &tb.Callback{
+		Data:    data,
+		Message: &tb.Message{ID: 7, Chat: &tb.Chat{ID: 42}},
+	})
+	req.NoError(err)
+	ctx.CurrentStep = step
+	return responses
+}
+
+func TestStartRoundPicksProblemsWithButtons(t *testing.T) {
+	req := require.New(t)
+
+	rounds := &fakeStartRounds{}
+	h := &handlers.StartRound{
+		Handler:      handlers.Handler{Name: "/start_round"},
+		RoundService: rounds,
+		ProblemService: &fakeProblems{problems: []mathbattle.Problem{
+			{ID: "1", MinGrade: 1, MaxGrade: 11, Extension: ".png", Content: []byte("first")},
+			{ID: "2", MinGrade: 1, MaxGrade: 11, Extension: ".png", Content: []byte("second")},
+		}},
+		Thumbnails: fakeThumbnails{},
+	}
+	ctx := newStartRoundContext(req)
+	ctx.CurrentStep = 2
+
+	step, responses, err := h.Handle(ctx, &tb.Message{Text: ctx.Replier.Yes()})
+	req.NoError(err)
+	req.Equal(3, step)
+	req.Len(responses, 3)
+	req.Equal([]byte("thumbnail"), responses[0].Img.Content)
+	req.Equal([]byte("thumbnail"), responses[1].Img.Content)
+	req.NotNil(responses[2].Keyboard)
+	req.NotEmpty(responses[2].Keyboard.InlineKeyboard)
+	ctx.CurrentStep = step
+
+	responses = pressButton(req, h, &ctx, "done")
+	req.Equal(3, ctx.CurrentStep)
+	req.Equal(ctx.Replier.StartRoundPickNothing(), responses[0].Text)
+	req.NotNil(responses[0].Edit)
+
+	pressButton(req, h, &ctx, "pick:2")
+	pressButton(req, h, &ctx, "pick:1")
+	pressButton(req, h, &ctx, "pick:2")
+	responses = pressButton(req, h, &ctx, "pick:2")
+	req.Equal("1,2", ctx.Variables["problems_ids"].AsString())
+	req.Equal(infrastructure.MessageRef{ChatID: 42, MessageID: 7}, *responses[0].Edit)
+
+	responses = pressButton(req, h, &ctx, "done")
+	req.Equal(5, ctx.CurrentStep)
+	req.NotNil(responses[0].Edit)
+	req.NotEmpty(responses[len(responses)-1].Keyboard.InlineKeyboard)
+
+	// Кнопка выбора задачи после "Готово" ничего не меняет
+	responses = pressButton(req, h, &ctx, "pick:1")
+	req.Equal(5, ctx.CurrentStep)
+	req.Len(responses, 0)
+	req.Equal("1,2", ctx.Variables["problems_ids"].AsString())
+
+	pressButton(req, h, &ctx, "mode:team")
+	req.Equal(-1, ctx.CurrentStep)
+	req.Len(rounds.orders, 1)
+	req.Equal([]string{"1", "2"}, rounds.orders[0].ProblemsIDs)
+	req.Equal(mathbattle.RoundTeam, rounds.orders[0].Mode)
+	req.Equal(int64(42), rounds.orders[0].ActorID)
+}
+
+func TestStartRoundReorderAndAbort(t *testing.T) {
+	req := require.New(t)
+
+	rounds := &fakeStartRounds{}
+	h := &handlers.StartRound{
+		Handler:      handlers.Handler{Name: "/start_round"},
+		RoundService: rounds,
+		ProblemService: &fakeProblems{problems: []mathbattle.Problem{
+			{ID: "1", MinGrade: 1, MaxGrade: 11},
+			{ID: "2", MinGrade: 1, MaxGrade: 11},
+		}},
+	}
+	ctx := newStartRoundContext(req)
+	ctx.CurrentStep = 3
+	ctx.Variables["problems_ids"] = infrastructure.NewContextVariableStr("1,2")
+
+	pressButton(req, h, &ctx, "reorder")
+	req.Equal(4, ctx.CurrentStep)
+
+	step, _, err := h.Handle(ctx, &tb.Message{Text: "B, A"})
+	req.NoError(err)
+	req.Equal(3, step)
+	req.Equal("2,1", ctx.Variables["problems_ids"].AsString())
+	ctx.CurrentStep = step
+
+	responses := pressButton(req, h, &ctx, "abort")
+	req.Equal(-1, ctx.CurrentStep)
+	req.Equal(ctx.Replier.Cancel(), responses[0].Text)
+	req.Len(rounds.orders, 0)
+}
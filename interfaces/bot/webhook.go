@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"mathbattle/libs/mlog"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// SecretTokenHeader - заголовок, в котором телеграм присылает secret_token, указанный при setWebhook
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// secretWebhook - то же что tb.Webhook, но проверяет secret_token, которого нет в этой версии telebot.
+// Сертификат в телеграм не загружается, поэтому он должен быть доверенным, либо TLS должен
+// терминироваться на reverse proxy перед ботом
+type secretWebhook struct {
+	Listen    string
+	PublicURL string
+	Secret    string
+	TLSCert   string
+	TLSKey    string
+
+	// Если false, вебхук не регистрируется в телеграме. Нужно для локальной проверки без телеграма
+	Register bool
+	Logger   *mlog.Logger
+}
+
+func (h *secretWebhook) logger() *mlog.Logger {
+	if h.Logger == nil {
+		return mlog.Default()
+	}
+	return h.Logger
+}
+
+func (h *secretWebhook) Poll(b *tb.Bot, dest chan tb.Update, stop chan struct{}) {
+	if h.Register {
+		_, err := b.Raw("setWebhook", map[string]string{
+			"url":          h.PublicURL,
+			"secret_token": h.Secret,
+		})
+		if err != nil {
+			// Без вебхука телеграм не пришлёт обновлений, но завершать процесс - дело не поллера
+			h.logger().Errorf("Failed to set webhook, error: %v", err)
+			return
+		}
+	}
+
+	path := "/"
+	if publicURL, err := url.Parse(h.PublicURL); err == nil && publicURL.Path != "" {
+		path = publicURL.Path
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, h.handler(dest, stop))
+	server := &http.Server{
+		Addr:    h.Listen,
+		Handler: mux,
+	}
+
+	go func() {
+		<-stop
+		// Вебхук в телеграме не удаляем: пока бот перезапускается, телеграм копит обновления
+		// и отдаст их новому экземпляру
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			h.logger().Errorf("Failed to shutdown webhook server, error: %v", err)
+		}
+	}()
+
+	h.logger().Infof("Webhook is listening on %s%s", h.Listen, path)
+
+	var err error
+	if h.TLSCert != "" && h.TLSKey != "" {
+		err = server.ListenAndServeTLS(h.TLSCert, h.TLSKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		h.logger().Errorf("Webhook server stopped, error: %v", err)
+	}
+}
+
+// handler передаёт обновления в dest. После stop обновления не принимаются: телеграм пришлёт их снова
+func (h *secretWebhook) handler(dest chan tb.Update, stop chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		secret := r.Header.Get(SecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(h.Secret)) != 1 {
+			h.logger().Warnf("Webhook: wrong secret token from %s", r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var update tb.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			h.logger().Warnf("Webhook: failed to decode update, error: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case dest <- update:
+			w.WriteHeader(http.StatusOK)
+		case <-stop:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// redirectTransport отправляет все запросы к Bot API на другой адрес, например на локальный
+// webhooktest.FakeTelegramAPI
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newTelegramHTTPClient(apiURL string) (*http.Client, error) {
+	if apiURL == "" {
+		return &http.Client{}, nil
+	}
+
+	target, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: &redirectTransport{target: target}}, nil
+}
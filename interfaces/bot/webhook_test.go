@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mathbattle/interfaces/bot/webhooktest"
+
+	"github.com/stretchr/testify/require"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestWebhookChecksSecret(t *testing.T) {
+	req := require.New(t)
+
+	dest := make(chan tb.Update, 10)
+	webhook := &secretWebhook{Secret: "secret"}
+	server := httptest.NewServer(webhook.handler(dest, make(chan struct{})))
+	defer server.Close()
+
+	updates := []json.RawMessage{
+		json.RawMessage(`{"update_id": 1, "message": {"message_id": 1, "text": "/help"}}`),
+	}
+
+	req.Error(webhooktest.ReplayUpdates(server.URL, "wrong", updates, 0))
+	req.Len(dest, 0)
+
+	req.NoError(webhooktest.ReplayUpdates(server.URL, "secret", updates, 0))
+	req.Len(dest, 1)
+	update := <-dest
+	req.Equal("/help", update.Message.Text)
+}
+
+func TestFakeTelegramAPI(t *testing.T) {
+	req := require.New(t)
+
+	api := webhooktest.NewFakeTelegramAPI()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	client, err := newTelegramHTTPClient(server.URL)
+	req.NoError(err)
+
+	b, err := tb.NewBot(tb.Settings{
+		Token:  "token",
+		Poller: &tb.LongPoller{Timeout: time.Second},
+		Client: client,
+	})
+	req.NoError(err)
+
+	_, err = b.Send(&tb.User{ID: 42}, "hello")
+	req.NoError(err)
+
+	calls := api.Calls()
+	req.Equal("sendMessage", calls[len(calls)-1].Method)
+	req.Equal("42", calls[len(calls)-1].Params["chat_id"])
+	req.Equal("hello", calls[len(calls)-1].Params["text"])
+}
+
+func TestWebhookStopsAcceptingUpdates(t *testing.T) {
+	req := require.New(t)
+
+	// Очередь полна, и бот остановлен: обработчик не должен ждать вечно
+	dest := make(chan tb.Update)
+	stop := make(chan struct{})
+	close(stop)
+	webhook := &secretWebhook{Secret: "secret"}
+	server := httptest.NewServer(webhook.handler(dest, stop))
+	defer server.Close()
+
+	updates := []json.RawMessage{
+		json.RawMessage(`{"update_id": 1, "message": {"message_id": 1, "text": "/help"}}`),
+	}
+	req.Error(webhooktest.ReplayUpdates(server.URL, "secret", updates, 0))
+}
@@ -0,0 +1,168 @@
+// webhooktest помогает проверять бота целиком без телеграма: FakeTelegramAPI отвечает вместо Bot API,
+// а ReplayUpdates отправляет записанные обновления в вебхук бота
+package webhooktest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// APICall - запрос бота к Bot API, который получил FakeTelegramAPI
+type APICall struct {
+	Method string
+	Params map[string]string
+}
+
+// FakeTelegramAPI отвечает на запросы бота так, как ответил бы телеграм, и запоминает их
+type FakeTelegramAPI struct {
+	mutex         sync.Mutex
+	calls         []APICall
+	nextMessageID int
+}
+
+func NewFakeTelegramAPI() *FakeTelegramAPI {
+	return &FakeTelegramAPI{nextMessageID: 1}
+}
+
+func (api *FakeTelegramAPI) Calls() []APICall {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	return append([]APICall{}, api.calls...)
+}
+
+func (api *FakeTelegramAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Файлы: /file/bot<token>/<path>
+	if strings.HasPrefix(r.URL.Path, "/file/") {
+		w.Write([]byte("fake file content"))
+		return
+	}
+
+	// Методы: /bot<token>/<method>
+	parts := strings.Split(r.URL.Path, "/")
+	method := parts[len(parts)-1]
+	params := readParams(r)
+
+	api.mutex.Lock()
+	api.calls = append(api.calls, APICall{Method: method, Params: params})
+	messageID := api.nextMessageID
+	api.nextMessageID++
+	api.mutex.Unlock()
+
+	log.Printf("FakeTelegramAPI: %s %v", method, params)
+
+	var result interface{} = true
+	switch method {
+	case "getMe":
+		result = map[string]interface{}{"id": 1, "is_bot": true, "first_name": "Fake", "username": "fake_bot"}
+	case "getFile":
+		result = map[string]interface{}{"file_id": params["file_id"], "file_path": "files/" + params["file_id"]}
+	case "sendMessage", "sendPhoto", "sendDocument", "editMessageText":
+		result = fakeMessage(messageID, params)
+	case "sendMediaGroup":
+		result = []interface{}{fakeMessage(messageID, params)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func fakeMessage(messageID int, params map[string]string) map[string]interface{} {
+	var chatID int64
+	fmt.Sscan(params["chat_id"], &chatID)
+
+	return map[string]interface{}{
+		"message_id": messageID,
+		"date":       time.Now().Unix(),
+		"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+		"text":       params["text"],
+	}
+}
+
+// readParams понимает все три формата, в которых библиотеки шлют запросы: json, form и multipart
+func readParams(r *http.Request) map[string]string {
+	result := make(map[string]string)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		raw := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&raw)
+		for key, value := range raw {
+			if str, isStr := value.(string); isStr {
+				result[key] = str
+			} else {
+				serialized, _ := json.Marshal(value)
+				result[key] = string(serialized)
+			}
+		}
+		return result
+	}
+
+	r.ParseMultipartForm(32 << 20)
+	for key, values := range r.Form {
+		result[key] = strings.Join(values, ",")
+	}
+	return result
+}
+
+// LoadUpdates читает записанные обновления, по одному json объекту на строку
+func LoadUpdates(path string) ([]json.RawMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := []json.RawMessage{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			return result, fmt.Errorf("Invalid update: %s", line)
+		}
+		result = append(result, json.RawMessage(line))
+	}
+
+	return result, scanner.Err()
+}
+
+// ReplayUpdates отправляет обновления в вебхук бота по одному, как это делает телеграм
+func ReplayUpdates(webhookURL string, secret string, updates []json.RawMessage, delay time.Duration) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	for i, update := range updates {
+		req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(update))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(secretTokenHeader, secret)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("Update %d: unexpected HTTP status: %d", i, resp.StatusCode)
+		}
+
+		time.Sleep(delay)
+	}
+
+	return nil
+}
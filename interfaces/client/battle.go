@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+
+	"mathbattle/models/mathbattle"
+)
+
+type APIBattle struct {
+	API
+}
+
+func (a *APIBattle) Start(order mathbattle.BattleOrder) (mathbattle.Battle, error) {
+	result := mathbattle.Battle{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/battles"), order, &result)
+	return result, err
+}
+
+func (a *APIBattle) Get(ID string) (mathbattle.Battle, error) {
+	result := mathbattle.Battle{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/battles", ID), &result)
+	return result, err
+}
+
+func (a *APIBattle) GetRunning(participantID string) (mathbattle.Battle, error) {
+	result := mathbattle.Battle{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/battles/running", participantID), &result)
+	return result, err
+}
+
+func (a *APIBattle) GetByRound(roundID string) ([]mathbattle.Battle, error) {
+	result := []mathbattle.Battle{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/battles/round", roundID), &result)
+	return result, err
+}
+
+func (a *APIBattle) Move(move mathbattle.BattleMove) (mathbattle.Battle, error) {
+	result := mathbattle.Battle{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/battles/move"), move, &result)
+	return result, err
+}
+
+func (a *APIBattle) Judge(verdict mathbattle.BattleVerdict) (mathbattle.Battle, error) {
+	result := mathbattle.Battle{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/battles/judge"), verdict, &result)
+	return result, err
+}
@@ -5,26 +5,63 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 
+	"mathbattle/libs/mlog"
 	"mathbattle/models/mathbattle"
 )
 
-func sendReq(method string, endpoint string, object interface{}) (*http.Response, error) {
-	if object != nil {
-		log.Printf("%s %s object: %v", method, endpoint, object)
-	} else {
-		log.Printf("%s %s", method, endpoint)
+// API - адрес сервера и ID запроса, который уходит на сервер в mlog.RequestIDHeader.
+// Общий для клиентов всех сервисов
+type API struct {
+	BaseUrl string
+	// Пусто - без ID, сервер выдаст свой
+	RequestID string
+	// Telegram ID пользователя, от имени которого идут запросы без своего автора, 0 - без ActorHeader
+	ActorID int64
+	// Уходит в TokenHeader, см. api_token в конфиге
+	Token string
+	// Логгер обновления, см. MBotContainer.ForRequest. Nil - mlog.Default с RequestID
+	Logger *mlog.Logger
+}
+
+func (a API) logger() *mlog.Logger {
+	if a.Logger != nil {
+		return a.Logger
+	}
+	if a.RequestID == "" {
+		return mlog.Default()
+	}
+	return mlog.Default().With("request_id", a.RequestID)
+}
+
+// actor - запрос без тела от имени пользователя: уходит только ActorHeader
+type actor int64
+
+func (a actor) Actor() int64 {
+	return int64(a)
+}
+
+func (a API) sendReq(method string, endpoint string, object interface{}) (*http.Response, error) {
+	order, isActed := object.(mathbattle.ActedOrder)
+	if _, ok := object.(actor); ok {
+		object = nil
 	}
 
+	logger := a.logger()
+	logger.Infof("%s %s", method, endpoint)
+
 	var body io.Reader = nil
 	if object != nil {
 		jsonStr, err := json.Marshal(object)
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("Sending json: %s", string(jsonStr))
+		if logger.IsEnabled(mlog.LevelDebug) {
+			logger.Debugf("Sending json: %s", string(jsonStr))
+		}
 		body = bytes.NewBuffer(jsonStr)
 	}
 
@@ -36,6 +73,15 @@ func sendReq(method string, endpoint string, object interface{}) (*http.Response
 	if object != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if isActed {
+		req.Header.Set(mathbattle.ActorHeader, strconv.FormatInt(order.Actor(), 10))
+	} else if a.ActorID != 0 {
+		req.Header.Set(mathbattle.ActorHeader, strconv.FormatInt(a.ActorID, 10))
+	}
+	if a.RequestID != "" {
+		req.Header.Set(mlog.RequestIDHeader, a.RequestID)
+	}
+	req.Header.Set(mathbattle.TokenHeader, a.Token)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -46,8 +92,8 @@ func sendReq(method string, endpoint string, object interface{}) (*http.Response
 	return resp, nil
 }
 
-func PostJsonRecieveJson(endpoint string, send interface{}, recieve interface{}) error {
-	resp, err := sendReq("POST", endpoint, send)
+func (a API) PostJsonRecieveJson(endpoint string, send interface{}, recieve interface{}) error {
+	resp, err := a.sendReq("POST", endpoint, send)
 	if err != nil {
 		return err
 	}
@@ -57,6 +103,24 @@ func PostJsonRecieveJson(endpoint string, send interface{}, recieve interface{})
 		if resp.StatusCode == http.StatusNotFound {
 			return mathbattle.ErrNotFound
 		}
+		if resp.StatusCode == http.StatusBadRequest {
+			return mathbattle.ErrWrongUserInput
+		}
+		if resp.StatusCode == http.StatusConflict {
+			return mathbattle.ErrConflict
+		}
+		if resp.StatusCode == http.StatusLocked {
+			return mathbattle.ErrTeamLocked
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			return mathbattle.ErrForbidden
+		}
+		if resp.StatusCode == http.StatusPreconditionFailed {
+			return mathbattle.ErrDistributionChanged
+		}
+		if resp.StatusCode == http.StatusUnprocessableEntity {
+			return mathbattle.ErrReviewSubmitted
+		}
 		return fmt.Errorf("Unexpected HTTP status: %d", resp.StatusCode)
 	}
 
@@ -68,8 +132,8 @@ func PostJsonRecieveJson(endpoint string, send interface{}, recieve interface{})
 	return nil
 }
 
-func PostJsonRecieveNone(endpoint string, object interface{}) error {
-	resp, err := sendReq("POST", endpoint, object)
+func (a API) PostJsonRecieveNone(endpoint string, object interface{}) error {
+	resp, err := a.sendReq("POST", endpoint, object)
 
 	if err != nil {
 		return err
@@ -77,8 +141,15 @@ func PostJsonRecieveNone(endpoint string, object interface{}) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusNotFound {
+		switch resp.StatusCode {
+		case http.StatusNotFound:
 			return mathbattle.ErrNotFound
+		case http.StatusRequestEntityTooLarge:
+			return mathbattle.ErrSolutionPartTooLarge
+		case http.StatusUnsupportedMediaType:
+			return mathbattle.ErrSolutionPartUnsupported
+		case http.StatusForbidden:
+			return mathbattle.ErrForbidden
 		}
 		return fmt.Errorf("Unexpected HTTP status: %d", resp.StatusCode)
 	}
@@ -86,8 +157,8 @@ func PostJsonRecieveNone(endpoint string, object interface{}) error {
 	return nil
 }
 
-func PostNoneRecieveNone(endpoint string) error {
-	resp, err := sendReq("POST", endpoint, nil)
+func (a API) PostNoneRecieveNone(endpoint string) error {
+	resp, err := a.sendReq("POST", endpoint, nil)
 
 	if err != nil {
 		return err
@@ -98,14 +169,20 @@ func PostNoneRecieveNone(endpoint string) error {
 		if resp.StatusCode == http.StatusNotFound {
 			return mathbattle.ErrNotFound
 		}
+		if resp.StatusCode == http.StatusLocked {
+			return mathbattle.ErrTeamLocked
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			return mathbattle.ErrForbidden
+		}
 		return fmt.Errorf("Unexpected HTTP status: %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
-func SendGetNoneRecieveJson(endpoint string, object interface{}) error {
-	resp, err := sendReq("GET", endpoint, nil)
+func (a API) SendGetNoneRecieveJson(endpoint string, object interface{}) error {
+	resp, err := a.sendReq("GET", endpoint, nil)
 	if err != nil {
 		return err
 	}
@@ -115,6 +192,9 @@ func SendGetNoneRecieveJson(endpoint string, object interface{}) error {
 		if resp.StatusCode == http.StatusNotFound {
 			return mathbattle.ErrNotFound
 		}
+		if resp.StatusCode == http.StatusForbidden {
+			return mathbattle.ErrForbidden
+		}
 		return fmt.Errorf("Unexpected HTTP status: %d", resp.StatusCode)
 	}
 
@@ -126,8 +206,8 @@ func SendGetNoneRecieveJson(endpoint string, object interface{}) error {
 	return nil
 }
 
-func SendGetJsonRecieveJson(endpoint string, send interface{}, recieve interface{}) error {
-	resp, err := sendReq("GET", endpoint, send)
+func (a API) SendGetJsonRecieveJson(endpoint string, send interface{}, recieve interface{}) error {
+	resp, err := a.sendReq("GET", endpoint, send)
 	if err != nil {
 		return err
 	}
@@ -148,8 +228,25 @@ func SendGetJsonRecieveJson(endpoint string, send interface{}, recieve interface
 	return nil
 }
 
-func PutJsonRecieveNone(endpoint string, object interface{}) error {
-	resp, err := sendReq("PUT", endpoint, object)
+func (a API) SendGetNoneRecieveBytes(endpoint string) ([]byte, error) {
+	resp, err := a.sendReq("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, mathbattle.ErrNotFound
+		}
+		return nil, fmt.Errorf("Unexpected HTTP status: %d", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (a API) PutJsonRecieveNone(endpoint string, object interface{}) error {
+	resp, err := a.sendReq("PUT", endpoint, object)
 	if err != nil {
 		return err
 	}
@@ -159,14 +256,17 @@ func PutJsonRecieveNone(endpoint string, object interface{}) error {
 		if resp.StatusCode == http.StatusNotFound {
 			return mathbattle.ErrNotFound
 		}
+		if resp.StatusCode == http.StatusBadRequest {
+			return mathbattle.ErrWrongUserInput
+		}
 		return fmt.Errorf("Unexpected HTTP status: %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
-func DeleteRecieveNone(endpoint string) error {
-	resp, err := sendReq("DELETE", endpoint, nil)
+func (a API) DeleteRecieveNone(endpoint string, actorID int64) error {
+	resp, err := a.sendReq("DELETE", endpoint, actor(actorID))
 	if err != nil {
 		return err
 	}
@@ -176,6 +276,9 @@ func DeleteRecieveNone(endpoint string) error {
 		if resp.StatusCode == http.StatusNotFound {
 			return mathbattle.ErrNotFound
 		}
+		if resp.StatusCode == http.StatusForbidden {
+			return mathbattle.ErrForbidden
+		}
 		return fmt.Errorf("Unexpected HTTP status: %d", resp.StatusCode)
 	}
 
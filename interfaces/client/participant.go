@@ -7,41 +7,48 @@ import (
 )
 
 type APIParticipant struct {
-	BaseUrl string
+	API
 }
 
 func (a *APIParticipant) Store(participant mathbattle.Participant) (mathbattle.Participant, error) {
 	result := participant
-	err := PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/participants"), &result, &result)
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/participants"), &result, &result)
 	return result, err
 }
 
 func (a *APIParticipant) GetByID(ID string) (mathbattle.Participant, error) {
 	result := mathbattle.Participant{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants", ID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants", ID), &result)
 	return result, err
 }
 
 func (a *APIParticipant) GetByTelegramID(TelegramID int64) (mathbattle.Participant, error) {
 	result := mathbattle.Participant{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%d", a.BaseUrl, "/participants/telegram", TelegramID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%d", a.BaseUrl, "/participants/telegram", TelegramID), &result)
 	return result, err
 }
 
 func (a *APIParticipant) GetAll() ([]mathbattle.Participant, error) {
 	result := []mathbattle.Participant{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/participants"), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/participants"), &result)
 	return result, err
 }
 
 func (a *APIParticipant) Update(participant mathbattle.Participant) error {
-	return PutJsonRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants", participant.ID), participant)
+	return a.PutJsonRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants", participant.ID), participant)
 }
 
-func (a *APIParticipant) Delete(ID string) error {
-	return DeleteRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants", ID))
+func (a *APIParticipant) Delete(ID string, actorID int64) error {
+	return a.DeleteRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants", ID), actorID)
 }
 
 func (a *APIParticipant) Unsubscribe(ID string) error {
-	return PostNoneRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants/unsubscribe", ID))
+	return a.PostNoneRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/participants/unsubscribe", ID))
+}
+
+func (a *APIParticipant) PromoteGrades(schoolYear int, actorID int64) (mathbattle.GradePromotion, error) {
+	result := mathbattle.GradePromotion{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s/%d", a.BaseUrl, "/participants/promote_grades", schoolYear),
+		actor(actorID), &result)
+	return result, err
 }
@@ -8,11 +8,11 @@ import (
 )
 
 type APIPostman struct {
-	BaseUrl string
+	API
 }
 
 func (a *APIPostman) SendSimpleToUsers(msg mathbattle.SimpleMessage) error {
-	return PostJsonRecieveNone(fmt.Sprintf("%s%s", a.BaseUrl, "/postman/send_to_users"), msg)
+	return a.PostJsonRecieveNone(fmt.Sprintf("%s%s", a.BaseUrl, "/postman/send_to_users"), msg)
 }
 
 func (a *APIPostman) SendSimpleMessage(chatID int64, message string) error {
@@ -26,3 +26,7 @@ func (a *APIPostman) SendImage(chatID int64, imageCaption string, image []byte)
 func (a *APIPostman) SendAlbum(chatID int64, albumCaption string, images [][]byte) error {
 	return errors.New("Not implemented")
 }
+
+func (a *APIPostman) SendDocument(chatID int64, fileName string, caption string, content []byte) error {
+	return errors.New("Not implemented")
+}
@@ -6,11 +6,17 @@ import (
 )
 
 type APIProblem struct {
-	BaseUrl string
+	API
 }
 
 func (a *APIProblem) GetByID(ID string) (mathbattle.Problem, error) {
 	result := mathbattle.Problem{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/problems", ID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/problems", ID), &result)
+	return result, err
+}
+
+func (a *APIProblem) GetAll() ([]mathbattle.Problem, error) {
+	result := []mathbattle.Problem{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/problems"), &result)
 	return result, err
 }
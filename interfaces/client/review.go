@@ -6,27 +6,27 @@ import (
 )
 
 type APIReview struct {
-	BaseUrl string
+	API
 }
 
 func (a *APIReview) Store(review mathbattle.Review) (mathbattle.Review, error) {
 	result := mathbattle.Review{}
-	err := PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/reviews"), review, &result)
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/reviews"), review, &result)
 	return result, err
 }
 
 func (a *APIReview) FindMany(descriptor mathbattle.ReviewFindDescriptor) ([]mathbattle.Review, error) {
 	result := []mathbattle.Review{}
-	err := SendGetJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/reviews/find/descriptor"), descriptor, &result)
+	err := a.SendGetJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/reviews/find/descriptor"), descriptor, &result)
 	return result, err
 }
 
-func (a *APIReview) Delete(ID string) error {
-	return DeleteRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/reviews", ID))
+func (a *APIReview) Delete(ID string, actorID int64) error {
+	return a.DeleteRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/reviews", ID), actorID)
 }
 
 func (a *APIReview) RevewStageDescriptors(participantID string) ([]mathbattle.SolutionDescriptor, error) {
 	var result []mathbattle.SolutionDescriptor
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/reviews/descriptors", participantID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/reviews/descriptors", participantID), &result)
 	return result, err
 }
@@ -2,70 +2,98 @@ package client
 
 import (
 	"fmt"
+	"net/url"
 
 	"mathbattle/models/mathbattle"
 )
 
 type APIRound struct {
-	BaseUrl string
+	API
 }
 
 func (a *APIRound) StartNew(startOrder mathbattle.StartOrder) (mathbattle.SSStartResult, error) {
 	result := mathbattle.SSStartResult{}
-	err := PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/start"), startOrder, &result)
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/start"), startOrder, &result)
 	return result, err
 }
 
 func (a *APIRound) StartReviewStage(startOrder mathbattle.StartOrder) (mathbattle.CSStartResult, error) {
 	result := mathbattle.CSStartResult{}
-	err := PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/start_review"), startOrder, &result)
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/start_review"), startOrder, &result)
 	return result, err
 }
 
-func (a *APIRound) ReviewStageDistributionDesc() (mathbattle.ReviewDistributionDesc, error) {
+func (a *APIRound) ReviewStageDistributionDesc(league string, seed int64) (mathbattle.ReviewDistributionDesc, error) {
 	var result mathbattle.ReviewDistributionDesc
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/review_stage_distribution"), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s?seed=%d&league=%s", a.BaseUrl, "/rounds/review_stage_distribution", seed, url.QueryEscape(league)), &result)
+	return result, err
+}
+
+func (a *APIRound) ReassignReview(order mathbattle.ReassignOrder) (mathbattle.ReassignResult, error) {
+	result := mathbattle.ReassignResult{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/reassign_review"), order, &result)
+	return result, err
+}
+
+func (a *APIRound) ReassignOptions(league string, seed int64) (mathbattle.ReassignOptions, error) {
+	var result mathbattle.ReassignOptions
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s?seed=%d&league=%s", a.BaseUrl, "/rounds/reassign_options", seed, url.QueryEscape(league)), &result)
+	return result, err
+}
+
+func (a *APIRound) PublishResults(roundID string, actorID int64) (mathbattle.Round, error) {
+	result := mathbattle.Round{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/rounds/publish_results", roundID), actor(actorID), &result)
+	if err == mathbattle.ErrConflict {
+		return result, mathbattle.ErrRoundNotFinished
+	}
+	return result, err
+}
+
+func (a *APIRound) RevealIdentities(order mathbattle.RevealOrder) ([]mathbattle.SolutionIdentity, error) {
+	result := []mathbattle.SolutionIdentity{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/reveal_identities"), order, &result)
 	return result, err
 }
 
 func (a *APIRound) GetAll() ([]mathbattle.Round, error) {
 	result := []mathbattle.Round{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds"), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds"), &result)
 	return result, err
 }
 
 func (a *APIRound) GetByID(ID string) (mathbattle.Round, error) {
 	result := mathbattle.Round{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/rounds", ID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/rounds", ID), &result)
 	return result, err
 }
 
-func (a *APIRound) GetRunning() (mathbattle.Round, error) {
+func (a *APIRound) GetRunning(league string) (mathbattle.Round, error) {
 	result := mathbattle.Round{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/running"), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s?league=%s", a.BaseUrl, "/rounds/running", url.QueryEscape(league)), &result)
 	return result, err
 }
 
-func (a *APIRound) GetReviewPending() (mathbattle.Round, error) {
+func (a *APIRound) GetReviewPending(league string) (mathbattle.Round, error) {
 	result := mathbattle.Round{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/review_pending"), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s?league=%s", a.BaseUrl, "/rounds/review_pending", url.QueryEscape(league)), &result)
 	return result, err
 }
 
-func (a *APIRound) GetReviewRunning() (mathbattle.Round, error) {
+func (a *APIRound) GetReviewRunning(league string) (mathbattle.Round, error) {
 	result := mathbattle.Round{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/review_running"), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s?league=%s", a.BaseUrl, "/rounds/review_running", url.QueryEscape(league)), &result)
 	return result, err
 }
 
-func (a *APIRound) GetLast() (mathbattle.Round, error) {
+func (a *APIRound) GetLast(league string) (mathbattle.Round, error) {
 	result := mathbattle.Round{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/rounds/last"), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s?league=%s", a.BaseUrl, "/rounds/last", url.QueryEscape(league)), &result)
 	return result, err
 }
 
 func (a *APIRound) GetProblemDescriptors(participantID string) ([]mathbattle.ProblemDescriptor, error) {
 	result := []mathbattle.ProblemDescriptor{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/rounds/problem_descriptors", participantID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/rounds/problem_descriptors", participantID), &result)
 	return result, err
 }
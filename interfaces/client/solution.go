@@ -2,46 +2,57 @@ package client
 
 import (
 	"fmt"
+	"net/url"
 
 	"mathbattle/models/mathbattle"
 )
 
 type APISolution struct {
-	BaseUrl string
+	API
 }
 
 func (a *APISolution) Create(solution mathbattle.Solution) (mathbattle.Solution, error) {
 	result := solution
-	err := PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/solutions"), &result, &result)
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/solutions"), &result, &result)
 	return result, err
 }
 
 func (a *APISolution) Get(ID string) (mathbattle.Solution, error) {
 	result := mathbattle.Solution{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions", ID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions", ID), &result)
 	return result, err
 }
 
 func (a *APISolution) Find(findDescriptor mathbattle.FindDescriptor) ([]mathbattle.Solution, error) {
 	result := []mathbattle.Solution{}
-	err := SendGetJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/solutions/find/descriptor"), findDescriptor, &result)
+	err := a.SendGetJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/solutions/find/descriptor"), findDescriptor, &result)
 	return result, err
 }
 
 func (a *APISolution) AppendPart(ID string, part mathbattle.Image) error {
-	return PostJsonRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions/append_part", ID), part)
+	return a.PostJsonRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions/append_part", ID), part)
 }
 
 func (a *APISolution) Update(solution mathbattle.Solution) error {
-	return PutJsonRecieveNone(fmt.Sprintf("%s%s", a.BaseUrl, "/solutions"), solution)
+	return a.PutJsonRecieveNone(fmt.Sprintf("%s%s", a.BaseUrl, "/solutions"), solution)
 }
 
-func (a *APISolution) Delete(ID string) error {
-	return DeleteRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions", ID))
+func (a *APISolution) Delete(ID string, actorID int64) error {
+	return a.DeleteRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions", ID), actorID)
 }
 
 func (a *APISolution) GetProblemDescriptors(participantID string) ([]mathbattle.ProblemDescriptor, error) {
 	result := []mathbattle.ProblemDescriptor{}
-	err := SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions/descriptors", participantID), &result)
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/solutions/descriptors", participantID), &result)
+	return result, err
+}
+
+func (a *APISolution) MergedPDF(roundID string, problemID string) ([]byte, error) {
+	return a.SendGetNoneRecieveBytes(fmt.Sprintf("%s%s/%s?problem_id=%s", a.BaseUrl, "/solutions/merged_pdf", roundID, url.QueryEscape(problemID)))
+}
+
+func (a *APISolution) JurySolutions(roundID string, problemID string) ([]mathbattle.Solution, error) {
+	result := []mathbattle.Solution{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s?problem_id=%s", a.BaseUrl, "/solutions/jury", roundID, url.QueryEscape(problemID)), &result)
 	return result, err
 }
@@ -5,9 +5,9 @@ import (
 )
 
 type APIStat struct {
-	BaseUrl string
+	API
 }
 
-func (a *APIStat) Stat() (mathbattle.Stat, error) {
+func (a *APIStat) Stat(league string) (mathbattle.Stat, error) {
 	return mathbattle.Stat{}, nil
 }
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"fmt"
+
+	"mathbattle/models/mathbattle"
+)
+
+type APITeam struct {
+	API
+}
+
+func (a *APITeam) Create(team mathbattle.Team) (mathbattle.Team, error) {
+	result := mathbattle.Team{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/teams"), team, &result)
+	return result, err
+}
+
+func (a *APITeam) Get(ID string) (mathbattle.Team, error) {
+	result := mathbattle.Team{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/teams", ID), &result)
+	return result, err
+}
+
+func (a *APITeam) GetByMember(participantID string) (mathbattle.Team, error) {
+	result := mathbattle.Team{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/teams/member", participantID), &result)
+	return result, err
+}
+
+func (a *APITeam) GetAll() ([]mathbattle.Team, error) {
+	result := []mathbattle.Team{}
+	err := a.SendGetNoneRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/teams"), &result)
+	return result, err
+}
+
+func (a *APITeam) Join(order mathbattle.JoinOrder) (mathbattle.Team, error) {
+	result := mathbattle.Team{}
+	err := a.PostJsonRecieveJson(fmt.Sprintf("%s%s", a.BaseUrl, "/teams/join"), order, &result)
+	return result, err
+}
+
+func (a *APITeam) Leave(participantID string) error {
+	return a.PostNoneRecieveNone(fmt.Sprintf("%s%s/%s", a.BaseUrl, "/teams/leave", participantID))
+}
@@ -0,0 +1,102 @@
+package replier
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Catalog - тексты ответов бота на одном языке: ключ сообщения -> шаблон для fmt.Sprintf.
+// Формы множественного числа перечисляются через "|", например "лист|листа|листов"
+type Catalog map[string]string
+
+const pluralSeparator = "|"
+
+// ReferenceCatalog - каталог, в котором есть все ключи. Остальные каталоги сверяются с ним
+func ReferenceCatalog() Catalog {
+	return russianCatalog
+}
+
+func builtinCatalogs() map[string]Catalog {
+	return map[string]Catalog{
+		"ru": russianCatalog,
+		"en": englishCatalog,
+	}
+}
+
+// LoadCatalog читает каталог из yaml файла вида "ключ: текст"
+func LoadCatalog(path string) (Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := Catalog{}
+	if err := yaml.NewDecoder(f).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode catalog %s: %v", path, err)
+	}
+
+	return result, nil
+}
+
+// MissingKeys возвращает ключи эталонного каталога, которых нет в catalog
+func MissingKeys(catalog Catalog) []string {
+	result := []string{}
+	for key := range ReferenceCatalog() {
+		if _, exists := catalog[key]; !exists {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+var formatVerbRegexp = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+// FormatVerbs - глаголы fmt в шаблоне, по порядку. Перевод должен принимать те же аргументы
+func FormatVerbs(template string) []string {
+	return formatVerbRegexp.FindAllString(template, -1)
+}
+
+// NormalizeLanguage приводит код языка из телеграма ("en-US", "pt_BR") к коду каталога ("en", "pt")
+func NormalizeLanguage(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i != -1 {
+		code = code[:i]
+	}
+	return code
+}
+
+// pluralForm выбирает форму слова для числа n по правилам языка
+func pluralForm(language string, forms []string, n int) string {
+	if len(forms) == 3 && (language == "ru" || language == "uk" || language == "be") {
+		return GetDeclensionByNumeral([3]string{forms[0], forms[1], forms[2]}, n)
+	}
+
+	if n == 1 || len(forms) == 1 {
+		return forms[0]
+	}
+	return forms[len(forms)-1]
+}
+
+func GetDeclensionByNumeral(forms [3]string, numeral int) string {
+	ld := numeral % 10
+	ltd := numeral % 100
+	if ld == 1 && ltd != 11 {
+		//1, 21, 31, ... 101, ...
+		return forms[0]
+	}
+
+	if ld > 1 && ld < 5 && ltd != 12 && ltd != 13 && ltd != 14 {
+		//2, 3, 4, 22, 23, 24, 32, 33, 34, ...
+		return forms[1]
+	}
+
+	return forms[2]
+}
@@ -0,0 +1,376 @@
+package replier
+
+var englishCatalog = Catalog{
+	"language_name":   "English",
+	"duration":        "%dd %dh %dm",
+	"datetime_format": "02 Jan 2006 15:04",
+
+	"yes":    "Yes",
+	"no":     "No",
+	"cancel": "Cancelled",
+
+	"start_message":      "Hi! This bot will help you prepare for math battles.\n",
+	"available_commands": "You can do the following now:\n\n",
+	"help_rules": `
+	This bot will help you prepare for math battles. In every round you will solve problems, write up your solutions and review solutions of other participants. 
+To take part, subscribe to the problem mailing. If you don't want to take part in a round, you can unsubscribe.
+*A round has three stages:* solving, reviewing and results. Each stage lasts several days, the deadlines are announced at the start of the round. 
+
+*1. Solving.* At the start of the round the problems are sent out together with the deadline for solutions. You don't have to solve every problem, pick any you like. Write the solution of each problem on a separate sheet, then scan it or take a good photo and send it. 
+
+*Solution requirements:*
+•  The solution must be complete, every statement must be justified.
+•  The solution must be written neatly and legibly.
+•  The text on the photo must be sharp and easy to read. Take photos in good light, adjust brightness and contrast before sending.
+•  Photos must be oriented correctly. Rotate them before sending if needed. 
+
+*To send a solution:*
+•  Press %s.
+•  Choose the problem.
+•  Upload the images, there may be several of them.
+•  When all images of the solution are uploaded, press «%s».
+•  After that you can upload a solution of another problem.
+To change your solution, send a new one for the same problem, it will replace the old one. Solutions can't be sent or replaced after the solving stage is over.
+
+*2. Reviewing.* At the start of this stage you will receive solutions of other participants. These are solutions of only those problems you have sent a solution for yourself. Check the solutions you received and write a comment. If a solution has several images, they come as an album.
+
+*Comment requirements*:
+•  Start with whether you consider the solution correct. A solution is correct if it is complete and every statement is properly justified. 
+•  If you consider the solution correct, you may write nothing else or point out minor flaws, if any.
+•  If you consider the solution wrong, explain why and point out the mistakes. You may suggest a fix, but you don't have to.
+•  Don't compare the solution with yours. What matters is whether the reviewed solution is correct, not whether it is optimal or other approaches exist. 
+•  Criticizing the author and obscene language are forbidden. 
+Example of a comment: "I think the solution is wrong. It uses that 0.5n is an integer, but this holds only for even n, while by the statement n is any natural number. The claim is not proved for odd n."
+
+*To send a comment*:
+•  Press %s.
+•  Choose the solution you want to comment on.
+•  Write and send the comment.
+A comment can be changed until the reviewing stage is over. Please try to review all solutions you received. 
+When the stage is over, comments are sent to the authors. You may receive several comments on one solution. 
+`,
+	"help_results": `
+*3. Results.* The jury checks and grades the solutions and comments. You will receive comments on your solutions and on the solutions you reviewed. The solutions of the problems are published too, so you can study them and check yourself. 
+Points are given by the following rules:
+• A completely correct solution is worth 12 points.
+• If a solution has gaps, the score is reduced depending on how serious they are.
+• A completely correct comment is worth 6 points. 
+• If a solution has gaps and the comment doesn't mention them, the score for the comment is reduced. If there are many gaps, it is enough to point out the most important ones. 
+• If the reviewer considers wrong something that is actually correct, the score is reduced too.
+• UNLIKE THE MATH BATTLE RULES: a comment on a correct solution also earns points. So if the solution is completely correct, the comment "Everything is correct" can earn 6 points. 
+
+Points for solutions and comments are summed up into the rating. 
+
+If you have any problems or questions, write to @mathbattle\_support.
+
+Thank you for your interest!`,
+
+	"cmd_help_desc":               "Help",
+	"cmd_subscribe_desc":          "Subscribe to the problem mailing",
+	"cmd_unsubscribe_desc":        "Unsubscribe from the problem mailing",
+	"cmd_get_problems_desc":       "Show the problems",
+	"cmd_submit_solution_desc":    "Send a solution of a problem from the current round",
+	"cmd_start_review_stage_desc": "Start the review stage",
+	"cmd_submit_review_desc":      "Send comments on a solution",
+	"cmd_stat_desc":               "Statistics",
+	"cmd_get_reviews_desc":        "Read comments on your solutions",
+	"cmd_start_round_desc":        "Start a new round",
+	"cmd_service_msg_desc":        "Send a message to all participants",
+	"cmd_get_my_results_desc":     "Show jury comments and marks",
+	"cmd_reassign_review_desc":    "Change the review distribution",
+	"cmd_reminders_desc":          "Turn stage end reminders on or off",
+	"cmd_language_desc":           "Choose the language",
+	"cmd_time_zone_desc":          "Choose the time zone for deadlines",
+	"cmd_league_desc":             "Choose the league whose rounds you manage",
+	"cmd_role_desc":               "Grant or revoke an organizer role",
+	"cmd_profile_desc":            "View and edit your participant profile",
+	"cmd_team_desc":               "Create, join or leave a team",
+	"cmd_battle_desc":             "Make a move in a math battle: challenge the rival, accept or refuse a challenge",
+
+	"internal_error":    "An internal error occurred. Please contact %s and describe your problem.",
+	"not_participant":   "You are not a participant. Please register first.",
+	"callback_outdated": "This button no longer works",
+	"no_round_running":  "The round hasn't started yet.",
+
+	"solve_stage_end": "The solving stage is over. The next stage, reviewing, will start in a while. " +
+		"When it starts, you will receive solutions of other participants to review.",
+	"solve_stage_end_no_solutions": "The solving stage is over. Unfortunately, you haven't sent a solution of any problem.",
+	"review_stage_end": "The reviewing stage is over. " +
+		"Now you can read comments of other participants on your solutions. " +
+		"To do this, press here: %s\n" +
+		"You will receive jury comments on your solutions and on your reviews a bit later.",
+
+	"reminder_solve_stage": "Time left to send solutions: %s\n" +
+		"You haven't sent solutions of the problems: %s\n" +
+		"Send a solution: %s\n" +
+		"Turn off reminders: %s",
+	"reminder_review_stage": "Time left to send comments: %s\n" +
+		"You haven't commented on:\n" +
+		"%s" +
+		"Send a comment: %s\n" +
+		"Turn off reminders: %s",
+	"reminders_status_off":  "Stage end reminders are off now. Turn them on?",
+	"reminders_status_on":   "Stage end reminders are on now. Turn them off?",
+	"reminders_turn_on":     "Turn on",
+	"reminders_turn_off":    "Turn off",
+	"reminders_changed_off": "Reminders are off",
+	"reminders_changed_on":  "Reminders are on",
+
+	"language_ask":     "Choose the language",
+	"language_wrong":   "Choose the language with the buttons",
+	"language_changed": "Language changed",
+
+	"time_zone_ask": "Deadlines are shown in time zone %s now, your time is %s.\n" +
+		"Send the time zone name (for example, Asia/Yekaterinburg) or the offset from UTC (for example, UTC+5).",
+	"time_zone_event_default": "Same as the event",
+	"time_zone_wrong":         "Unknown time zone. Send the name, for example Asia/Yekaterinburg, or the offset from UTC, for example UTC+5.",
+	"time_zone_changed":       "Time zone changed: %s. Your time is %s now.",
+
+	"league_ask":     "You manage the rounds of league \"%s\" now. Choose a league.",
+	"league_main":    "Main",
+	"league_wrong":   "There is no such league. Choose a league with the buttons.",
+	"league_changed": "You manage the rounds of league \"%s\" now.",
+
+	"role_staff_header": "Organizing committee:\n",
+	"role_staff_item":   "%s - %s\n",
+	"role_ask_user":     "\nSend the Telegram username (for example, @ivanov) or the Telegram ID of the user.",
+	"role_ask_role":     "Choose the role",
+	"role_wrong_role":   "Choose the role with the buttons",
+	"role_failed":       "Failed: the user is not found (they must message the bot at least once) or this is the last owner.",
+	"role_changed":      "Role of %s: %s",
+	"role_none":         "No role",
+	"role_owner":        "Owner",
+	"role_organizer":    "Organizer",
+	"role_jury":         "Jury",
+	"role_moderator":    "Moderator",
+
+	"already_registered":    "You are already subscribed to the problem mailing.",
+	"register_name_expect":  "Enter your name. The name must contain letters only.",
+	"register_name_wrong":   "The name must contain letters only.",
+	"register_grade_expect": "Great! Now enter your school grade (digits only).",
+	"register_grade_wrong":  "Wrong grade. A number from 1 to 11 is expected.",
+	"register_success":      "You are registered. Wait for the round to start and the problems to be sent.",
+	"register_success_round_running": "You are registered.\n" +
+		"A round is already running. Join in solving the problems!\n" +
+		"\n" +
+		"The solving stage will last %s. " +
+		"Solutions won't be accepted after %s.",
+
+	"register_skip":                   "Skip",
+	"register_school_expect":          "Enter your school, for example \"Lyceum No. 1\" or \"School 57\".",
+	"register_school_choose":          "Choose your school from the list. If it is not there, send the name again.",
+	"register_school_wrong":           "The school name must be at most 256 characters long.",
+	"register_region_expect":          "Enter your city or region.",
+	"register_region_wrong":           "The city or region may contain only letters, spaces, hyphens, dots and commas and must be at most 100 characters long.",
+	"register_teacher_contact_expect": "Enter the name and contact (phone, e-mail or telegram username) of your math teacher.",
+	"register_teacher_contact_wrong":  "The teacher contact must be at most 200 characters long.",
+	"register_consent_expect": "Minors need the consent of their parents or legal guardians " +
+		"to take part in the math battle and to the processing of personal data. Do your parents consent?",
+	"register_consent_required": "You can't take part in the math battle without your parents' consent.",
+
+	"profile_header":                 "Your profile:",
+	"profile_not_specified":          "not specified",
+	"profile_field_name":             "Name",
+	"profile_field_grade":            "Grade",
+	"profile_field_school":           "School",
+	"profile_field_region":           "City or region",
+	"profile_field_teacher_contact":  "Teacher",
+	"profile_field_parental_consent": "Parental consent",
+	"profile_choose_field":           "What do you want to change?",
+	"profile_grade_expect":           "Enter your school grade (digits only).",
+	"profile_wrong_field":            "Choose a field with the buttons below the message.",
+	"profile_updated":                "Profile updated.",
+	"profile_not_changed":            "Profile not changed.",
+
+	"team_header":             "Team \"%s\":\n",
+	"team_invite_code":        "Invite code: `%s`. Send it to the participants you invite to the team.",
+	"team_none":               "You are not in a team. Create your own team or join one with the invite code from its captain.",
+	"team_create":             "Create a team",
+	"team_join":               "Join by code",
+	"team_leave":              "Leave the team",
+	"team_expect_name":        "Enter the team name.",
+	"team_wrong_name":         "The team name must be at most 50 characters long.",
+	"team_expect_invite_code": "Enter the invite code you got from the team captain.",
+	"team_wrong_invite_code":  "There is no team with this code. Check the code and send it again.",
+	"team_created":            "Team \"%s\" is created, you are its captain.\nInvite code: `%s`. Send it to the participants you invite to the team.",
+	"team_joined":             "You joined team \"%s\".",
+	"team_left":               "You left the team.",
+	"team_locked":             "A team round is running. Teams can be changed after it ends.",
+	"team_not_changed":        "Team not changed.",
+	"team_round_no_team":      "A team round is running and you are not in a team. You can create a team after the round: %s",
+
+	"battle_preparation_end":    "The preparation for the math battle is over. The jury will announce the battles soon, captains will make moves with %s.",
+	"battle_started":            "The math battle against team \"%s\" begins! Problems: %s.",
+	"battle_rival_challenges":   "Team \"%s\" is choosing a problem to challenge your team.",
+	"battle_rival_passed":       "Team \"%s\" declined to challenge.\n",
+	"battle_your_challenge":     "Your turn to challenge team \"%s\". The captain picks a problem with %s or declines to challenge. Open problems: %s.",
+	"battle_you_are_challenged": "Team \"%s\" challenges you on problem %s. The captain accepts or refuses the challenge with %s.",
+	"battle_rival_responds":     "You challenged team \"%s\" on problem %s. Waiting for the answer.",
+	"battle_report":             "Problem %s: \"%s\" reports, \"%s\" opposes. Waiting for the jury points.",
+	"battle_report_refused":     "The challenge on problem %s is refused: \"%s\" proves the challenge is correct, \"%s\" opposes. Waiting for the jury points.",
+	"battle_finished":           "The math battle is over!",
+	"battle_score":              "Score: \"%s\" %d : %d \"%s\", jury %d.",
+	"battle_judged":             "Problem %s: reporter \"%s\" gets %d, opponent \"%s\" - %d, jury - %d.",
+	"battle_none":               "Your team is not playing a math battle now.",
+	"battle_pass":               "Decline to challenge",
+	"battle_accept":             "Accept the challenge",
+	"battle_refuse":             "Refuse the challenge",
+	"battle_move_done":          "Move accepted.",
+	"battle_move_wrong":         "This move is not allowed now.",
+
+	"not_subscribed":      "You are not subscribed to the problem mailing.",
+	"unsubscribe_success": "You are unsubscribed from the problem mailing.",
+
+	"problems_post_before": "Hi! A new round has started. The first stage is solving the problems. " +
+		"\n\n" +
+		"The stage will last %s. " +
+		"Solutions won't be accepted after %s." +
+		"\n\n" +
+		"Solution requirements:\n" +
+		"•  The solution must be complete, every statement must be justified.\n" +
+		"•  The solution must be written neatly and legibly.\n" +
+		"•  The text on the photo must be sharp and easy to read. Take photos in good light, adjust brightness and contrast before sending.\n" +
+		"•  Photos must be oriented correctly. Rotate them before sending if needed.\n",
+	"problems_post_after": "When you are ready, send your solution. To do this, press here: \n" +
+		"%s\n" +
+		"Write the solution on paper and send a good photo or scan. ",
+
+	"solution_part_uploaded":  "Sheet #%d uploaded",
+	"solution_upload_success": "The solution is uploaded. It has %d %s",
+	"sheets":                  "sheet|sheets",
+	"solution_expect_part": "Great, now send your solution. Write it on paper and send a good photo or scan. " +
+		"You can upload any number of photos or pdf files. " +
+		"When everything is sent, press '%s'",
+	"solution_is_rewrite_old":         "You have already sent a solution of this problem. The new solution will replace the old one.\n\nContinue?",
+	"solution_wrong_problem_caption":  "There is no such problem.",
+	"solution_expect_problem_caption": "Choose the problem you want to send a solution for.",
+	"solution_finish_uploading":       "Finish sending the solution",
+	"solution_wrong_format":           "Wrong solution format. Only photos and pdf files are accepted.",
+	"solution_empty":                  "You haven't sent any photo of your solution :(",
+	"solution_part_too_large":         "The file is too large. The maximum size is %.1f MB. This sheet is not uploaded.",
+	"solution_part_unsupported":       "Files of this type are not accepted. Send a photo or a pdf. This sheet is not uploaded.",
+
+	"wrong_round_end": "The round end date is wrong",
+	"start_review_get_duration": "Enter the round end date (time zone %s) in one of the formats:\n" +
+		"DD.MM.YYYY HH:MM (Reviews won't be accepted after this date)\n" +
+		"DD.MM.YYYY (The last day to send reviews. Reviews are accepted until midnight)\n",
+	"start_review_confirm_duration": "Reviews won't be accepted after %s\n" +
+		"Total duration of the review stage: %s\n" +
+		"Correct?\n",
+	"start_review_success":     "Solutions are sent, the stage has started.\n",
+	"participants_with_errors": "Participants with errors: %d\n",
+	"start_review_distribution_changed": "The round solutions changed while you were confirming, so the distribution is different now. " +
+		"The stage is not started, run the command again to see the new distribution.",
+
+	"reassign_ask_action":            "What should be done?",
+	"reassign_action_move":           "Give the solution to another participant",
+	"reassign_action_add_reviewer":   "Add a reviewer",
+	"reassign_action_to_organizers":  "Give the solution to the organizers",
+	"reassign_abort":                 "Cancel",
+	"reassign_preview":               "The review stage hasn't started yet. Changes apply to the distribution the stage will start with.\n",
+	"reassign_use_buttons":           "Choose with the buttons above",
+	"reassign_nothing":               "There are no solutions in the round",
+	"reassign_choose_problem":        "Choose the problem",
+	"reassign_problem_button":        "Problem %s",
+	"reassign_choose_solution":       "Choose the solution",
+	"reassign_choose_from":           "Whom to take the solution from? Reviewers who already sent a review are not listed",
+	"reassign_choose_to":             "Whom to give the solution to?",
+	"reassign_no_choice":             "No one fits, choose another solution",
+	"reassign_confirm_move":          "Give solution '%s' from %s to %s?",
+	"reassign_confirm_add_reviewer":  "Send solution '%s' for review to %s?",
+	"reassign_confirm_to_organizers": "Take solution '%s' from reviewers who haven't sent a review yet and give it to the organizers?",
+	"reassign_wrong":                 "Failed. The distribution has probably changed, try again",
+	"reassign_review_submitted":      "The reviewer has already sent a review on this solution, it can't be taken away",
+	"reassign_success":               "The distribution is changed, participants are notified.\n",
+	"reassign_success_preview":       "The distribution is changed. Participants will get it when the review stage starts",
+
+	"start_round_get_duration": "Enter the round end date (time zone %s) in one of the formats:\n" +
+		"DD.MM.YYYY HH:MM (Solutions won't be accepted after this date)\n" +
+		"DD.MM.YYYY (The last day to send solutions. Solutions are accepted until midnight)\n",
+	"start_round_confirm_duration": "Solutions won't be accepted after %s\n" +
+		"Total duration of the solving stage: %s\n" +
+		"Correct?\n",
+	"start_round_success":                 "The round has started\nProblems are sent to *%d/%d* participants",
+	"start_round_failed_participants":     "\nParticipants the round failed to start for:\n",
+	"start_round_problem_bank_empty":      "The problem bank is empty. Add problems with mb-admin add-problems first",
+	"start_round_problem_caption":         "Problem #%s, for grades %d–%d",
+	"start_round_problem_button":          "#%s (grades %d–%d)",
+	"start_round_pick_problems":           "Above are all problems from the bank. Press a problem to add it to the round. Pressing it again removes the problem from the round. Problems are labeled A, B, C, ... in the order you pick them.",
+	"start_round_picked_none":             "No problems picked yet.",
+	"start_round_picked_header":           "Round problems:\n",
+	"start_round_picked_item":             "%s — problem #%s (grades %d–%d)\n",
+	"start_round_pick_wrong":              "There is no such problem. Choose a problem with the buttons.",
+	"start_round_pick_nothing":            "Pick at least one problem.",
+	"start_round_pick_done":               "Done",
+	"start_round_reorder":                 "Change the order",
+	"start_round_reorder_expect":          "\nList the problem labels separated by commas in the new order, for example: %s",
+	"start_round_reorder_wrong":           "List every problem label exactly once.",
+	"start_round_abort":                   "Cancel the round start",
+	"start_round_preview":                 "This is how participants will see the round start:",
+	"start_round_grades_without_problems": "\nWarning: no problem is suitable for grades %s\n",
+	"start_round_confirm_start":           "\nStart the round and send the problems to participants? Choose who competes: participants, teams or teams in a math battle.",
+	"start_round_mode_individual":         "Participants",
+	"start_round_mode_team":               "Teams",
+	"start_round_mode_matboi":             "Math battle",
+
+	"review_post_before": "The peer review stage has started. " +
+		"During it you need to check solutions of other participants and find flaws in them, if any." +
+		"\n\n" +
+		"The stage will last %s. " +
+		"Comments won't be accepted after %s." +
+		"\n\n" +
+		"Comment requirements:\n" +
+		"•  Start with whether you consider the solution correct. A solution is correct if it is complete and every statement is properly justified. \n" +
+		"•  If you consider the solution correct, you may write nothing else or point out minor flaws, if any.\n" +
+		"•  If you consider the solution wrong, explain why and point out the mistakes. You may suggest a fix, but you don't have to.\n" +
+		"•  Don't compare the solution with yours. What matters is whether the reviewed solution is correct, not whether it is optimal or other approaches exist. \n" +
+		"•  Criticizing the author and obscene language are forbidden. \n" +
+		"Example of a comment: \"I think the solution is wrong. It uses that 0.5n is an integer, but this holds only for even n, while by the statement n is any natural number. The claim is not proved for odd n.\"\n" +
+		"\n" +
+		"Below are the solutions of other participants you need to review.",
+	"review_post_caption": "(Solution %d of problem %s)",
+	"review_post_after": "When you are ready, send your comments on the solutions of other participants. To do this, press here: \n" +
+		"%s\n" +
+		"Send the comment as plain text.",
+	"review_reassign_removed":      "The organizers took solution %d of problem %s from your review, you don't need to check it anymore.\n",
+	"review_reassign_nothing_left": "You have no more solutions to review.",
+	"review_reassign_left":         "Numbers of the remaining solutions may have changed. You are reviewing now:\n",
+	"review_reassign_added":        "The organizers sent you one more solution to review. You can send a comment on it by pressing here: %s",
+
+	"review_expect_solution_caption": "Choose the solution you want to comment on.",
+	"review_wrong_solution_caption":  "There is no such solution.",
+	"review_is_rewrite_old":          "You have already commented on this solution. The new comment will replace the old one.\n\nContinue?",
+	"review_expect_content": "Great, now send your comment as a text message. " +
+		"State whether you consider the solution correct. If not, explain why.",
+	"review_upload_success":   "The comment is saved.",
+	"review_msg_for_reviewee": "You received a comment on your solution from another participant:\n\n%s",
+	"get_reviews_comment":     "Comment #%d on problem %s\n%s",
+
+	"stat_league":       "League: %s\n",
+	"stat_participants": "Participants total: %d\nNew today: %d\n",
+	"stat_no_round":     "No active round.",
+	"stat_round_header": "\nActive round statistics:\n",
+	"stat_solve_stage":  "Solving stage is running\nTime left: %s\n",
+	"stat_review_stage": "Review stage is running\nTime left: %s\n",
+	"stat_totals":       "Solutions sent: %d\nComments sent: %d\n",
+
+	"service_msg_get_text":             "Enter the message you want to send to all participants.",
+	"service_msg_text_is_empty":        "The message is empty",
+	"service_msg_cancel_send":          "Cancel sending",
+	"service_msg_ask_recievers_type":   "Who do you want to send the message to?",
+	"service_msg_wrong_recievers_type": "It is unclear who you want to send the message to",
+	"service_msg_recievers_type_all":   "Everyone",
+	"service_msg_recievers_type_some":  "Particular users",
+	"service_msg_input_recievers":      "Enter IDs of the users to send the message to, separated by commas",
+	"service_msg_final_ask":            "Sending the message to: %s\n",
+	"service_msg_send_success":         "The message is sent",
+
+	"my_results_problem":       "*Problem*: %s\n",
+	"my_results_mark":          "*Mark*: %d\n",
+	"my_results_not_solved":    "Unfortunately, you haven't solved this problem :(",
+	"my_results_juri_comment":  "*Jury comment:*\n",
+	"my_results_other_reviews": "*Comments from other participants:*\n",
+	"my_results_other_review":  "Comment %d\n",
+	"my_results_review":        "Your comment on solution %d of problem %s:\n",
+	"my_results_not_commented": "Unfortunately, you haven't commented on this solution :(",
+}
@@ -0,0 +1,1257 @@
+package replier
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mathbattle/application"
+	"mathbattle/libs/mstd"
+	"mathbattle/models/mathbattle"
+)
+
+// Названия команд одинаковы для всех языков: по ним телеграм и роутер находят обработчик
+const (
+	cmdHelp             = "/help"
+	cmdSubscribe        = "/subscribe"
+	cmdUnsubscribe      = "/unsubscribe"
+	cmdGetProblems      = "/get_problems"
+	cmdSubmitSolution   = "/submit_solution"
+	cmdStartReviewStage = "/start_review_stage"
+	cmdSubmitReview     = "/submit_review"
+	cmdStat             = "/stat"
+	cmdGetReviews       = "/get_reviews"
+	cmdStartRound       = "/start_round"
+	cmdServiceMsg       = "/send_service_message"
+	cmdGetMyResults     = "/get_my_results"
+	cmdReassignReview   = "/reassign_review"
+	cmdReminders        = "/reminders"
+	cmdLanguage         = "/language"
+	cmdTimeZone         = "/timezone"
+	cmdLeague           = "/league"
+	cmdRole             = "/role"
+	cmdProfile          = "/profile"
+	cmdTeam             = "/team"
+	cmdBattle           = "/battle"
+)
+
+// CatalogReplier формирует ответы бота по каталогу одного языка.
+// Ключи, которых нет в каталоге, берутся из каталога языка по умолчанию
+type CatalogReplier struct {
+	language string
+	catalog  Catalog
+	fallback Catalog
+}
+
+func NewCatalogReplier(language string, catalog Catalog, fallback Catalog) *CatalogReplier {
+	return &CatalogReplier{
+		language: language,
+		catalog:  catalog,
+		fallback: fallback,
+	}
+}
+
+func (r *CatalogReplier) Language() string {
+	return r.language
+}
+
+func (r *CatalogReplier) t(key string) string {
+	if text, exists := r.catalog[key]; exists {
+		return text
+	}
+	if text, exists := r.fallback[key]; exists {
+		return text
+	}
+	return key
+}
+
+func (r *CatalogReplier) f(key string, args ...interface{}) string {
+	return fmt.Sprintf(r.t(key), args...)
+}
+
+func (r *CatalogReplier) plural(key string, n int) string {
+	return pluralForm(r.language, strings.Split(r.t(key), pluralSeparator), n)
+}
+
+func (r *CatalogReplier) duration(d time.Duration) string {
+	day, hour, minute := mstd.DurationToDayHourMinute(d)
+	return r.f("duration", day, hour, minute)
+}
+
+// date - дата с часовым поясом, в котором она показана
+func (r *CatalogReplier) date(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.Format(r.t("datetime_format")), mathbattle.TimeZoneLabel(t))
+}
+
+func (r *CatalogReplier) failedParticipants(failed []mathbattle.ParticipantError) string {
+	msg := r.f("participants_with_errors", len(failed))
+	for i, item := range failed {
+		msg += fmt.Sprintf("%d) %s %s\n", i+1, item.Participant.ID, item.Error)
+	}
+	return msg
+}
+
+func escapeCommandNameForMarkdown(commandName string) string {
+	return strings.Replace(commandName, "_", "\\_", -1)
+}
+
+func (r *CatalogReplier) GetSupportAccountName() string {
+	return "@mathbattle_support"
+}
+
+func (r *CatalogReplier) Yes() string {
+	return r.t("yes")
+}
+
+func (r *CatalogReplier) No() string {
+	return r.t("no")
+}
+
+func (r *CatalogReplier) Cancel() string {
+	return r.t("cancel")
+}
+
+func (r *CatalogReplier) LanguageName() string {
+	return r.t("language_name")
+}
+
+func (r *CatalogReplier) GetStartMessage() string {
+	return r.t("start_message")
+}
+
+func (r *CatalogReplier) GetAvailableCommands(availableCommands []application.TelegramCommandHelp) string {
+	msg := r.t("available_commands")
+	for _, cmd := range availableCommands {
+		msg += cmd.Name + " " + cmd.Desc + "\n"
+	}
+	return msg
+}
+
+func (r *CatalogReplier) GetHelpMessages() []string {
+	return []string{
+		r.f("help_rules",
+			escapeCommandNameForMarkdown(r.CmdSubmitSolutionName()),
+			r.SolutionFinishUploading(),
+			escapeCommandNameForMarkdown(r.CmdSubmitReviewName())),
+		r.t("help_results"),
+	}
+}
+
+func (r *CatalogReplier) CmdHelpName() string {
+	return cmdHelp
+}
+
+func (r *CatalogReplier) CmdHelpDesc() string {
+	return r.t("cmd_help_desc")
+}
+
+func (r *CatalogReplier) CmdSubscribeName() string {
+	return cmdSubscribe
+}
+
+func (r *CatalogReplier) CmdSubscribeDesc() string {
+	return r.t("cmd_subscribe_desc")
+}
+
+func (r *CatalogReplier) CmdUnsubscribeName() string {
+	return cmdUnsubscribe
+}
+
+func (r *CatalogReplier) CmdUnsubscribeDesc() string {
+	return r.t("cmd_unsubscribe_desc")
+}
+
+func (r *CatalogReplier) CmdGetProblemsName() string {
+	return cmdGetProblems
+}
+
+func (r *CatalogReplier) CmdGetProblemsDesc() string {
+	return r.t("cmd_get_problems_desc")
+}
+
+func (r *CatalogReplier) CmdSubmitSolutionName() string {
+	return cmdSubmitSolution
+}
+
+func (r *CatalogReplier) CmdSubmitSolutionDesc() string {
+	return r.t("cmd_submit_solution_desc")
+}
+
+func (r *CatalogReplier) CmdStartReviewStageName() string {
+	return cmdStartReviewStage
+}
+
+func (r *CatalogReplier) CmdStartReviewStageDesc() string {
+	return r.t("cmd_start_review_stage_desc")
+}
+
+func (r *CatalogReplier) CmdSubmitReviewName() string {
+	return cmdSubmitReview
+}
+
+func (r *CatalogReplier) CmdSubmitReviewDesc() string {
+	return r.t("cmd_submit_review_desc")
+}
+
+func (r *CatalogReplier) CmdStatName() string {
+	return cmdStat
+}
+
+func (r *CatalogReplier) CmdStatDesc() string {
+	return r.t("cmd_stat_desc")
+}
+
+func (r *CatalogReplier) CmdGetReviewsName() string {
+	return cmdGetReviews
+}
+
+func (r *CatalogReplier) CmdGetReviewsDesc() string {
+	return r.t("cmd_get_reviews_desc")
+}
+
+func (r *CatalogReplier) CmdStartRoundName() string {
+	return cmdStartRound
+}
+
+func (r *CatalogReplier) CmdStartRoundDesc() string {
+	return r.t("cmd_start_round_desc")
+}
+
+func (r *CatalogReplier) CmdServiceMsgName() string {
+	return cmdServiceMsg
+}
+
+func (r *CatalogReplier) CmdServiceMsgDesc() string {
+	return r.t("cmd_service_msg_desc")
+}
+
+func (r *CatalogReplier) CmdGetMyResultsName() string {
+	return cmdGetMyResults
+}
+
+func (r *CatalogReplier) CmdGetMyResultsDesc() string {
+	return r.t("cmd_get_my_results_desc")
+}
+
+func (r *CatalogReplier) CmdReassignReviewName() string {
+	return cmdReassignReview
+}
+
+func (r *CatalogReplier) CmdReassignReviewDesc() string {
+	return r.t("cmd_reassign_review_desc")
+}
+
+func (r *CatalogReplier) CmdRemindersName() string {
+	return cmdReminders
+}
+
+func (r *CatalogReplier) CmdRemindersDesc() string {
+	return r.t("cmd_reminders_desc")
+}
+
+func (r *CatalogReplier) CmdLanguageName() string {
+	return cmdLanguage
+}
+
+func (r *CatalogReplier) CmdLanguageDesc() string {
+	return r.t("cmd_language_desc")
+}
+
+func (r *CatalogReplier) CmdTimeZoneName() string {
+	return cmdTimeZone
+}
+
+func (r *CatalogReplier) CmdTimeZoneDesc() string {
+	return r.t("cmd_time_zone_desc")
+}
+
+func (r *CatalogReplier) CmdLeagueName() string {
+	return cmdLeague
+}
+
+func (r *CatalogReplier) CmdLeagueDesc() string {
+	return r.t("cmd_league_desc")
+}
+
+func (r *CatalogReplier) CmdRoleName() string {
+	return cmdRole
+}
+
+func (r *CatalogReplier) CmdRoleDesc() string {
+	return r.t("cmd_role_desc")
+}
+
+func (r *CatalogReplier) CmdProfileName() string {
+	return cmdProfile
+}
+
+func (r *CatalogReplier) CmdProfileDesc() string {
+	return r.t("cmd_profile_desc")
+}
+
+func (r *CatalogReplier) CmdTeamName() string {
+	return cmdTeam
+}
+
+func (r *CatalogReplier) CmdTeamDesc() string {
+	return r.t("cmd_team_desc")
+}
+
+func (r *CatalogReplier) CmdBattleName() string {
+	return cmdBattle
+}
+
+func (r *CatalogReplier) CmdBattleDesc() string {
+	return r.t("cmd_battle_desc")
+}
+
+func (r *CatalogReplier) InternalError() string {
+	return r.f("internal_error", r.GetSupportAccountName())
+}
+
+func (r *CatalogReplier) NotParticipant() string {
+	return r.t("not_participant")
+}
+
+func (r *CatalogReplier) CallbackOutdated() string {
+	return r.t("callback_outdated")
+}
+
+func (r *CatalogReplier) NoRoundRunning() string {
+	return r.t("no_round_running")
+}
+
+func (r *CatalogReplier) SolveStageEnd() string {
+	return r.t("solve_stage_end")
+}
+
+func (r *CatalogReplier) SolveStageEndNoSolutions() string {
+	return r.t("solve_stage_end_no_solutions")
+}
+
+func (r *CatalogReplier) ReviewStageEnd() string {
+	return r.f("review_stage_end", r.CmdGetReviewsName())
+}
+
+func (r *CatalogReplier) ReminderSolveStage(timeLeft time.Duration, notSolvedCaptions []string) string {
+	return r.f("reminder_solve_stage", r.duration(timeLeft), strings.Join(notSolvedCaptions, ", "),
+		r.CmdSubmitSolutionName(), r.CmdRemindersName())
+}
+
+func (r *CatalogReplier) ReminderReviewStage(timeLeft time.Duration, notReviewedCaptions []string) string {
+	captions := ""
+	for _, caption := range notReviewedCaptions {
+		captions += caption + "\n"
+	}
+	return r.f("reminder_review_stage", r.duration(timeLeft), captions, r.CmdSubmitReviewName(), r.CmdRemindersName())
+}
+
+func (r *CatalogReplier) RemindersStatus(isOff bool) string {
+	if isOff {
+		return r.t("reminders_status_off")
+	}
+	return r.t("reminders_status_on")
+}
+
+func (r *CatalogReplier) RemindersTurnOn() string {
+	return r.t("reminders_turn_on")
+}
+
+func (r *CatalogReplier) RemindersTurnOff() string {
+	return r.t("reminders_turn_off")
+}
+
+func (r *CatalogReplier) RemindersChanged(isOff bool) string {
+	if isOff {
+		return r.t("reminders_changed_off")
+	}
+	return r.t("reminders_changed_on")
+}
+
+func (r *CatalogReplier) LanguageAsk() string {
+	return r.t("language_ask")
+}
+
+func (r *CatalogReplier) LanguageWrong() string {
+	return r.t("language_wrong")
+}
+
+func (r *CatalogReplier) LanguageChanged() string {
+	return r.t("language_changed")
+}
+
+func (r *CatalogReplier) TimeZoneAsk(now time.Time) string {
+	return r.f("time_zone_ask", mathbattle.TimeZoneLabel(now), now.Format("15:04"))
+}
+
+func (r *CatalogReplier) TimeZoneEventDefault() string {
+	return r.t("time_zone_event_default")
+}
+
+func (r *CatalogReplier) TimeZoneWrong() string {
+	return r.t("time_zone_wrong")
+}
+
+func (r *CatalogReplier) TimeZoneChanged(now time.Time) string {
+	return r.f("time_zone_changed", mathbattle.TimeZoneLabel(now), now.Format("15:04"))
+}
+
+// leagueName - название лиги для сообщений, основная лига названия не имеет
+func (r *CatalogReplier) leagueName(league string) string {
+	if league == "" {
+		return r.t("league_main")
+	}
+	return league
+}
+
+func (r *CatalogReplier) LeagueAsk(current string) string {
+	return r.f("league_ask", r.leagueName(current))
+}
+
+func (r *CatalogReplier) LeagueMain() string {
+	return r.t("league_main")
+}
+
+func (r *CatalogReplier) LeagueWrong() string {
+	return r.t("league_wrong")
+}
+
+func (r *CatalogReplier) LeagueChanged(league string) string {
+	return r.f("league_changed", r.leagueName(league))
+}
+
+// userName - как показывать пользователя организаторам
+func (r *CatalogReplier) userName(user mathbattle.User) string {
+	if user.TelegramUsername != "" {
+		return "@" + user.TelegramUsername
+	}
+	return strings.TrimSpace(user.TelegramFirstName + " " + user.TelegramLastName + " (" + strconv.FormatInt(user.TelegramID, 10) + ")")
+}
+
+func (r *CatalogReplier) RoleAskUser(staff []mathbattle.User) string {
+	result := r.t("role_staff_header")
+	for _, user := range staff {
+		result += r.f("role_staff_item", r.userName(user), r.RoleName(user.Role))
+	}
+	return result + r.t("role_ask_user")
+}
+
+func (r *CatalogReplier) RoleName(role mathbattle.Role) string {
+	if role == mathbattle.RoleNone {
+		return r.t("role_none")
+	}
+	return r.t("role_" + string(role))
+}
+
+func (r *CatalogReplier) RoleAskRole() string {
+	return r.t("role_ask_role")
+}
+
+func (r *CatalogReplier) RoleWrongRole() string {
+	return r.t("role_wrong_role")
+}
+
+func (r *CatalogReplier) RoleFailed() string {
+	return r.t("role_failed")
+}
+
+func (r *CatalogReplier) RoleChanged(user mathbattle.User) string {
+	return r.f("role_changed", r.userName(user), r.RoleName(user.Role))
+}
+
+func (r *CatalogReplier) AlreadyRegistered() string {
+	return r.t("already_registered")
+}
+
+func (r *CatalogReplier) RegisterNameExpect() string {
+	return r.t("register_name_expect")
+}
+
+func (r *CatalogReplier) RegisterNameWrong() string {
+	return r.t("register_name_wrong")
+}
+
+func (r *CatalogReplier) RegisterGradeExpect() string {
+	return r.t("register_grade_expect")
+}
+
+func (r *CatalogReplier) RegisterGradeWrong() string {
+	return r.t("register_grade_wrong")
+}
+
+func (r *CatalogReplier) RegisterSuccess() string {
+	return r.t("register_success")
+}
+
+func (r *CatalogReplier) RegisterSuccessRoundRunning(solveStageDuration time.Duration, solveStageEnd time.Time) string {
+	return r.f("register_success_round_running", r.duration(solveStageDuration), r.date(solveStageEnd))
+}
+
+func (r *CatalogReplier) RegisterSkip() string {
+	return r.t("register_skip")
+}
+
+func (r *CatalogReplier) RegisterSchoolExpect() string {
+	return r.t("register_school_expect")
+}
+
+func (r *CatalogReplier) RegisterSchoolChoose() string {
+	return r.t("register_school_choose")
+}
+
+func (r *CatalogReplier) RegisterSchoolWrong() string {
+	return r.t("register_school_wrong")
+}
+
+func (r *CatalogReplier) RegisterRegionExpect() string {
+	return r.t("register_region_expect")
+}
+
+func (r *CatalogReplier) RegisterRegionWrong() string {
+	return r.t("register_region_wrong")
+}
+
+func (r *CatalogReplier) RegisterTeacherContactExpect() string {
+	return r.t("register_teacher_contact_expect")
+}
+
+func (r *CatalogReplier) RegisterTeacherContactWrong() string {
+	return r.t("register_teacher_contact_wrong")
+}
+
+func (r *CatalogReplier) RegisterConsentExpect() string {
+	return r.t("register_consent_expect")
+}
+
+func (r *CatalogReplier) RegisterConsentRequired() string {
+	return r.t("register_consent_required")
+}
+
+// Profile - анкета участника. Поля, которые не спрашиваются и не заполнены, не показываются
+func (r *CatalogReplier) Profile(participant mathbattle.Participant, form mathbattle.RegistrationForm) string {
+	line := func(caption, value string) string {
+		if value == "" {
+			value = r.t("profile_not_specified")
+		}
+		return fmt.Sprintf("%s: %s\n", caption, value)
+	}
+
+	msg := r.t("profile_header") + "\n"
+	msg += line(r.ProfileFieldName(), participant.Name)
+	msg += line(r.ProfileFieldGrade(), strconv.Itoa(participant.Grade))
+	if form.School.IsAsked() || participant.School != "" {
+		msg += line(r.ProfileFieldSchool(), participant.School)
+	}
+	if form.Region.IsAsked() || participant.Region != "" {
+		msg += line(r.ProfileFieldRegion(), participant.Region)
+	}
+	if form.TeacherContact.IsAsked() || participant.TeacherContact != "" {
+		msg += line(r.ProfileFieldTeacherContact(), participant.TeacherContact)
+	}
+	if form.IsConsentRequired(participant.Grade) || participant.ParentalConsent {
+		consent := r.No()
+		if participant.ParentalConsent {
+			consent = r.Yes()
+		}
+		msg += line(r.ProfileFieldParentalConsent(), consent)
+	}
+	return strings.TrimSuffix(msg, "\n")
+}
+
+func (r *CatalogReplier) ProfileFieldName() string {
+	return r.t("profile_field_name")
+}
+
+func (r *CatalogReplier) ProfileFieldGrade() string {
+	return r.t("profile_field_grade")
+}
+
+func (r *CatalogReplier) ProfileGradeExpect() string {
+	return r.t("profile_grade_expect")
+}
+
+func (r *CatalogReplier) ProfileFieldSchool() string {
+	return r.t("profile_field_school")
+}
+
+func (r *CatalogReplier) ProfileFieldRegion() string {
+	return r.t("profile_field_region")
+}
+
+func (r *CatalogReplier) ProfileFieldTeacherContact() string {
+	return r.t("profile_field_teacher_contact")
+}
+
+func (r *CatalogReplier) ProfileFieldParentalConsent() string {
+	return r.t("profile_field_parental_consent")
+}
+
+func (r *CatalogReplier) ProfileChooseField() string {
+	return r.t("profile_choose_field")
+}
+
+func (r *CatalogReplier) ProfileWrongField() string {
+	return r.t("profile_wrong_field")
+}
+
+func (r *CatalogReplier) ProfileUpdated() string {
+	return r.t("profile_updated")
+}
+
+func (r *CatalogReplier) ProfileNotChanged() string {
+	return r.t("profile_not_changed")
+}
+
+// Team - состав команды. Код приглашения видит только капитан
+func (r *CatalogReplier) Team(team mathbattle.Team, memberNames []string, isCaptain bool) string {
+	msg := r.f("team_header", team.Name)
+	for i, name := range memberNames {
+		msg += fmt.Sprintf("%d) %s\n", i+1, name)
+	}
+	if isCaptain {
+		msg += r.f("team_invite_code", team.InviteCode)
+	}
+	return strings.TrimSuffix(msg, "\n")
+}
+
+func (r *CatalogReplier) TeamNone() string {
+	return r.t("team_none")
+}
+
+func (r *CatalogReplier) TeamCreate() string {
+	return r.t("team_create")
+}
+
+func (r *CatalogReplier) TeamJoin() string {
+	return r.t("team_join")
+}
+
+func (r *CatalogReplier) TeamLeave() string {
+	return r.t("team_leave")
+}
+
+func (r *CatalogReplier) TeamExpectName() string {
+	return r.t("team_expect_name")
+}
+
+func (r *CatalogReplier) TeamWrongName() string {
+	return r.t("team_wrong_name")
+}
+
+func (r *CatalogReplier) TeamExpectInviteCode() string {
+	return r.t("team_expect_invite_code")
+}
+
+func (r *CatalogReplier) TeamWrongInviteCode() string {
+	return r.t("team_wrong_invite_code")
+}
+
+func (r *CatalogReplier) TeamCreated(team mathbattle.Team) string {
+	return r.f("team_created", team.Name, team.InviteCode)
+}
+
+func (r *CatalogReplier) TeamJoined(team mathbattle.Team) string {
+	return r.f("team_joined", team.Name)
+}
+
+func (r *CatalogReplier) TeamLeft() string {
+	return r.t("team_left")
+}
+
+func (r *CatalogReplier) TeamLocked() string {
+	return r.t("team_locked")
+}
+
+func (r *CatalogReplier) TeamNotChanged() string {
+	return r.t("team_not_changed")
+}
+
+func (r *CatalogReplier) TeamRoundNoTeam() string {
+	return r.f("team_round_no_team", cmdTeam)
+}
+
+func (r *CatalogReplier) BattlePreparationEnd() string {
+	return r.f("battle_preparation_end", cmdBattle)
+}
+
+func (r *CatalogReplier) BattleStarted(battle mathbattle.Battle, teamID string) string {
+	return r.f("battle_started", battle.TeamName(battle.Rival(teamID)), strings.Join(mathbattle.ProblemsCaptions(battle.Problems), ", ")) +
+		"\n" + r.BattleStatus(battle, teamID)
+}
+
+// BattleStatus - что сейчас происходит в бою, глазами команды teamID
+func (r *CatalogReplier) BattleStatus(battle mathbattle.Battle, teamID string) string {
+	rivalID := battle.Rival(teamID)
+	rivalName := battle.TeamName(rivalID)
+
+	msg := ""
+	switch battle.State {
+	case mathbattle.BattleChallenge:
+		if battle.Turn != teamID {
+			msg = r.f("battle_rival_challenges", rivalName)
+			break
+		}
+		if battle.PassedTeamID == rivalID {
+			msg = r.f("battle_rival_passed", rivalName)
+		}
+		msg += r.f("battle_your_challenge", rivalName, cmdBattle,
+			strings.Join(mathbattle.ProblemsCaptions(battle.OpenProblems()), ", "))
+	case mathbattle.BattleResponse:
+		caption := battle.Caption(battle.Current().ProblemID)
+		if battle.Turn == teamID {
+			msg = r.f("battle_you_are_challenged", rivalName, caption, cmdBattle)
+		} else {
+			msg = r.f("battle_rival_responds", rivalName, caption)
+		}
+	case mathbattle.BattleReport:
+		fight := battle.Current()
+		key := "battle_report"
+		if fight.Refused {
+			key = "battle_report_refused"
+		}
+		msg = r.f(key, battle.Caption(fight.ProblemID), battle.TeamName(fight.ReporterTeamID),
+			battle.TeamName(fight.OpponentTeamID))
+	case mathbattle.BattleFinished:
+		msg = r.t("battle_finished")
+	}
+
+	msg += "\n" + r.f("battle_score", battle.TeamName(teamID), battle.Score(teamID), battle.Score(rivalID), rivalName,
+		battle.JuryScore())
+	return msg
+}
+
+func (r *CatalogReplier) BattleJudged(battle mathbattle.Battle, fight mathbattle.BattleFight) string {
+	return r.f("battle_judged", battle.Caption(fight.ProblemID), battle.TeamName(fight.ReporterTeamID), fight.ReporterPoints,
+		battle.TeamName(fight.OpponentTeamID), fight.OpponentPoints, fight.JuryPoints)
+}
+
+func (r *CatalogReplier) BattleNone() string {
+	return r.t("battle_none")
+}
+
+func (r *CatalogReplier) BattlePass() string {
+	return r.t("battle_pass")
+}
+
+func (r *CatalogReplier) BattleAccept() string {
+	return r.t("battle_accept")
+}
+
+func (r *CatalogReplier) BattleRefuse() string {
+	return r.t("battle_refuse")
+}
+
+func (r *CatalogReplier) BattleMoveDone() string {
+	return r.t("battle_move_done")
+}
+
+func (r *CatalogReplier) BattleMoveWrong() string {
+	return r.t("battle_move_wrong")
+}
+
+func (r *CatalogReplier) NotSubscribed() string {
+	return r.t("not_subscribed")
+}
+
+func (r *CatalogReplier) UnsubscribeSuccess() string {
+	return r.t("unsubscribe_success")
+}
+
+func (r *CatalogReplier) ProblemsPostBefore(stageDuration time.Duration, stageEnd time.Time) string {
+	return r.f("problems_post_before", r.duration(stageDuration), r.date(stageEnd))
+}
+
+func (r *CatalogReplier) ProblemsPostAfter() string {
+	return r.f("problems_post_after", r.CmdSubmitSolutionName())
+}
+
+func (r *CatalogReplier) SolutionPartUploaded(partNumber int) string {
+	return r.f("solution_part_uploaded", partNumber)
+}
+
+func (r *CatalogReplier) SolutionUploadSuccess(totalUpload int) string {
+	return r.f("solution_upload_success", totalUpload, r.plural("sheets", totalUpload))
+}
+
+func (r *CatalogReplier) SolutionExpectPart() string {
+	return r.f("solution_expect_part", r.SolutionFinishUploading())
+}
+
+func (r *CatalogReplier) SolutionIsRewriteOld() string {
+	return r.t("solution_is_rewrite_old")
+}
+
+func (r *CatalogReplier) SolutionDeclineRewriteOld() string {
+	return r.t("cancel")
+}
+
+func (r *CatalogReplier) SolutionWrongProblemCaptionFormat() string {
+	return r.t("solution_wrong_problem_caption")
+}
+
+func (r *CatalogReplier) SolutionWrongProblemCaption() string {
+	return r.t("solution_wrong_problem_caption")
+}
+
+func (r *CatalogReplier) SolutionExpectProblemCaption() string {
+	return r.t("solution_expect_problem_caption")
+}
+
+func (r *CatalogReplier) SolutionFinishUploading() string {
+	return r.t("solution_finish_uploading")
+}
+
+func (r *CatalogReplier) SolutionWrongFormat() string {
+	return r.t("solution_wrong_format")
+}
+
+func (r *CatalogReplier) SolutionEmpty() string {
+	return r.t("solution_empty")
+}
+
+func (r *CatalogReplier) SolutionPartTooLarge(maxSize int64) string {
+	return r.f("solution_part_too_large", float64(maxSize)/(1024*1024))
+}
+
+func (r *CatalogReplier) SolutionPartUnsupported() string {
+	return r.t("solution_part_unsupported")
+}
+
+func (r *CatalogReplier) StartReviewGetDuration(timeZone *time.Location) string {
+	return r.f("start_review_get_duration", mathbattle.TimeZoneLabel(time.Now().In(timeZone)))
+}
+
+func (r *CatalogReplier) StartReviewWrongDuration() string {
+	return r.t("wrong_round_end")
+}
+
+func (r *CatalogReplier) StartReviewConfirmDuration(untilDate time.Time) string {
+	return r.f("start_review_confirm_duration", r.date(untilDate), r.duration(time.Until(untilDate)))
+}
+
+func (r *CatalogReplier) StartReviewSuccess(failedParticipants []mathbattle.ParticipantError) string {
+	return r.t("start_review_success") + r.failedParticipants(failedParticipants)
+}
+
+func (r *CatalogReplier) StartReviewDistributionChanged() string {
+	return r.t("start_review_distribution_changed")
+}
+
+func (r *CatalogReplier) ReassignAskAction(isPreview bool) string {
+	if isPreview {
+		return r.t("reassign_preview") + r.t("reassign_ask_action")
+	}
+	return r.t("reassign_ask_action")
+}
+
+func (r *CatalogReplier) ReassignActionMove() string {
+	return r.t("reassign_action_move")
+}
+
+func (r *CatalogReplier) ReassignActionAddReviewer() string {
+	return r.t("reassign_action_add_reviewer")
+}
+
+func (r *CatalogReplier) ReassignActionToOrganizers() string {
+	return r.t("reassign_action_to_organizers")
+}
+
+func (r *CatalogReplier) ReassignAbort() string {
+	return r.t("reassign_abort")
+}
+
+func (r *CatalogReplier) ReassignUseButtons() string {
+	return r.t("reassign_use_buttons")
+}
+
+func (r *CatalogReplier) ReassignNothing() string {
+	return r.t("reassign_nothing")
+}
+
+func (r *CatalogReplier) ReassignChooseProblem() string {
+	return r.t("reassign_choose_problem")
+}
+
+func (r *CatalogReplier) ReassignProblemButton(problemID string) string {
+	return r.f("reassign_problem_button", problemID)
+}
+
+func (r *CatalogReplier) ReassignChooseSolution() string {
+	return r.t("reassign_choose_solution")
+}
+
+func (r *CatalogReplier) ReassignChooseFrom() string {
+	return r.t("reassign_choose_from")
+}
+
+func (r *CatalogReplier) ReassignChooseTo() string {
+	return r.t("reassign_choose_to")
+}
+
+func (r *CatalogReplier) ReassignNoChoice() string {
+	return r.t("reassign_no_choice")
+}
+
+func (r *CatalogReplier) ReassignConfirm(action string, solution string, from string, to string) string {
+	switch action {
+	case mathbattle.ReassignMove:
+		return r.f("reassign_confirm_move", solution, from, to)
+	case mathbattle.ReassignAddReviewer:
+		return r.f("reassign_confirm_add_reviewer", solution, to)
+	default:
+		return r.f("reassign_confirm_to_organizers", solution)
+	}
+}
+
+func (r *CatalogReplier) ReassignWrong() string {
+	return r.t("reassign_wrong")
+}
+
+func (r *CatalogReplier) ReassignReviewSubmitted() string {
+	return r.t("reassign_review_submitted")
+}
+
+func (r *CatalogReplier) ReassignSuccess(result mathbattle.ReassignResult) string {
+	if result.IsPreview {
+		return r.t("reassign_success_preview")
+	}
+	return r.t("reassign_success") + r.failedParticipants(result.FailedParticipants)
+}
+
+func (r *CatalogReplier) StartRoundGetDuration(timeZone *time.Location) string {
+	return r.f("start_round_get_duration", mathbattle.TimeZoneLabel(time.Now().In(timeZone)))
+}
+
+func (r *CatalogReplier) StartRoundWrongDuration() string {
+	return r.t("wrong_round_end")
+}
+
+func (r *CatalogReplier) StartRoundConfirmDuration(untilDate time.Time) string {
+	return r.f("start_round_confirm_duration", r.date(untilDate), r.duration(time.Until(untilDate)))
+}
+
+func (r *CatalogReplier) StartRoundSuccess(startResult mathbattle.SSStartResult) string {
+	msg := r.f("start_round_success", startResult.TotalSuccessParticipants, startResult.TotalParticipants)
+	if len(startResult.FailedParticipants) != 0 {
+		msg += r.t("start_round_failed_participants")
+		for i, failed := range startResult.FailedParticipants {
+			msg += fmt.Sprintf("%d) ID: %s, Error: '%v'\n", i+1, failed.Participant.ID, failed.Error)
+		}
+	}
+	return msg
+}
+
+func (r *CatalogReplier) StartRoundProblemBankEmpty() string {
+	return r.t("start_round_problem_bank_empty")
+}
+
+func (r *CatalogReplier) StartRoundProblemCaption(problem mathbattle.Problem) string {
+	return r.f("start_round_problem_caption", problem.ID, problem.MinGrade, problem.MaxGrade)
+}
+
+func (r *CatalogReplier) StartRoundProblemButton(problem mathbattle.Problem, isPicked bool) string {
+	result := r.f("start_round_problem_button", problem.ID, problem.MinGrade, problem.MaxGrade)
+	if isPicked {
+		result = "✅ " + result
+	}
+	return result
+}
+
+func (r *CatalogReplier) StartRoundPickProblems() string {
+	return r.t("start_round_pick_problems")
+}
+
+func (r *CatalogReplier) StartRoundPickedProblems(picked []mathbattle.Problem) string {
+	if len(picked) == 0 {
+		return r.t("start_round_picked_none")
+	}
+
+	msg := r.t("start_round_picked_header")
+	for i, problem := range picked {
+		msg += r.f("start_round_picked_item", mstd.IndexToLetter(i), problem.ID, problem.MinGrade, problem.MaxGrade)
+	}
+	return msg
+}
+
+func (r *CatalogReplier) StartRoundPickWrong() string {
+	return r.t("start_round_pick_wrong")
+}
+
+func (r *CatalogReplier) StartRoundPickNothing() string {
+	return r.t("start_round_pick_nothing")
+}
+
+func (r *CatalogReplier) StartRoundPickDone() string {
+	return r.t("start_round_pick_done")
+}
+
+func (r *CatalogReplier) StartRoundReorder() string {
+	return r.t("start_round_reorder")
+}
+
+func (r *CatalogReplier) StartRoundReorderExpect(picked []mathbattle.Problem) string {
+	captions := []string{}
+	for i := len(picked) - 1; i >= 0; i-- {
+		captions = append(captions, mstd.IndexToLetter(i))
+	}
+	return r.StartRoundPickedProblems(picked) + r.f("start_round_reorder_expect", strings.Join(captions, ", "))
+}
+
+func (r *CatalogReplier) StartRoundReorderWrong() string {
+	return r.t("start_round_reorder_wrong")
+}
+
+func (r *CatalogReplier) StartRoundAbort() string {
+	return r.t("start_round_abort")
+}
+
+func (r *CatalogReplier) StartRoundPreview() string {
+	return r.t("start_round_preview")
+}
+
+func (r *CatalogReplier) StartRoundConfirmStart(picked []mathbattle.Problem) string {
+	msg := r.StartRoundPickedProblems(picked)
+	withoutProblems := mathbattle.GradesWithoutProblems(picked)
+	if len(withoutProblems) != 0 {
+		grades := []string{}
+		for _, grade := range withoutProblems {
+			grades = append(grades, strconv.Itoa(grade))
+		}
+		msg += r.f("start_round_grades_without_problems", strings.Join(grades, ", "))
+	}
+	msg += r.t("start_round_confirm_start")
+	return msg
+}
+
+func (r *CatalogReplier) StartRoundModeIndividual() string {
+	return r.t("start_round_mode_individual")
+}
+
+func (r *CatalogReplier) StartRoundModeTeam() string {
+	return r.t("start_round_mode_team")
+}
+
+func (r *CatalogReplier) StartRoundModeMatboi() string {
+	return r.t("start_round_mode_matboi")
+}
+
+func (r *CatalogReplier) ReviewPostBefore(stageDuration time.Duration, stageEnd time.Time) string {
+	return r.f("review_post_before", r.duration(stageDuration), r.date(stageEnd))
+}
+
+func (r *CatalogReplier) ReviewPostCaption(problemCaption string, solutionNumber int) string {
+	return r.f("review_post_caption", solutionNumber, problemCaption)
+}
+
+func (r *CatalogReplier) ReviewPostAfter() string {
+	return r.f("review_post_after", r.CmdSubmitReviewName())
+}
+
+func (r *CatalogReplier) ReviewReassignRemoved(problemCaption string, solutionNumber int,
+	actual []mathbattle.SolutionDescriptor) string {
+
+	msg := r.f("review_reassign_removed", solutionNumber, problemCaption)
+	if len(actual) == 0 {
+		msg += r.t("review_reassign_nothing_left")
+		return msg
+	}
+
+	msg += r.t("review_reassign_left")
+	for _, caption := range r.ReviewGetSolutionCaptions(actual) {
+		msg += caption + "\n"
+	}
+	return msg
+}
+
+func (r *CatalogReplier) ReviewReassignAdded() string {
+	return r.f("review_reassign_added", r.CmdSubmitReviewName())
+}
+
+func (r *CatalogReplier) ReviewGetSolutionCaptions(descriptors []mathbattle.SolutionDescriptor) []string {
+	result := []string{}
+
+	for _, descriptor := range descriptors {
+		result = append(result, r.ReviewPostCaption(descriptor.ProblemCaption, descriptor.SolutionNumber))
+	}
+
+	return result
+}
+
+// ReviewGetDescriptor разбирает подпись решения, составленную ReviewPostCaption. Шаблон подписи
+// в каталоге может ставить номер решения и задачу в любом порядке
+func (r *CatalogReplier) ReviewGetDescriptor(userInput string) (mathbattle.SolutionDescriptor, bool) {
+	userInput = strings.Trim(userInput, "\t\r\n ")
+	template := r.t("review_post_caption")
+	numberPos, captionPos := strings.Index(template, "%d"), strings.Index(template, "%s")
+	if numberPos == -1 || captionPos == -1 {
+		return mathbattle.SolutionDescriptor{}, false
+	}
+
+	pattern := regexp.QuoteMeta(template)
+	pattern = strings.Replace(pattern, "%d", `(\d+)`, 1)
+	pattern = strings.Replace(pattern, "%s", `(\S+)`, 1)
+	match := regexp.MustCompile("^" + pattern + "$").FindStringSubmatch(userInput)
+	if match == nil {
+		return mathbattle.SolutionDescriptor{}, false
+	}
+
+	numberStr, caption := match[1], match[2]
+	if captionPos < numberPos {
+		numberStr, caption = match[2], match[1]
+	}
+
+	solutionNumber, err := strconv.Atoi(numberStr)
+	if err != nil {
+		return mathbattle.SolutionDescriptor{}, false
+	}
+
+	return mathbattle.SolutionDescriptor{
+		ProblemCaption: caption,
+		SolutionNumber: solutionNumber,
+	}, true
+}
+
+func (r *CatalogReplier) ReviewExpectSolutionCaption() string {
+	return r.t("review_expect_solution_caption")
+}
+
+func (r *CatalogReplier) ReviewWrongSolutionCaption() string {
+	return r.t("review_wrong_solution_caption")
+}
+
+func (r *CatalogReplier) ReviewIsRewriteOld() string {
+	return r.t("review_is_rewrite_old")
+}
+
+func (r *CatalogReplier) ReviewExpectContent() string {
+	return r.t("review_expect_content")
+}
+
+func (r *CatalogReplier) ReviewUploadSuccess() string {
+	return r.t("review_upload_success")
+}
+
+func (r *CatalogReplier) ReviewMsgForReviewee(review mathbattle.Review) string {
+	return r.f("review_msg_for_reviewee", review.Content)
+}
+
+func (r *CatalogReplier) GetReviewsComment(number int, problemCaption string, content string) string {
+	return r.f("get_reviews_comment", number, problemCaption, content)
+}
+
+func (r *CatalogReplier) FormatStat(stat mathbattle.Stat) string {
+	result := r.f("stat_league", r.leagueName(stat.League))
+	result += r.f("stat_participants", stat.ParticipantsTotal, stat.ParticipantsToday)
+
+	if stat.RoundStage == mathbattle.StageNotStarted || stat.RoundStage == mathbattle.StageFinished {
+		result += r.t("stat_no_round")
+		return result
+	}
+
+	result += r.t("stat_round_header")
+	if stat.RoundStage == mathbattle.StageSolve {
+		result += r.f("stat_solve_stage", r.duration(stat.TimeToSolveLeft))
+	} else if stat.RoundStage == mathbattle.StageReview {
+		result += r.f("stat_review_stage", r.duration(stat.TimeToReviewLeft))
+	}
+	result += r.f("stat_totals", stat.SolutionsTotal, stat.ReviewsTotal)
+
+	return result
+}
+
+func (r *CatalogReplier) ServiceMsgGetText() string {
+	return r.t("service_msg_get_text")
+}
+
+func (r *CatalogReplier) ServiceMsgTextIsEmpty() string {
+	return r.t("service_msg_text_is_empty")
+}
+
+func (r *CatalogReplier) ServiceMsgCancelSend() string {
+	return r.t("service_msg_cancel_send")
+}
+
+func (r *CatalogReplier) ServiceMsgAskRecieversType() string {
+	return r.t("service_msg_ask_recievers_type")
+}
+
+func (r *CatalogReplier) ServiceMsgWrongRecieversType() string {
+	return r.t("service_msg_wrong_recievers_type")
+}
+
+func (r *CatalogReplier) ServiceMsgRecieversTypeAll() string {
+	return r.t("service_msg_recievers_type_all")
+}
+
+func (r *CatalogReplier) ServiceMsgRecieversTypeSome() string {
+	return r.t("service_msg_recievers_type_some")
+}
+
+func (r *CatalogReplier) ServiceMsgInputRecievers() string {
+	return r.t("service_msg_input_recievers")
+}
+
+func (r *CatalogReplier) ServiceMsgFinalAsk(recieversType string, recievers ...string) string {
+	if recieversType == r.ServiceMsgRecieversTypeAll() {
+		return r.f("service_msg_final_ask", r.ServiceMsgRecieversTypeAll())
+	}
+
+	if recieversType == r.ServiceMsgRecieversTypeSome() {
+		list := ""
+		for _, reciever := range recievers {
+			list += reciever + ","
+		}
+		return r.f("service_msg_final_ask", list)
+	}
+
+	return ""
+}
+
+func (r *CatalogReplier) ServiceMsgSendSuccess() string {
+	return r.t("service_msg_send_success")
+}
+
+func (r *CatalogReplier) MyResultsProblemNotSolved(problemCaption string) string {
+	result := r.f("my_results_problem", problemCaption)
+	result += r.f("my_results_mark", 0)
+	result += r.t("my_results_not_solved")
+	return result
+}
+
+func (r *CatalogReplier) MyResultsProblemResults(problemCaption string, juriComment string, mark mathbattle.Mark,
+	otherParticipantsReviews []mathbattle.Review) string {
+
+	result := r.f("my_results_problem", problemCaption)
+	result += r.f("my_results_mark", mark)
+
+	result += r.t("my_results_juri_comment")
+	result += juriComment
+	result += "\n\n"
+
+	result += r.t("my_results_other_reviews")
+	for i, review := range otherParticipantsReviews {
+		result += r.f("my_results_other_review", i+1)
+		result += "\t" + review.Content
+		result += "\n\n"
+	}
+	result += "\n"
+
+	return result
+}
+
+func (r *CatalogReplier) MyResultsReviewResults(problemCaption string, solutionNumber int, isCommented bool,
+	juriComment string, mark mathbattle.Mark) string {
+
+	result := r.f("my_results_review", solutionNumber, problemCaption)
+	if !isCommented {
+		result += r.f("my_results_mark", 0)
+		result += r.t("my_results_not_commented")
+	} else {
+		result += r.f("my_results_mark", mark)
+		result += r.t("my_results_juri_comment")
+		result += juriComment
+	}
+	return result
+}
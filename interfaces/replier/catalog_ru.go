@@ -0,0 +1,376 @@
+package replier
+
+var russianCatalog = Catalog{
+	"language_name":   "Русский",
+	"duration":        "%dд. %dч. %dм.",
+	"datetime_format": "02.01.2006 15:04",
+
+	"yes":    "Да",
+	"no":     "Нет",
+	"cancel": "Отменено",
+
+	"start_message":      "Привет! Этот бот поможет подготовиться к математическим боям.\n",
+	"available_commands": "Сейчас доступны следующие действия:\n\n",
+	"help_rules": `
+	Этот бот поможет вам подготовиться к математическим боям. В процессе каждого раунда вы будете решать задачи, оформлять решения и проверять решения других участников. 
+Чтобы принять участие, необходимо подписаться на рассылку задач. Если не хотите участвовать в раунде, можете отписаться от рассылки задач.
+*Раунд состоит из трёх этапов:* решение задач, проверка решений, подведение итогов. Каждый из этапов длится несколько дней, сроки объявляются в начале раунда. 
+
+*1. Решение задач.* На старте раунда рассылаются задачи и объявляется срок, до которого следует сдать решения. Не обязательно решать все задачи, можете выбрать любые. Решение каждой задачи следует оформлять письменно на отдельном листе, затем отсканировать или качественно сфотографировать и отправить. 
+
+*Требования к решению:*
+•  Решение должно быть полным, все утверждения должны быть обоснованы.
+•  Решение должно быть аккуратно оформлено, разборчивым почерком.
+•  Текст на фотографии должен быть чётким и легко читаться. Фотографируйте с хорошим освещением, перед отправкой отрегулируйте яркость и контрастность.
+•  Фотографии должны быть правильно ориентированы. Если нужно, переверните перед отправкой. 
+
+*Чтобы оправить решение:*
+•  Нажмите %s.
+•  Выберете задачу.
+•  Загрузите изображения, их может быть несколько.
+•  Когда все изображения с решением данной задачи загружены, нажмите кнопку «%s».
+•  После этого можно загрузить решение другой задачи.
+Если хотите поменять своё решение, отправьте новое на ту же задачу, новое решение заменит старое. После окончания этапа решения задач нельзя присылать и заменять решения.
+
+*2. Проверка решений.* В начале этого этапа вы получите решения других участников. Это будут решения только тех задач, на которые вы сами отправили решение. Полученные решения необходимо проверить и написать комментарий. Если в одном решении несколько изображений, они приходят альбомом.
+
+*Требования к комментарию*:
+•  Начните с того, считаете вы решение верным или нет. Решение верное, если оно доведено до конца и все утверждения правильно обоснованы. 
+•  Если считаете решение верным, можно ничего больше не писать или указать небольшие недочёты, если они есть.
+•  Если считаете решение неверным, объясните почему, укажите, где допущены ошибки. Можете предложить вариант исправления, но необязательно.
+•  Не надо сравнивать решение со своим. Важна правильность проверяемого решения, а не его оптимальность или возможность других подходов. 
+•  Запрещается критиковать автора решения и употреблять нецензурную лексику. 
+Пример комментария: "Я считаю решение неверным. В решении используется то, что 0,5n – целое число, но это верно только для чётных n, но по условию n – любое натуральное число. Для нечётных n утверждение не доказано."
+
+*Чтобы отправить комментарий*:
+•  Нажмите %s.
+•  Выберете номер решения, которое хотите прокомментировать.
+•  Напишите и отправьте комментарий.
+Комментарий можно изменить до окончания этапа проверки решений. Пожалуйста, старайтесь проверить все присланные решения. 
+После окончания этапа комментарии отправляются авторам. Вам может прийти несколько комментариев на одно решение. 
+`,
+	"help_results": `
+*3. Подведение итогов.* Присланные решение и комментарии проверяются и оцениваются жюри. Вы получите комментарии к своим решениям и тем решениям, которые вы проверяли. Также публикуются решения задач, вы можете изучить их и проверить себя. 
+Баллы выставляются по следующим правилам:
+• Полностью верное решение оцениватся в 12 баллов.
+• Если в решении есть "дыры", балл снижается в зависимости от серьёзности "дыр".
+• Полностью верный комментарий оценивается в 6 баллов. 
+• Если в решении есть "дыры" и они не указаны в комментарии, балл за комментарий снижается. Если "дыр" много, достаточно указать наиболее существенные. 
+• Если комментатор считает неверным то, что на самом деле верно, балл также снижается.
+• В ОТЛИЧИЕ ОТ ПРАВИЛ МАТБОЁВ: за комментарий к верному решению тоже ставятся баллы. То есть если решение полностью верное, можно получить 6 баллов за комментарий "Всё верно". 
+
+Баллы за решения и комментарии суммируются и формируется рейтинг. 
+
+Если у вас есть какие-либо проблемы или вопросы, пишите @mathbattle\_support.
+
+Спасибо за интерес!`,
+
+	"cmd_help_desc":               "Помощь",
+	"cmd_subscribe_desc":          "Подписаться на рассылку задач",
+	"cmd_unsubscribe_desc":        "Отписаться от рассылки задач",
+	"cmd_get_problems_desc":       "Показать задачи",
+	"cmd_submit_solution_desc":    "Отправить решение на задачу из текущего раунда",
+	"cmd_start_review_stage_desc": "Начать этап ревью",
+	"cmd_submit_review_desc":      "Отправить замечания по решению",
+	"cmd_stat_desc":               "Статистика",
+	"cmd_get_reviews_desc":        "Прочитать комментарии на свои решения",
+	"cmd_start_round_desc":        "Начать новый рануд",
+	"cmd_service_msg_desc":        "Разослать сообщение всем участникам",
+	"cmd_get_my_results_desc":     "Показать комментарии и оценки жюри",
+	"cmd_reassign_review_desc":    "Изменить распределение решений на ревью",
+	"cmd_reminders_desc":          "Включить или выключить напоминания о конце этапа",
+	"cmd_language_desc":           "Выбрать язык",
+	"cmd_time_zone_desc":          "Выбрать часовой пояс для сроков",
+	"cmd_league_desc":             "Выбрать лигу, раундами которой вы управляете",
+	"cmd_role_desc":               "Выдать пользователю роль организатора или забрать её",
+	"cmd_profile_desc":            "Посмотреть и изменить анкету участника",
+	"cmd_team_desc":               "Создать команду, вступить в неё или выйти",
+	"cmd_battle_desc":             "Ход в матбое: вызвать соперника, принять или отклонить вызов",
+
+	"internal_error":    "Произошла внутрення ошибка. Свяжитесь с %s и опишите свою проблему.",
+	"not_participant":   "Вы не являетесь участником. Сначала зарегистрируйтесь.",
+	"callback_outdated": "Эта кнопка больше не действует",
+	"no_round_running":  "Раунд ещё не начался.",
+
+	"solve_stage_end": "Этап решения задач завершён. Через некоторое время начнётся следующий этап - этап проверки решений. " +
+		"Когда этап начнётся, вам будут разосланы решения других участников для проверки.",
+	"solve_stage_end_no_solutions": "Этап решения задач завершён. К сожалению, вы не прислали решения ни для одной из задач.",
+	"review_stage_end": "Этап проверки решений завершён. " +
+		"Теперь вы можете получить комментарии от других участников на свои решения. " +
+		"Для этого нажмите сюда: %s\n" +
+		"Комментарии от жюри на свои решения и на свои комментарии вы получите чуть позже.",
+
+	"reminder_solve_stage": "До конца приёма решений осталось %s\n" +
+		"Вы ещё не прислали решения на задачи: %s\n" +
+		"Отправить решение: %s\n" +
+		"Отключить напоминания: %s",
+	"reminder_review_stage": "До конца приёма комментариев осталось %s\n" +
+		"Вы ещё не прокомментировали:\n" +
+		"%s" +
+		"Отправить комментарий: %s\n" +
+		"Отключить напоминания: %s",
+	"reminders_status_off":  "Сейчас напоминания о конце этапа выключены. Включить?",
+	"reminders_status_on":   "Сейчас напоминания о конце этапа включены. Выключить?",
+	"reminders_turn_on":     "Включить",
+	"reminders_turn_off":    "Выключить",
+	"reminders_changed_off": "Напоминания выключены",
+	"reminders_changed_on":  "Напоминания включены",
+
+	"language_ask":     "Выберите язык",
+	"language_wrong":   "Выберите язык с помощью кнопок",
+	"language_changed": "Язык изменён",
+
+	"time_zone_ask": "Сейчас сроки показываются в часовом поясе %s, у вас %s.\n" +
+		"Отправьте название часового пояса (например, Asia/Yekaterinburg) или смещение от UTC (например, UTC+5).",
+	"time_zone_event_default": "Как у мероприятия",
+	"time_zone_wrong":         "Неизвестный часовой пояс. Отправьте название, например Asia/Yekaterinburg, или смещение от UTC, например UTC+5.",
+	"time_zone_changed":       "Часовой пояс изменён: %s. Сейчас у вас %s.",
+
+	"league_ask":     "Сейчас вы управляете раундами лиги «%s». Выберите лигу.",
+	"league_main":    "Основная",
+	"league_wrong":   "Такой лиги нет. Выберите лигу с помощью кнопок.",
+	"league_changed": "Теперь вы управляете раундами лиги «%s».",
+
+	"role_staff_header": "Оргкомитет:\n",
+	"role_staff_item":   "%s - %s\n",
+	"role_ask_user":     "\nОтправьте имя пользователя в телеграме (например, @ivanov) или его Telegram ID.",
+	"role_ask_role":     "Выберите роль",
+	"role_wrong_role":   "Выберите роль с помощью кнопок",
+	"role_failed":       "Не удалось: пользователь не найден (он должен хотя бы раз написать боту) или это последний владелец.",
+	"role_changed":      "Роль %s: %s",
+	"role_none":         "Без роли",
+	"role_owner":        "Владелец",
+	"role_organizer":    "Организатор",
+	"role_jury":         "Жюри",
+	"role_moderator":    "Модератор",
+
+	"already_registered":    "Вы уже подписаны на рассылку задач.",
+	"register_name_expect":  "Введите своё имя. Имя должно состоять только из букв.",
+	"register_name_wrong":   "Имя должно состоять только из букв.",
+	"register_grade_expect": "Отлично! Теперь укажите класс, в котором учитесь (используйте только цифры).",
+	"register_grade_wrong":  "Введён неправильный класс. Ожидается число от 1 до 11.",
+	"register_success":      "Вы успешно зарегистрированы. Ожидайте начала раунда и рассылки задач.",
+	"register_success_round_running": "Вы успешно зарегистрированы.\n" +
+		"В данный момент уже идёт раунд. Присоединяйтесь к решению задач!\n" +
+		"\n" +
+		"Этап решения задач продлится %s " +
+		"После %s решения приниматься не будут.",
+
+	"register_skip":                   "Пропустить",
+	"register_school_expect":          "Укажите вашу школу, например «Лицей №1» или «Школа 57».",
+	"register_school_choose":          "Выберите школу из списка. Если вашей школы в нём нет, отправьте название ещё раз.",
+	"register_school_wrong":           "Название школы должно быть не длиннее 256 символов.",
+	"register_region_expect":          "Укажите ваш город или регион.",
+	"register_region_wrong":           "Название города или региона может содержать только буквы, пробелы, дефисы, точки и запятые и должно быть не длиннее 100 символов.",
+	"register_teacher_contact_expect": "Укажите имя и контакт (телефон, e-mail или ник в телеграме) вашего учителя математики.",
+	"register_teacher_contact_wrong":  "Контакт учителя должен быть не длиннее 200 символов.",
+	"register_consent_expect": "Для участия несовершеннолетних нужно согласие родителей или законных представителей " +
+		"на участие в матбое и обработку персональных данных. Родители согласны?",
+	"register_consent_required": "Без согласия родителей участвовать в матбое нельзя.",
+
+	"profile_header":                 "Ваша анкета:",
+	"profile_not_specified":          "не указано",
+	"profile_field_name":             "Имя",
+	"profile_field_grade":            "Класс",
+	"profile_field_school":           "Школа",
+	"profile_field_region":           "Город или регион",
+	"profile_field_teacher_contact":  "Учитель",
+	"profile_field_parental_consent": "Согласие родителей",
+	"profile_choose_field":           "Что вы хотите изменить?",
+	"profile_grade_expect":           "Укажите класс, в котором учитесь (используйте только цифры).",
+	"profile_wrong_field":            "Выберите поле кнопкой под сообщением.",
+	"profile_updated":                "Анкета обновлена.",
+	"profile_not_changed":            "Анкета не изменена.",
+
+	"team_header":             "Команда «%s»:\n",
+	"team_invite_code":        "Код приглашения: `%s`. Отправьте его участникам, которых зовёте в команду.",
+	"team_none":               "Вы не состоите в команде. Создайте свою команду или вступите в команду по коду приглашения от капитана.",
+	"team_create":             "Создать команду",
+	"team_join":               "Вступить по коду",
+	"team_leave":              "Выйти из команды",
+	"team_expect_name":        "Введите название команды.",
+	"team_wrong_name":         "Название команды должно быть не длиннее 50 символов.",
+	"team_expect_invite_code": "Введите код приглашения, который вам дал капитан команды.",
+	"team_wrong_invite_code":  "Команды с таким кодом нет. Проверьте код и отправьте его ещё раз.",
+	"team_created":            "Команда «%s» создана, вы её капитан.\nКод приглашения: `%s`. Отправьте его участникам, которых зовёте в команду.",
+	"team_joined":             "Вы вступили в команду «%s».",
+	"team_left":               "Вы вышли из команды.",
+	"team_locked":             "Сейчас идёт командный раунд. Состав команд можно будет изменить после его окончания.",
+	"team_not_changed":        "Команда не изменена.",
+	"team_round_no_team":      "Сейчас идёт командный раунд, а вы не состоите в команде. Команду можно создать после раунда: %s",
+
+	"battle_preparation_end":    "Подготовка к матбою закончилась. Скоро жюри объявит бои, капитаны будут ходить командой %s.",
+	"battle_started":            "Начинается матбой с командой «%s»! Задачи: %s.",
+	"battle_rival_challenges":   "Команда «%s» выбирает задачу, на которую вызовет вашу команду.",
+	"battle_rival_passed":       "Команда «%s» отказалась вызывать.\n",
+	"battle_your_challenge":     "Ваша очередь вызывать команду «%s». Капитан выбирает задачу командой %s или отказывается от вызова. Свободные задачи: %s.",
+	"battle_you_are_challenged": "Команда «%s» вызывает вас на задачу %s. Капитан принимает или отклоняет вызов командой %s.",
+	"battle_rival_responds":     "Вы вызвали команду «%s» на задачу %s. Ждём ответа.",
+	"battle_report":             "Задача %s: докладывает «%s», оппонирует «%s». Ждём баллы жюри.",
+	"battle_report_refused":     "Вызов на задачу %s отклонён: «%s» доказывает корректность вызова, оппонирует «%s». Ждём баллы жюри.",
+	"battle_finished":           "Матбой окончен!",
+	"battle_score":              "Счёт: «%s» %d : %d «%s», у жюри %d.",
+	"battle_judged":             "Задача %s: докладчик «%s» получает %d, оппонент «%s» - %d, жюри - %d.",
+	"battle_none":               "Сейчас ваша команда не играет в матбое.",
+	"battle_pass":               "Отказаться от вызова",
+	"battle_accept":             "Принять вызов",
+	"battle_refuse":             "Отклонить вызов",
+	"battle_move_done":          "Ход принят.",
+	"battle_move_wrong":         "Сейчас так сходить нельзя.",
+
+	"not_subscribed":      "Вы не подписаны на рассылку задач.",
+	"unsubscribe_success": "Вы успешно отписаны от рассылки задач.",
+
+	"problems_post_before": "Привет! Начался новый раунд. Первый этап - этап решения задач. " +
+		"\n\n" +
+		"Этап продлится %s " +
+		"После %s решения приниматься не будут." +
+		"\n\n" +
+		"Требования к решению:\n" +
+		"•  Решение должно быть полным, все утверждения должны быть обоснованы.\n" +
+		"•  Решение должно быть аккуратно оформлено, разборчивым почерком.\n" +
+		"•  Текст на фотографии должен быть чётким и легко читаться. Фотографируйте с хорошим освещением, перед отправкой отрегулируйте яркость и контрастность.\n" +
+		"•  Фотографии должны быть правильно ориентированы. Если нужно, переверните перед отправкой.\n",
+	"problems_post_after": "Как будете готовы - присылайте решение. Для этого нажмите сюда: \n" +
+		"%s\n" +
+		"Решение следует оформить на бумаге и прислать качественное фото или скан. ",
+
+	"solution_part_uploaded":  "Завершена загрузка листа №%d",
+	"solution_upload_success": "Загрузка решения завершена. Всего в решении %d %s",
+	"sheets":                  "лист|листа|листов",
+	"solution_expect_part": "Отлично, теперь присылайте решение. Решение следует оформить на бумаге и прислать качественное фото или скан." +
+		"Можно загрузить сколько угодно фотографий или pdf файлов. " +
+		"После того как отошлёте всё - нажмите кнопку '%s'",
+	"solution_is_rewrite_old":         "Для этой задачи вы уже отправляли решение. Новое решение перезапишет старое.\n\nПродолжить?",
+	"solution_wrong_problem_caption":  "Указана несуществующая задача.",
+	"solution_expect_problem_caption": "Укажите задачу, для которой хотите отправить решение.",
+	"solution_finish_uploading":       "Завершить отправку решения",
+	"solution_wrong_format":           "Неверный формат решения. В решении ожидаются только фотографии и pdf файлы.",
+	"solution_empty":                  "Вы не отправили ни одной фотографии своего решения :(",
+	"solution_part_too_large":         "Файл слишком большой. Максимальный размер - %.1f МБ. Этот лист не загружен.",
+	"solution_part_unsupported":       "Файлы такого типа не принимаются. Пришлите фотографию или pdf. Этот лист не загружен.",
+
+	"wrong_round_end": "Дата окончания раунда введена неверно",
+	"start_review_get_duration": "Введите дату окончания раунда (часовой пояс %s) в одном из следующих форматов:\n" +
+		"DD.MM.YYYY HH:MM (Ревью нельзя будет отослать после указанной даты)\n" +
+		"DD.MM.YYYY (Последний день приёма ревью. Приём ревью окончится в полночь)\n",
+	"start_review_confirm_duration": "После %s ревью приниматься не будут\n" +
+		"Общая продолжительность фазы отсылки ревью: %s\n" +
+		"Верно?\n",
+	"start_review_success":     "Решения разосланы, этап успешно начался.\n",
+	"participants_with_errors": "Участников с ошбиками: %d\n",
+	"start_review_distribution_changed": "Пока вы подтверждали, решения раунда изменились, и распределение стало другим. " +
+		"Этап не начат, запустите команду заново, чтобы посмотреть новое распределение.",
+
+	"reassign_ask_action":            "Что нужно сделать?",
+	"reassign_action_move":           "Передать решение другому участнику",
+	"reassign_action_add_reviewer":   "Добавить проверяющего",
+	"reassign_action_to_organizers":  "Отдать решение организаторам",
+	"reassign_abort":                 "Отменить",
+	"reassign_preview":               "Этап ревью ещё не начат. Изменения применятся к распределению, с которым он начнётся.\n",
+	"reassign_use_buttons":           "Выберите с помощью кнопок выше",
+	"reassign_nothing":               "В раунде нет решений",
+	"reassign_choose_problem":        "Выберите задачу",
+	"reassign_problem_button":        "Задача %s",
+	"reassign_choose_solution":       "Выберите решение",
+	"reassign_choose_from":           "У кого забрать решение? Те, кто уже прислал ревью, в списке не показаны",
+	"reassign_choose_to":             "Кому отдать решение?",
+	"reassign_no_choice":             "Подходящих участников нет, выберите другое решение",
+	"reassign_confirm_move":          "Передать решение «%s» от %s участнику %s?",
+	"reassign_confirm_add_reviewer":  "Отправить решение «%s» на проверку участнику %s?",
+	"reassign_confirm_to_organizers": "Забрать решение «%s» у проверяющих, которые ещё не прислали ревью, и отдать организаторам?",
+	"reassign_wrong":                 "Не получилось. Вероятно, распределение уже изменилось, попробуйте ещё раз",
+	"reassign_review_submitted":      "Проверяющий уже прислал ревью на это решение, забрать его нельзя",
+	"reassign_success":               "Распределение изменено, участники уведомлены.\n",
+	"reassign_success_preview":       "Распределение изменено. Участники получат его, когда начнётся этап ревью",
+
+	"start_round_get_duration": "Введите дату окончания раунда (часовой пояс %s) в одном из следующих форматов:\n" +
+		"DD.MM.YYYY HH:MM (Решения нельзя будет отослать после указанной даты)\n" +
+		"DD.MM.YYYY (Последний день приёма ревью. Приём ревью окончится в полночь)\n",
+	"start_round_confirm_duration": "После %s решения приниматься не будут\n" +
+		"Общая продолжительность фазы решения: %s\n" +
+		"Верно?\n",
+	"start_round_success":                 "Раунд начался\nЗадачи успешно разосланы *%d/%d* участникам",
+	"start_round_failed_participants":     "\nУчастники, для которых раунд не удалось начать:\n",
+	"start_round_problem_bank_empty":      "В банке нет ни одной задачи. Сначала добавьте задачи с помощью mb-admin add-problems",
+	"start_round_problem_caption":         "Задача №%s, подходит для %d–%d классов",
+	"start_round_problem_button":          "№%s (%d–%d кл.)",
+	"start_round_pick_problems":           "Выше все задачи из банка. Нажимайте на задачи, чтобы добавить их в раунд. Повторное нажатие убирает задачу из раунда. Задачи получат обозначения A, B, C, ... в том порядке, в котором вы их выбрали.",
+	"start_round_picked_none":             "Пока не выбрано ни одной задачи.",
+	"start_round_picked_header":           "Задачи раунда:\n",
+	"start_round_picked_item":             "%s — задача №%s (%d–%d кл.)\n",
+	"start_round_pick_wrong":              "Такой задачи нет. Выберите задачу с помощью кнопок.",
+	"start_round_pick_nothing":            "Нужно выбрать хотя бы одну задачу.",
+	"start_round_pick_done":               "Готово",
+	"start_round_reorder":                 "Изменить порядок",
+	"start_round_reorder_expect":          "\nПеречислите обозначения задач через запятую в новом порядке, например: %s",
+	"start_round_reorder_wrong":           "Нужно перечислить каждое обозначение задачи ровно один раз.",
+	"start_round_abort":                   "Отменить запуск раунда",
+	"start_round_preview":                 "Так участники увидят начало раунда:",
+	"start_round_grades_without_problems": "\nВнимание: ни одна задача не подходит для классов %s\n",
+	"start_round_confirm_start":           "\nНачать раунд и разослать задачи участникам? Выберите, кто соревнуется: участники, команды или команды в матбое.",
+	"start_round_mode_individual":         "Участники",
+	"start_round_mode_team":               "Команды",
+	"start_round_mode_matboi":             "Матбой",
+
+	"review_post_before": "Начался этап взаимной проверки решений. " +
+		"Во время него необходимо проверить решения других участников и найти в них недочёты, если они есть." +
+		"\n\n" +
+		"Этап продлится %s " +
+		"После %s отправлять комментарии будет нельзя." +
+		"\n\n" +
+		"Требования к комментарию:\n" +
+		"•  Начните с того, считаете вы решение верным или нет. Решение верное, если оно доведено до конца и все утверждения правильно обоснованы. \n" +
+		"•  Если считаете решение верным, можно ничего больше не писать или указать небольшие недочёты, если они есть.\n" +
+		"•  Если считаете решение неверным, объясните почему, укажите, где допущены ошибки. Можете предложить вариант исправления, но необязательно.\n" +
+		"•  Не надо сравнивать решение со своим. Важна правильность проверяемого решения, а не его оптимальность или возможность других подходов. \n" +
+		"•  Запрещается критиковать автора решения и употреблять нецензурную лексику. \n" +
+		"Пример комментария: \"Я считаю решение неверным. В решении используется то, что 0,5n – целое число, но это верно только для чётных n, но по условию n – любое натуральное число. Для нечётных n утверждение не доказано.\"\n" +
+		"\n" +
+		"Ниже решения других участников, которые вам необходимо проверить.",
+	"review_post_caption": "(Решение %d на задачу %s)",
+	"review_post_after": "Как будете готовы - присылайте свои комментарии на решения других участников. Для этого нажмите сюда: \n" +
+		"%s\n" +
+		"Комментарий следует присылать обычным текстом.",
+	"review_reassign_removed":      "Организаторы забрали у вас на проверку решение %d на задачу %s, проверять его больше не нужно.\n",
+	"review_reassign_nothing_left": "Больше решений для проверки у вас нет.",
+	"review_reassign_left":         "Номера оставшихся решений могли поменяться. Сейчас у вас на проверке:\n",
+	"review_reassign_added":        "Организаторы отправили вам на проверку ещё одно решение. Комментарий на него можно прислать, нажав сюда: %s",
+
+	"review_expect_solution_caption": "Укажите решение, для которого хотите отправить комментарий.",
+	"review_wrong_solution_caption":  "Указано несуществующее решение.",
+	"review_is_rewrite_old":          "Для этого решения вы уже отправляли комментарий. Новый комментарий перезапишет старый.\n\nПродолжить?",
+	"review_expect_content": "Отлично, теперь посылайте комментарий текстовым сообщением. " +
+		"В комментарии следует указать, считаете ли вы решение верным. Если нет, объяснить почему.",
+	"review_upload_success":   "Комментарий записан.",
+	"review_msg_for_reviewee": "Вы получили комментарий на своё решение от другого участника:\n\n%s",
+	"get_reviews_comment":     "Комментарий №%d на задачу %s\n%s",
+
+	"stat_league":       "Лига: %s\n",
+	"stat_participants": "Участников всего: %d\nИз них новых сегодня: %d\n",
+	"stat_no_round":     "Нет активного раунда.",
+	"stat_round_header": "\nСтатистика активного раунда:\n",
+	"stat_solve_stage":  "Идёт фаза отсылки решений\nДо её конца осталось: %s\n",
+	"stat_review_stage": "Идёт фаза проверки решений\nДо её конца осталось: %s\n",
+	"stat_totals":       "Всего решений прислано: %d\nВсего комментариев прислано: %d\n",
+
+	"service_msg_get_text":             "Введите сообщение, которое вы хотите разослать всем участникам.",
+	"service_msg_text_is_empty":        "Текст сообщения пуст",
+	"service_msg_cancel_send":          "Отменить отправку",
+	"service_msg_ask_recievers_type":   "Кому вы хотите отправить сообщение?",
+	"service_msg_wrong_recievers_type": "Неясно кому вы хотите отправить сообщение",
+	"service_msg_recievers_type_all":   "Всем",
+	"service_msg_recievers_type_some":  "Определённым пользователям",
+	"service_msg_input_recievers":      "Введите ID пользователей, кому вы хотите отправить сообщение, через запятую",
+	"service_msg_final_ask":            "Отправляем сообщение: %s\n",
+	"service_msg_send_success":         "Сообщение успешно разослано",
+
+	"my_results_problem":       "*Задача*: %s\n",
+	"my_results_mark":          "*Оценка*: %d\n",
+	"my_results_not_solved":    "К сожалению, вы не решили эту задачу :(",
+	"my_results_juri_comment":  "*Комментарий от жюри:*\n",
+	"my_results_other_reviews": "*Комментарии от других участников:*\n",
+	"my_results_other_review":  "Комментарий %d\n",
+	"my_results_review":        "Ваш комментарий на решение %d задачи %s:\n",
+	"my_results_not_commented": "К сожалению, вы никак не прокомментировали это решение :(",
+}
@@ -0,0 +1,111 @@
+package replier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogsHaveAllKeys(t *testing.T) {
+	req := require.New(t)
+
+	for language, catalog := range builtinCatalogs() {
+		req.Empty(MissingKeys(catalog), "catalog %s", language)
+		for key := range catalog {
+			_, exists := ReferenceCatalog()[key]
+			req.True(exists, "catalog %s has unknown key %s", language, key)
+		}
+	}
+}
+
+func TestCatalogsFormatVerbs(t *testing.T) {
+	req := require.New(t)
+
+	for language, catalog := range builtinCatalogs() {
+		for key, reference := range ReferenceCatalog() {
+			expected, actual := FormatVerbs(reference), FormatVerbs(catalog[key])
+			sort.Strings(expected)
+			sort.Strings(actual)
+			req.Equal(expected, actual, "catalog %s, key %s", language, key)
+		}
+	}
+}
+
+func TestReviewDescriptorRoundTrip(t *testing.T) {
+	req := require.New(t)
+
+	repliers, err := NewRepliers("", nil, mlog.Default())
+	req.Nil(err)
+
+	for _, language := range repliers.Languages() {
+		replier := repliers.ForLanguage(language)
+		descriptor, ok := replier.ReviewGetDescriptor(replier.ReviewPostCaption("B", 12))
+		req.True(ok, language)
+		req.Equal(mathbattle.SolutionDescriptor{ProblemCaption: "B", SolutionNumber: 12}, descriptor)
+
+		_, ok = replier.ReviewGetDescriptor("B 12")
+		req.False(ok, language)
+	}
+}
+
+func TestForLanguage(t *testing.T) {
+	req := require.New(t)
+
+	repliers, err := NewRepliers("", nil, mlog.Default())
+	req.Nil(err)
+
+	req.Equal([]string{"ru", "en"}, repliers.Languages())
+	req.Equal("en", repliers.ForLanguage("en-US").Language())
+	req.Equal("ru", repliers.ForLanguage("").Language())
+	req.Equal("ru", repliers.ForLanguage("de").Language())
+
+	_, err = NewRepliers("de", nil, mlog.Default())
+	req.NotNil(err)
+}
+
+func TestPlural(t *testing.T) {
+	req := require.New(t)
+
+	repliers, err := NewRepliers("", nil, mlog.Default())
+	req.Nil(err)
+
+	ru := repliers.ForLanguage("ru")
+	req.Equal("Загрузка решения завершена. Всего в решении 1 лист", ru.SolutionUploadSuccess(1))
+	req.Equal("Загрузка решения завершена. Всего в решении 3 листа", ru.SolutionUploadSuccess(3))
+	req.Equal("Загрузка решения завершена. Всего в решении 11 листов", ru.SolutionUploadSuccess(11))
+
+	en := repliers.ForLanguage("en")
+	req.Equal("The solution is uploaded. It has 1 sheet", en.SolutionUploadSuccess(1))
+	req.Equal("The solution is uploaded. It has 3 sheets", en.SolutionUploadSuccess(3))
+}
+
+func TestCatalogFromConfig(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "catalogs")
+	req.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "de.yaml")
+	req.Nil(ioutil.WriteFile(path, []byte("language_name: Deutsch\nyes: Ja\n"), 0666))
+
+	repliers, err := NewRepliers("en", map[string]string{"de": path}, mlog.Default())
+	req.Nil(err)
+
+	req.Equal([]string{"en", "de", "ru"}, repliers.Languages())
+	de := repliers.ForLanguage("de-AT")
+	req.Equal("Deutsch", de.LanguageName())
+	req.Equal("Ja", de.Yes())
+	// Непереведённые тексты берутся из языка по умолчанию
+	req.Equal("No", de.No())
+
+	_, err = NewRepliers("", map[string]string{"de": filepath.Join(dir, "missing.yaml")}, mlog.Default())
+	req.NotNil(err)
+}
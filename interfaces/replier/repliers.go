@@ -0,0 +1,95 @@
+package replier
+
+import (
+	"fmt"
+	"sort"
+
+	"mathbattle/application"
+	"mathbattle/libs/mlog"
+)
+
+const DefaultLanguage = "ru"
+
+// Repliers хранит Replier для каждого языка. Встроены русский и английский каталоги,
+// остальные языки (или правки встроенных) подключаются yaml каталогами из конфига
+type Repliers struct {
+	defaultLanguage string
+	repliers        map[string]*CatalogReplier
+}
+
+// NewRepliers пишет в logger, каких текстов не хватает в каталогах. Nil logger - mlog.Default
+func NewRepliers(defaultLanguage string, catalogPaths map[string]string, logger *mlog.Logger) (*Repliers, error) {
+	if logger == nil {
+		logger = mlog.Default()
+	}
+	if defaultLanguage == "" {
+		defaultLanguage = DefaultLanguage
+	}
+	defaultLanguage = NormalizeLanguage(defaultLanguage)
+
+	catalogs := builtinCatalogs()
+	for language, path := range catalogPaths {
+		loaded, err := LoadCatalog(path)
+		if err != nil {
+			return nil, err
+		}
+
+		language = NormalizeLanguage(language)
+		merged := Catalog{}
+		for key, text := range catalogs[language] {
+			merged[key] = text
+		}
+		for key, text := range loaded {
+			merged[key] = text
+		}
+		catalogs[language] = merged
+	}
+
+	fallback, exists := catalogs[defaultLanguage]
+	if !exists {
+		return nil, fmt.Errorf("no catalog for default language %s", defaultLanguage)
+	}
+	if missing := MissingKeys(fallback); len(missing) != 0 {
+		return nil, fmt.Errorf("catalog of default language %s misses keys: %v", defaultLanguage, missing)
+	}
+
+	result := &Repliers{
+		defaultLanguage: defaultLanguage,
+		repliers:        make(map[string]*CatalogReplier),
+	}
+	for language, catalog := range catalogs {
+		if missing := MissingKeys(catalog); len(missing) != 0 {
+			logger.Warnf("Catalog %s misses %d keys, texts of %s will be used for them: %v",
+				language, len(missing), defaultLanguage, missing)
+		}
+		result.repliers[language] = NewCatalogReplier(language, catalog, fallback)
+	}
+
+	return result, nil
+}
+
+func (r *Repliers) Default() application.Replier {
+	return r.repliers[r.defaultLanguage]
+}
+
+// ForLanguage возвращает Replier для кода языка из телеграма или настроек пользователя.
+// Для неизвестного или пустого языка - Replier языка по умолчанию
+func (r *Repliers) ForLanguage(language string) application.Replier {
+	if result, exists := r.repliers[NormalizeLanguage(language)]; exists {
+		return result
+	}
+	return r.Default()
+}
+
+// Languages - коды доступных языков, язык по умолчанию первый
+func (r *Repliers) Languages() []string {
+	result := []string{}
+	for language := range r.repliers {
+		if language != r.defaultLanguage {
+			result = append(result, language)
+		}
+	}
+	sort.Strings(result)
+
+	return append([]string{r.defaultLanguage}, result...)
+}
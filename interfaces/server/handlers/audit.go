@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"mathbattle/models/mathbattle"
+)
+
+type AuditHandler struct {
+	As mathbattle.AuditService
+}
+
+// auditFilter - фильтр из параметров запроса: action, actor_id, target, since, until.
+// Время - RFC3339 или дата YYYY-MM-DD
+func auditFilter(r *http.Request) (mathbattle.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := mathbattle.AuditFilter{
+		Action: mathbattle.AuditAction(query.Get("action")),
+		Target: query.Get("target"),
+	}
+
+	if value := query.Get("actor_id"); value != "" {
+		actorID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return filter, err
+		}
+		filter.ActorID = actorID
+	}
+
+	parseTime := func(value string) (time.Time, error) {
+		if value == "" {
+			return time.Time{}, nil
+		}
+		if result, err := time.Parse(time.RFC3339, value); err == nil {
+			return result, nil
+		}
+		return time.Parse("2006-01-02", value)
+	}
+
+	var err error
+	if filter.Since, err = parseTime(query.Get("since")); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseTime(query.Get("until")); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func (h *AuditHandler) Find(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilter(r)
+	if err != nil {
+		ResponseJSON(w, http.StatusBadRequest, nil)
+		return
+	}
+
+	records, err := h.As.Find(filter)
+	if err != nil {
+		requestLogger(r).Errorf("Failed to find audit records, error: '%v'", err)
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, records)
+}
+
+func (h *AuditHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilter(r)
+	if err != nil {
+		ResponseJSON(w, http.StatusBadRequest, nil)
+		return
+	}
+
+	content, err := h.As.ExportCSV(filter)
+	if err != nil {
+		requestLogger(r).Errorf("Failed to export audit log, error: '%v'", err)
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"audit.csv\"")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/gorilla/mux"
+)
+
+type BattleHandler struct {
+	Bs mathbattle.BattleService
+}
+
+func responseBattleError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	switch err {
+	case mathbattle.ErrNotFound:
+		ResponseJSON(w, http.StatusNotFound, nil)
+	case mathbattle.ErrWrongUserInput:
+		ResponseJSON(w, http.StatusBadRequest, nil)
+	default:
+		requestLogger(r).Errorf("Failed to %s, error: '%v'", action, err)
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+	}
+}
+
+func (h *BattleHandler) Start(w http.ResponseWriter, r *http.Request) {
+	var order mathbattle.BattleOrder
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+	order.ActorID = actorID(r)
+
+	battle, err := h.Bs.Start(order)
+	if err != nil {
+		responseBattleError(w, r, err, "start battle")
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, battle)
+}
+
+func (h *BattleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ID := mux.Vars(r)["id"]
+
+	battle, err := h.Bs.Get(ID)
+	if err != nil {
+		responseBattleError(w, r, err, "get battle "+ID)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, battle)
+}
+
+func (h *BattleHandler) GetRunning(w http.ResponseWriter, r *http.Request) {
+	participantID := mux.Vars(r)["participant_id"]
+
+	battle, err := h.Bs.GetRunning(participantID)
+	if err != nil {
+		responseBattleError(w, r, err, "get running battle of participant "+participantID)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, battle)
+}
+
+func (h *BattleHandler) GetByRound(w http.ResponseWriter, r *http.Request) {
+	roundID := mux.Vars(r)["round_id"]
+
+	battles, err := h.Bs.GetByRound(roundID)
+	if err != nil {
+		responseBattleError(w, r, err, "get battles of round "+roundID)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, battles)
+}
+
+func (h *BattleHandler) Move(w http.ResponseWriter, r *http.Request) {
+	var move mathbattle.BattleMove
+	err := json.NewDecoder(r.Body).Decode(&move)
+	if err != nil {
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+	move.ActorID = actorID(r)
+
+	battle, err := h.Bs.Move(move)
+	if err != nil {
+		responseBattleError(w, r, err, "make battle move")
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, battle)
+}
+
+func (h *BattleHandler) Judge(w http.ResponseWriter, r *http.Request) {
+	var verdict mathbattle.BattleVerdict
+	err := json.NewDecoder(r.Body).Decode(&verdict)
+	if err != nil {
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+	verdict.ActorID = actorID(r)
+
+	battle, err := h.Bs.Judge(verdict)
+	if err != nil {
+		responseBattleError(w, r, err, "judge battle")
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, battle)
+}
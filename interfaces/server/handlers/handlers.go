@@ -2,8 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
+
+	"mathbattle/libs/mlog"
 )
 
 func ResponseJSON(w http.ResponseWriter, code int, object interface{}) {
@@ -22,5 +23,5 @@ func ResponseJSON(w http.ResponseWriter, code int, object interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(encoded)
 
-	log.Printf("Response: %s", encoded)
+	mlog.Default().Debugf("Response: %s", encoded)
 }
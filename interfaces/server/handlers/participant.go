@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -25,7 +24,12 @@ func (h *ParticipantHandler) Store(w http.ResponseWriter, r *http.Request) {
 
 	participant, err = h.Ps.Store(participant)
 	if err != nil {
-		ResponseJSON(w, http.StatusInternalServerError, nil)
+		if err == mathbattle.ErrWrongUserInput {
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		} else {
+			requestLogger(r).Errorf("Failed to store participant, error: '%v'", err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
 		return
 	}
 
@@ -40,7 +44,7 @@ func (h *ParticipantHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
 		} else {
-			log.Printf("Failed to get participant by ID='%s', error: '%v'", ID, err)
+			requestLogger(r).Errorf("Failed to get participant by ID='%s', error: '%v'", ID, err)
 			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
 		return
@@ -63,7 +67,7 @@ func (h *ParticipantHandler) GetByTelegramID(w http.ResponseWriter, r *http.Requ
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
 		} else {
-			log.Printf("Failed to get participant by telegram ID='%d', error: '%v'", telegramID, err)
+			requestLogger(r).Errorf("Failed to get participant by telegram ID='%d', error: '%v'", telegramID, err)
 			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
 		return
@@ -93,7 +97,12 @@ func (h *ParticipantHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	err = h.Ps.Update(participant)
 	if err != nil {
-		ResponseJSON(w, http.StatusInternalServerError, nil)
+		if err == mathbattle.ErrWrongUserInput {
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		} else {
+			requestLogger(r).Errorf("Failed to update participant ID='%s', error: '%v'", participant.ID, err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
 		return
 	}
 
@@ -103,9 +112,13 @@ func (h *ParticipantHandler) Update(w http.ResponseWriter, r *http.Request) {
 func (h *ParticipantHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	ID := mux.Vars(r)["id"]
 
-	err := h.Ps.Delete(ID)
+	err := h.Ps.Delete(ID, actorID(r))
 	if err != nil {
-		ResponseJSON(w, http.StatusInternalServerError, nil)
+		if err == mathbattle.ErrNotFound {
+			ResponseJSON(w, http.StatusNotFound, nil)
+		} else {
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
 		return
 	}
 
@@ -123,3 +136,24 @@ func (h *ParticipantHandler) Unsubscribe(w http.ResponseWriter, r *http.Request)
 
 	ResponseJSON(w, http.StatusOK, nil)
 }
+
+func (h *ParticipantHandler) PromoteGrades(w http.ResponseWriter, r *http.Request) {
+	schoolYear, err := strconv.Atoi(mux.Vars(r)["year"])
+	if err != nil {
+		ResponseJSON(w, http.StatusBadRequest, nil)
+		return
+	}
+
+	promotion, err := h.Ps.PromoteGrades(schoolYear, actorID(r))
+	if err != nil {
+		if err == mathbattle.ErrWrongUserInput {
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		} else {
+			requestLogger(r).Errorf("Failed to promote grades to school year %d, error: '%v'", schoolYear, err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, promotion)
+}
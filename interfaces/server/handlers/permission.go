@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/gorilla/mux"
+)
+
+// TokenAuth пропускает только запросы с общим секретом в mathbattle.TokenHeader: без него ActorHeader
+// мог бы прислать кто угодно
+type TokenAuth struct {
+	Token string
+}
+
+func (a *TokenAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(mathbattle.TokenHeader)
+		if a.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) != 1 {
+			requestLogger(r).Warnf("Request without valid API token: %s %s", r.Method, r.URL.Path)
+			ResponseJSON(w, http.StatusUnauthorized, nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PermissionGuard пропускает запрос, только если у пользователя из mathbattle.ActorHeader есть право
+type PermissionGuard struct {
+	Users mathbattle.UserRepository
+}
+
+// actorID - Telegram ID пользователя, от имени которого сделан запрос, 0 - не указан
+func actorID(r *http.Request) int64 {
+	ID, err := strconv.ParseInt(r.Header.Get(mathbattle.ActorHeader), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return ID
+}
+
+func (g *PermissionGuard) Require(permission mathbattle.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ID := actorID(r)
+		if ID == 0 {
+			ResponseJSON(w, http.StatusForbidden, nil)
+			return
+		}
+
+		user, err := g.Users.GetByTelegramID(ID)
+		if err != nil {
+			if err == mathbattle.ErrNotFound {
+				ResponseJSON(w, http.StatusForbidden, nil)
+			} else {
+				requestLogger(r).Errorf("Failed to get user %d, error: '%v'", ID, err)
+				ResponseJSON(w, http.StatusInternalServerError, nil)
+			}
+			return
+		}
+
+		if !user.Can(permission) {
+			requestLogger(r).Warnf("User %d has no permission '%s' for %s %s", ID, permission, r.Method, r.URL.Path)
+			ResponseJSON(w, http.StatusForbidden, nil)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// IsOwner - принадлежит ли то, что меняет запрос, участнику с Telegram ID actorID
+type IsOwner func(r *http.Request, actorID int64) (bool, error)
+
+// RequireOwnerOr пропускает владельца, например участника, удаляющего своё решение, или пользователя с правом
+func (g *PermissionGuard) RequireOwnerOr(permission mathbattle.Permission, isOwner IsOwner, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ID := actorID(r)
+		if ID == 0 {
+			ResponseJSON(w, http.StatusForbidden, nil)
+			return
+		}
+
+		owner, err := isOwner(r, ID)
+		if err != nil {
+			requestLogger(r).Errorf("Failed to check owner %d of %s %s, error: '%v'", ID, r.Method, r.URL.Path, err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+			return
+		}
+		if owner {
+			next(w, r)
+			return
+		}
+
+		g.Require(permission, next)(w, r)
+	}
+}
+
+// Owners - проверки владельца для RequireOwnerOr. Что не найдено, никому не принадлежит
+type Owners struct {
+	Participants mathbattle.ParticipantRepository
+	Solutions    mathbattle.SolutionRepository
+	Reviews      mathbattle.ReviewRepository
+	Teams        mathbattle.TeamRepository
+}
+
+// isCompetitor - участник с Telegram ID actorID - это participantID или член команды teamID
+func (o *Owners) isCompetitor(actorID int64, participantID string, teamID string) (bool, error) {
+	participant, err := o.Participants.GetByTelegramID(actorID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if participant.ID == participantID {
+		return true, nil
+	}
+	if teamID == "" {
+		return false, nil
+	}
+
+	team, err := o.Teams.Get(teamID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return team.IsMember(participant.ID), nil
+}
+
+// Participant - участник {id} - это сам пользователь
+func (o *Owners) Participant(r *http.Request, actorID int64) (bool, error) {
+	return o.ParticipantVar("id")(r, actorID)
+}
+
+// ParticipantVar - участник из переменной пути name - это сам пользователь
+func (o *Owners) ParticipantVar(name string) IsOwner {
+	return func(r *http.Request, actorID int64) (bool, error) {
+		return o.isCompetitor(actorID, mux.Vars(r)[name], "")
+	}
+}
+
+// peekJSON разбирает JSON тело запроса в v и оставляет тело для обработчика.
+// Тело не разобралось - false, обработчик сам ответит на кривой запрос
+func peekJSON(r *http.Request, v interface{}) (bool, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return false, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	return json.Unmarshal(data, v) == nil, nil
+}
+
+// BodyParticipant - participant_id из тела запроса - это сам пользователь
+func (o *Owners) BodyParticipant(r *http.Request, actorID int64) (bool, error) {
+	var body struct {
+		ParticipantID string `json:"participant_id"`
+	}
+	ok, err := peekJSON(r, &body)
+	if !ok || err != nil {
+		return false, err
+	}
+	return o.isCompetitor(actorID, body.ParticipantID, "")
+}
+
+// isDescriptorOwner - поиск по teamID, если он задан, иначе по participantID, идёт от имени самого пользователя
+func (o *Owners) isDescriptorOwner(actorID int64, participantID string, teamID string) (bool, error) {
+	if teamID != "" {
+		return o.isCompetitor(actorID, "", teamID)
+	}
+	return o.isCompetitor(actorID, participantID, "")
+}
+
+// Solution - решение {id} сдал пользователь или его команда
+func (o *Owners) Solution(r *http.Request, actorID int64) (bool, error) {
+	solution, err := o.Solutions.Get(mux.Vars(r)["id"])
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return o.isCompetitor(actorID, solution.ParticipantID, solution.TeamID)
+}
+
+// SolutionReader - решение {id} сдал пользователь или его команда либо пользователь прислал на него ревью
+func (o *Owners) SolutionReader(r *http.Request, actorID int64) (bool, error) {
+	ID := mux.Vars(r)["id"]
+	owner, err := o.Solution(r, actorID)
+	if owner || err != nil {
+		return owner, err
+	}
+
+	reviews, err := o.Reviews.FindMany("", ID)
+	if err != nil {
+		return false, err
+	}
+	for _, review := range reviews {
+		reviewer, err := o.isCompetitor(actorID, review.ReviewerID, review.ReviewerTeamID)
+		if reviewer || err != nil {
+			return reviewer, err
+		}
+	}
+	return false, nil
+}
+
+// SolutionFind - mathbattle.FindDescriptor из тела ищет решения самого пользователя или его команды
+func (o *Owners) SolutionFind(r *http.Request, actorID int64) (bool, error) {
+	var descriptor mathbattle.FindDescriptor
+	ok, err := peekJSON(r, &descriptor)
+	if !ok || err != nil {
+		return false, err
+	}
+	return o.isDescriptorOwner(actorID, descriptor.ParticipantID, descriptor.TeamID)
+}
+
+// ReviewFind - mathbattle.ReviewFindDescriptor из тела ищет ревью пользователя или его команды
+// либо ревью на решение пользователя
+func (o *Owners) ReviewFind(r *http.Request, actorID int64) (bool, error) {
+	var descriptor mathbattle.ReviewFindDescriptor
+	ok, err := peekJSON(r, &descriptor)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	reviewer, err := o.isDescriptorOwner(actorID, descriptor.ReviewerID, descriptor.ReviewerTeamID)
+	if reviewer || err != nil || descriptor.SolutionID == "" {
+		return reviewer, err
+	}
+
+	solution, err := o.Solutions.Get(descriptor.SolutionID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return o.isCompetitor(actorID, solution.ParticipantID, solution.TeamID)
+}
+
+// Review - ревью {id} написал пользователь или его команда
+func (o *Owners) Review(r *http.Request, actorID int64) (bool, error) {
+	review, err := o.Reviews.Get(mux.Vars(r)["id"])
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return o.isCompetitor(actorID, review.ReviewerID, review.ReviewerTeamID)
+}
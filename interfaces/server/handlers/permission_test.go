@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryUsers struct {
+	mathbattle.UserRepository
+	users []mathbattle.User
+}
+
+func (r *memoryUsers) GetByTelegramID(ID int64) (mathbattle.User, error) {
+	for _, user := range r.users {
+		if user.TelegramID == ID {
+			return user, nil
+		}
+	}
+	return mathbattle.User{}, mathbattle.ErrNotFound
+}
+
+type memoryParticipants struct {
+	mathbattle.ParticipantRepository
+	participants []mathbattle.Participant
+}
+
+func (r *memoryParticipants) GetByTelegramID(ID int64) (mathbattle.Participant, error) {
+	for _, participant := range r.participants {
+		if participant.TelegramID == ID {
+			return participant, nil
+		}
+	}
+	return mathbattle.Participant{}, mathbattle.ErrNotFound
+}
+
+type memorySolutions struct {
+	mathbattle.SolutionRepository
+	solutions []mathbattle.Solution
+}
+
+func (r *memorySolutions) Get(ID string) (mathbattle.Solution, error) {
+	for _, solution := range r.solutions {
+		if solution.ID == ID {
+			return solution, nil
+		}
+	}
+	return mathbattle.Solution{}, mathbattle.ErrNotFound
+}
+
+type memoryReviews struct {
+	mathbattle.ReviewRepository
+	reviews []mathbattle.Review
+}
+
+func (r *memoryReviews) FindMany(reviewerID, solutionID string) ([]mathbattle.Review, error) {
+	result := []mathbattle.Review{}
+	for _, review := range r.reviews {
+		if (reviewerID == "" || review.ReviewerID == reviewerID) && (solutionID == "" || review.SolutionID == solutionID) {
+			result = append(result, review)
+		}
+	}
+	return result, nil
+}
+
+type memoryTeams struct {
+	mathbattle.TeamRepository
+	teams []mathbattle.Team
+}
+
+func (r *memoryTeams) Get(ID string) (mathbattle.Team, error) {
+	for _, team := range r.teams {
+		if team.ID == ID {
+			return team, nil
+		}
+	}
+	return mathbattle.Team{}, mathbattle.ErrNotFound
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	ResponseJSON(w, http.StatusOK, nil)
+}
+
+func request(router http.Handler, method string, path string, token string, actorID int64) int {
+	return requestBody(router, method, path, "", token, actorID)
+}
+
+func requestBody(router http.Handler, method string, path string, body string, token string, actorID int64) int {
+	var reader io.Reader = nil
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, path, reader)
+	if token != "" {
+		r.Header.Set(mathbattle.TokenHeader, token)
+	}
+	if actorID != 0 {
+		r.Header.Set(mathbattle.ActorHeader, strconv.FormatInt(actorID, 10))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestTokenAuth(t *testing.T) {
+	req := require.New(t)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/", okHandler)
+	auth := TokenAuth{Token: "secret"}
+	router.Use(auth.Middleware)
+
+	req.Equal(http.StatusOK, request(router, "GET", "/", "secret", 0))
+	req.Equal(http.StatusUnauthorized, request(router, "GET", "/", "", 0))
+	req.Equal(http.StatusUnauthorized, request(router, "GET", "/", "secre", 0))
+
+	// Без настроенного токена не пускаем никого
+	auth.Token = ""
+	req.Equal(http.StatusUnauthorized, request(router, "GET", "/", "", 0))
+}
+
+func TestRequireOwnerOr(t *testing.T) {
+	req := require.New(t)
+
+	guard := PermissionGuard{Users: &memoryUsers{users: []mathbattle.User{
+		{TelegramID: 101},
+		{TelegramID: 102},
+		{TelegramID: 103},
+		{TelegramID: 200, Role: mathbattle.RoleModerator},
+	}}}
+	owners := Owners{
+		Participants: &memoryParticipants{participants: []mathbattle.Participant{
+			{ID: "1", User: mathbattle.User{TelegramID: 101}},
+			{ID: "2", User: mathbattle.User{TelegramID: 102}},
+			{ID: "3", User: mathbattle.User{TelegramID: 103}},
+		}},
+		Solutions: &memorySolutions{solutions: []mathbattle.Solution{
+			{ID: "s1", ParticipantID: "1"},
+			{ID: "s2", ParticipantID: "2", TeamID: "t1"},
+		}},
+		Teams: &memoryTeams{teams: []mathbattle.Team{{ID: "t1", MemberIDs: []string{"1", "2"}}}},
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/participants/{id}", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.Participant, okHandler))
+	router.HandleFunc("/solutions/{id}", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.Solution, okHandler))
+
+	req.Equal(http.StatusOK, request(router, "PUT", "/participants/1", "", 101))
+	req.Equal(http.StatusForbidden, request(router, "PUT", "/participants/1", "", 102))
+	req.Equal(http.StatusForbidden, request(router, "PUT", "/participants/1", "", 0))
+	req.Equal(http.StatusOK, request(router, "PUT", "/participants/1", "", 200))
+
+	req.Equal(http.StatusOK, request(router, "DELETE", "/solutions/s1", "", 101))
+	req.Equal(http.StatusForbidden, request(router, "DELETE", "/solutions/s1", "", 102))
+	// Командное решение удаляет любой участник команды
+	req.Equal(http.StatusOK, request(router, "DELETE", "/solutions/s2", "", 101))
+	req.Equal(http.StatusForbidden, request(router, "DELETE", "/solutions/s2", "", 103))
+	req.Equal(http.StatusForbidden, request(router, "DELETE", "/solutions/unknown", "", 101))
+	req.Equal(http.StatusOK, request(router, "DELETE", "/solutions/unknown", "", 200))
+}
+
+func TestOwnersFromBodyAndReviews(t *testing.T) {
+	req := require.New(t)
+
+	guard := PermissionGuard{Users: &memoryUsers{users: []mathbattle.User{
+		{TelegramID: 101},
+		{TelegramID: 102},
+		{TelegramID: 103},
+		{TelegramID: 300, Role: mathbattle.RoleJury},
+	}}}
+	owners := Owners{
+		Participants: &memoryParticipants{participants: []mathbattle.Participant{
+			{ID: "1", User: mathbattle.User{TelegramID: 101}},
+			{ID: "2", User: mathbattle.User{TelegramID: 102}},
+			{ID: "3", User: mathbattle.User{TelegramID: 103}},
+		}},
+		Solutions: &memorySolutions{solutions: []mathbattle.Solution{{ID: "s1", ParticipantID: "1"}}},
+		Reviews:   &memoryReviews{reviews: []mathbattle.Review{{ID: "r1", ReviewerID: "2", SolutionID: "s1"}}},
+		Teams:     &memoryTeams{teams: []mathbattle.Team{{ID: "t1", MemberIDs: []string{"1", "2"}}}},
+	}
+
+	// Обработчик должен получить тело целиком, даже если его уже читала проверка владельца
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil || len(data) == 0 {
+			ResponseJSON(w, http.StatusBadRequest, nil)
+			return
+		}
+		ResponseJSON(w, http.StatusOK, nil)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/teams/leave/{participant_id}",
+		guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.ParticipantVar("participant_id"), okHandler))
+	router.HandleFunc("/battles/move", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.BodyParticipant, echo))
+	router.HandleFunc("/solutions/find/descriptor", guard.RequireOwnerOr(mathbattle.PermissionJudge, owners.SolutionFind, echo))
+	router.HandleFunc("/solutions/{id}", guard.RequireOwnerOr(mathbattle.PermissionJudge, owners.SolutionReader, okHandler))
+	router.HandleFunc("/reviews/find/descriptor", guard.RequireOwnerOr(mathbattle.PermissionJudge, owners.ReviewFind, echo))
+
+	req.Equal(http.StatusOK, request(router, "POST", "/teams/leave/1", "", 101))
+	req.Equal(http.StatusForbidden, request(router, "POST", "/teams/leave/1", "", 102))
+
+	req.Equal(http.StatusOK, requestBody(router, "POST", "/battles/move", `{"participant_id":"1"}`, "", 101))
+	req.Equal(http.StatusForbidden, requestBody(router, "POST", "/battles/move", `{"participant_id":"1"}`, "", 102))
+	req.Equal(http.StatusForbidden, requestBody(router, "POST", "/battles/move", `not json`, "", 101))
+
+	req.Equal(http.StatusOK, requestBody(router, "GET", "/solutions/find/descriptor", `{"participant_id":"1"}`, "", 101))
+	req.Equal(http.StatusOK, requestBody(router, "GET", "/solutions/find/descriptor", `{"team_id":"t1"}`, "", 102))
+	// Команда важнее участника: свой participant_id не открывает решения чужой команды
+	req.Equal(http.StatusForbidden, requestBody(router, "GET", "/solutions/find/descriptor", `{"participant_id":"3","team_id":"t1"}`, "", 103))
+	req.Equal(http.StatusForbidden, requestBody(router, "GET", "/solutions/find/descriptor", `{"round_id":"round"}`, "", 101))
+	req.Equal(http.StatusOK, requestBody(router, "GET", "/solutions/find/descriptor", `{"round_id":"round"}`, "", 300))
+
+	// Решение читают автор, его ревьюер и жюри
+	req.Equal(http.StatusOK, request(router, "GET", "/solutions/s1", "", 101))
+	req.Equal(http.StatusOK, request(router, "GET", "/solutions/s1", "", 102))
+	req.Equal(http.StatusForbidden, request(router, "GET", "/solutions/s1", "", 103))
+	req.Equal(http.StatusOK, request(router, "GET", "/solutions/s1", "", 300))
+
+	// Ревью читают ревьюер и автор решения
+	req.Equal(http.StatusOK, requestBody(router, "GET", "/reviews/find/descriptor", `{"reviewer_id":"2","solution_id":"s1"}`, "", 102))
+	req.Equal(http.StatusOK, requestBody(router, "GET", "/reviews/find/descriptor", `{"solution_id":"s1"}`, "", 101))
+	req.Equal(http.StatusForbidden, requestBody(router, "GET", "/reviews/find/descriptor", `{"solution_id":"s1"}`, "", 103))
+	req.Equal(http.StatusForbidden, requestBody(router, "GET", "/reviews/find/descriptor", `{"reviewer_id":"2"}`, "", 103))
+}
@@ -17,6 +17,7 @@ func (h *PostmanHandler) SendToUsers(w http.ResponseWriter, r *http.Request) {
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
 	}
+	msg.ActorID = actorID(r)
 
 	err = h.Ps.SendSimpleToUsers(msg)
 	if err != nil {
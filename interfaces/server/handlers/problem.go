@@ -22,3 +22,13 @@ func (h *ProblemHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	ResponseJSON(w, http.StatusOK, problem)
 }
+
+func (h *ProblemHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	problems, err := h.Ps.GetAll()
+	if err != nil {
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, problems)
+}
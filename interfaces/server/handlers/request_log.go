@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"mathbattle/libs/mlog"
+)
+
+// RequestLogger пишет в лог каждый запрос и передаёт обработчику логгер с ID запроса.
+// ID берётся из mlog.RequestIDHeader, если его прислал бот, иначе выдаётся новый
+type RequestLogger struct {
+	Logger *mlog.Logger
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (l *RequestLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(mlog.RequestIDHeader)
+		if requestID == "" {
+			requestID = mlog.NewRequestID()
+		}
+		logger := l.Logger.With("request_id", requestID)
+		w.Header().Set(mlog.RequestIDHeader, requestID)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		startTime := time.Now()
+		next.ServeHTTP(recorder, r.WithContext(mlog.NewContext(r.Context(), logger)))
+
+		logger.With("method", r.Method, "path", r.URL.Path, "status", recorder.status,
+			"duration", time.Since(startTime)).Infof("Request handled")
+	})
+}
+
+// requestLogger - логгер с ID запроса r
+func requestLogger(r *http.Request) *mlog.Logger {
+	return mlog.FromContext(r.Context())
+}
@@ -49,9 +49,13 @@ func (h *ReviewHandler) FindMany(w http.ResponseWriter, r *http.Request) {
 func (h *ReviewHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	ID := mux.Vars(r)["id"]
 
-	err := h.Rs.Delete(ID)
+	err := h.Rs.Delete(ID, actorID(r))
 	if err != nil {
-		ResponseJSON(w, http.StatusInternalServerError, nil)
+		if err == mathbattle.ErrNotFound {
+			ResponseJSON(w, http.StatusNotFound, nil)
+		} else {
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
 		return
 	}
 
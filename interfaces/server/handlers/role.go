@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mathbattle/models/mathbattle"
+)
+
+type RoleHandler struct {
+	Rs mathbattle.RoleService
+}
+
+func (h *RoleHandler) Grant(w http.ResponseWriter, r *http.Request) {
+	var order mathbattle.RoleOrder
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+	order.ActorID = actorID(r)
+
+	user, err := h.Rs.Grant(order)
+	if err != nil {
+		if err == mathbattle.ErrWrongUserInput {
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		} else {
+			requestLogger(r).Errorf("Failed to grant role, error: '%v'", err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, user)
+}
+
+func (h *RoleHandler) GetStaff(w http.ResponseWriter, r *http.Request) {
+	staff, err := h.Rs.GetStaff()
+	if err != nil {
+		requestLogger(r).Errorf("Failed to get staff, error: '%v'", err)
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, staff)
+}
@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
+	"strconv"
 
 	"mathbattle/models/mathbattle"
 
@@ -21,10 +21,11 @@ func (h *RoundHandler) StartNew(w http.ResponseWriter, r *http.Request) {
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
 	}
+	startOrder.ActorID = actorID(r)
 
 	result, err := h.Rs.StartNew(startOrder)
 	if err != nil {
-		log.Printf("Failed to start new round, error: '%v'", err)
+		requestLogger(r).Errorf("Failed to start new round, error: '%v'", err)
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
 	}
@@ -33,7 +34,17 @@ func (h *RoundHandler) StartNew(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RoundHandler) GetReivewStageDistribution(w http.ResponseWriter, r *http.Request) {
-	desc, err := h.Rs.ReviewStageDistributionDesc()
+	seed := int64(0)
+	if seedStr := r.URL.Query().Get("seed"); seedStr != "" {
+		var err error
+		seed, err = strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			ResponseJSON(w, http.StatusBadRequest, nil)
+			return
+		}
+	}
+
+	desc, err := h.Rs.ReviewStageDistributionDesc(r.URL.Query().Get("league"), seed)
 	if err != nil {
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
@@ -49,23 +60,135 @@ func (h *RoundHandler) StartReviewStage(w http.ResponseWriter, r *http.Request)
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
 	}
+	startOrder.ActorID = actorID(r)
 
 	round, err := h.Rs.StartReviewStage(startOrder)
 	if err != nil {
-		log.Printf("Failed to start review stage, error: '%v'", err)
-		ResponseJSON(w, http.StatusInternalServerError, nil)
+		switch err {
+		case mathbattle.ErrWrongUserInput:
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		case mathbattle.ErrNotFound:
+			ResponseJSON(w, http.StatusNotFound, nil)
+		case mathbattle.ErrDistributionChanged:
+			ResponseJSON(w, http.StatusPreconditionFailed, nil)
+		default:
+			requestLogger(r).Errorf("Failed to start review stage, error: '%v'", err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, round)
+}
+
+func (h *RoundHandler) ReassignReview(w http.ResponseWriter, r *http.Request) {
+	var order mathbattle.ReassignOrder
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		ResponseJSON(w, http.StatusBadRequest, nil)
+		return
+	}
+	order.ActorID = actorID(r)
+
+	result, err := h.Rs.ReassignReview(order)
+	if err != nil {
+		switch err {
+		case mathbattle.ErrWrongUserInput:
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		case mathbattle.ErrNotFound:
+			ResponseJSON(w, http.StatusNotFound, nil)
+		case mathbattle.ErrReviewSubmitted:
+			ResponseJSON(w, http.StatusUnprocessableEntity, nil)
+		default:
+			requestLogger(r).Errorf("Failed to reassign review, error: '%v'", err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, result)
+}
+
+func (h *RoundHandler) ReassignOptions(w http.ResponseWriter, r *http.Request) {
+	seed := int64(0)
+	if seedStr := r.URL.Query().Get("seed"); seedStr != "" {
+		var err error
+		seed, err = strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			ResponseJSON(w, http.StatusBadRequest, nil)
+			return
+		}
+	}
+
+	options, err := h.Rs.ReassignOptions(r.URL.Query().Get("league"), seed)
+	if err != nil {
+		switch err {
+		case mathbattle.ErrWrongUserInput:
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		case mathbattle.ErrNotFound:
+			ResponseJSON(w, http.StatusNotFound, nil)
+		default:
+			requestLogger(r).Errorf("Failed to get reassign options, error: '%v'", err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, options)
+}
+
+func (h *RoundHandler) PublishResults(w http.ResponseWriter, r *http.Request) {
+	ID := mux.Vars(r)["id"]
+
+	round, err := h.Rs.PublishResults(ID, actorID(r))
+	if err != nil {
+		switch err {
+		case mathbattle.ErrRoundNotFinished:
+			ResponseJSON(w, http.StatusConflict, nil)
+		case mathbattle.ErrNotFound:
+			ResponseJSON(w, http.StatusNotFound, nil)
+		default:
+			requestLogger(r).Errorf("Failed to publish results of round '%s', error: '%v'", ID, err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
 		return
 	}
 
 	ResponseJSON(w, http.StatusOK, round)
 }
 
+func (h *RoundHandler) RevealIdentities(w http.ResponseWriter, r *http.Request) {
+	var order mathbattle.RevealOrder
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		ResponseJSON(w, http.StatusBadRequest, nil)
+		return
+	}
+	order.ActorID = actorID(r)
+
+	result, err := h.Rs.RevealIdentities(order)
+	if err != nil {
+		switch err {
+		case mathbattle.ErrWrongUserInput:
+			ResponseJSON(w, http.StatusBadRequest, nil)
+		case mathbattle.ErrNotFound:
+			ResponseJSON(w, http.StatusNotFound, nil)
+		default:
+			requestLogger(r).Errorf("Failed to reveal identities, error: '%v'", err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, result)
+}
+
 func (h *RoundHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	log.Print("Handler: GetAll")
+	requestLogger(r).Debugf("Handler: GetAll")
 
 	rounds, err := h.Rs.GetAll()
 	if err != nil {
-		log.Printf("Failed to get all rounds, error: '%v'", err)
+		requestLogger(r).Errorf("Failed to get all rounds, error: '%v'", err)
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
 	}
@@ -74,7 +197,7 @@ func (h *RoundHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RoundHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	log.Print("Handler: GetByID")
+	requestLogger(r).Debugf("Handler: GetByID")
 
 	ID := mux.Vars(r)["id"]
 
@@ -83,7 +206,7 @@ func (h *RoundHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
 		} else {
-			log.Printf("Failed to get round by ID='%s', error: '%v'", ID, err)
+			requestLogger(r).Errorf("Failed to get round by ID='%s', error: '%v'", ID, err)
 			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
 		return
@@ -93,14 +216,14 @@ func (h *RoundHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RoundHandler) GetRunning(w http.ResponseWriter, r *http.Request) {
-	log.Print("Handler: GetRunning")
+	requestLogger(r).Debugf("Handler: GetRunning")
 
-	round, err := h.Rs.GetRunning()
+	round, err := h.Rs.GetRunning(r.URL.Query().Get("league"))
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
 		} else {
-			log.Printf("Failed to get current round, error: '%v'", err)
+			requestLogger(r).Errorf("Failed to get current round, error: '%v'", err)
 			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
 		return
@@ -110,14 +233,14 @@ func (h *RoundHandler) GetRunning(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RoundHandler) GetReviewPending(w http.ResponseWriter, r *http.Request) {
-	log.Print("Handler: GetReviewPending")
+	requestLogger(r).Debugf("Handler: GetReviewPending")
 
-	round, err := h.Rs.GetReviewPending()
+	round, err := h.Rs.GetReviewPending(r.URL.Query().Get("league"))
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
 		} else {
-			log.Printf("Failed to get review pending round, error: '%v'", err)
+			requestLogger(r).Errorf("Failed to get review pending round, error: '%v'", err)
 			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
 		return
@@ -127,14 +250,14 @@ func (h *RoundHandler) GetReviewPending(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *RoundHandler) GetReviewRunning(w http.ResponseWriter, r *http.Request) {
-	log.Print("Handler: GetReviewRunning")
+	requestLogger(r).Debugf("Handler: GetReviewRunning")
 
-	round, err := h.Rs.GetReviewRunning()
+	round, err := h.Rs.GetReviewRunning(r.URL.Query().Get("league"))
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
 		} else {
-			log.Printf("Failed to get review running round, error: '%v'", err)
+			requestLogger(r).Errorf("Failed to get review running round, error: '%v'", err)
 			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
 		return
@@ -144,14 +267,14 @@ func (h *RoundHandler) GetReviewRunning(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *RoundHandler) GetLast(w http.ResponseWriter, r *http.Request) {
-	log.Print("Handler: GetLast")
+	requestLogger(r).Debugf("Handler: GetLast")
 
-	round, err := h.Rs.GetLast()
+	round, err := h.Rs.GetLast(r.URL.Query().Get("league"))
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
 		} else {
-			log.Printf("Failed to get last, error: '%v'", err)
+			requestLogger(r).Errorf("Failed to get last, error: '%v'", err)
 			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
 		return
@@ -161,8 +284,6 @@ func (h *RoundHandler) GetLast(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RoundHandler) GetProblemDescriptors(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Handler: GetProblemDescriptors")
-
 	participantID := mux.Vars(r)["participant_id"]
 
 	desc, err := h.Rs.GetProblemDescriptors(participantID)
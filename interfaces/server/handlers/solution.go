@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
 
 	"mathbattle/models/mathbattle"
@@ -38,8 +38,11 @@ func (h *SolutionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if err == mathbattle.ErrNotFound {
 			ResponseJSON(w, http.StatusNotFound, nil)
-			return
+		} else {
+			requestLogger(r).Errorf("Failed to get solution %s, error: %v", ID, err)
+			ResponseJSON(w, http.StatusInternalServerError, nil)
 		}
+		return
 	}
 
 	ResponseJSON(w, http.StatusOK, solution)
@@ -49,14 +52,14 @@ func (h *SolutionHandler) Find(w http.ResponseWriter, r *http.Request) {
 	var findDescriptor mathbattle.FindDescriptor
 	err := json.NewDecoder(r.Body).Decode(&findDescriptor)
 	if err != nil {
-		log.Printf("Failed to decode findDescriptor, error: %v", err)
+		requestLogger(r).Errorf("Failed to decode findDescriptor, error: %v", err)
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
 	}
 
 	solutions, err := h.Ss.Find(findDescriptor)
 	if err != nil {
-		log.Printf("Failed to find solutions by descriptor, error: %v", err)
+		requestLogger(r).Errorf("Failed to find solutions by descriptor, error: %v", err)
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 		return
 	}
@@ -76,7 +79,14 @@ func (h *SolutionHandler) AppendPart(w http.ResponseWriter, r *http.Request) {
 
 	err = h.Ss.AppendPart(ID, part)
 	if err != nil {
-		ResponseJSON(w, http.StatusInternalServerError, nil)
+		switch err {
+		case mathbattle.ErrSolutionPartTooLarge:
+			ResponseJSON(w, http.StatusRequestEntityTooLarge, nil)
+		case mathbattle.ErrSolutionPartUnsupported:
+			ResponseJSON(w, http.StatusUnsupportedMediaType, nil)
+		default:
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
 		return
 	}
 
@@ -86,9 +96,13 @@ func (h *SolutionHandler) AppendPart(w http.ResponseWriter, r *http.Request) {
 func (h *SolutionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	ID := mux.Vars(r)["id"]
 
-	err := h.Ss.Delete(ID)
+	err := h.Ss.Delete(ID, actorID(r))
 	if err != nil {
-		ResponseJSON(w, http.StatusInternalServerError, nil)
+		if err == mathbattle.ErrNotFound {
+			ResponseJSON(w, http.StatusNotFound, nil)
+		} else {
+			ResponseJSON(w, http.StatusInternalServerError, nil)
+		}
 		return
 	}
 
@@ -106,3 +120,46 @@ func (h *SolutionHandler) GetProblemDescriptors(w http.ResponseWriter, r *http.R
 
 	ResponseJSON(w, http.StatusOK, desc)
 }
+
+func (h *SolutionHandler) JurySolutions(w http.ResponseWriter, r *http.Request) {
+	roundID := mux.Vars(r)["round_id"]
+	problemID := r.URL.Query().Get("problem_id")
+
+	solutions, err := h.Ss.JurySolutions(roundID, problemID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			ResponseJSON(w, http.StatusNotFound, nil)
+			return
+		}
+		requestLogger(r).Errorf("Failed to get solutions for jury, error: %v", err)
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, solutions)
+}
+
+func (h *SolutionHandler) MergedPDF(w http.ResponseWriter, r *http.Request) {
+	roundID := mux.Vars(r)["round_id"]
+	problemID := r.URL.Query().Get("problem_id")
+
+	content, err := h.Ss.MergedPDF(roundID, problemID)
+	if err != nil {
+		if err == mathbattle.ErrNotFound {
+			ResponseJSON(w, http.StatusNotFound, nil)
+			return
+		}
+		requestLogger(r).Errorf("Failed to build merged pdf, error: %v", err)
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	fileName := "round_" + roundID
+	if problemID != "" {
+		fileName += "_" + problemID
+	}
+	w.Header().Set("Content-Type", mathbattle.MimeTypePDF)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pdf\"", fileName))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
@@ -11,7 +11,7 @@ type StatHandler struct {
 }
 
 func (h *StatHandler) Stat(w http.ResponseWriter, r *http.Request) {
-	stat, err := h.Ss.Stat()
+	stat, err := h.Ss.Stat(r.URL.Query().Get("league"))
 	if err != nil {
 		ResponseJSON(w, http.StatusInternalServerError, nil)
 	}
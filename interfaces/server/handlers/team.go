@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mathbattle/models/mathbattle"
+
+	"github.com/gorilla/mux"
+)
+
+type TeamHandler struct {
+	Ts mathbattle.TeamService
+}
+
+// responseTeamError - ошибки TeamService, которые бот должен отличать друг от друга
+func responseTeamError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	switch err {
+	case mathbattle.ErrNotFound:
+		ResponseJSON(w, http.StatusNotFound, nil)
+	case mathbattle.ErrWrongUserInput:
+		ResponseJSON(w, http.StatusBadRequest, nil)
+	case mathbattle.ErrTeamLocked:
+		ResponseJSON(w, http.StatusLocked, nil)
+	default:
+		requestLogger(r).Errorf("Failed to %s, error: '%v'", action, err)
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+	}
+}
+
+func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var team mathbattle.Team
+	err := json.NewDecoder(r.Body).Decode(&team)
+	if err != nil {
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	team, err = h.Ts.Create(team)
+	if err != nil {
+		responseTeamError(w, r, err, "create team")
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, team)
+}
+
+func (h *TeamHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ID := mux.Vars(r)["id"]
+
+	team, err := h.Ts.Get(ID)
+	if err != nil {
+		responseTeamError(w, r, err, "get team "+ID)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, team)
+}
+
+func (h *TeamHandler) GetByMember(w http.ResponseWriter, r *http.Request) {
+	participantID := mux.Vars(r)["participant_id"]
+
+	team, err := h.Ts.GetByMember(participantID)
+	if err != nil {
+		responseTeamError(w, r, err, "get team of participant "+participantID)
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, team)
+}
+
+func (h *TeamHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	teams, err := h.Ts.GetAll()
+	if err != nil {
+		responseTeamError(w, r, err, "get teams")
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, teams)
+}
+
+func (h *TeamHandler) Join(w http.ResponseWriter, r *http.Request) {
+	var order mathbattle.JoinOrder
+	err := json.NewDecoder(r.Body).Decode(&order)
+	if err != nil {
+		ResponseJSON(w, http.StatusInternalServerError, nil)
+		return
+	}
+
+	team, err := h.Ts.Join(order)
+	if err != nil {
+		responseTeamError(w, r, err, "join team")
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, team)
+}
+
+func (h *TeamHandler) Leave(w http.ResponseWriter, r *http.Request) {
+	participantID := mux.Vars(r)["participant_id"]
+
+	if err := h.Ts.Leave(participantID); err != nil {
+		responseTeamError(w, r, err, "leave team")
+		return
+	}
+
+	ResponseJSON(w, http.StatusOK, nil)
+}
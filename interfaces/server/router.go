@@ -4,17 +4,17 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 
 	"mathbattle/infrastructure"
 	"mathbattle/interfaces/server/handlers"
+	"mathbattle/libs/mlog"
+	"mathbattle/models/mathbattle"
 
-	ghandlers "github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
 
 func notFound(w http.ResponseWriter, r *http.Request) {
-	log.Printf("NOT FOUND HANDLER FOR URL: %v", r.URL)
+	mlog.FromContext(r.Context()).Warnf("Not found handler for url: %v", r.URL)
 
 	w.WriteHeader(http.StatusNotFound)
 }
@@ -26,61 +26,118 @@ func homePage(w http.ResponseWriter, r *http.Request) {
 func Start(container infrastructure.Container) {
 	myRouter := mux.NewRouter()
 
-	myRouter.NotFoundHandler = http.HandlerFunc(notFound)
+	// Каждый запрос пишется в лог со своим ID, см. handlers.RequestLogger
+	requestLogger := handlers.RequestLogger{Logger: container.Logger()}
+	myRouter.Use(requestLogger.Middleware)
+	myRouter.NotFoundHandler = requestLogger.Middleware(http.HandlerFunc(notFound))
+
+	// Сервер доступен только тем, у кого есть api_token, см. handlers.TokenAuth
+	if container.Config().APIToken == "" {
+		log.Fatal("api_token is not set in config")
+	}
+	tokenAuth := handlers.TokenAuth{Token: container.Config().APIToken}
+	myRouter.Use(tokenAuth.Middleware)
 
 	// Home Page
-	myRouter.Handle("/", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(homePage)))
+	myRouter.HandleFunc("/", homePage)
+
+	// Действия организаторов доступны только пользователям с нужным правом, см. mathbattle.ActorHeader
+	guard := handlers.PermissionGuard{Users: container.UserRepository()}
+	// Своё участник меняет сам, чужое - только организаторы
+	owners := handlers.Owners{
+		Participants: container.ParticipantRepository(),
+		Solutions:    container.SolutionRepository(),
+		Reviews:      container.ReviewRepository(),
+		Teams:        container.TeamRepository(),
+	}
 
 	// Stat
 	sh := handlers.StatHandler{Ss: container.StatService()}
-	myRouter.HandleFunc("/stat", sh.Stat)
+	myRouter.HandleFunc("/stat", guard.Require(mathbattle.PermissionViewStat, sh.Stat))
 
 	// Rounds
 	rh := handlers.RoundHandler{Rs: container.RoundService()}
-	myRouter.HandleFunc("/rounds/start", rh.StartNew).Methods("POST")
-	myRouter.HandleFunc("/rounds/start_review", rh.StartReviewStage).Methods("POST")
+	myRouter.HandleFunc("/rounds/start", guard.Require(mathbattle.PermissionManageRounds, rh.StartNew)).Methods("POST")
+	myRouter.HandleFunc("/rounds/start_review", guard.Require(mathbattle.PermissionManageRounds, rh.StartReviewStage)).Methods("POST")
+	myRouter.HandleFunc("/rounds/reassign_review", guard.Require(mathbattle.PermissionModerate, rh.ReassignReview)).Methods("POST")
+	myRouter.HandleFunc("/rounds/reassign_options", guard.Require(mathbattle.PermissionModerate, rh.ReassignOptions)).Methods("GET")
+	myRouter.HandleFunc("/rounds/publish_results/{id}", guard.Require(mathbattle.PermissionPublish, rh.PublishResults)).Methods("POST")
+	myRouter.HandleFunc("/rounds/reveal_identities", guard.Require(mathbattle.PermissionPublish, rh.RevealIdentities)).Methods("POST")
 	myRouter.HandleFunc("/rounds", rh.GetAll).Methods("GET")
 	myRouter.HandleFunc("/rounds/running", rh.GetRunning).Methods("GET")
 	myRouter.HandleFunc("/rounds/review_pending", rh.GetReviewPending).Methods("GET")
 	myRouter.HandleFunc("/rounds/review_running", rh.GetReviewRunning).Methods("GET")
 	myRouter.HandleFunc("/rounds/last", rh.GetLast).Methods("GET")
-	myRouter.HandleFunc("/rounds/review_stage_distribution", rh.GetReivewStageDistribution).Methods("GET")
+	myRouter.HandleFunc("/rounds/review_stage_distribution", guard.Require(mathbattle.PermissionManageRounds, rh.GetReivewStageDistribution)).Methods("GET")
 	myRouter.HandleFunc("/rounds/problem_descriptors/{participant_id}", rh.GetProblemDescriptors).Methods("GET")
 	myRouter.HandleFunc("/rounds/{id}", rh.GetByID).Methods("GET")
 
 	// Participants
 	ph := handlers.ParticipantHandler{Ps: container.ParticipantService()}
-	myRouter.Handle("/participants", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(ph.Store))).Methods("POST")
-	myRouter.Handle("/participants", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(ph.GetAll))).Methods("GET")
-	myRouter.Handle("/participants/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(ph.GetByID))).Methods("GET")
-	myRouter.Handle("/participants/telegram/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(ph.GetByTelegramID))).Methods("GET")
-	myRouter.Handle("/participants/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(ph.Update))).Methods("PUT")
-	myRouter.Handle("/participants/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(ph.Delete))).Methods("DELETE")
-	myRouter.Handle("/participants/unsubscribe/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(ph.Unsubscribe))).Methods("POST")
+	myRouter.HandleFunc("/participants", ph.Store).Methods("POST")
+	myRouter.HandleFunc("/participants", guard.Require(mathbattle.PermissionModerate, ph.GetAll)).Methods("GET")
+	myRouter.HandleFunc("/participants/{id}", ph.GetByID).Methods("GET")
+	myRouter.HandleFunc("/participants/telegram/{id}", ph.GetByTelegramID).Methods("GET")
+	myRouter.HandleFunc("/participants/{id}", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.Participant, ph.Update)).Methods("PUT")
+	myRouter.HandleFunc("/participants/{id}", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.Participant, ph.Delete)).Methods("DELETE")
+	myRouter.HandleFunc("/participants/unsubscribe/{id}", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.Participant, ph.Unsubscribe)).Methods("POST")
+	myRouter.HandleFunc("/participants/promote_grades/{year}", guard.Require(mathbattle.PermissionModerate, ph.PromoteGrades)).Methods("POST")
+
+	// Teams
+	th := handlers.TeamHandler{Ts: container.TeamService()}
+	myRouter.HandleFunc("/teams", th.Create).Methods("POST")
+	myRouter.HandleFunc("/teams", th.GetAll).Methods("GET")
+	myRouter.HandleFunc("/teams/join", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.BodyParticipant, th.Join)).Methods("POST")
+	myRouter.HandleFunc("/teams/leave/{participant_id}",
+		guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.ParticipantVar("participant_id"), th.Leave)).Methods("POST")
+	myRouter.HandleFunc("/teams/member/{participant_id}", th.GetByMember).Methods("GET")
+	myRouter.HandleFunc("/teams/{id}", th.Get).Methods("GET")
+
+	// Battles
+	bh := handlers.BattleHandler{Bs: container.BattleService()}
+	myRouter.HandleFunc("/battles", guard.Require(mathbattle.PermissionManageRounds, bh.Start)).Methods("POST")
+	myRouter.HandleFunc("/battles/move", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.BodyParticipant, bh.Move)).Methods("POST")
+	myRouter.HandleFunc("/battles/judge", guard.Require(mathbattle.PermissionJudge, bh.Judge)).Methods("POST")
+	myRouter.HandleFunc("/battles/round/{round_id}", bh.GetByRound).Methods("GET")
+	myRouter.HandleFunc("/battles/running/{participant_id}", bh.GetRunning).Methods("GET")
+	myRouter.HandleFunc("/battles/{id}", bh.Get).Methods("GET")
 
 	// Solutions
 	slh := handlers.SolutionHandler{Ss: container.SolutionService()}
-	myRouter.Handle("/solutions", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(slh.Create))).Methods("POST")
-	myRouter.Handle("/solutions/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(slh.GetByID))).Methods("GET")
-	myRouter.Handle("/solutions/find/descriptor", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(slh.Find))).Methods("GET")
-	myRouter.Handle("/solutions/append_part/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(slh.AppendPart))).Methods("POST")
-	myRouter.Handle("/solutions/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(slh.Delete))).Methods("DELETE")
-	myRouter.Handle("/solutions/descriptors/{participant_id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(slh.GetProblemDescriptors))).Methods("GET")
+	myRouter.HandleFunc("/solutions", slh.Create).Methods("POST")
+	myRouter.HandleFunc("/solutions/{id}", guard.RequireOwnerOr(mathbattle.PermissionJudge, owners.SolutionReader, slh.GetByID)).Methods("GET")
+	myRouter.HandleFunc("/solutions/find/descriptor", guard.RequireOwnerOr(mathbattle.PermissionJudge, owners.SolutionFind, slh.Find)).Methods("GET")
+	myRouter.HandleFunc("/solutions/append_part/{id}", slh.AppendPart).Methods("POST")
+	myRouter.HandleFunc("/solutions/{id}", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.Solution, slh.Delete)).Methods("DELETE")
+	myRouter.HandleFunc("/solutions/descriptors/{participant_id}", slh.GetProblemDescriptors).Methods("GET")
+	myRouter.HandleFunc("/solutions/jury/{round_id}", guard.Require(mathbattle.PermissionJudge, slh.JurySolutions)).Methods("GET")
+	myRouter.HandleFunc("/solutions/merged_pdf/{round_id}", guard.Require(mathbattle.PermissionJudge, slh.MergedPDF)).Methods("GET")
 
 	// Reviews
 	rs := handlers.ReviewHandler{Rs: container.ReviewService()}
-	myRouter.Handle("/reviews", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(rs.Create))).Methods("POST")
-	myRouter.Handle("/reviews/find/descriptor", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(rs.FindMany))).Methods("GET")
-	myRouter.Handle("/reviews/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(rs.Delete))).Methods("DELETE")
-	myRouter.Handle("/reviews/descriptors/{participant_id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(rs.GetSolutionDescriptors))).Methods("GET")
+	myRouter.HandleFunc("/reviews", rs.Create).Methods("POST")
+	myRouter.HandleFunc("/reviews/find/descriptor", guard.RequireOwnerOr(mathbattle.PermissionJudge, owners.ReviewFind, rs.FindMany)).Methods("GET")
+	myRouter.HandleFunc("/reviews/{id}", guard.RequireOwnerOr(mathbattle.PermissionModerate, owners.Review, rs.Delete)).Methods("DELETE")
+	myRouter.HandleFunc("/reviews/descriptors/{participant_id}", rs.GetSolutionDescriptors).Methods("GET")
 
 	// Problems
 	prh := handlers.ProblemHandler{Ps: container.ProblemService()}
-	myRouter.Handle("/problems/{id}", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(prh.GetByID))).Methods("GET")
+	myRouter.HandleFunc("/problems", guard.Require(mathbattle.PermissionManageRounds, prh.GetAll)).Methods("GET")
+	myRouter.HandleFunc("/problems/{id}", prh.GetByID).Methods("GET")
 
 	// Postman
 	psth := handlers.PostmanHandler{Ps: container.Postman()}
-	myRouter.Handle("/postman/send_to_users", ghandlers.LoggingHandler(os.Stdout, http.HandlerFunc(psth.SendToUsers)))
+	myRouter.HandleFunc("/postman/send_to_users", guard.Require(mathbattle.PermissionModerate, psth.SendToUsers)).Methods("POST")
+
+	// Roles
+	roh := handlers.RoleHandler{Rs: container.RoleService()}
+	myRouter.HandleFunc("/roles", guard.Require(mathbattle.PermissionManageRoles, roh.Grant)).Methods("POST")
+	myRouter.HandleFunc("/roles", guard.Require(mathbattle.PermissionManageRoles, roh.GetStaff)).Methods("GET")
+
+	// Audit
+	auh := handlers.AuditHandler{As: container.AuditService()}
+	myRouter.HandleFunc("/audit", guard.Require(mathbattle.PermissionViewAudit, auh.Find)).Methods("GET")
+	myRouter.HandleFunc("/audit/export", guard.Require(mathbattle.PermissionViewAudit, auh.ExportCSV)).Methods("GET")
 
 	log.Fatal(http.ListenAndServe(container.Config().APIUrl, myRouter))
 }
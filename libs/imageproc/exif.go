@@ -0,0 +1,81 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const exifOrientationTag = 0x0112
+
+// ReadOrientation возвращает EXIF Orientation (1-8) из jpeg. 1 - если тега нет или файл не jpeg
+func ReadOrientation(content []byte) int {
+	if len(content) < 4 || content[0] != 0xFF || content[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(content) {
+		if content[pos] != 0xFF {
+			return 1
+		}
+		marker := content[pos+1]
+		// Дальше начинаются сами данные изображения, EXIF в них не бывает
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+
+		segmentLength := int(binary.BigEndian.Uint16(content[pos+2 : pos+4]))
+		segmentEnd := pos + 2 + segmentLength
+		if segmentLength < 2 || segmentEnd > len(content) {
+			return 1
+		}
+
+		segment := content[pos+4 : segmentEnd]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return orientationFromTIFF(segment[6:])
+		}
+
+		pos = segmentEnd
+	}
+
+	return 1
+}
+
+func orientationFromTIFF(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifdOffset := int(order.Uint32(tiff[4:8]))
+	if ifdOffset+2 > len(tiff) {
+		return 1
+	}
+
+	entriesCount := int(order.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	for i := 0; i < entriesCount; i++ {
+		entry := ifdOffset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) == exifOrientationTag {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
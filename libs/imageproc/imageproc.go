@@ -0,0 +1,229 @@
+// imageproc приводит фотографии решений к единому виду: поворот по EXIF, уменьшение, jpeg.
+// Только стандартная библиотека
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // регистрирует декодер png для image.Decode
+)
+
+// DefaultMaxPixels - предел ширина*высота исходного изображения, если MaxPixels не задан.
+// Распакованное изображение занимает 4 байта на пиксель, а сжатое может весить совсем немного
+const DefaultMaxPixels = 40 * 1000 * 1000
+
+var ErrTooManyPixels = errors.New("image dimensions are too large")
+
+type Options struct {
+	MaxPixels     int     // предел ширина*высота исходного, 0 - DefaultMaxPixels
+	MaxSize       int     // максимальная длина большей стороны, 0 - не уменьшать
+	Quality       int     // качество jpeg, 1-100
+	Grayscale     bool    // перевести в оттенки серого
+	Contrast      float64 // коэффициент контраста, 1 или 0 - не менять
+	ThumbnailSize int     // большая сторона превью, 0 - превью не нужно
+}
+
+type Result struct {
+	Processed []byte // jpeg
+	Thumbnail []byte // jpeg, nil если ThumbnailSize == 0
+}
+
+// Process декодирует jpeg или png, применяет опции и кодирует обратно в jpeg
+func Process(content []byte, opts Options) (Result, error) {
+	result := Result{}
+
+	// Размеры из заголовка проверяем до декодирования, чтобы не выделять память под огромную картинку
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return result, err
+	}
+	maxPixels := opts.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > int64(maxPixels) {
+		return result, ErrTooManyPixels
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return result, err
+	}
+
+	img := toRGBA(decoded)
+	img = applyOrientation(img, ReadOrientation(content))
+	img = Downscale(img, opts.MaxSize)
+	if opts.Contrast != 0 && opts.Contrast != 1 {
+		adjustContrast(img, opts.Contrast)
+	}
+
+	var final image.Image = img
+	if opts.Grayscale {
+		final = toGray(img)
+	}
+
+	result.Processed, err = encodeJPEG(final, opts.Quality)
+	if err != nil {
+		return result, err
+	}
+
+	if opts.ThumbnailSize > 0 {
+		downscaled := Downscale(img, opts.ThumbnailSize)
+		var thumbnail image.Image = downscaled
+		if opts.Grayscale {
+			thumbnail = toGray(downscaled)
+		}
+		result.Thumbnail, err = encodeJPEG(thumbnail, opts.Quality)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	if quality <= 0 || quality > 100 {
+		quality = jpeg.DefaultQuality
+	}
+
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, isRGBA := img.(*image.RGBA); isRGBA && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	result := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(result, result.Rect, img, bounds.Min, draw.Src)
+	return result
+}
+
+// applyOrientation поворачивает и отражает изображение так, чтобы оно выглядело как задумано при съёмке
+func applyOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := w, h
+	// 5-8: стороны меняются местами
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	result := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // отражение по горизонтали
+				sx, sy = w-1-x, y
+			case 3: // поворот на 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // отражение по вертикали
+				sx, sy = x, h-1-y
+			case 5: // транспонирование
+				sx, sy = y, x
+			case 6: // поворот на 90 по часовой
+				sx, sy = y, h-1-x
+			case 7: // транспонирование относительно побочной диагонали
+				sx, sy = w-1-y, h-1-x
+			case 8: // поворот на 90 против часовой
+				sx, sy = w-1-y, x
+			}
+
+			src := img.PixOffset(sx, sy)
+			dst := result.PixOffset(x, y)
+			copy(result.Pix[dst:dst+4], img.Pix[src:src+4])
+		}
+	}
+
+	return result
+}
+
+// Downscale уменьшает изображение так, чтобы большая сторона была не больше maxSize.
+// Каждый пиксель результата - среднее по соответствующему прямоугольнику исходного
+func Downscale(img *image.RGBA, maxSize int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if maxSize <= 0 || (w <= maxSize && h <= maxSize) {
+		return img
+	}
+
+	dw, dh := maxSize, h*maxSize/w
+	if h > w {
+		dw, dh = w*maxSize/h, maxSize
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	result := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0, sy1 := y*h/dh, (y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			sx0, sx1 := x*w/dw, (x+1)*w/dw
+
+			var sum [4]int
+			for sy := sy0; sy < sy1; sy++ {
+				offset := img.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(img.Pix[offset+c])
+					}
+					offset += 4
+				}
+			}
+
+			count := (sy1 - sy0) * (sx1 - sx0)
+			dst := result.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				result.Pix[dst+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+
+	return result
+}
+
+func adjustContrast(img *image.RGBA, factor float64) {
+	table := [256]uint8{}
+	for v := 0; v < 256; v++ {
+		adjusted := (float64(v)-128)*factor + 128
+		if adjusted < 0 {
+			adjusted = 0
+		}
+		if adjusted > 255 {
+			adjusted = 255
+		}
+		table[v] = uint8(adjusted + 0.5)
+	}
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = table[img.Pix[i]]
+		img.Pix[i+1] = table[img.Pix[i+1]]
+		img.Pix[i+2] = table[img.Pix[i+2]]
+	}
+}
+
+func toGray(img *image.RGBA) *image.Gray {
+	result := image.NewGray(img.Rect)
+	for i, j := 0, 0; i < len(img.Pix); i, j = i+4, j+1 {
+		// Коэффициенты яркости ITU-R BT.601, как в color.GrayModel
+		y := (19595*uint32(img.Pix[i]) + 38470*uint32(img.Pix[i+1]) + 7471*uint32(img.Pix[i+2]) + 1<<15) >> 16
+		result.Pix[j] = uint8(y)
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// jpegWithOrientation вставляет в jpeg минимальный EXIF сегмент с одним тегом Orientation
+func jpegWithOrientation(req *require.Assertions, img image.Image, orientation uint16) []byte {
+	buf := bytes.Buffer{}
+	req.NoError(jpeg.Encode(&buf, img, nil))
+	encoded := buf.Bytes()
+
+	tiff := bytes.Buffer{}
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(exifOrientationTag))
+	binary.Write(&tiff, binary.BigEndian, uint16(3)) // SHORT
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = append(app1, byte((len(segment)+2)>>8), byte(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	result := append([]byte{}, encoded[:2]...)
+	result = append(result, app1...)
+	return append(result, encoded[2:]...)
+}
+
+func TestReadOrientation(t *testing.T) {
+	req := require.New(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	req.Equal(6, ReadOrientation(jpegWithOrientation(req, img, 6)))
+	req.Equal(1, ReadOrientation([]byte("not an image")))
+
+	buf := bytes.Buffer{}
+	req.NoError(jpeg.Encode(&buf, img, nil))
+	req.Equal(1, ReadOrientation(buf.Bytes()))
+}
+
+func TestApplyOrientation(t *testing.T) {
+	req := require.New(t)
+
+	// Два пикселя в ряд: красный слева, синий справа
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.RGBA{0, 0, 255, 255})
+
+	rotated := applyOrientation(img, 6) // на 90 по часовой: красный сверху
+	req.Equal(image.Rect(0, 0, 1, 2), rotated.Rect)
+	req.Equal(color.RGBA{255, 0, 0, 255}, rotated.RGBAAt(0, 0))
+	req.Equal(color.RGBA{0, 0, 255, 255}, rotated.RGBAAt(0, 1))
+
+	rotated = applyOrientation(img, 8) // против часовой: синий сверху
+	req.Equal(color.RGBA{0, 0, 255, 255}, rotated.RGBAAt(0, 0))
+
+	flipped := applyOrientation(img, 2)
+	req.Equal(color.RGBA{0, 0, 255, 255}, flipped.RGBAAt(0, 0))
+}
+
+func TestDownscale(t *testing.T) {
+	req := require.New(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 100; y++ {
+			if x%2 == 0 {
+				img.Set(x, y, color.RGBA{200, 200, 200, 255})
+			} else {
+				img.Set(x, y, color.RGBA{100, 100, 100, 255})
+			}
+		}
+	}
+
+	result := Downscale(img, 200)
+	req.Equal(image.Rect(0, 0, 200, 50), result.Rect)
+	req.Equal(color.RGBA{150, 150, 150, 255}, result.RGBAAt(10, 10))
+
+	req.Equal(img, Downscale(img, 1000))
+}
+
+func TestProcess(t *testing.T) {
+	req := require.New(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for x := 0; x < 300; x++ {
+		for y := 0; y < 200; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 50, 255})
+		}
+	}
+	buf := bytes.Buffer{}
+	req.NoError(png.Encode(&buf, img))
+
+	result, err := Process(buf.Bytes(), Options{MaxSize: 150, Quality: 80, Grayscale: true, Contrast: 1.5, ThumbnailSize: 30})
+	req.NoError(err)
+
+	processed, format, err := image.Decode(bytes.NewReader(result.Processed))
+	req.NoError(err)
+	req.Equal("jpeg", format)
+	req.Equal(image.Rect(0, 0, 150, 100), processed.Bounds())
+	_, isGray := processed.(*image.Gray)
+	req.True(isGray)
+
+	thumbnail, _, err := image.Decode(bytes.NewReader(result.Thumbnail))
+	req.NoError(err)
+	req.Equal(image.Rect(0, 0, 30, 20), thumbnail.Bounds())
+
+	rotated, err := Process(jpegWithOrientation(req, img, 6), Options{})
+	req.NoError(err)
+	rotatedImg, _, err := image.Decode(bytes.NewReader(rotated.Processed))
+	req.NoError(err)
+	req.Equal(image.Rect(0, 0, 200, 300), rotatedImg.Bounds())
+	req.Nil(rotated.Thumbnail)
+
+	_, err = Process([]byte("not an image"), Options{})
+	req.Error(err)
+}
+
+// pngWithSize подменяет размеры в заголовке IHDR, сами данные остаются от img
+func pngWithSize(req *require.Assertions, img image.Image, width uint32, height uint32) []byte {
+	buf := bytes.Buffer{}
+	req.NoError(png.Encode(&buf, img))
+	encoded := buf.Bytes()
+
+	// Сигнатура 8 байт, затем длина и тип чанка IHDR
+	ihdr := encoded[8+4 : 8+4+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	binary.BigEndian.PutUint32(encoded[8+4+4+13:], crc32.ChecksumIEEE(ihdr))
+	return encoded
+}
+
+func TestProcessRejectsTooManyPixels(t *testing.T) {
+	req := require.New(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 30, 20))
+	_, err := Process(pngWithSize(req, img, 100000, 100000), Options{})
+	req.Equal(ErrTooManyPixels, err)
+
+	buf := bytes.Buffer{}
+	req.NoError(png.Encode(&buf, img))
+	_, err = Process(buf.Bytes(), Options{MaxPixels: 500})
+	req.Equal(ErrTooManyPixels, err)
+	_, err = Process(buf.Bytes(), Options{MaxPixels: 600})
+	req.NoError(err)
+}
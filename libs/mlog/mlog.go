@@ -0,0 +1,224 @@
+// Package mlog - логгер с уровнями и полями. Пишет строку "время уровень сообщение ключ=значение"
+// или json объект на каждую запись
+package mlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel - уровень по названию, пусто - info
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return LevelInfo, nil
+	}
+
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level '%s'", name)
+}
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat - формат по названию, пусто - text
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return FormatText, fmt.Errorf("unknown log format '%s'", name)
+	}
+}
+
+// output общий у логгера и всех логгеров, полученных из него через With
+type output struct {
+	mutex  sync.Mutex
+	w      io.Writer
+	level  Level
+	format Format
+}
+
+type Logger struct {
+	out *output
+	// Пары ключ, значение в порядке добавления
+	fields []interface{}
+}
+
+func New(w io.Writer, level Level, format Format) *Logger {
+	return &Logger{out: &output{w: w, level: level, format: format}}
+}
+
+// With - логгер, добавляющий к каждой записи поля keysAndValues: ключ, значение, ключ, значение...
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	if len(keysAndValues)%2 != 0 {
+		keysAndValues = append(keysAndValues, "")
+	}
+
+	fields := make([]interface{}, 0, len(l.fields)+len(keysAndValues))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keysAndValues...)
+	return &Logger{out: l.out, fields: fields}
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.log(LevelDebug, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(LevelInfo, format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(LevelWarn, format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(LevelError, format, args...)
+}
+
+func (l *Logger) IsEnabled(level Level) bool {
+	return level >= l.out.level
+}
+
+func (l *Logger) log(level Level, format string, args ...interface{}) {
+	if !l.IsEnabled(level) {
+		return
+	}
+
+	message := format
+	if len(args) != 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+
+	var buf bytes.Buffer
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if l.out.format == FormatJSON {
+		record := map[string]interface{}{}
+		for i := 0; i < len(l.fields); i += 2 {
+			record[fmt.Sprint(l.fields[i])] = jsonValue(l.fields[i+1])
+		}
+		record["time"] = now
+		record["level"] = level.String()
+		record["msg"] = message
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"time": now, "level": level.String(), "msg": message})
+		}
+		buf.Write(data)
+	} else {
+		buf.WriteString(now)
+		buf.WriteString(" ")
+		buf.WriteString(strings.ToUpper(level.String()))
+		buf.WriteString(" ")
+		buf.WriteString(message)
+		for i := 0; i < len(l.fields); i += 2 {
+			buf.WriteString(" ")
+			buf.WriteString(fmt.Sprint(l.fields[i]))
+			buf.WriteString("=")
+			buf.WriteString(textValue(l.fields[i+1]))
+		}
+	}
+	buf.WriteString("\n")
+
+	l.out.mutex.Lock()
+	defer l.out.mutex.Unlock()
+	_, _ = l.out.w.Write(buf.Bytes())
+}
+
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+// textValue - значение поля, в кавычках, если в нём есть пробелы
+func textValue(value interface{}) string {
+	str := fmt.Sprint(jsonValue(value))
+	if str == "" || strings.ContainsAny(str, " =\"\t\n") {
+		return strconv.Quote(str)
+	}
+	return str
+}
+
+// Writer - io.Writer для стандартного пакета log: каждая строка становится записью логгера.
+// Строки, начинающиеся с "Failed", пишутся с уровнем error, остальные - info
+func (l *Logger) Writer() io.Writer {
+	return stdWriter{logger: l}
+}
+
+type stdWriter struct {
+	logger *Logger
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		level := LevelInfo
+		if strings.HasPrefix(line, "Failed") {
+			level = LevelError
+		}
+		w.logger.log(level, "%s", line)
+	}
+	return len(p), nil
+}
+
+var (
+	defaultMutex  sync.RWMutex
+	defaultLogger = New(os.Stderr, LevelInfo, FormatText)
+)
+
+// Default - логгер для кода, которому логгер не передали
+func Default() *Logger {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultLogger
+}
+
+func SetDefault(l *Logger) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultLogger = l
+}
@@ -0,0 +1,67 @@
+package mlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextFormat(t *testing.T) {
+	req := require.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, LevelInfo, FormatText).With("request_id", "abc", "command", "/start round")
+	logger.Debugf("hidden")
+	logger.Infof("handled in %d ms", 5)
+	logger.With("error", errors.New("timeout")).Errorf("failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	req.Len(lines, 2)
+	req.True(strings.HasSuffix(lines[0], ` INFO handled in 5 ms request_id=abc command="/start round"`), lines[0])
+	req.True(strings.HasSuffix(lines[1], ` ERROR failed request_id=abc command="/start round" error=timeout`), lines[1])
+}
+
+func TestJSONFormat(t *testing.T) {
+	req := require.New(t)
+
+	var buf bytes.Buffer
+	New(&buf, LevelDebug, FormatJSON).With("chat_id", int64(42)).Debugf("step %d", 2)
+
+	record := map[string]interface{}{}
+	req.Nil(json.Unmarshal(buf.Bytes(), &record))
+	req.Equal("debug", record["level"])
+	req.Equal("step 2", record["msg"])
+	req.Equal(float64(42), record["chat_id"])
+}
+
+func TestStdWriter(t *testing.T) {
+	req := require.New(t)
+
+	var buf bytes.Buffer
+	std := log.New(New(&buf, LevelWarn, FormatText).Writer(), "", 0)
+	std.Printf("Bot started")
+	std.Printf("Failed to send message")
+
+	req.Equal(1, strings.Count(buf.String(), "\n"))
+	req.Contains(buf.String(), " ERROR Failed to send message")
+}
+
+func TestParseLevel(t *testing.T) {
+	req := require.New(t)
+
+	level, err := ParseLevel("WARN")
+	req.Nil(err)
+	req.Equal(LevelWarn, level)
+
+	level, err = ParseLevel("")
+	req.Nil(err)
+	req.Equal(LevelInfo, level)
+
+	_, err = ParseLevel("verbose")
+	req.NotNil(err)
+}
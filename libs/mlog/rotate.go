@@ -0,0 +1,136 @@
+package mlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RotatingFile - файл лога, который переименовывается в "имя.время" и начинается заново,
+// когда становится больше MaxSize байт или старше MaxAge. Из переименованных файлов хранятся MaxBackups последних
+type RotatingFile struct {
+	Path string
+	// 0 - не ограничивать размер
+	MaxSize int64
+	// 0 - не ограничивать возраст
+	MaxAge time.Duration
+	// 0 - хранить все старые файлы
+	MaxBackups int
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.isFull(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) isFull(writeSize int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.MaxSize > 0 && f.size+writeSize > f.MaxSize {
+		return true
+	}
+	return f.MaxAge > 0 && time.Since(f.openedAt) > f.MaxAge
+}
+
+// open открывает файл для дописывания. Возраст файла, оставшегося с прошлого запуска, считается от его изменения
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0777); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	if f.size != 0 {
+		f.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	backupPath := fmt.Sprintf("%s.%s", f.Path, time.Now().UTC().Format("20060102-150405.000"))
+	if err := os.Rename(f.Path, backupPath); err != nil {
+		return err
+	}
+
+	if err := f.removeOldBackups(); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) removeOldBackups() error {
+	if f.MaxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(f.Path + ".*")
+	if err != nil {
+		return err
+	}
+
+	// Время в имени файла сортируется как строка
+	sort.Strings(backups)
+	if len(backups) <= f.MaxBackups {
+		return nil
+	}
+	for _, backup := range backups[:len(backups)-f.MaxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
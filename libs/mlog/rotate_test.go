@@ -0,0 +1,62 @@
+package mlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileBySize(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "mlog")
+	req.Nil(err)
+	defer os.RemoveAll(dir)
+
+	f := &RotatingFile{Path: filepath.Join(dir, "bot.log"), MaxSize: 10, MaxBackups: 2}
+	defer f.Close()
+	for i := 0; i < 5; i++ {
+		_, err := f.Write([]byte("12345678\n"))
+		req.Nil(err)
+		// Имена старых файлов различаются по времени с точностью до миллисекунды
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "bot.log.*"))
+	req.Nil(err)
+	req.Len(backups, 2)
+
+	content, err := ioutil.ReadFile(f.Path)
+	req.Nil(err)
+	req.Equal("12345678\n", string(content))
+}
+
+func TestRotatingFileByAge(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "mlog")
+	req.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "server.log")
+	req.Nil(ioutil.WriteFile(path, []byte("old\n"), 0666))
+	old := time.Now().Add(-48 * time.Hour)
+	req.Nil(os.Chtimes(path, old, old))
+
+	f := &RotatingFile{Path: path, MaxAge: 24 * time.Hour}
+	defer f.Close()
+	_, err = f.Write([]byte("new\n"))
+	req.Nil(err)
+
+	content, err := ioutil.ReadFile(path)
+	req.Nil(err)
+	req.Equal("new\n", string(content))
+
+	backups, err := filepath.Glob(path + ".*")
+	req.Nil(err)
+	req.Len(backups, 1)
+}
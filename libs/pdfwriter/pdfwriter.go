@@ -0,0 +1,182 @@
+// pdfwriter собирает простой pdf из текстовых страниц и страниц с jpeg картинками.
+// Текст пишется стандартным шрифтом Helvetica, поэтому поддерживается только ASCII
+package pdfwriter
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"strings"
+)
+
+// Размер страницы A4 в пунктах
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+	margin     = 36.0
+)
+
+type object struct {
+	dict   string
+	stream []byte
+}
+
+type Document struct {
+	objects []object // объект N хранится в objects[N-1]
+	pages   []int
+}
+
+// Объекты 1 и 2 - каталог и дерево страниц, их содержимое известно только в конце
+func New() *Document {
+	return &Document{objects: []object{{}, {}}}
+}
+
+func (d *Document) add(obj object) int {
+	d.objects = append(d.objects, obj)
+	return len(d.objects)
+}
+
+func (d *Document) addPage(content string, resources string) {
+	contentID := d.add(object{dict: "<<>>", stream: []byte(content)})
+	pageID := d.add(object{dict: fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources %s /Contents %d 0 R >>",
+		PageWidth, PageHeight, resources, contentID)})
+	d.pages = append(d.pages, pageID)
+}
+
+// AddTextPage - страница со строками текста сверху вниз. Первая строка крупнее, как заголовок
+func (d *Document) AddTextPage(lines []string) {
+	content := strings.Builder{}
+	content.WriteString("BT\n")
+	y := PageHeight - margin - 24
+	for i, line := range lines {
+		size := 14
+		if i == 0 {
+			size = 24
+		}
+		fmt.Fprintf(&content, "/F1 %d Tf 1 0 0 1 %.0f %.0f Tm (%s) Tj\n", size, margin, y, escapeText(line))
+		y -= float64(size) * 1.5
+	}
+	content.WriteString("ET\n")
+
+	d.addPage(content.String(), "<< /Font << /F1 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica >> >> >>")
+}
+
+// AddJPEGPage - страница с картинкой, вписанной в поля с сохранением пропорций
+func (d *Document) AddJPEGPage(content []byte) error {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	colorSpace := "/DeviceRGB"
+	switch colorComponents(content) {
+	case 1:
+		colorSpace = "/DeviceGray"
+	case 4:
+		colorSpace = "/DeviceCMYK"
+	}
+
+	imageID := d.add(object{
+		dict: fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode >>",
+			cfg.Width, cfg.Height, colorSpace),
+		stream: content,
+	})
+
+	maxWidth, maxHeight := PageWidth-2*margin, PageHeight-2*margin
+	scale := maxWidth / float64(cfg.Width)
+	if heightScale := maxHeight / float64(cfg.Height); heightScale < scale {
+		scale = heightScale
+	}
+	width, height := float64(cfg.Width)*scale, float64(cfg.Height)*scale
+	x, y := (PageWidth-width)/2, (PageHeight-height)/2
+
+	d.addPage(fmt.Sprintf("q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q\n", width, height, x, y),
+		fmt.Sprintf("<< /XObject << /Im1 %d 0 R >> >>", imageID))
+	return nil
+}
+
+func (d *Document) PagesCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	kids := []string{}
+	for _, pageID := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+	d.objects[0] = object{dict: "<< /Type /Catalog /Pages 2 0 R >>"}
+	d.objects[1] = object{dict: fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))}
+
+	buf := bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := []int{}
+	for i, obj := range d.objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		if obj.stream != nil {
+			// Длина потока дописывается в словарь
+			fmt.Fprintf(&buf, "%s /Length %d >>\nstream\n", strings.TrimSuffix(obj.dict, ">>"), len(obj.stream))
+			buf.Write(obj.stream)
+			buf.WriteString("\nendstream\n")
+		} else {
+			buf.WriteString(obj.dict + "\n")
+		}
+		buf.WriteString("endobj\n")
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, xrefOffset)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+func (d *Document) Bytes() []byte {
+	buf := bytes.Buffer{}
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// escapeText экранирует строку для pdf и заменяет символы, которых нет в Helvetica, на '?'
+func escapeText(text string) string {
+	result := strings.Builder{}
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			result.WriteRune('\\')
+			result.WriteRune(r)
+		case r < 32 || r > 126:
+			result.WriteRune('?')
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+// colorComponents читает число компонент цвета из заголовка SOF jpeg
+func colorComponents(content []byte) int {
+	pos := 2
+	for pos+4 <= len(content) {
+		if content[pos] != 0xFF {
+			return 3
+		}
+		marker := content[pos+1]
+		length := int(content[pos+2])<<8 | int(content[pos+3])
+		// SOF0-SOF15, кроме DHT (C4), JPG (C8) и DAC (CC)
+		if marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC {
+			if pos+9 < len(content) {
+				return int(content[pos+9])
+			}
+			return 3
+		}
+		pos += 2 + length
+	}
+	return 3
+}
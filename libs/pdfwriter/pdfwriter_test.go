@@ -0,0 +1,45 @@
+package pdfwriter
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument(t *testing.T) {
+	req := require.New(t)
+
+	buf := bytes.Buffer{}
+	req.NoError(jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 100, 200)), nil))
+
+	doc := New()
+	doc.AddTextPage([]string{"Solution (1)", "Задача A"})
+	req.NoError(doc.AddJPEGPage(buf.Bytes()))
+	req.Error(doc.AddJPEGPage([]byte("not a jpeg")))
+	req.Equal(2, doc.PagesCount())
+
+	result := doc.Bytes()
+	req.True(bytes.HasPrefix(result, []byte("%PDF-1.4")))
+	req.Contains(string(result), "/Count 2")
+	req.Contains(string(result), `(Solution \(1\)) Tj`)
+	req.Contains(string(result), "(?????? A) Tj")
+	req.Contains(string(result), "/ColorSpace /DeviceGray")
+
+	// Таблица xref должна указывать на начала объектов
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(result)
+	req.NotNil(startxref)
+	xrefOffset, err := strconv.Atoi(string(startxref[1]))
+	req.NoError(err)
+	req.True(bytes.HasPrefix(result[xrefOffset:], []byte("xref")))
+
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(result, -1)
+	for i, match := range offsets {
+		offset, _ := strconv.Atoi(string(match[1]))
+		req.True(bytes.HasPrefix(result[offset:], []byte(strconv.Itoa(i+1)+" 0 obj")))
+	}
+}
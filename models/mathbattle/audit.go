@@ -0,0 +1,55 @@
+package mathbattle
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditRoundStart        AuditAction = "round_start"
+	AuditReviewStart       AuditAction = "review_start"
+	AuditReviewReassign    AuditAction = "review_reassign"
+	AuditResultsPublish    AuditAction = "results_publish"
+	AuditIdentitiesReveal  AuditAction = "identities_reveal"
+	AuditBroadcast         AuditAction = "broadcast"
+	AuditBattleStart       AuditAction = "battle_start"
+	AuditBattleMark        AuditAction = "battle_mark"
+	AuditParticipantDelete AuditAction = "participant_delete"
+	AuditGradesPromote     AuditAction = "grades_promote"
+	AuditSolutionDelete    AuditAction = "solution_delete"
+	AuditReviewDelete      AuditAction = "review_delete"
+	AuditRoleChange        AuditAction = "role_change"
+)
+
+// AuditRecord - запись журнала аудита. Журнал только дописывается
+type AuditRecord struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Telegram ID того, кто сделал действие. 0 - mb-admin или запрос без ActorHeader
+	ActorID int64       `json:"actor_id"`
+	Action  AuditAction `json:"action"`
+	// ID объекта действия: раунда, решения, участника
+	Target string `json:"target"`
+	// Значения объекта до и после действия в json, пусто - объекта не было или не стало
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// AuditFilter - какие записи журнала нужны. Пустые поля не ограничивают выборку
+type AuditFilter struct {
+	Action  AuditAction `json:"action"`
+	ActorID int64       `json:"actor_id"`
+	Target  string      `json:"target"`
+	Since   time.Time   `json:"since"`
+	Until   time.Time   `json:"until"`
+}
+
+type AuditRepository interface {
+	Store(record AuditRecord) (AuditRecord, error)
+	Find(filter AuditFilter) ([]AuditRecord, error)
+}
+
+type AuditService interface {
+	Find(filter AuditFilter) ([]AuditRecord, error)
+	// ExportCSV - записи журнала в csv, по записи на строку
+	ExportCSV(filter AuditFilter) ([]byte, error)
+}
@@ -0,0 +1,314 @@
+package mathbattle
+
+import "mathbattle/libs/mstd"
+
+// BattleState - этап матбоя
+type BattleState string
+
+const (
+	// Команда Turn выбирает задачу и вызывает соперника или отказывается от вызова
+	BattleChallenge BattleState = "challenge"
+	// Вызванная команда Turn принимает вызов или отказывается от него
+	BattleResponse BattleState = "response"
+	// Идёт доклад и оппонирование по текущей задаче, жюри выставляет баллы
+	BattleReport   BattleState = "report"
+	BattleFinished BattleState = "finished"
+)
+
+// BattleProblemPoints - сколько баллов разыгрывается по каждой задаче
+const BattleProblemPoints = 12
+
+const (
+	BattleMoveChallenge = "challenge"
+	BattleMoveAccept    = "accept"
+	BattleMoveRefuse    = "refuse"
+	BattleMovePass      = "pass"
+)
+
+// BattleFight - разбор одной задачи
+type BattleFight struct {
+	ProblemID    string `json:"problem_id"`
+	ChallengerID string `json:"challenger_id"`
+	// Вызов отклонён: докладывает вызвавшая команда, доказывая корректность вызова
+	Refused        bool   `json:"refused"`
+	ReporterTeamID string `json:"reporter_team_id"`
+	OpponentTeamID string `json:"opponent_team_id"`
+	ReporterPoints int    `json:"reporter_points"`
+	OpponentPoints int    `json:"opponent_points"`
+	JuryPoints     int    `json:"jury_points"`
+	IsJudged       bool   `json:"is_judged"`
+}
+
+// Battle - матбой двух команд в раунде RoundMatboi.
+// Команды по очереди вызывают друг друга на задачи, вызванная команда докладывает, вызвавшая оппонирует.
+// Если вызов отклонён, докладывает вызвавшая команда. Если она не получила и половины баллов за доклад,
+// вызов некорректен и она вызывает ещё раз. Бой заканчивается, когда разобраны все задачи
+// или обе команды подряд отказались вызывать
+type Battle struct {
+	ID      string   `json:"id"`
+	RoundID string   `json:"round_id"`
+	TeamIDs []string `json:"team_ids"`
+	// Названия команд на момент начала боя, в том же порядке, что и TeamIDs
+	TeamNames []string `json:"team_names"`
+	// Задачи боя с обозначениями из распределения задач раунда
+	Problems []ProblemDescriptor `json:"problems"`
+
+	State BattleState `json:"state"`
+	// Чей сейчас ход: кто вызывает или кто отвечает на вызов. Пусто во время доклада и после боя
+	Turn string `json:"turn"`
+	// Разборы задач по порядку. Во время BattleResponse и BattleReport последний разбор - текущий
+	Fights []BattleFight `json:"fights"`
+	// Команда, отказавшаяся вызывать на прошлом ходу
+	PassedTeamID string `json:"passed_team_id"`
+}
+
+// NewBattle - бой двух команд, первой вызывает teams[0]
+func NewBattle(roundID string, teams []Team, problems []ProblemDescriptor) Battle {
+	result := Battle{
+		RoundID:  roundID,
+		Problems: problems,
+		State:    BattleChallenge,
+		Turn:     teams[0].ID,
+		Fights:   []BattleFight{},
+	}
+	for _, team := range teams {
+		result.TeamIDs = append(result.TeamIDs, team.ID)
+		result.TeamNames = append(result.TeamNames, team.Name)
+	}
+	return result
+}
+
+func (b *Battle) IsParticipant(teamID string) bool {
+	return mstd.IndexOf(b.TeamIDs, teamID) != -1
+}
+
+// Rival - соперник команды teamID
+func (b *Battle) Rival(teamID string) string {
+	if b.TeamIDs[0] == teamID {
+		return b.TeamIDs[1]
+	}
+	return b.TeamIDs[0]
+}
+
+func (b *Battle) TeamName(teamID string) string {
+	index := mstd.IndexOf(b.TeamIDs, teamID)
+	if index == -1 || index >= len(b.TeamNames) {
+		return ""
+	}
+	return b.TeamNames[index]
+}
+
+func (b *Battle) Caption(problemID string) string {
+	for _, problem := range b.Problems {
+		if problem.ProblemID == problemID {
+			return problem.Caption
+		}
+	}
+	return ""
+}
+
+// Current - задача, которая сейчас разбирается. nil, если сейчас ничего не разбирается
+func (b *Battle) Current() *BattleFight {
+	if b.State != BattleResponse && b.State != BattleReport {
+		return nil
+	}
+	return &b.Fights[len(b.Fights)-1]
+}
+
+// OpenProblems - задачи, на которые ещё можно вызвать
+func (b *Battle) OpenProblems() []ProblemDescriptor {
+	result := []ProblemDescriptor{}
+	for _, problem := range b.Problems {
+		isDiscussed := false
+		for _, fight := range b.Fights {
+			if fight.ProblemID == problem.ProblemID {
+				isDiscussed = true
+				break
+			}
+		}
+		if !isDiscussed {
+			result = append(result, problem)
+		}
+	}
+	return result
+}
+
+func (b *Battle) isOpen(problemID string) bool {
+	for _, problem := range b.OpenProblems() {
+		if problem.ProblemID == problemID {
+			return true
+		}
+	}
+	return false
+}
+
+// Score - сколько баллов набрала команда teamID
+func (b *Battle) Score(teamID string) int {
+	result := 0
+	for _, fight := range b.Fights {
+		if fight.ReporterTeamID == teamID {
+			result += fight.ReporterPoints
+		}
+		if fight.OpponentTeamID == teamID {
+			result += fight.OpponentPoints
+		}
+	}
+	return result
+}
+
+// JuryScore - сколько баллов забрало жюри
+func (b *Battle) JuryScore() int {
+	result := 0
+	for _, fight := range b.Fights {
+		result += fight.JuryPoints
+	}
+	return result
+}
+
+// Move - ход команды teamID. problemID нужен только для BattleMoveChallenge
+func (b *Battle) Move(teamID string, action string, problemID string) error {
+	if b.Turn == "" || b.Turn != teamID {
+		return ErrWrongUserInput
+	}
+
+	switch {
+	case b.State == BattleChallenge && action == BattleMoveChallenge:
+		if !b.isOpen(problemID) {
+			return ErrWrongUserInput
+		}
+		b.Fights = append(b.Fights, BattleFight{
+			ProblemID:    problemID,
+			ChallengerID: teamID,
+		})
+		b.PassedTeamID = ""
+		b.State = BattleResponse
+		b.Turn = b.Rival(teamID)
+	case b.State == BattleChallenge && action == BattleMovePass:
+		if b.PassedTeamID == b.Rival(teamID) {
+			b.finish()
+			return nil
+		}
+		b.PassedTeamID = teamID
+		b.Turn = b.Rival(teamID)
+	case b.State == BattleResponse && (action == BattleMoveAccept || action == BattleMoveRefuse):
+		fight := b.Current()
+		fight.Refused = action == BattleMoveRefuse
+		if fight.Refused {
+			fight.ReporterTeamID = fight.ChallengerID
+			fight.OpponentTeamID = teamID
+		} else {
+			fight.ReporterTeamID = teamID
+			fight.OpponentTeamID = fight.ChallengerID
+		}
+		b.State = BattleReport
+		b.Turn = ""
+	default:
+		return ErrWrongUserInput
+	}
+
+	return nil
+}
+
+// Judge - жюри выставляет баллы за текущий доклад. Что не досталось докладчику и оппоненту, забирает жюри
+func (b *Battle) Judge(reporterPoints, opponentPoints int) error {
+	if b.State != BattleReport {
+		return ErrWrongUserInput
+	}
+	if reporterPoints < 0 || opponentPoints < 0 || reporterPoints+opponentPoints > BattleProblemPoints {
+		return ErrWrongUserInput
+	}
+
+	fight := b.Current()
+	fight.ReporterPoints = reporterPoints
+	fight.OpponentPoints = opponentPoints
+	fight.JuryPoints = BattleProblemPoints - reporterPoints - opponentPoints
+	fight.IsJudged = true
+
+	if len(b.OpenProblems()) == 0 {
+		b.finish()
+		return nil
+	}
+
+	b.State = BattleChallenge
+	if fight.Refused && 2*reporterPoints < BattleProblemPoints {
+		// Некорректный вызов: вызвавшая команда вызывает ещё раз
+		b.Turn = fight.ChallengerID
+	} else {
+		b.Turn = b.Rival(fight.ChallengerID)
+	}
+
+	return nil
+}
+
+// Finish - жюри досрочно заканчивает бой. Неоценённый доклад не учитывается
+func (b *Battle) Finish() {
+	if current := b.Current(); current != nil && !current.IsJudged {
+		b.Fights = b.Fights[:len(b.Fights)-1]
+	}
+	b.finish()
+}
+
+func (b *Battle) finish() {
+	b.State = BattleFinished
+	b.Turn = ""
+	b.PassedTeamID = ""
+}
+
+type BattleRepository interface {
+	Store(battle Battle) (Battle, error)
+	Get(ID string) (Battle, error)
+	GetByRound(roundID string) ([]Battle, error)
+	Update(battle Battle) error
+	Delete(ID string) error
+}
+
+// BattleOrder - жюри начинает бой между двумя командами текущего раунда лиги League. Первой вызывает TeamIDs[0]
+type BattleOrder struct {
+	TeamIDs []string `json:"team_ids"`
+	League  string   `json:"league"`
+	// Telegram ID того, кто начинает бой. Передаётся в ActorHeader
+	ActorID int64 `json:"-"`
+}
+
+func (o BattleOrder) Actor() int64 {
+	return o.ActorID
+}
+
+// BattleMove - ход команды, см. BattleMove* константы. Ходить может только капитан
+type BattleMove struct {
+	BattleID string `json:"battle_id"`
+	// Капитан, от имени которого ход. Должен совпадать с участником ActorID
+	ParticipantID string `json:"participant_id"`
+	Action        string `json:"action"`
+	ProblemID     string `json:"problem_id"`
+	// Telegram ID того, кто ходит. Передаётся в ActorHeader
+	ActorID int64 `json:"-"`
+}
+
+func (m BattleMove) Actor() int64 {
+	return m.ActorID
+}
+
+// BattleVerdict - баллы жюри за текущий доклад. Finish - закончить бой досрочно, баллы тогда не нужны
+type BattleVerdict struct {
+	BattleID       string `json:"battle_id"`
+	ReporterPoints int    `json:"reporter_points"`
+	OpponentPoints int    `json:"opponent_points"`
+	Finish         bool   `json:"finish"`
+	// Telegram ID члена жюри. Передаётся в ActorHeader
+	ActorID int64 `json:"-"`
+}
+
+func (v BattleVerdict) Actor() int64 {
+	return v.ActorID
+}
+
+type BattleService interface {
+	Start(order BattleOrder) (Battle, error)
+	Get(ID string) (Battle, error)
+	// GetRunning - идущий бой команды участника в текущем раунде
+	GetRunning(participantID string) (Battle, error)
+	GetByRound(roundID string) ([]Battle, error)
+	Move(move BattleMove) (Battle, error)
+	Judge(verdict BattleVerdict) (Battle, error)
+}
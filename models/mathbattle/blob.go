@@ -0,0 +1,29 @@
+package mathbattle
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// BlobStore - хранилище файлов задач и решений. Ключ файла - sha256 его содержимого в hex,
+// поэтому одинаковые файлы хранятся один раз
+type BlobStore interface {
+	// Put сохраняет содержимое и возвращает его ключ
+	Put(content io.Reader) (string, error)
+	// Open - чтение файла потоком, ErrNotFound если такого ключа нет
+	Open(key string) (io.ReadCloser, error)
+	Exists(key string) (bool, error)
+	// Delete не считает ошибкой отсутствие ключа
+	Delete(key string) error
+}
+
+// ReadBlob - весь файл целиком, только для небольших файлов
+func ReadBlob(store BlobStore, key string) ([]byte, error) {
+	reader, err := store.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
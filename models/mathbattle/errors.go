@@ -3,6 +3,18 @@ package mathbattle
 import "errors"
 
 var (
-	ErrNotFound       = errors.New("not found")
-	ErrWrongUserInput = errors.New("wrong user input")
+	ErrNotFound         = errors.New("not found")
+	ErrWrongUserInput   = errors.New("wrong user input")
+	ErrRoundNotFinished = errors.New("round is not finished")
+	ErrTeamLocked       = errors.New("teams can't change during a team round")
+	ErrForbidden        = errors.New("not enough permissions")
+	// Запрос противоречит текущему состоянию. Клиент сервера уточняет ошибку по методу, см. APIRound.PublishResults
+	ErrConflict = errors.New("request conflicts with the current state")
+	// Распределение решений на ревью изменилось после пробного запуска, например кто-то удалил решение
+	ErrDistributionChanged = errors.New("review distribution changed since the dry run")
+	// Решение нельзя забрать у ревьюера, который уже прислал на него ревью
+	ErrReviewSubmitted = errors.New("reviewer already submitted the review")
+
+	ErrSolutionPartTooLarge    = errors.New("solution part is too large")
+	ErrSolutionPartUnsupported = errors.New("solution part type is not supported")
 )
@@ -1,6 +1,98 @@
 package mathbattle
 
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	MimeTypeJPEG = "image/jpeg"
+	MimeTypePDF  = "application/pdf"
+)
+
+// Image - файл задачи или часть решения. Несмотря на название, это может быть и документ (pdf и т.п.),
+// тогда его нужно отправлять документом, а не фотографией
 type Image struct {
 	Extension string `json:"extension"`
+	MimeType  string `json:"mime_type"`
 	Content   []byte `json:"content"`
+
+	// Ключ файла в BlobStore. Части решений читаются из репозитория без Content, только с ключами
+	Key string `json:"key,omitempty"`
+
+	// Содержимое заполняется только при загрузке части решения, из репозитория читаются только ключи
+	Original  *Image `json:"original,omitempty"`  // исходный файл, если Content - обработанная копия
+	Thumbnail []byte `json:"thumbnail,omitempty"` // маленькое превью в jpeg
+	// Ключ превью в BlobStore, у Original - свой Key
+	ThumbnailKey string `json:"thumbnail_key,omitempty"`
+}
+
+// IsPicture - часть без MimeType картинкой не считается. Тип загруженных частей определяет
+// SolutionService.AppendPart, сохранённым до появления MimeType его проставляет репозиторий
+func (img Image) IsPicture() bool {
+	return strings.HasPrefix(img.MimeType, "image/")
+}
+
+func (img Image) IsPDF() bool {
+	return img.MimeType == MimeTypePDF
+}
+
+// SolutionPartLimits - ограничения на одну часть решения. Нулевой размер - без ограничения,
+// пустой DocumentTypes - любые документы
+type SolutionPartLimits struct {
+	MaxImageSize    int64    `yaml:"image_max_size" json:"image_max_size"`
+	MaxDocumentSize int64    `yaml:"document_max_size" json:"document_max_size"`
+	DocumentTypes   []string `yaml:"document_types" json:"document_types"`
+}
+
+// Check возвращает ErrSolutionPartTooLarge или ErrSolutionPartUnsupported
+func (l SolutionPartLimits) Check(part Image) error {
+	if part.IsPicture() {
+		if l.MaxImageSize > 0 && int64(len(part.Content)) > l.MaxImageSize {
+			return ErrSolutionPartTooLarge
+		}
+		return nil
+	}
+
+	if len(l.DocumentTypes) > 0 {
+		isAllowed := false
+		for _, mimeType := range l.DocumentTypes {
+			if mimeType == part.MimeType {
+				isAllowed = true
+				break
+			}
+		}
+		if !isAllowed {
+			return ErrSolutionPartUnsupported
+		}
+	}
+
+	if l.MaxDocumentSize > 0 && int64(len(part.Content)) > l.MaxDocumentSize {
+		return ErrSolutionPartTooLarge
+	}
+
+	return nil
+}
+
+// MaxSize - ограничение размера для части такого типа, 0 - без ограничения
+func (l SolutionPartLimits) MaxSize(part Image) int64 {
+	if part.IsPicture() {
+		return l.MaxImageSize
+	}
+	return l.MaxDocumentSize
+}
+
+// PartFileName - имя, с которым часть решения отправляется документом
+func PartFileName(solutionNumber int, partNumber int, part Image) string {
+	return fmt.Sprintf("solution_%d_%d%s", solutionNumber, partNumber, part.Extension)
+}
+
+// ImageNormalizer приводит загруженные фотографии к единому виду. Исходник сохраняется в Original
+type ImageNormalizer interface {
+	Normalize(part Image) (Image, error)
+}
+
+// DocumentPreviewer рисует первые страницы документа картинками, чтобы его можно было посмотреть не скачивая
+type DocumentPreviewer interface {
+	Preview(document Image) ([][]byte, error)
 }
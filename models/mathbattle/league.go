@@ -0,0 +1,55 @@
+package mathbattle
+
+import "mathbattle/libs/mstd"
+
+// League - группа участников со своими раундами. Раунды разных лиг идут одновременно и независимо.
+// Пустое название - основная лига, в ней все, кого не распределили в другие лиги
+type League struct {
+	Name string `json:"name"`
+	// Участники этих классов попадают в лигу автоматически. Пусто - в лигу записывает только администратор
+	Grades []int `json:"grades"`
+}
+
+type Leagues []League
+
+func (l Leagues) Names() []string {
+	result := []string{}
+	for _, league := range l {
+		result = append(result, league.Name)
+	}
+	return result
+}
+
+func (l Leagues) IsExist(name string) bool {
+	return name == "" || mstd.IndexOf(l.Names(), name) != -1
+}
+
+// ForGrade - лига, в которую автоматически попадает участник класса grade. Пусто - основная лига
+func (l Leagues) ForGrade(grade int) string {
+	for _, league := range l {
+		for _, leagueGrade := range league.Grades {
+			if leagueGrade == grade {
+				return league.Name
+			}
+		}
+	}
+	return ""
+}
+
+func (l Leagues) isGradeLeague(name string) bool {
+	for _, league := range l {
+		if league.Name == name {
+			return len(league.Grades) != 0
+		}
+	}
+	return false
+}
+
+// Assign переводит участника в лигу его класса. Участников, которых администратор записал
+// в лигу без классов, не трогает
+func (l Leagues) Assign(participant *Participant) {
+	if participant.League != "" && !l.isGradeLeague(participant.League) {
+		return
+	}
+	participant.League = l.ForGrade(participant.Grade)
+}
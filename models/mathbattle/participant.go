@@ -2,6 +2,7 @@ package mathbattle
 
 import (
 	"strconv"
+	"time"
 	"unicode"
 )
 
@@ -12,6 +13,18 @@ type Participant struct {
 	School   string `json:"school"`
 	Grade    int    `json:"grade"`
 	IsActive bool   `json:"is_active"`
+	// Город или регион
+	Region string `json:"region"`
+	// Имя и контакт учителя математики
+	TeacherContact string `json:"teacher_contact"`
+	// Родители несовершеннолетнего участника согласились на участие
+	ParentalConsent bool `json:"parental_consent"`
+	// Учебный год, к которому относится Grade. 0 - участник зарегистрирован до перевода по классам
+	GradeYear int `json:"grade_year"`
+	// Участник не хочет получать напоминания о приближении конца этапа
+	RemindersOff bool `json:"reminders_off"`
+	// Лига участника, см. Leagues. Пусто - основная лига
+	League string `json:"league"`
 }
 
 type ParticipantRepository interface {
@@ -29,8 +42,41 @@ type ParticipantService interface {
 	GetByTelegramID(TelegramID int64) (Participant, error)
 	GetAll() ([]Participant, error)
 	Update(participant Participant) error
-	Delete(ID string) error
+	Delete(ID string, actorID int64) error
 	Unsubscribe(ID string) error
+	PromoteGrades(schoolYear int, actorID int64) (GradePromotion, error)
+}
+
+// GradePromotion - итог перевода участников в следующий класс
+type GradePromotion struct {
+	SchoolYear int `json:"school_year"`
+	Promoted   int `json:"promoted"`
+	Graduated  int `json:"graduated"`
+}
+
+// SchoolYear - учебный год, идущий в момент t. Год начинается 1 сентября и обозначается годом начала
+func SchoolYear(t time.Time) int {
+	if t.Month() >= time.September {
+		return t.Year()
+	}
+	return t.Year() - 1
+}
+
+// PromoteGrade переводит участника в класс учебного года schoolYear. Окончившие школу остаются в 11 классе
+// и перестают быть активными. Возвращает false, если участник уже в классе этого года или его учебный год
+// неизвестен: у записанных до появления GradeYear его заполняет по дате регистрации репозиторий
+func (p *Participant) PromoteGrade(schoolYear int) bool {
+	if p.GradeYear == 0 || p.GradeYear >= schoolYear {
+		return false
+	}
+
+	p.Grade += schoolYear - p.GradeYear
+	p.GradeYear = schoolYear
+	if p.Grade > 11 {
+		p.Grade = 11
+		p.IsActive = false
+	}
+	return true
 }
 
 func IsValidGrade(grade int) bool {
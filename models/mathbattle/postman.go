@@ -3,6 +3,12 @@ package mathbattle
 type SimpleMessage struct {
 	Text     string   `json:"text"`
 	UsersIDS []string `json:"users_ids"`
+	// Telegram ID того, кто рассылает. Передаётся в ActorHeader
+	ActorID int64 `json:"-"`
+}
+
+func (m SimpleMessage) Actor() int64 {
+	return m.ActorID
 }
 
 type PostmanService interface {
@@ -10,4 +16,5 @@ type PostmanService interface {
 	SendSimpleMessage(chatID int64, message string) error
 	SendImage(chatID int64, imageCaption string, image []byte) error
 	SendAlbum(chatID int64, albumCaption string, images [][]byte) error
+	SendDocument(chatID int64, fileName string, caption string, content []byte) error
 }
@@ -17,6 +17,7 @@ type ProblemRepository interface {
 
 type ProblemService interface {
 	GetByID(ID string) (Problem, error)
+	GetAll() ([]Problem, error)
 }
 
 func GetProblemIDs(problems []Problem) []string {
@@ -33,3 +34,33 @@ func IsProblemSuitableForParticipant(problem *Problem, participant *Participant)
 	}
 	return false
 }
+
+// GradesWithoutProblems возвращает классы, для которых не подходит ни одна из задач
+func GradesWithoutProblems(problems []Problem) []int {
+	result := []int{}
+	for grade := 1; grade <= 11; grade++ {
+		isCovered := false
+		for _, problem := range problems {
+			if grade >= problem.MinGrade && grade <= problem.MaxGrade {
+				isCovered = true
+				break
+			}
+		}
+
+		if !isCovered {
+			result = append(result, grade)
+		}
+	}
+
+	return result
+}
+
+func FindProblemByID(problems []Problem, ID string) (Problem, bool) {
+	for _, problem := range problems {
+		if problem.ID == ID {
+			return problem, true
+		}
+	}
+
+	return Problem{}, false
+}
@@ -0,0 +1,176 @@
+package mathbattle
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// FieldMode - спрашивается ли поле анкеты при регистрации
+type FieldMode string
+
+const (
+	FieldOff      FieldMode = "off"
+	FieldOptional FieldMode = "optional"
+	FieldRequired FieldMode = "required"
+)
+
+func (m FieldMode) IsAsked() bool {
+	return m == FieldOptional || m == FieldRequired
+}
+
+// ParseFieldMode разбирает режим поля из конфига, пустой режим - defaultMode
+func ParseFieldMode(mode string, defaultMode FieldMode) (FieldMode, error) {
+	switch FieldMode(mode) {
+	case "":
+		return defaultMode, nil
+	case FieldOff, FieldOptional, FieldRequired:
+		return FieldMode(mode), nil
+	default:
+		return defaultMode, fmt.Errorf("unknown field mode '%s', expected off, optional or required", mode)
+	}
+}
+
+// RegistrationForm - анкета участника. Имя и класс спрашиваются всегда
+type RegistrationForm struct {
+	School         FieldMode
+	Region         FieldMode
+	TeacherContact FieldMode
+	// Согласие родителей спрашивается у участников до этого класса включительно, 0 - не спрашивается
+	ConsentMaxGrade int
+}
+
+// NewRegistrationForm создаёт анкету по настройкам из конфига. По умолчанию спрашивается только школа
+func NewRegistrationForm(school, region, teacherContact string, consentMaxGrade int) (RegistrationForm, error) {
+	result := RegistrationForm{ConsentMaxGrade: consentMaxGrade}
+
+	var err error
+	if result.School, err = ParseFieldMode(school, FieldRequired); err != nil {
+		return result, fmt.Errorf("school: %w", err)
+	}
+	if result.Region, err = ParseFieldMode(region, FieldOff); err != nil {
+		return result, fmt.Errorf("region: %w", err)
+	}
+	if result.TeacherContact, err = ParseFieldMode(teacherContact, FieldOff); err != nil {
+		return result, fmt.Errorf("teacher contact: %w", err)
+	}
+	if consentMaxGrade < 0 || consentMaxGrade > 11 {
+		return result, fmt.Errorf("consent max grade %d is out of range 0..11", consentMaxGrade)
+	}
+
+	return result, nil
+}
+
+func (f RegistrationForm) IsConsentRequired(grade int) bool {
+	return grade <= f.ConsentMaxGrade
+}
+
+// Validate проверяет анкету нового участника: формат всех полей и заполненность обязательных
+func (f RegistrationForm) Validate(participant Participant) error {
+	if err := ValidateParticipantFields(participant); err != nil {
+		return err
+	}
+
+	if f.School == FieldRequired && participant.School == "" ||
+		f.Region == FieldRequired && participant.Region == "" ||
+		f.TeacherContact == FieldRequired && participant.TeacherContact == "" {
+		return ErrWrongUserInput
+	}
+	if f.IsConsentRequired(participant.Grade) && !participant.ParentalConsent {
+		return ErrWrongUserInput
+	}
+
+	return nil
+}
+
+// ValidateParticipantFields проверяет только формат полей. Анкеты, заполненные до
+// появления обязательного поля, должны оставаться изменяемыми
+func ValidateParticipantFields(participant Participant) error {
+	if !IsParticipantNameValid(participant.Name) || !IsValidGrade(participant.Grade) {
+		return ErrWrongUserInput
+	}
+
+	if participant.School != "" {
+		if _, ok := ValidateSchool(participant.School); !ok {
+			return ErrWrongUserInput
+		}
+	}
+	if participant.Region != "" {
+		if _, ok := ValidateRegion(participant.Region); !ok {
+			return ErrWrongUserInput
+		}
+	}
+	if participant.TeacherContact != "" {
+		if _, ok := ValidateTeacherContact(participant.TeacherContact); !ok {
+			return ErrWrongUserInput
+		}
+	}
+
+	return nil
+}
+
+// normalizeSpaces убирает пробелы по краям и повторяющиеся пробелы
+func normalizeSpaces(input string) string {
+	return strings.Join(strings.Fields(input), " ")
+}
+
+func isPrintable(input string) bool {
+	for _, r := range input {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func ValidateSchool(userInput string) (string, bool) {
+	school := normalizeSpaces(userInput)
+	length := len([]rune(school))
+	if length == 0 || length > 256 || !isPrintable(school) {
+		return "", false
+	}
+	return school, true
+}
+
+func ValidateRegion(userInput string) (string, bool) {
+	region := normalizeSpaces(userInput)
+	length := len([]rune(region))
+	if length == 0 || length > 100 {
+		return "", false
+	}
+
+	for _, r := range region {
+		if !(unicode.IsLetter(r) || r == ' ' || r == '-' || r == '.' || r == ',') {
+			return "", false
+		}
+	}
+	return region, true
+}
+
+// ValidateTeacherContact - имя учителя и телефон, e-mail или ник в телеграме, формат не проверяется
+func ValidateTeacherContact(userInput string) (string, bool) {
+	contact := normalizeSpaces(userInput)
+	length := len([]rune(contact))
+	if length == 0 || length > 200 || !isPrintable(contact) {
+		return "", false
+	}
+	return contact, true
+}
+
+// SchoolDirectory - справочник школ для подсказок при вводе школы
+type SchoolDirectory interface {
+	// Suggest возвращает до limit школ, подходящих под запрос. Точное совпадение идёт первым
+	Suggest(query string, limit int) []string
+}
+
+// NormalizeSchoolName приводит название школы к виду для сравнения: "Лицей  №1 «Ёлка»" -> "лицей 1 елка"
+func NormalizeSchoolName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("ё", "е", "№", " ", "«", " ", "»", " ", "\"", " ", "'", " ").Replace(name)
+	return normalizeSpaces(name)
+}
+
+// IsSameSchool - названия совпадают с точностью до регистра, кавычек и пробелов
+func IsSameSchool(a, b string) bool {
+	return NormalizeSchoolName(a) == NormalizeSchoolName(b)
+}
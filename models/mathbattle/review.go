@@ -8,12 +8,23 @@ type Review struct {
 	Content     string `json:"content"`
 	JuriComment string `json:"juri_comment"`
 	Mark        Mark   `json:"mark"`
+	// ReviewerTeamID - команда, от имени которой ReviewerID прислал ревью в командном раунде
+	ReviewerTeamID string `json:"reviewer_team_id"`
+}
+
+// ReviewerCompetitorID - кто проверял решение в распределениях раунда: команда или участник
+func (r Review) ReviewerCompetitorID() string {
+	if r.ReviewerTeamID != "" {
+		return r.ReviewerTeamID
+	}
+	return r.ReviewerID
 }
 
 type ReviewRepository interface {
 	Store(review Review) (Review, error) // Return newly created Review with filled in ID
 	Get(ID string) (Review, error)
 	FindMany(reviewerID, solutionID string) ([]Review, error)
+	FindManyByTeam(reviewerTeamID, solutionID string) ([]Review, error)
 	Update(review Review) error
 	Delete(ID string) error
 }
@@ -30,11 +41,21 @@ type ReviewFindDescriptor struct {
 	ReviewerID string `json:"reviewer_id"`
 	SolutionID string `json:"solution_id"`
 	ProblemID  string `json:"problem_id"`
+	// Ревью команды, ReviewerID при этом не учитывается
+	ReviewerTeamID string `json:"reviewer_team_id"`
 }
 
 type ReviewService interface {
 	Store(review Review) (Review, error)
 	FindMany(descriptor ReviewFindDescriptor) ([]Review, error)
-	Delete(ID string) error
+	Delete(ID string, actorID int64) error
 	RevewStageDescriptors(participantID string) ([]SolutionDescriptor, error)
 }
+
+// FindCompetitorReviews - ревью участника или, в командном раунде, команды с ID competitorID
+func FindCompetitorReviews(reviews ReviewRepository, round Round, competitorID string, solutionID string) ([]Review, error) {
+	if round.IsTeam() {
+		return reviews.FindManyByTeam(competitorID, solutionID)
+	}
+	return reviews.FindMany(competitorID, solutionID)
+}
@@ -0,0 +1,105 @@
+package mathbattle
+
+import "strings"
+
+// Role - роль организатора. У участников роли нет
+type Role string
+
+const (
+	RoleNone      Role = ""
+	RoleOwner     Role = "owner"
+	RoleOrganizer Role = "organizer"
+	RoleJury      Role = "jury"
+	RoleModerator Role = "moderator"
+)
+
+// Permission - право на действие, доступное не всем пользователям
+type Permission string
+
+const (
+	// PermissionNone - действие доступно всем
+	PermissionNone Permission = ""
+	// Начинать раунды и этапы, проводить бои матбоя, выбирать лигу
+	PermissionManageRounds Permission = "manage_rounds"
+	// Оценивать решения и бои
+	PermissionJudge Permission = "judge"
+	// Перераспределять проверку решений и рассылать сообщения участникам
+	PermissionModerate Permission = "moderate"
+	// Смотреть статистику
+	PermissionViewStat Permission = "view_stat"
+	// Публиковать результаты, раскрывать авторов решений, переводить участников в следующий класс
+	PermissionPublish Permission = "publish"
+	// Выдавать и забирать роли
+	PermissionManageRoles Permission = "manage_roles"
+	// Смотреть и выгружать журнал аудита
+	PermissionViewAudit Permission = "view_audit"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {PermissionManageRounds, PermissionJudge, PermissionModerate, PermissionViewStat, PermissionPublish,
+		PermissionManageRoles, PermissionViewAudit},
+	RoleOrganizer: {PermissionManageRounds, PermissionJudge, PermissionModerate, PermissionViewStat, PermissionPublish,
+		PermissionViewAudit},
+	RoleJury:      {PermissionJudge, PermissionViewStat},
+	RoleModerator: {PermissionModerate, PermissionViewStat},
+}
+
+// Roles - все роли в порядке убывания прав
+func Roles() []Role {
+	return []Role{RoleOwner, RoleOrganizer, RoleJury, RoleModerator}
+}
+
+// ParseRole - роль по названию, пустое название - без роли
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if role == RoleNone {
+		return role, nil
+	}
+	if _, isExist := rolePermissions[role]; !isExist {
+		return RoleNone, ErrWrongUserInput
+	}
+	return role, nil
+}
+
+func (r Role) Can(permission Permission) bool {
+	if permission == PermissionNone {
+		return true
+	}
+
+	for _, rolePermission := range rolePermissions[r] {
+		if rolePermission == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ActorHeader - HTTP заголовок с Telegram ID пользователя, от имени которого сделан запрос к API.
+// Сервер ему доверяет, если в запросе есть TokenHeader
+const ActorHeader = "X-Telegram-ID"
+
+// TokenHeader - HTTP заголовок с общим секретом бота и сервера, api_token в конфиге
+const TokenHeader = "X-API-Token"
+
+// ActedOrder - распоряжение, у которого есть автор, см. ActorHeader
+type ActedOrder interface {
+	Actor() int64
+}
+
+// RoleOrder - выдать пользователю роль или забрать её (Role пусто). Пользователь ищется по TelegramID,
+// если он не задан - по имени в телеграме
+type RoleOrder struct {
+	ActorID      int64  `json:"-"`
+	TelegramID   int64  `json:"telegram_id"`
+	TelegramName string `json:"telegram_name"`
+	Role         Role   `json:"role"`
+}
+
+func (o RoleOrder) Actor() int64 {
+	return o.ActorID
+}
+
+type RoleService interface {
+	Grant(order RoleOrder) (User, error)
+	GetStaff() ([]User, error)
+}
@@ -1,12 +1,17 @@
 package mathbattle
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"mathbattle/libs/mstd"
 )
 
 type RoundStage string
@@ -36,6 +41,51 @@ type Round struct {
 
 	ProblemDistribution RoundDistribution  `json:"problem_distribution"`
 	ReviewDistribution  ReviewDistribution `json:"solution_distribution"`
+
+	// Результаты опубликованы, после этого жюри видят участников даже при слепой проверке
+	ResultsPublished bool `json:"results_published"`
+	// Кто и зачем раскрывал участников до публикации результатов. Дописывается только
+	// RoundRepository.AppendIdentityReveal, Update их не меняет
+	IdentityReveals []IdentityReveal `json:"identity_reveals"`
+
+	// В командном раунде распределения ключуются ID команд, а решения и ревью принадлежат командам.
+	// Пустой режим у раундов, начатых до появления команд, означает RoundIndividual
+	Mode RoundMode `json:"mode"`
+
+	// Лига раунда. В каждой лиге одновременно идёт не больше одного раунда
+	League string `json:"league"`
+}
+
+// IsTeam - в раунде соревнуются команды. Матбой тоже командный раунд
+func (r *Round) IsTeam() bool {
+	return r.Mode == RoundTeam || r.Mode == RoundMatboi
+}
+
+func (r *Round) IsMatboi() bool {
+	return r.Mode == RoundMatboi
+}
+
+// IdentityReveal - запись о раскрытии участников слепой проверки администратором
+type IdentityReveal struct {
+	Date   time.Time `json:"date"`
+	By     string    `json:"by"`
+	Reason string    `json:"reason"`
+}
+
+func NewIdentityReveal(by, reason string) IdentityReveal {
+	return IdentityReveal{
+		Date:   time.Now().Round(time.Second).UTC(),
+		By:     by,
+		Reason: reason,
+	}
+}
+
+// IdentitiesHidden - надо ли скрывать от жюри, чьи это решения
+func (r *Round) IdentitiesHidden(blindGrading bool) bool {
+	if !blindGrading {
+		return false
+	}
+	return !(r.ResultsPublished && GetRoundStage(*r) == StageFinished)
 }
 
 func (r *Round) IsActive() bool {
@@ -47,8 +97,10 @@ func (r *Round) IsActive() bool {
 	return true
 }
 
+// Начало этапа отбрасывает доли секунды: при округлении вверх этап, начатый сейчас, ещё полсекунды
+// считался бы не начавшимся, и scheduleRound не запланировал бы его окончание
 func (r *Round) SetSolveStartDate(datetime time.Time) {
-	r.SolveStartDate = datetime.Round(time.Second).UTC()
+	r.SolveStartDate = datetime.Truncate(time.Second).UTC()
 }
 
 func (r *Round) GetSolveStartDate() time.Time {
@@ -67,16 +119,8 @@ func (r *Round) GetSolveStageDuration() time.Duration {
 	return r.GetSolveEndDate().Sub(r.GetSolveStartDate())
 }
 
-func (r *Round) GetSolveEndDateMsk() (time.Time, error) {
-	location, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		return time.Time{}, err
-	}
-	return r.SolveEndDate.In(location), nil
-}
-
 func (r *Round) SetReviewStartDate(datetime time.Time) {
-	r.ReviewStartDate = datetime.Round(time.Second).UTC()
+	r.ReviewStartDate = datetime.Truncate(time.Second).UTC()
 }
 
 func (r *Round) GetReviewStartDate() time.Time {
@@ -91,34 +135,49 @@ func (r *Round) GetReviewEndDate() time.Time {
 	return r.ReviewEndDate
 }
 
-func (r *Round) GetReviewEndDateMsk() (time.Time, error) {
-	location, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		return time.Time{}, err
-	}
-	return r.ReviewEndDate.In(location), nil
-}
-
 func (r *Round) GetReviewStageDuration() time.Duration {
 	return r.GetReviewEndDate().Sub(r.GetReviewStartDate())
 }
 
+// RoundRepository - методы с параметром league ищут раунд этой лиги
 type RoundRepository interface {
 	Store(round Round) (Round, error)
 	Get(ID string) (Round, error)
-	GetRunning() (Round, error)
-	GetSolveRunning() (Round, error)
-	GetReviewPending() (Round, error)
-	GetReviewRunning() (Round, error)
+	GetRunning(league string) (Round, error)
+	GetSolveRunning(league string) (Round, error)
+	GetReviewPending(league string) (Round, error)
+	GetReviewRunning(league string) (Round, error)
+	// GetAllRunning - идущие раунды всех лиг
+	GetAllRunning() ([]Round, error)
 	GetAll() ([]Round, error)
-	GetLast() (Round, error)
+	GetLast(league string) (Round, error)
 	Update(round Round) error
+	// AppendIdentityReveal дописывает раскрытие к сохранённым, чтобы Update устаревшей копии раунда его не потерял
+	AppendIdentityReveal(roundID string, reveal IdentityReveal) error
 	Delete(roundID string) error
 }
 
 type StartOrder struct {
 	ProblemsIDs []string `json:"problems_ids"`
 	StageEnd    string   `json:"stage_end"`
+	// Часовой пояс, в котором указан StageEnd. Пусто - часовой пояс мероприятия
+	TimeZone string `json:"time_zone"`
+	// DryRun - только посчитать распределение, ничего не сохранять и никому не писать
+	DryRun bool `json:"dry_run"`
+	// Seed для распределения решений на ревью. 0 - сгенерировать новый
+	Seed int64 `json:"seed"`
+	// Хеш распределения, показанного в пробном запуске. Если задан, этап начнётся только с тем же распределением
+	DistributionHash string `json:"distribution_hash"`
+	// Режим нового раунда, пусто - RoundIndividual
+	Mode RoundMode `json:"mode"`
+	// Лига, в которой начинается раунд или этап
+	League string `json:"league"`
+	// Telegram ID того, кто начинает раунд или этап. Передаётся в ActorHeader
+	ActorID int64 `json:"-"`
+}
+
+func (o StartOrder) Actor() int64 {
+	return o.ActorID
 }
 
 type ParticipantError struct {
@@ -132,25 +191,60 @@ type SSStartResult struct {
 	TotalSuccessParticipants int                `json:"total_success_participants"`
 	FailedParticipants       []ParticipantError `json:"failed_participants"`
 	Round                    Round              `json:"round"`
+	DryRun                   bool               `json:"dry_run"`
 }
 
 /// CSStartResult - Comment Stage Start Result
 type CSStartResult struct {
 	FailedParticipants []ParticipantError `json:"failed_participants"`
 	Round              Round              `json:"round"`
+	Seed               int64              `json:"seed"`
+	DryRun             bool               `json:"dry_run"`
+	DistributionHash   string             `json:"distribution_hash"`
+	// Распределение для организаторов, только в пробном запуске
+	Desc string `json:"desc"`
+}
+
+type RevealOrder struct {
+	RoundID string `json:"round_id"`
+	By      string `json:"by"`
+	Reason  string `json:"reason"`
+	// Telegram ID того, кто раскрывает авторов. Передаётся в ActorHeader
+	ActorID int64 `json:"-"`
+}
+
+func (o RevealOrder) Actor() int64 {
+	return o.ActorID
+}
+
+// SolutionIdentity - кому принадлежит решение с данным псевдонимом
+type SolutionIdentity struct {
+	SolutionID      string `json:"solution_id"`
+	Pseudonym       string `json:"pseudonym"`
+	ProblemID       string `json:"problem_id"`
+	ParticipantID   string `json:"participant_id"`
+	ParticipantName string `json:"participant_name"`
+	// Только в командном раунде
+	TeamID   string `json:"team_id"`
+	TeamName string `json:"team_name"`
 }
 
 type RoundService interface {
 	StartNew(startOrder StartOrder) (SSStartResult, error)
 	StartReviewStage(startOrder StartOrder) (CSStartResult, error)
-	ReviewStageDistributionDesc() (ReviewDistributionDesc, error)
+	ReviewStageDistributionDesc(league string, seed int64) (ReviewDistributionDesc, error)
+	ReassignReview(order ReassignOrder) (ReassignResult, error)
+	ReassignOptions(league string, seed int64) (ReassignOptions, error)
 	GetAll() ([]Round, error)
 	GetByID(ID string) (Round, error)
-	GetRunning() (Round, error)
-	GetReviewPending() (Round, error)
-	GetReviewRunning() (Round, error)
-	GetLast() (Round, error)
+	GetRunning(league string) (Round, error)
+	GetReviewPending(league string) (Round, error)
+	GetReviewRunning(league string) (Round, error)
+	GetLast(league string) (Round, error)
+	// GetProblemDescriptors - задачи участника в идущем раунде его лиги
 	GetProblemDescriptors(participantID string) ([]ProblemDescriptor, error)
+	PublishResults(roundID string, actorID int64) (Round, error)
+	RevealIdentities(order RevealOrder) ([]SolutionIdentity, error)
 }
 
 func NewRoundFromEnd(solveStageEnd time.Time) Round {
@@ -170,6 +264,8 @@ func NewRoundFromDuration(solveDuration time.Duration) Round {
 
 type ReviewDistributionDesc struct {
 	Desc string `json:"desc"`
+	Seed int64  `json:"seed"`
+	Hash string `json:"hash"`
 }
 
 type ProblemDescriptor struct {
@@ -181,7 +277,8 @@ type ProblemDescriptor struct {
 	ProblemID string `json:"problem_id"`
 }
 
-// RoundDistribution is a mapping from participant ID to list of problems that participant get to solve
+// RoundDistribution is a mapping from participant ID to list of problems that participant get to solve.
+// In team rounds it is keyed by team ID, see CompetitorID
 type RoundDistribution map[string][]ProblemDescriptor
 
 func (pd RoundDistribution) FindDescriptor(participantID string, problemID string) (ProblemDescriptor, error) {
@@ -200,9 +297,169 @@ func (pd RoundDistribution) FindDescriptor(participantID string, problemID strin
 }
 
 type ReviewDistribution struct {
-	// Mapping from participantID to list of solution IDs that he got
+	// Mapping from participantID to list of solution IDs that he got. In team rounds participantID is team ID
 	BetweenParticipants map[string][]string `json:"between_participants"`
 	ToOrganizers        []string            `json:"to_organizers"`
+	// История изменений распределения после начала этапа ревью
+	Reassignments []ReviewReassignment `json:"reassignments"`
+	// Только до начала этапа ревью: seed предпросмотра, который поправили организаторы, и сами правки.
+	// Распределение с этим seed считается с правками, в том числе при начале этапа
+	PreviewSeed      int64           `json:"preview_seed,omitempty"`
+	PreviewOverrides []ReassignOrder `json:"preview_overrides,omitempty"`
+}
+
+const (
+	ReassignReasonManual   = "manual"
+	ReassignReasonInactive = "inactive_reviewer"
+)
+
+// ReviewReassignment - запись о том, что решение забрали у одного участника и/или отдали другому.
+// Пустой ToParticipantID означает, что решение отдано организаторам
+type ReviewReassignment struct {
+	Date              time.Time `json:"date"`
+	SolutionID        string    `json:"solution_id"`
+	FromParticipantID string    `json:"from_participant_id"`
+	ToParticipantID   string    `json:"to_participant_id"`
+	Reason            string    `json:"reason"`
+}
+
+func (d *ReviewDistribution) LogReassignment(solutionID, fromParticipantID, toParticipantID, reason string) {
+	d.Reassignments = append(d.Reassignments, ReviewReassignment{
+		Date:              time.Now().Round(time.Second).UTC(),
+		SolutionID:        solutionID,
+		FromParticipantID: fromParticipantID,
+		ToParticipantID:   toParticipantID,
+		Reason:            reason,
+	})
+}
+
+// Reviewers возвращает ID участников, которым решение отправлено на ревью
+func (d *ReviewDistribution) Reviewers(solutionID string) []string {
+	result := []string{}
+	for participantID, solutionIDs := range d.BetweenParticipants {
+		if mstd.IndexOf(solutionIDs, solutionID) != -1 {
+			result = append(result, participantID)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (d *ReviewDistribution) IsReviewer(participantID string, solutionID string) bool {
+	return mstd.IndexOf(d.BetweenParticipants[participantID], solutionID) != -1
+}
+
+func (d *ReviewDistribution) AddReview(participantID string, solutionID string) {
+	if d.IsReviewer(participantID, solutionID) {
+		return
+	}
+	if d.BetweenParticipants == nil {
+		d.BetweenParticipants = make(map[string][]string)
+	}
+	d.BetweenParticipants[participantID] = append(d.BetweenParticipants[participantID], solutionID)
+}
+
+func (d *ReviewDistribution) RemoveReview(participantID string, solutionID string) {
+	updated := []string{}
+	for _, ID := range d.BetweenParticipants[participantID] {
+		if ID != solutionID {
+			updated = append(updated, ID)
+		}
+	}
+
+	if len(updated) == 0 {
+		delete(d.BetweenParticipants, participantID)
+	} else {
+		d.BetweenParticipants[participantID] = updated
+	}
+}
+
+func (d *ReviewDistribution) AddToOrganizers(solutionID string) {
+	if mstd.IndexOf(d.ToOrganizers, solutionID) == -1 {
+		d.ToOrganizers = append(d.ToOrganizers, solutionID)
+	}
+}
+
+// Copy нужен, потому что BetweenParticipants - map и при обычном присваивании не копируется
+func (d *ReviewDistribution) Copy() ReviewDistribution {
+	result := ReviewDistribution{
+		BetweenParticipants: make(map[string][]string),
+		ToOrganizers:        append([]string{}, d.ToOrganizers...),
+		Reassignments:       append([]ReviewReassignment{}, d.Reassignments...),
+		PreviewSeed:         d.PreviewSeed,
+		PreviewOverrides:    append([]ReassignOrder{}, d.PreviewOverrides...),
+	}
+	for participantID, solutionIDs := range d.BetweenParticipants {
+		result.BetweenParticipants[participantID] = append([]string{}, solutionIDs...)
+	}
+	return result
+}
+
+// Hash - отпечаток того, кому какие решения достались. История переназначений не учитывается
+func (d *ReviewDistribution) Hash() string {
+	// json сортирует ключи map, поэтому одинаковые распределения дают одинаковый хеш
+	content, _ := json.Marshal(ReviewDistribution{
+		BetweenParticipants: d.BetweenParticipants,
+		ToOrganizers:        d.ToOrganizers,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	ReassignMove         = "move"
+	ReassignAddReviewer  = "add_reviewer"
+	ReassignToOrganizers = "to_organizers"
+)
+
+// ReassignOrder - ручное изменение распределения решений на ревью
+type ReassignOrder struct {
+	Action     string `json:"action"`
+	SolutionID string `json:"solution_id"`
+	// Только для ReassignMove - у кого забрать решение
+	FromParticipantID string `json:"from_participant_id"`
+	// Для ReassignMove и ReassignAddReviewer - кому отдать решение
+	ToParticipantID string `json:"to_participant_id"`
+	// Лига, в которой идёт этап ревью
+	League string `json:"league"`
+	// До начала этапа ревью - seed предпросмотра, который правится. 0 - уже поправленный предпросмотр
+	Seed int64 `json:"seed"`
+	// Telegram ID того, кто перераспределяет. Передаётся в ActorHeader
+	ActorID int64 `json:"-"`
+}
+
+func (o ReassignOrder) Actor() int64 {
+	return o.ActorID
+}
+
+type ReassignResult struct {
+	FailedParticipants []ParticipantError `json:"failed_participants"`
+	Round              Round              `json:"round"`
+	// Правка предпросмотра: этап ревью ещё не начат, участникам ничего не отправлено
+	IsPreview bool `json:"is_preview"`
+}
+
+// ReassignChoice - вариант для выбора в боте. Submitted - ревьюер уже прислал ревью на решение
+type ReassignChoice struct {
+	ID        string `json:"id"`
+	Label     string `json:"label"`
+	Submitted bool   `json:"submitted"`
+}
+
+// ReassignSolution - решение с его ревьюерами и теми, кому его можно отдать
+type ReassignSolution struct {
+	ReassignChoice
+	ProblemID  string           `json:"problem_id"`
+	Reviewers  []ReassignChoice `json:"reviewers"`
+	Candidates []ReassignChoice `json:"candidates"`
+}
+
+// ReassignOptions - всё, из чего организатор выбирает при перераспределении
+type ReassignOptions struct {
+	// До начала этапа ревью - seed показанного предпросмотра
+	Seed      int64              `json:"seed"`
+	IsPreview bool               `json:"is_preview"`
+	Solutions []ReassignSolution `json:"solutions"`
 }
 
 func ProblemIDsFromSolutionIDs(solutions SolutionRepository, solutionIDs []string) ([]string, error) {
@@ -331,6 +588,30 @@ func ValidateCaptions(userInput string, descriptors []ProblemDescriptor) (int, b
 	return -1, false
 }
 
+// ValidateCaptionsOrder проверяет, что пользователь перечислил каждое обозначение задачи ровно один раз.
+// Возвращает новый порядок в виде индексов, например для ["A", "B", "C"] и ввода "C, A, B" вернёт [2, 0, 1]
+func ValidateCaptionsOrder(userInput string, captions []string) ([]int, bool) {
+	parts := strings.FieldsFunc(userInput, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	})
+	if len(parts) != len(captions) {
+		return []int{}, false
+	}
+
+	result := []int{}
+	isUsed := make(map[int]bool)
+	for _, part := range parts {
+		index := mstd.IndexOf(captions, strings.ToUpper(part))
+		if index == -1 || isUsed[index] {
+			return []int{}, false
+		}
+		isUsed[index] = true
+		result = append(result, index)
+	}
+
+	return result, true
+}
+
 func GetRoundStage(round Round) RoundStage {
 	if round.GetSolveStartDate().IsZero() || round.GetSolveStartDate().After(time.Now()) {
 		return StageNotStarted
@@ -351,18 +632,15 @@ func GetRoundStage(round Round) RoundStage {
 	return StageFinished
 }
 
-func ParseStageEndDate(endDateTime string) (time.Time, error) {
+// ParseStageEndDate разбирает введённую организатором дату окончания этапа в его часовом поясе
+func ParseStageEndDate(endDateTime string, location *time.Location) (time.Time, error) {
 	endDateTime = strings.Trim(endDateTime, " \t\n")
-	moscowLocation, err := time.LoadLocation("Europe/Moscow")
-	if err != nil {
-		return time.Time{}, err
-	}
 	if len(endDateTime) == len("DD.MM.YYYY") {
 		t, err := time.Parse("02.01.2006", endDateTime)
 		if err != nil {
 			return time.Time{}, ErrWrongUserInput
 		}
-		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, moscowLocation)
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
 		t = t.AddDate(0, 0, 1)
 		return t, nil
 	} else if len(endDateTime) == len("DD.MM.YYYY HH:MM") {
@@ -370,7 +648,7 @@ func ParseStageEndDate(endDateTime string) (time.Time, error) {
 		if err != nil {
 			return time.Time{}, ErrWrongUserInput
 		}
-		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, moscowLocation)
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, location)
 		return t, nil
 	}
 
@@ -0,0 +1,51 @@
+package mathbattle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentitiesHidden(t *testing.T) {
+	req := require.New(t)
+
+	round := NewRoundFromEnd(time.Now().Add(-48 * time.Hour))
+	round.SetSolveStartDate(time.Now().Add(-72 * time.Hour))
+	round.SetReviewStartDate(time.Now().Add(-24 * time.Hour))
+	round.SetReviewEndDate(time.Now().Add(time.Hour))
+
+	req.False(round.IdentitiesHidden(false))
+	req.True(round.IdentitiesHidden(true))
+
+	// Результаты опубликованы, но ревью ещё идёт
+	round.ResultsPublished = true
+	req.True(round.IdentitiesHidden(true))
+
+	round.SetReviewEndDate(time.Now().Add(-time.Hour))
+	req.False(round.IdentitiesHidden(true))
+
+	round.ResultsPublished = false
+	req.True(round.IdentitiesHidden(true))
+}
+
+func TestParseStageEndDate(t *testing.T) {
+	req := require.New(t)
+
+	location := time.FixedZone("UTC+5", 5*3600)
+
+	// Только дата - этап идёт до конца этого дня
+	end, err := ParseStageEndDate(" 20.10.2026\n", location)
+	req.NoError(err)
+	req.True(end.Equal(time.Date(2026, 10, 21, 0, 0, 0, 0, location)))
+	req.Equal(location, end.Location())
+
+	end, err = ParseStageEndDate("20.10.2026 18:30", location)
+	req.NoError(err)
+	req.True(end.Equal(time.Date(2026, 10, 20, 13, 30, 0, 0, time.UTC)))
+
+	for _, input := range []string{"", "2026-10-20", "32.10.2026", "20.10.2026 25:00", "20.10.2026 18:30:00"} {
+		_, err := ParseStageEndDate(input, location)
+		req.Equal(ErrWrongUserInput, err, input)
+	}
+}
@@ -1,5 +1,13 @@
 package mathbattle
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
 type Solution struct {
 	ID            string  `json:"id"`
 	ParticipantID string  `json:"participant_id"`
@@ -8,6 +16,33 @@ type Solution struct {
 	JuriComment   string  `json:"juri_comment"`
 	Mark          Mark    `json:"mark"`
 	Parts         []Image `json:"parts"`
+	// Pseudonym - случайный код решения, под которым его видят жюри при слепой проверке
+	Pseudonym string `json:"pseudonym"`
+	// TeamID - команда, которой принадлежит решение в командном раунде. ParticipantID - кто из неё его сдал
+	TeamID string `json:"team_id"`
+}
+
+// CompetitorID - чьё это решение в распределениях раунда: команды или участника
+func (s Solution) CompetitorID() string {
+	if s.TeamID != "" {
+		return s.TeamID
+	}
+	return s.ParticipantID
+}
+
+// Alias - под каким кодом показывать решение жюри. У решений, сданных до появления псевдонимов, его нет
+func (s Solution) Alias() string {
+	if s.Pseudonym != "" {
+		return s.Pseudonym
+	}
+	return SolutionCode(s.RoundID, s.ID)
+}
+
+// Anonymized - копия решения без ссылки на участника и его команду
+func (s Solution) Anonymized() Solution {
+	s.ParticipantID = ""
+	s.TeamID = ""
+	return s
 }
 
 type SolutionRepository interface {
@@ -16,7 +51,10 @@ type SolutionRepository interface {
 	Find(roundID string, participantID string, problemID string) (Solution, error)
 	FindMany(roundID string, participantID string, problemID string) ([]Solution, error) //Leave IDs empty if it's not important
 	FindOrCreate(roundID string, participantID string, problemID string) (Solution, error)
+	FindManyByTeam(roundID string, teamID string, problemID string) ([]Solution, error) //Leave IDs empty if it's not important
 	AppendPart(ID string, part Image) error
+	// OpenPart - содержимое части решения по её Key. Get возвращает части без содержимого
+	OpenPart(key string) (io.ReadCloser, error)
 	Update(solution Solution) error
 	Delete(ID string) error
 }
@@ -25,6 +63,8 @@ type FindDescriptor struct {
 	RoundID       string `json:"round_id"`
 	ParticipantID string `json:"participant_id"`
 	ProblemID     string `json:"problem_id"`
+	// Решения команды, ParticipantID при этом не учитывается
+	TeamID string `json:"team_id"`
 }
 
 type SolutionService interface {
@@ -32,9 +72,48 @@ type SolutionService interface {
 	Get(ID string) (Solution, error)
 	Find(descriptor FindDescriptor) ([]Solution, error)
 	Update(solution Solution) error
-	Delete(ID string) error
+	Delete(ID string, actorID int64) error
 	AppendPart(ID string, part Image) error
 	GetProblemDescriptors(participantID string) ([]ProblemDescriptor, error)
+	// MergedPDF - все решения раунда (или одной задачи, если problemID не пуст) одним pdf для жюри
+	MergedPDF(roundID string, problemID string) ([]byte, error)
+	// JurySolutions - решения раунда для жюри. При слепой проверке без участников, пока результаты не опубликованы
+	JurySolutions(roundID string, problemID string) ([]Solution, error)
+}
+
+// SolutionCode - обезличенный код решения для жюри. Не раскрывает участника, но однозначно указывает на решение
+func SolutionCode(roundID string, solutionID string) string {
+	hash := sha256.Sum256([]byte(roundID + ":" + solutionID))
+	return strings.ToUpper(hex.EncodeToString(hash[:4]))
+}
+
+// WithContent - копия частей решения с прочитанным содержимым
+func WithContent(solutions SolutionRepository, parts []Image) ([]Image, error) {
+	result := []Image{}
+	for _, part := range parts {
+		if len(part.Content) == 0 && part.Key != "" {
+			reader, err := solutions.OpenPart(part.Key)
+			if err != nil {
+				return result, err
+			}
+			part.Content, err = ioutil.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				return result, err
+			}
+		}
+		result = append(result, part)
+	}
+
+	return result, nil
+}
+
+// FindCompetitorSolutions - решения участника или, в командном раунде, команды с ID competitorID
+func FindCompetitorSolutions(solutions SolutionRepository, round Round, competitorID string, problemID string) ([]Solution, error) {
+	if round.IsTeam() {
+		return solutions.FindManyByTeam(round.ID, competitorID, problemID)
+	}
+	return solutions.FindMany(round.ID, competitorID, problemID)
 }
 
 func SplitInGroupsByProblem(solutions []Solution) map[string][]Solution {
@@ -5,8 +5,9 @@ import "time"
 type Stat struct {
 	//TODO: Add VisitorsToday
 	//TODO: Add Current online
-	ParticipantsTotal int `json:"participants_total"`
-	ParticipantsToday int `json:"participants_today"`
+	League            string `json:"league"`
+	ParticipantsTotal int    `json:"participants_total"`
+	ParticipantsToday int    `json:"participants_today"`
 
 	RoundStage       RoundStage    `json:"round_stage"`
 	TimeToSolveLeft  time.Duration `json:"time_to_solve_left"`
@@ -18,5 +19,5 @@ type Stat struct {
 }
 
 type StatService interface {
-	Stat() (Stat, error)
+	Stat(league string) (Stat, error)
 }
@@ -0,0 +1,116 @@
+package mathbattle
+
+import (
+	"unicode"
+
+	"mathbattle/libs/mstd"
+)
+
+// RoundMode - кто соревнуется в раунде: отдельные участники или команды
+type RoundMode string
+
+const (
+	RoundIndividual RoundMode = "individual"
+	RoundTeam       RoundMode = "team"
+	// Матбой: команды готовят задачи, а потом рассказывают их друг другу в бою, см. Battle
+	RoundMatboi RoundMode = "matboi"
+)
+
+// Team - команда участников. Капитан тоже входит в MemberIDs
+type Team struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	CaptainID string   `json:"captain_id"`
+	MemberIDs []string `json:"member_ids"`
+	// По этому коду капитан приглашает участников в команду
+	InviteCode string `json:"invite_code"`
+}
+
+func (t *Team) IsMember(participantID string) bool {
+	return mstd.IndexOf(t.MemberIDs, participantID) != -1
+}
+
+// RemoveMember убирает участника из команды. Если ушёл капитан, капитаном становится следующий участник
+func (t *Team) RemoveMember(participantID string) {
+	members := []string{}
+	for _, ID := range t.MemberIDs {
+		if ID != participantID {
+			members = append(members, ID)
+		}
+	}
+	t.MemberIDs = members
+
+	if t.CaptainID == participantID {
+		t.CaptainID = ""
+		if len(members) != 0 {
+			t.CaptainID = members[0]
+		}
+	}
+}
+
+type TeamRepository interface {
+	Store(team Team) (Team, error)
+	Get(ID string) (Team, error)
+	GetByMember(participantID string) (Team, error)
+	GetByInviteCode(inviteCode string) (Team, error)
+	GetAll() ([]Team, error)
+	Update(team Team) error
+	Delete(ID string) error
+}
+
+// JoinOrder - участник хочет вступить в команду по коду приглашения
+type JoinOrder struct {
+	ParticipantID string `json:"participant_id"`
+	InviteCode    string `json:"invite_code"`
+}
+
+type TeamService interface {
+	// Create создаёт команду, капитан - единственный участник Team.CaptainID
+	Create(team Team) (Team, error)
+	Get(ID string) (Team, error)
+	GetByMember(participantID string) (Team, error)
+	GetAll() ([]Team, error)
+	Join(order JoinOrder) (Team, error)
+	Leave(participantID string) error
+}
+
+// TeamFinder - всё, что нужно для CompetitorID. Подходят и TeamRepository, и TeamService
+type TeamFinder interface {
+	GetByMember(participantID string) (Team, error)
+}
+
+// CompetitorID - под каким ID участник соревнуется в раунде: в командном раунде это ID его команды.
+// Этими ID в раунде ключуются распределения задач и решений на ревью
+func CompetitorID(round Round, participantID string, teams TeamFinder) (string, error) {
+	if !round.IsTeam() {
+		return participantID, nil
+	}
+
+	team, err := teams.GetByMember(participantID)
+	if err != nil {
+		return "", err
+	}
+	return team.ID, nil
+}
+
+func IsTeamNameValid(input string) bool {
+	letters := []rune(input)
+	if len(letters) == 0 || len(letters) > 50 {
+		return false
+	}
+
+	for _, r := range letters {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func ValidateTeamName(userInput string) (string, bool) {
+	name := normalizeSpaces(userInput)
+	if !IsTeamNameValid(name) {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,83 @@
+package mathbattle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeZone - часовой пояс мероприятия, если в конфиге не указан другой
+const DefaultTimeZone = "Europe/Moscow"
+
+var utcOffsetRegexp = regexp.MustCompile(`^(?:UTC|GMT)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// LoadTimeZone разбирает часовой пояс: имя из базы IANA ("Asia/Yekaterinburg") или смещение
+// от UTC ("UTC+5", "+05:30"). Имя полученного пояса (Location.String()) снова разбирается LoadTimeZone
+func LoadTimeZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrWrongUserInput
+	}
+
+	if match := utcOffsetRegexp.FindStringSubmatch(strings.ToUpper(name)); match != nil {
+		hours, _ := strconv.Atoi(match[2])
+		minutes := 0
+		if match[3] != "" {
+			minutes, _ = strconv.Atoi(match[3])
+		}
+		if hours > 14 || minutes >= 60 {
+			return nil, ErrWrongUserInput
+		}
+
+		offset := hours*3600 + minutes*60
+		zoneName := fmt.Sprintf("UTC%s%d", match[1], hours)
+		if minutes != 0 {
+			zoneName += fmt.Sprintf(":%02d", minutes)
+		}
+		if match[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(zoneName, offset), nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil || location == time.Local {
+		return nil, ErrWrongUserInput
+	}
+	return location, nil
+}
+
+// TimeZoneOr - часовой пояс с именем name, а если он не задан или неизвестен - fallback
+func TimeZoneOr(name string, fallback *time.Location) *time.Location {
+	if name == "" {
+		return fallback
+	}
+
+	location, err := LoadTimeZone(name)
+	if err != nil {
+		return fallback
+	}
+	return location
+}
+
+// TimeZoneLabel - часовой пояс в сообщениях пользователям, например "Asia/Yekaterinburg, UTC+5"
+func TimeZoneLabel(t time.Time) string {
+	_, offset := t.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	label := fmt.Sprintf("UTC%s%d", sign, offset/3600)
+	if offset%3600 != 0 {
+		label += fmt.Sprintf(":%02d", offset%3600/60)
+	}
+
+	if name := t.Location().String(); name != label && name != "UTC" {
+		label = name + ", " + label
+	}
+	return label
+}
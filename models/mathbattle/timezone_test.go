@@ -0,0 +1,59 @@
+package mathbattle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTimeZone(t *testing.T) {
+	req := require.New(t)
+
+	location, err := LoadTimeZone(" Asia/Yekaterinburg ")
+	req.NoError(err)
+	req.Equal("Asia/Yekaterinburg", location.String())
+
+	for name, expected := range map[string]int{
+		"UTC+5":    5 * 3600,
+		"+05:30":   5*3600 + 30*60,
+		"gmt-3":    -3 * 3600,
+		"UTC-9:30": -(9*3600 + 30*60),
+	} {
+		location, err := LoadTimeZone(name)
+		req.NoError(err, name)
+		_, offset := time.Date(2026, 1, 1, 0, 0, 0, 0, location).Zone()
+		req.Equal(expected, offset, name)
+
+		// Имя полученного пояса разбирается снова
+		again, err := LoadTimeZone(location.String())
+		req.NoError(err, name)
+		req.Equal(location.String(), again.String())
+	}
+
+	for _, name := range []string{"", "Mars/Olympus", "Local", "UTC+15", "+05:60"} {
+		_, err := LoadTimeZone(name)
+		req.Equal(ErrWrongUserInput, err, name)
+	}
+}
+
+func TestTimeZoneOr(t *testing.T) {
+	req := require.New(t)
+
+	fallback := time.FixedZone("fallback", 3600)
+	req.Equal(fallback, TimeZoneOr("", fallback))
+	req.Equal(fallback, TimeZoneOr("Mars/Olympus", fallback))
+	req.Equal("UTC+5", TimeZoneOr("UTC+5", fallback).String())
+}
+
+func TestTimeZoneLabel(t *testing.T) {
+	req := require.New(t)
+
+	yekaterinburg, err := time.LoadLocation("Asia/Yekaterinburg")
+	req.NoError(err)
+	req.Equal("Asia/Yekaterinburg, UTC+5", TimeZoneLabel(time.Date(2026, 1, 1, 0, 0, 0, 0, yekaterinburg)))
+
+	req.Equal("UTC+5:30", TimeZoneLabel(time.Date(2026, 1, 1, 0, 0, 0, 0, time.FixedZone("UTC+5:30", 5*3600+30*60))))
+	req.Equal("UTC-3", TimeZoneLabel(time.Date(2026, 1, 1, 0, 0, 0, 0, time.FixedZone("UTC-3", -3*3600))))
+	req.Equal("UTC+0", TimeZoneLabel(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
@@ -3,19 +3,35 @@ package mathbattle
 import "time"
 
 type User struct {
-	ID                string    `json:"id"`
-	TelegramID        int64     `json:"telegram_id"`
-	TelegramFirstName string    `json:"telegram_firstname"`
-	TelegramLastName  string    `json:"telegram_lastname"`
-	TelegramUsername  string    `json:"telegram_username"`
-	IsAdmin           bool      `json:"is_admin"`
-	RegistrationTime  time.Time `json:"registration_time"`
+	ID                string `json:"id"`
+	TelegramID        int64  `json:"telegram_id"`
+	TelegramFirstName string `json:"telegram_firstname"`
+	TelegramLastName  string `json:"telegram_lastname"`
+	TelegramUsername  string `json:"telegram_username"`
+	// Роль организатора, см. Role. Пусто - обычный пользователь
+	Role             Role      `json:"role"`
+	RegistrationTime time.Time `json:"registration_time"`
+	// Код языка ответов бота. Пусто - язык по умолчанию
+	Language string `json:"language"`
+	// Часовой пояс для сроков в сообщениях, см. LoadTimeZone. Пусто - часовой пояс мероприятия
+	TimeZone string `json:"time_zone"`
+	// Лига, раундами которой сейчас управляет администратор. Пусто - основная лига
+	League string `json:"league"`
 }
 
 func (u *User) SetRegistrationTime(t time.Time) {
 	u.RegistrationTime = t.Round(time.Second).UTC()
 }
 
+func (u User) Can(permission Permission) bool {
+	return u.Role.Can(permission)
+}
+
+// IsStaff - пользователь входит в оргкомитет: у него есть какая-то роль
+func (u User) IsStaff() bool {
+	return u.Role != RoleNone
+}
+
 type UserRepository interface {
 	Store(user User) (User, error)
 	GetAll() ([]User, error)